@@ -0,0 +1,277 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/option/internaloption"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// Directory API batch requests send many calls in one HTTP request, as parts
+// of a multipart/mixed body. Each call still counts against the Directory
+// API quota, but the round trips of thousands of single lookups are saved:
+// https://developers.google.com/workspace/admin/directory/v1/guides/batch
+const (
+	directoryBasePath         = "https://admin.googleapis.com/"
+	directoryBasePathTemplate = "https://admin.UNIVERSE_DOMAIN/"
+	directoryBatchPath        = "batch/admin/directory_v1"
+	directoryCallPrefix       = "/admin/directory/v1/"
+)
+
+// maxBatchSize is the most calls Google accepts in one batch request. Longer
+// batches are split. A var so tests can split small batches.
+var maxBatchSize = 1000
+
+type batchSizeKey struct{}
+
+// BatchSize returns how many API calls the request made with ctx counts as:
+// the number of calls in a batch request, or 1 for any other request.
+func BatchSize(ctx context.Context) int {
+	if n, ok := ctx.Value(batchSizeKey{}).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// DirectoryBatcher sends Directory API calls in batch requests. It has the
+// authorization of the options it was built with, so each Directory service
+// of GoogleWorkspaceClient has its own batcher.
+type DirectoryBatcher struct {
+	client   *http.Client
+	endpoint string
+}
+
+// NewDirectoryBatcher builds a batcher the way directoryAdmin.NewService
+// builds a service, so both take the same options.
+func NewDirectoryBatcher(ctx context.Context, opts ...option.ClientOption) (*DirectoryBatcher, error) {
+	opts = append(opts,
+		internaloption.WithDefaultEndpoint(directoryBasePath),
+		internaloption.WithDefaultEndpointTemplate(directoryBasePathTemplate),
+	)
+	client, endpoint, err := htransport.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &DirectoryBatcher{client: client, endpoint: strings.TrimSuffix(endpoint, "/") + "/" + directoryBatchPath}, nil
+}
+
+// BatchResult is the outcome of one call of a batch. Err is the error the
+// call would have returned if made on its own.
+type BatchResult[T any] struct {
+	Value *T
+	Err   error
+}
+
+// batchGet sends a GET of each path, relative to the Directory API, in as few
+// batch requests as maxBatchSize allows. The returned error is a failure of a
+// whole batch request; each call's own failure is its result's Err.
+func batchGet[T any](ctx context.Context, b *DirectoryBatcher, paths []string) ([]BatchResult[T], error) {
+	rv := make([]BatchResult[T], 0, len(paths))
+	for start := 0; start < len(paths); start += maxBatchSize {
+		results, err := batchGetChunk[T](ctx, b, paths[start:min(start+maxBatchSize, len(paths))])
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, results...)
+	}
+	return rv, nil
+}
+
+func batchGetChunk[T any](ctx context.Context, b *DirectoryBatcher, paths []string) ([]BatchResult[T], error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for i, path := range paths {
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"application/http"},
+			"Content-Id":   {"<" + strconv.Itoa(i) + ">"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fmt.Fprintf(part, "GET %s%s HTTP/1.1\r\n\r\n", directoryCallPrefix, path); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(context.WithValue(ctx, batchSizeKey{}, len(paths)), http.MethodPost, b.endpoint, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "multipart/mixed; boundary="+w.Boundary())
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, fmt.Errorf("google-workspace: batch response is %q, not multipart", resp.Header.Get("Content-Type"))
+	}
+
+	rv := make([]BatchResult[T], len(paths))
+	answered := make([]bool, len(paths))
+	r := multipart.NewReader(resp.Body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to read batch response: %w", err)
+		}
+		// Google answers the call with Content-ID <n> in the part with
+		// Content-ID <response-n>, in any order.
+		id := strings.TrimSuffix(strings.TrimPrefix(part.Header.Get("Content-Id"), "<response-"), ">")
+		i, err := strconv.Atoi(id)
+		if err != nil || i < 0 || i >= len(paths) {
+			return nil, fmt.Errorf("google-workspace: unexpected batch response part %q", part.Header.Get("Content-Id"))
+		}
+		answered[i] = true
+		rv[i], err = readBatchPart[T](part)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, ok := range answered {
+		if !ok {
+			rv[i].Err = fmt.Errorf("google-workspace: batch response has no answer for GET %s%s", directoryCallPrefix, paths[i])
+		}
+	}
+	return rv, nil
+}
+
+func readBatchPart[T any](part io.Reader) (BatchResult[T], error) {
+	resp, err := http.ReadResponse(bufio.NewReader(part), nil)
+	if err != nil {
+		return BatchResult[T]{}, fmt.Errorf("google-workspace: failed to read batch response part: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return BatchResult[T]{Err: err}, nil
+	}
+	var v T
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return BatchResult[T]{}, fmt.Errorf("google-workspace: failed to decode batch response part: %w", err)
+	}
+	return BatchResult[T]{Value: &v}, nil
+}
+
+// batchOrEach runs a call per key, in batches when b is set and one at a time
+// with single otherwise, and wraps each call's error as single does.
+func batchOrEach[K any, T any](
+	ctx context.Context,
+	b *DirectoryBatcher,
+	keys []K,
+	path func(K) string,
+	single func(context.Context, K) (*T, error),
+	contextMsg func(K) string,
+) ([]BatchResult[T], error) {
+	if b == nil {
+		rv := make([]BatchResult[T], len(keys))
+		for i, key := range keys {
+			rv[i].Value, rv[i].Err = single(ctx, key)
+		}
+		return rv, nil
+	}
+	paths := make([]string, len(keys))
+	for i, key := range keys {
+		paths[i] = path(key)
+	}
+	rv, err := batchGet[T](ctx, b, paths)
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, "failed to send batch request")
+	}
+	for i := range rv {
+		if rv[i].Err != nil {
+			rv[i].Err = wrapGoogleApiErrorWithContext(rv[i].Err, contextMsg(keys[i]))
+		}
+	}
+	return rv, nil
+}
+
+// BatchGetUsers is GetUser for each of userKeys, in batches.
+func (c *GoogleWorkspaceClient) BatchGetUsers(ctx context.Context, userKeys []string) ([]BatchResult[directoryAdmin.User], error) {
+	if c.UserService == nil {
+		return nil, errServiceNotAvailable("user service")
+	}
+	return batchOrEach(ctx, c.UserBatcher, userKeys,
+		func(userKey string) string { return "users/" + url.PathEscape(userKey) + "?projection=full" },
+		c.GetUser,
+		func(userKey string) string { return fmt.Sprintf("failed to get user: %s", userKey) },
+	)
+}
+
+// BatchGetGroups is GetGroup for each of groupKeys, in batches.
+func (c *GoogleWorkspaceClient) BatchGetGroups(ctx context.Context, groupKeys []string) ([]BatchResult[directoryAdmin.Group], error) {
+	if c.GroupService == nil {
+		return nil, errServiceNotAvailable("group service")
+	}
+	return batchOrEach(ctx, c.GroupBatcher, groupKeys,
+		func(groupKey string) string { return "groups/" + url.PathEscape(groupKey) },
+		c.GetGroup,
+		func(groupKey string) string { return fmt.Sprintf("failed to get group: %s", groupKey) },
+	)
+}
+
+// MemberKey names a member of a group.
+type MemberKey struct {
+	GroupKey  string
+	MemberKey string
+}
+
+// BatchGetMembers is GetMember for each of keys, in batches.
+func (c *GoogleWorkspaceClient) BatchGetMembers(ctx context.Context, keys []MemberKey) ([]BatchResult[directoryAdmin.Member], error) {
+	if c.GroupMemberProvisioningService == nil {
+		return nil, errServiceNotAvailable("group member provisioning service")
+	}
+	return batchOrEach(ctx, c.GroupMemberProvisioningBatcher, keys,
+		func(k MemberKey) string {
+			return "groups/" + url.PathEscape(k.GroupKey) + "/members/" + url.PathEscape(k.MemberKey)
+		},
+		func(ctx context.Context, k MemberKey) (*directoryAdmin.Member, error) {
+			return c.GetMember(ctx, k.GroupKey, k.MemberKey)
+		},
+		func(k MemberKey) string {
+			return fmt.Sprintf("failed to get member %s in group: %s", k.MemberKey, k.GroupKey)
+		},
+	)
+}
+
+// BatchListMembers is the first page of ListMembers for each of groupIds, in
+// batches. Later pages are listed with ListMembers and the page's token.
+func (c *GoogleWorkspaceClient) BatchListMembers(ctx context.Context, groupIds []string) ([]BatchResult[directoryAdmin.Members], error) {
+	if c.GroupMemberService == nil {
+		return nil, errServiceNotAvailable("group member service")
+	}
+	return batchOrEach(ctx, c.GroupMemberBatcher, groupIds,
+		func(groupId string) string {
+			return "groups/" + url.PathEscape(groupId) + "/members?maxResults=" + strconv.Itoa(listMembersPageSize)
+		},
+		func(ctx context.Context, groupId string) (*directoryAdmin.Members, error) {
+			return c.ListMembers(ctx, groupId, "")
+		},
+		func(groupId string) string { return fmt.Sprintf("failed to list members for group: %s", groupId) },
+	)
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2/google"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/conductorone/baton-google-workspace/pkg/fake"
+)
+
+// newFakeClient returns a client of srv with the user, group and member
+// services, batching calls when batch is set.
+func newFakeClient(t *testing.T, srv *fake.Server, batch bool) *GoogleWorkspaceClient {
+	t.Helper()
+	ctx := context.Background()
+	newOpts := func(scope string) []option.ClientOption {
+		cfg, err := google.JWTConfigFromJSON(srv.CredentialsJSON(), scope)
+		require.NoError(t, err)
+		cfg.Subject = "admin@example.com"
+		return []option.ClientOption{option.WithHTTPClient(cfg.Client(ctx)), option.WithEndpoint(srv.URL() + "/")}
+	}
+	c := &GoogleWorkspaceClient{}
+	for _, s := range []struct {
+		scope   string
+		service **directoryAdmin.Service
+		batcher **DirectoryBatcher
+	}{
+		{directoryAdmin.AdminDirectoryUserReadonlyScope, &c.UserService, &c.UserBatcher},
+		{directoryAdmin.AdminDirectoryGroupReadonlyScope, &c.GroupService, &c.GroupBatcher},
+		{directoryAdmin.AdminDirectoryGroupMemberReadonlyScope, &c.GroupMemberService, &c.GroupMemberBatcher},
+		{directoryAdmin.AdminDirectoryGroupMemberScope, &c.GroupMemberProvisioningService, &c.GroupMemberProvisioningBatcher},
+	} {
+		var err error
+		*s.service, err = directoryAdmin.NewService(ctx, newOpts(s.scope)...)
+		require.NoError(t, err)
+		if batch {
+			*s.batcher, err = NewDirectoryBatcher(ctx, newOpts(s.scope)...)
+			require.NoError(t, err)
+		}
+	}
+	return c
+}
+
+func TestBatchLookups(t *testing.T) {
+	prev := maxBatchSize
+	maxBatchSize = 2
+	t.Cleanup(func() { maxBatchSize = prev })
+
+	srv := fake.New("C0fake")
+	t.Cleanup(srv.Close)
+	srv.AddDomain("example.com", true)
+	srv.AddUser(&directoryAdmin.User{PrimaryEmail: "admin@example.com", IsAdmin: true})
+	alice := srv.AddUser(&directoryAdmin.User{PrimaryEmail: "alice@example.com"})
+	bob := srv.AddUser(&directoryAdmin.User{PrimaryEmail: "bob@example.com"})
+	eng := srv.AddGroup(&directoryAdmin.Group{Email: "eng@example.com", Name: "Engineering"})
+	ops := srv.AddGroup(&directoryAdmin.Group{Email: "ops@example.com", Name: "Operations"})
+	srv.AddMember("eng@example.com", &directoryAdmin.Member{Email: "alice@example.com", Role: "OWNER"})
+	srv.AddMember("eng@example.com", &directoryAdmin.Member{Email: "bob@example.com"})
+
+	for _, batch := range []bool{true, false} {
+		c := newFakeClient(t, srv, batch)
+		ctx := context.Background()
+		srv.ResetRequests()
+
+		users, err := c.BatchGetUsers(ctx, []string{"bob@example.com", "gone@example.com", alice.Id})
+		require.NoError(t, err)
+		require.Len(t, users, 3)
+		require.Equal(t, bob.Id, users[0].Value.Id)
+		require.Nil(t, users[1].Value)
+		var gerr *googleapi.Error
+		require.True(t, errors.As(users[1].Err, &gerr))
+		require.Equal(t, http.StatusNotFound, gerr.Code)
+		require.Equal(t, codes.NotFound, status.Code(users[1].Err))
+		require.ErrorContains(t, users[1].Err, "failed to get user: gone@example.com")
+		require.Equal(t, "alice@example.com", users[2].Value.PrimaryEmail)
+
+		groups, err := c.BatchGetGroups(ctx, []string{"ops@example.com", eng.Id})
+		require.NoError(t, err)
+		require.Equal(t, ops.Id, groups[0].Value.Id)
+		require.Equal(t, "Engineering", groups[1].Value.Name)
+
+		members, err := c.BatchGetMembers(ctx, []MemberKey{
+			{GroupKey: "eng@example.com", MemberKey: "alice@example.com"},
+			{GroupKey: "ops@example.com", MemberKey: "alice@example.com"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "OWNER", members[0].Value.Role)
+		require.Equal(t, codes.NotFound, status.Code(members[1].Err))
+
+		pages, err := c.BatchListMembers(ctx, []string{eng.Id, ops.Id})
+		require.NoError(t, err)
+		require.Len(t, pages[0].Value.Members, 2)
+		require.Empty(t, pages[1].Value.Members)
+
+		batches := slices.DeleteFunc(srv.Requests(), func(r string) bool { return r != "POST /batch/admin/directory_v1" })
+		if batch {
+			// Two batches of users and one each of groups, members and pages.
+			require.Len(t, batches, 5)
+		} else {
+			require.Empty(t, batches)
+		}
+	}
+}
+
+func TestBatchFailure(t *testing.T) {
+	srv := fake.New("C0fake")
+	t.Cleanup(srv.Close)
+	srv.AddDomain("example.com", true)
+	srv.AddUser(&directoryAdmin.User{PrimaryEmail: "admin@example.com", IsAdmin: true})
+	c := newFakeClient(t, srv, true)
+
+	srv.InjectFault(http.MethodPost, "/batch/", http.StatusServiceUnavailable, 1)
+	_, err := c.BatchGetUsers(context.Background(), []string{"admin@example.com"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	// A call the batch's token is not authorized for fails on its own.
+	c.UserBatcher = c.GroupBatcher
+	users, err := c.BatchGetUsers(context.Background(), []string{"admin@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, codes.PermissionDenied, status.Code(users[0].Err))
+}
@@ -7,9 +7,13 @@ import (
 	datatransferAdmin "google.golang.org/api/admin/datatransfer/v1"
 	directoryAdmin "google.golang.org/api/admin/directory/v1"
 	reportsAdmin "google.golang.org/api/admin/reports/v1"
+	alertcenter "google.golang.org/api/alertcenter/v1beta1"
 	cloudidentity "google.golang.org/api/cloudidentity/v1"
+	drive "google.golang.org/api/drive/v3"
+	gmail "google.golang.org/api/gmail/v1"
 	"google.golang.org/api/googleapi"
 	groupssettings "google.golang.org/api/groupssettings/v1"
+	licensing "google.golang.org/api/licensing/v1"
 )
 
 // errServiceNotAvailable returns a standardised error for when a required Google
@@ -35,13 +39,30 @@ type GoogleWorkspaceClient struct {
 	GroupMemberProvisioningService *directoryAdmin.Service
 	GroupProvisioningService       *directoryAdmin.Service
 
+	// Directory – batch requests, each authorized like the service of the
+	// same name (optional; nil when scope not granted, and then batch
+	// methods make each call on its own)
+	UserBatcher                    *DirectoryBatcher
+	GroupBatcher                   *DirectoryBatcher
+	GroupMemberBatcher             *DirectoryBatcher
+	GroupMemberProvisioningBatcher *DirectoryBatcher
+
 	// Directory – roles
 	RoleService             *directoryAdmin.Service
 	RoleProvisioningService *directoryAdmin.Service
 
+	// Directory – organizational units
+	OrgUnitService *directoryAdmin.Service
+
 	// Directory – domains (connector-level)
 	DomainService *directoryAdmin.Service
 
+	// Directory – devices (optional; nil when scope not granted)
+	MobileDeviceService             *directoryAdmin.Service
+	MobileDeviceActionService       *directoryAdmin.Service
+	ChromeDeviceService             *directoryAdmin.Service
+	ChromeDeviceProvisioningService *directoryAdmin.Service
+
 	// Other services
 	GroupsSettingsService *groupssettings.Service
 	DataTransferService   *datatransferAdmin.Service
@@ -49,8 +70,27 @@ type GoogleWorkspaceClient struct {
 
 	// Cloud Identity – SAML profiles (optional; nil when scope not granted)
 	CloudIdentityService *cloudidentity.Service
+
+	// Enterprise License Manager – license assignments (optional; nil when scope not granted)
+	LicensingService *licensing.Service
+
+	// Drive – shared drives and their members (optional; nil when scope not granted)
+	DriveService             *drive.Service
+	DriveProvisioningService *drive.Service
+
+	// Gmail – mailbox settings (optional; nil when scope not granted)
+	GmailService             GmailServiceFactory
+	GmailProvisioningService GmailServiceFactory
+
+	// Alert Center – security alerts (optional; nil when scope not granted)
+	AlertCenterService *alertcenter.Service
 }
 
+// GmailServiceFactory returns a Gmail service acting as userEmail. The Gmail
+// settings endpoints only accept the authenticated user's own mailbox, so
+// each mailbox needs a service impersonating its owner.
+type GmailServiceFactory func(ctx context.Context, userEmail string) (*gmail.Service, error)
+
 // ---------------------------------------------------------------------------
 // Domains
 // ---------------------------------------------------------------------------
@@ -95,7 +135,9 @@ const listUsersFields googleapi.Field = "nextPageToken,users(id,primaryEmail,nam
 	"creationTime,lastLoginTime,orgUnitPath,includeInGlobalAddressList," +
 	"customerId,relations,organizations,customSchemas,posixAccounts,externalIds)"
 
-func (c *GoogleWorkspaceClient) ListUsers(ctx context.Context, customerId, domain, pageToken string) (*directoryAdmin.Users, error) {
+// ListUsers lists one page of users. A non-empty query is passed through as
+// the Directory API search query, e.g. "isSuspended=false orgUnitPath='/Sales'".
+func (c *GoogleWorkspaceClient) ListUsers(ctx context.Context, customerId, domain, query, pageToken string) (*directoryAdmin.Users, error) {
 	if c.UserService == nil {
 		return nil, errServiceNotAvailable("user service")
 	}
@@ -109,6 +151,9 @@ func (c *GoogleWorkspaceClient) ListUsers(ctx context.Context, customerId, domai
 	} else {
 		r = r.Customer(customerId)
 	}
+	if query != "" {
+		r = r.Query(query)
+	}
 	if pageToken != "" {
 		r = r.PageToken(pageToken)
 	}
@@ -119,6 +164,28 @@ func (c *GoogleWorkspaceClient) ListUsers(ctx context.Context, customerId, domai
 	return resp, nil
 }
 
+// ListDeletedUsers lists one page of the customer's users deleted in the last
+// 20 days, which can still be restored. Deleted users can't be looked up by
+// email, but keep their ID and primary email here.
+func (c *GoogleWorkspaceClient) ListDeletedUsers(ctx context.Context, customerId, pageToken string) (*directoryAdmin.Users, error) {
+	if c.UserService == nil {
+		return nil, errServiceNotAvailable("user service")
+	}
+	r := c.UserService.Users.List().
+		Customer(customerId).
+		ShowDeleted("true").
+		MaxResults(500).
+		Fields("nextPageToken,users(id,primaryEmail,deletionTime)")
+	if pageToken != "" {
+		r = r.PageToken(pageToken)
+	}
+	resp, err := r.Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, "failed to list deleted users")
+	}
+	return resp, nil
+}
+
 func (c *GoogleWorkspaceClient) GetUser(ctx context.Context, userId string) (*directoryAdmin.User, error) {
 	if c.UserService == nil {
 		return nil, errServiceNotAvailable("user service")
@@ -305,11 +372,49 @@ func (c *GoogleWorkspaceClient) DeleteAsp(ctx context.Context, userId string, co
 	return nil
 }
 
+// ---------------------------------------------------------------------------
+// Users – watch channels
+// ---------------------------------------------------------------------------
+
+// WatchUsers opens a push channel that notifies channel.Address of the given
+// user event (add, delete, makeAdmin, undelete or update).
+func (c *GoogleWorkspaceClient) WatchUsers(ctx context.Context, customerId, domain, event string, channel *directoryAdmin.Channel) (*directoryAdmin.Channel, error) {
+	if c.UserService == nil {
+		return nil, errServiceNotAvailable("user service")
+	}
+	r := c.UserService.Users.Watch(channel).Event(event)
+	if domain != "" {
+		r = r.Domain(domain)
+	} else {
+		r = r.Customer(customerId)
+	}
+	resp, err := r.Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to watch user %s events", event))
+	}
+	return resp, nil
+}
+
+// StopChannel stops notifications on a channel opened by WatchUsers. Only
+// the channel's Id and ResourceId are used.
+func (c *GoogleWorkspaceClient) StopChannel(ctx context.Context, channel *directoryAdmin.Channel) error {
+	if c.UserService == nil {
+		return errServiceNotAvailable("user service")
+	}
+	err := c.UserService.Channels.Stop(&directoryAdmin.Channel{Id: channel.Id, ResourceId: channel.ResourceId}).Context(ctx).Do()
+	if err != nil {
+		return wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to stop watch channel: %s", channel.Id))
+	}
+	return nil
+}
+
 // ---------------------------------------------------------------------------
 // Groups – read
 // ---------------------------------------------------------------------------
 
-func (c *GoogleWorkspaceClient) ListGroups(ctx context.Context, customerId, domain, pageToken string) (*directoryAdmin.Groups, error) {
+// ListGroups lists one page of groups. A non-empty query is passed through as
+// the Directory API group search query, e.g. "email:eng-*".
+func (c *GoogleWorkspaceClient) ListGroups(ctx context.Context, customerId, domain, query, pageToken string) (*directoryAdmin.Groups, error) {
 	if c.GroupService == nil {
 		return nil, errServiceNotAvailable("group service")
 	}
@@ -319,6 +424,9 @@ func (c *GoogleWorkspaceClient) ListGroups(ctx context.Context, customerId, doma
 	} else {
 		r = r.Customer(customerId)
 	}
+	if query != "" {
+		r = r.Query(query)
+	}
 	if pageToken != "" {
 		r = r.PageToken(pageToken)
 	}
@@ -340,11 +448,14 @@ func (c *GoogleWorkspaceClient) GetGroup(ctx context.Context, groupKey string) (
 	return resp, nil
 }
 
+// listMembersPageSize is the Directory API's largest page of group members.
+const listMembersPageSize = 200
+
 func (c *GoogleWorkspaceClient) ListMembers(ctx context.Context, groupId, pageToken string) (*directoryAdmin.Members, error) {
 	if c.GroupMemberService == nil {
 		return nil, errServiceNotAvailable("group member service")
 	}
-	r := c.GroupMemberService.Members.List(groupId).MaxResults(200)
+	r := c.GroupMemberService.Members.List(groupId).MaxResults(listMembersPageSize)
 	if pageToken != "" {
 		r = r.PageToken(pageToken)
 	}
@@ -403,6 +514,17 @@ func (c *GoogleWorkspaceClient) GetMember(ctx context.Context, groupId, memberKe
 	return resp, nil
 }
 
+func (c *GoogleWorkspaceClient) PatchMember(ctx context.Context, groupId, memberKey string, member *directoryAdmin.Member) (*directoryAdmin.Member, error) {
+	if c.GroupMemberProvisioningService == nil {
+		return nil, errServiceNotAvailable("group member provisioning service")
+	}
+	resp, err := c.GroupMemberProvisioningService.Members.Patch(groupId, memberKey, member).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to update member %s in group: %s", memberKey, groupId))
+	}
+	return resp, nil
+}
+
 func (c *GoogleWorkspaceClient) DeleteMember(ctx context.Context, groupId, memberKey string) error {
 	if c.GroupMemberProvisioningService == nil {
 		return errServiceNotAvailable("group member provisioning service")
@@ -470,6 +592,19 @@ func (c *GoogleWorkspaceClient) GetRole(ctx context.Context, customerId, roleId
 	return resp, nil
 }
 
+// ListPrivileges lists the privilege tree assignable to custom roles; each
+// privilege carries its child privileges. The endpoint is not paginated.
+func (c *GoogleWorkspaceClient) ListPrivileges(ctx context.Context, customerId string) (*directoryAdmin.Privileges, error) {
+	if c.RoleService == nil {
+		return nil, errServiceNotAvailable("role service")
+	}
+	resp, err := c.RoleService.Privileges.List(customerId).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, "failed to list privileges")
+	}
+	return resp, nil
+}
+
 func (c *GoogleWorkspaceClient) ListRoleAssignments(ctx context.Context, customerId, roleId, pageToken string) (*directoryAdmin.RoleAssignments, error) {
 	if c.RoleService == nil {
 		return nil, errServiceNotAvailable("role service")
@@ -511,6 +646,228 @@ func (c *GoogleWorkspaceClient) DeleteRoleAssignment(ctx context.Context, custom
 	return nil
 }
 
+func (c *GoogleWorkspaceClient) InsertRole(ctx context.Context, customerId string, role *directoryAdmin.Role) (*directoryAdmin.Role, error) {
+	if c.RoleProvisioningService == nil {
+		return nil, errServiceNotAvailable("role provisioning service")
+	}
+	resp, err := c.RoleProvisioningService.Roles.Insert(customerId, role).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to create role: %s", role.RoleName))
+	}
+	return resp, nil
+}
+
+// PatchRole updates only the fields set on role (plus its ForceSendFields).
+func (c *GoogleWorkspaceClient) PatchRole(ctx context.Context, customerId, roleId string, role *directoryAdmin.Role) (*directoryAdmin.Role, error) {
+	if c.RoleProvisioningService == nil {
+		return nil, errServiceNotAvailable("role provisioning service")
+	}
+	resp, err := c.RoleProvisioningService.Roles.Patch(customerId, roleId, role).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to update role: %s", roleId))
+	}
+	return resp, nil
+}
+
+func (c *GoogleWorkspaceClient) DeleteRole(ctx context.Context, customerId, roleId string) error {
+	if c.RoleProvisioningService == nil {
+		return errServiceNotAvailable("role provisioning service")
+	}
+	err := c.RoleProvisioningService.Roles.Delete(customerId, roleId).Context(ctx).Do()
+	if err != nil {
+		return wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to delete role: %s", roleId))
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Organizational units – read
+// ---------------------------------------------------------------------------
+
+// ListOrgUnits returns the immediate children of parentOrgUnit, which may be
+// an OU path or an "id:..." OU ID; an empty parentOrgUnit lists the children
+// of the root OU. The orgunits API is not paginated.
+func (c *GoogleWorkspaceClient) ListOrgUnits(ctx context.Context, customerId, parentOrgUnit string) (*directoryAdmin.OrgUnits, error) {
+	if c.OrgUnitService == nil {
+		return nil, errServiceNotAvailable("org unit service")
+	}
+	r := c.OrgUnitService.Orgunits.List(customerId).Type("children")
+	if parentOrgUnit != "" {
+		r = r.OrgUnitPath(parentOrgUnit)
+	}
+	resp, err := r.Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, "failed to list org units")
+	}
+	return resp, nil
+}
+
+// ListAllOrgUnits lists every OU below the root in one call; the orgunits
+// API does not paginate.
+func (c *GoogleWorkspaceClient) ListAllOrgUnits(ctx context.Context, customerId string) (*directoryAdmin.OrgUnits, error) {
+	if c.OrgUnitService == nil {
+		return nil, errServiceNotAvailable("org unit service")
+	}
+	resp, err := c.OrgUnitService.Orgunits.List(customerId).Type("all").Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, "failed to list all org units")
+	}
+	return resp, nil
+}
+
+// GetOrgUnit fetches a single OU by path (without the leading '/') or by its
+// "id:..." OU ID.
+func (c *GoogleWorkspaceClient) GetOrgUnit(ctx context.Context, customerId, orgUnit string) (*directoryAdmin.OrgUnit, error) {
+	if c.OrgUnitService == nil {
+		return nil, errServiceNotAvailable("org unit service")
+	}
+	resp, err := c.OrgUnitService.Orgunits.Get(customerId, orgUnit).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to get org unit: %s", orgUnit))
+	}
+	return resp, nil
+}
+
+// ListUserOrgUnitsPage lists one page of users with only their ID, OU path
+// and status, for building an OU membership index in a single directory walk.
+// query is as for ListUsers.
+func (c *GoogleWorkspaceClient) ListUserOrgUnitsPage(ctx context.Context, customerId, domain, query, pageToken string) (*directoryAdmin.Users, error) {
+	if c.UserService == nil {
+		return nil, errServiceNotAvailable("user service")
+	}
+	r := c.UserService.Users.List().
+		MaxResults(500).
+		Fields("nextPageToken,users(id,orgUnitPath,archived,suspended)")
+	if domain != "" {
+		r = r.Domain(domain)
+	} else {
+		r = r.Customer(customerId)
+	}
+	if query != "" {
+		r = r.Query(query)
+	}
+	if pageToken != "" {
+		r = r.PageToken(pageToken)
+	}
+	resp, err := r.Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, "failed to list user org units")
+	}
+	return resp, nil
+}
+
+// ---------------------------------------------------------------------------
+// Devices – mobile
+// ---------------------------------------------------------------------------
+
+func (c *GoogleWorkspaceClient) ListMobileDevices(ctx context.Context, customerId, pageToken string) (*directoryAdmin.MobileDevices, error) {
+	if c.MobileDeviceService == nil {
+		return nil, errServiceNotAvailable("mobile device service")
+	}
+	r := c.MobileDeviceService.Mobiledevices.List(customerId).
+		MaxResults(100).
+		Projection("FULL")
+	if pageToken != "" {
+		r = r.PageToken(pageToken)
+	}
+	resp, err := r.Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, "failed to list mobile devices")
+	}
+	return resp, nil
+}
+
+func (c *GoogleWorkspaceClient) GetMobileDevice(ctx context.Context, customerId, resourceId string) (*directoryAdmin.MobileDevice, error) {
+	if c.MobileDeviceService == nil {
+		return nil, errServiceNotAvailable("mobile device service")
+	}
+	resp, err := c.MobileDeviceService.Mobiledevices.Get(customerId, resourceId).Projection("FULL").Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to get mobile device: %s", resourceId))
+	}
+	return resp, nil
+}
+
+// ActOnMobileDevice takes action ("approve", "block", "admin_account_wipe",
+// "admin_remote_wipe", ...) on the mobile device with the given resource ID.
+func (c *GoogleWorkspaceClient) ActOnMobileDevice(ctx context.Context, customerId, resourceId, action string) error {
+	if c.MobileDeviceActionService == nil {
+		return errServiceNotAvailable("mobile device action service")
+	}
+	err := c.MobileDeviceActionService.Mobiledevices.Action(customerId, resourceId, &directoryAdmin.MobileDeviceAction{Action: action}).Context(ctx).Do()
+	if err != nil {
+		return wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to %s mobile device: %s", action, resourceId))
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Devices – Chrome OS
+// ---------------------------------------------------------------------------
+
+// Chrome OS device status actions accepted by ChangeChromeOSDeviceStatus.
+const (
+	ChromeOSDeviceActionDisable     = "CHANGE_CHROME_OS_DEVICE_STATUS_ACTION_DISABLE"
+	ChromeOSDeviceActionDeprovision = "CHANGE_CHROME_OS_DEVICE_STATUS_ACTION_DEPROVISION"
+)
+
+func (c *GoogleWorkspaceClient) ListChromeOSDevices(ctx context.Context, customerId, pageToken string) (*directoryAdmin.ChromeOsDevices, error) {
+	if c.ChromeDeviceService == nil {
+		return nil, errServiceNotAvailable("chrome device service")
+	}
+	r := c.ChromeDeviceService.Chromeosdevices.List(customerId).
+		MaxResults(200).
+		Projection("BASIC")
+	if pageToken != "" {
+		r = r.PageToken(pageToken)
+	}
+	resp, err := r.Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, "failed to list chrome os devices")
+	}
+	return resp, nil
+}
+
+func (c *GoogleWorkspaceClient) GetChromeOSDevice(ctx context.Context, customerId, deviceId string) (*directoryAdmin.ChromeOsDevice, error) {
+	if c.ChromeDeviceService == nil {
+		return nil, errServiceNotAvailable("chrome device service")
+	}
+	resp, err := c.ChromeDeviceService.Chromeosdevices.Get(customerId, deviceId).Projection("BASIC").Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to get chrome os device: %s", deviceId))
+	}
+	return resp, nil
+}
+
+// ChangeChromeOSDeviceStatus applies a CHANGE_CHROME_OS_DEVICE_STATUS_ACTION_*
+// action to one Chrome OS device via batchChangeStatus, which replaces the
+// deprecated chromeosdevices.action. deprovisionReason (a DEPROVISION_REASON_*
+// value) is required by the API for deprovisioning and omitted otherwise.
+func (c *GoogleWorkspaceClient) ChangeChromeOSDeviceStatus(ctx context.Context, customerId, deviceId, action, deprovisionReason string) error {
+	if c.ChromeDeviceProvisioningService == nil {
+		return errServiceNotAvailable("chrome device provisioning service")
+	}
+	req := &directoryAdmin.BatchChangeChromeOsDeviceStatusRequest{
+		ChangeChromeOsDeviceStatusAction: action,
+		DeviceIds:                        []string{deviceId},
+	}
+	if action == ChromeOSDeviceActionDeprovision {
+		req.DeprovisionReason = deprovisionReason
+	}
+	resp, err := c.ChromeDeviceProvisioningService.Customer.Devices.Chromeos.BatchChangeStatus(customerId, req).Context(ctx).Do()
+	if err != nil {
+		return wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to change status of chrome os device: %s", deviceId))
+	}
+	// The batch call succeeds as a whole; per-device failures are reported
+	// in the results.
+	for _, r := range resp.ChangeChromeOsDeviceStatusResults {
+		if r.DeviceId == deviceId && r.Error != nil {
+			return fmt.Errorf("google-workspace: failed to change status of chrome os device %s: %s (code %d)", deviceId, r.Error.Message, r.Error.Code)
+		}
+	}
+	return nil
+}
+
 // ---------------------------------------------------------------------------
 // Data Transfer
 // ---------------------------------------------------------------------------
@@ -541,20 +898,24 @@ func (c *GoogleWorkspaceClient) InsertDataTransfer(ctx context.Context, transfer
 	return resp, nil
 }
 
-// ListUserIDsPage lists users returning only id and primaryEmail fields, optimized for
-// high-volume app discovery where full user profiles are not needed.
-func (c *GoogleWorkspaceClient) ListUserIDsPage(ctx context.Context, customerID, domain, pageToken string) (*directoryAdmin.Users, error) {
+// ListUserIDsPage lists users returning only their ids, primary emails and the
+// few fields sync filters match on, optimized for high-volume app discovery
+// where full user profiles are not needed. query is as for ListUsers.
+func (c *GoogleWorkspaceClient) ListUserIDsPage(ctx context.Context, customerID, domain, query, pageToken string) (*directoryAdmin.Users, error) {
 	if c.UserService == nil {
 		return nil, errServiceNotAvailable("user service")
 	}
 	r := c.UserService.Users.List().
 		MaxResults(500).
-		Fields("nextPageToken,users(id,primaryEmail)")
+		Fields("nextPageToken,users(id,primaryEmail,orgUnitPath,archived,suspended)")
 	if domain != "" {
 		r = r.Domain(domain)
 	} else {
 		r = r.Customer(customerID)
 	}
+	if query != "" {
+		r = r.Query(query)
+	}
 	if pageToken != "" {
 		r = r.PageToken(pageToken)
 	}
@@ -565,6 +926,305 @@ func (c *GoogleWorkspaceClient) ListUserIDsPage(ctx context.Context, customerID,
 	return resp, nil
 }
 
+// ---------------------------------------------------------------------------
+// Licensing
+// ---------------------------------------------------------------------------
+
+// ListLicenseAssignments lists one page of the users assigned skuId of
+// productId. Each assignment's UserId is the user's primary email address.
+func (c *GoogleWorkspaceClient) ListLicenseAssignments(ctx context.Context, customerId, productId, skuId, pageToken string, maxResults int64) (*licensing.LicenseAssignmentList, error) {
+	if c.LicensingService == nil {
+		return nil, errServiceNotAvailable("licensing service")
+	}
+	r := c.LicensingService.LicenseAssignments.ListForProductAndSku(productId, skuId, customerId).MaxResults(maxResults)
+	if pageToken != "" {
+		r = r.PageToken(pageToken)
+	}
+	resp, err := r.Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to list license assignments for %s/%s", productId, skuId))
+	}
+	return resp, nil
+}
+
+// InsertLicenseAssignment assigns skuId of productId to userEmail.
+func (c *GoogleWorkspaceClient) InsertLicenseAssignment(ctx context.Context, productId, skuId, userEmail string) (*licensing.LicenseAssignment, error) {
+	if c.LicensingService == nil {
+		return nil, errServiceNotAvailable("licensing service")
+	}
+	resp, err := c.LicensingService.LicenseAssignments.Insert(productId, skuId, &licensing.LicenseAssignmentInsert{UserId: userEmail}).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to assign license %s/%s to user: %s", productId, skuId, userEmail))
+	}
+	return resp, nil
+}
+
+// DeleteLicenseAssignment removes skuId of productId from userEmail.
+func (c *GoogleWorkspaceClient) DeleteLicenseAssignment(ctx context.Context, productId, skuId, userEmail string) error {
+	if c.LicensingService == nil {
+		return errServiceNotAvailable("licensing service")
+	}
+	_, err := c.LicensingService.LicenseAssignments.Delete(productId, skuId, userEmail).Context(ctx).Do()
+	if err != nil {
+		return wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to remove license %s/%s from user: %s", productId, skuId, userEmail))
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Drive – shared drives
+// ---------------------------------------------------------------------------
+//
+// Every call sets useDomainAdminAccess, so shared drives and their members are
+// visible to the impersonated admin without being a member of each drive.
+
+const listDrivePermissionsFields googleapi.Field = "nextPageToken,permissions(id,type,role,emailAddress,domain,displayName,deleted)"
+
+func (c *GoogleWorkspaceClient) ListSharedDrives(ctx context.Context, pageToken string) (*drive.DriveList, error) {
+	if c.DriveService == nil {
+		return nil, errServiceNotAvailable("drive service")
+	}
+	r := c.DriveService.Drives.List().
+		UseDomainAdminAccess(true).
+		PageSize(100)
+	if pageToken != "" {
+		r = r.PageToken(pageToken)
+	}
+	resp, err := r.Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, "failed to list shared drives")
+	}
+	return resp, nil
+}
+
+func (c *GoogleWorkspaceClient) GetSharedDrive(ctx context.Context, driveId string) (*drive.Drive, error) {
+	if c.DriveService == nil {
+		return nil, errServiceNotAvailable("drive service")
+	}
+	resp, err := c.DriveService.Drives.Get(driveId).UseDomainAdminAccess(true).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to get shared drive: %s", driveId))
+	}
+	return resp, nil
+}
+
+// ListDrivePermissions lists one page of a shared drive's members.
+func (c *GoogleWorkspaceClient) ListDrivePermissions(ctx context.Context, driveId, pageToken string) (*drive.PermissionList, error) {
+	if c.DriveService == nil {
+		return nil, errServiceNotAvailable("drive service")
+	}
+	r := c.DriveService.Permissions.List(driveId).
+		SupportsAllDrives(true).
+		UseDomainAdminAccess(true).
+		PageSize(100).
+		Fields(listDrivePermissionsFields)
+	if pageToken != "" {
+		r = r.PageToken(pageToken)
+	}
+	resp, err := r.Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to list permissions for shared drive: %s", driveId))
+	}
+	return resp, nil
+}
+
+// CreateDrivePermission adds a member to a shared drive without emailing them.
+func (c *GoogleWorkspaceClient) CreateDrivePermission(ctx context.Context, driveId string, permission *drive.Permission) (*drive.Permission, error) {
+	if c.DriveProvisioningService == nil {
+		return nil, errServiceNotAvailable("drive provisioning service")
+	}
+	resp, err := c.DriveProvisioningService.Permissions.Create(driveId, permission).
+		SupportsAllDrives(true).
+		UseDomainAdminAccess(true).
+		SendNotificationEmail(false).
+		Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to create permission on shared drive: %s", driveId))
+	}
+	return resp, nil
+}
+
+// UpdateDrivePermissionRole changes the role of an existing shared drive member.
+func (c *GoogleWorkspaceClient) UpdateDrivePermissionRole(ctx context.Context, driveId, permissionId, role string) (*drive.Permission, error) {
+	if c.DriveProvisioningService == nil {
+		return nil, errServiceNotAvailable("drive provisioning service")
+	}
+	resp, err := c.DriveProvisioningService.Permissions.Update(driveId, permissionId, &drive.Permission{Role: role}).
+		SupportsAllDrives(true).
+		UseDomainAdminAccess(true).
+		Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to update permission %s on shared drive: %s", permissionId, driveId))
+	}
+	return resp, nil
+}
+
+func (c *GoogleWorkspaceClient) DeleteDrivePermission(ctx context.Context, driveId, permissionId string) error {
+	if c.DriveProvisioningService == nil {
+		return errServiceNotAvailable("drive provisioning service")
+	}
+	err := c.DriveProvisioningService.Permissions.Delete(driveId, permissionId).
+		SupportsAllDrives(true).
+		UseDomainAdminAccess(true).
+		Context(ctx).Do()
+	if err != nil {
+		return wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to delete permission %s from shared drive: %s", permissionId, driveId))
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Gmail – mailbox settings
+// ---------------------------------------------------------------------------
+//
+// Every call impersonates the mailbox owner and addresses the mailbox as
+// "me".
+
+const gmailUserMe = "me"
+
+// ListMailboxUsers lists one page of users for mailbox sync. Pages are small
+// because each mailbox costs several Gmail calls. query is as for ListUsers.
+func (c *GoogleWorkspaceClient) ListMailboxUsers(ctx context.Context, customerID, domain, query, pageToken string) (*directoryAdmin.Users, error) {
+	if c.UserService == nil {
+		return nil, errServiceNotAvailable("user service")
+	}
+	r := c.UserService.Users.List().
+		OrderBy("email").
+		MaxResults(50).
+		Fields("nextPageToken,users(id,primaryEmail,name(fullName),suspended,archived,isMailboxSetup,orgUnitPath)")
+	if domain != "" {
+		r = r.Domain(domain)
+	} else {
+		r = r.Customer(customerID)
+	}
+	if query != "" {
+		r = r.Query(query)
+	}
+	if pageToken != "" {
+		r = r.PageToken(pageToken)
+	}
+	resp, err := r.Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, "failed to list mailbox users")
+	}
+	return resp, nil
+}
+
+func (c *GoogleWorkspaceClient) gmailService(ctx context.Context, userEmail string) (*gmail.Service, error) {
+	if c.GmailService == nil {
+		return nil, errServiceNotAvailable("gmail service")
+	}
+	return c.GmailService(ctx, userEmail)
+}
+
+func (c *GoogleWorkspaceClient) gmailProvisioningService(ctx context.Context, userEmail string) (*gmail.Service, error) {
+	if c.GmailProvisioningService == nil {
+		return nil, errServiceNotAvailable("gmail provisioning service")
+	}
+	return c.GmailProvisioningService(ctx, userEmail)
+}
+
+func (c *GoogleWorkspaceClient) ListMailDelegates(ctx context.Context, userEmail string) (*gmail.ListDelegatesResponse, error) {
+	srv, err := c.gmailService(ctx, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := srv.Users.Settings.Delegates.List(gmailUserMe).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to list mail delegates: %s", userEmail))
+	}
+	return resp, nil
+}
+
+func (c *GoogleWorkspaceClient) GetMailAutoForwarding(ctx context.Context, userEmail string) (*gmail.AutoForwarding, error) {
+	srv, err := c.gmailService(ctx, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := srv.Users.Settings.GetAutoForwarding(gmailUserMe).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to get mail auto-forwarding: %s", userEmail))
+	}
+	return resp, nil
+}
+
+func (c *GoogleWorkspaceClient) ListMailForwardingAddresses(ctx context.Context, userEmail string) (*gmail.ListForwardingAddressesResponse, error) {
+	srv, err := c.gmailService(ctx, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := srv.Users.Settings.ForwardingAddresses.List(gmailUserMe).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to list mail forwarding addresses: %s", userEmail))
+	}
+	return resp, nil
+}
+
+func (c *GoogleWorkspaceClient) ListMailSendAs(ctx context.Context, userEmail string) (*gmail.ListSendAsResponse, error) {
+	srv, err := c.gmailService(ctx, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := srv.Users.Settings.SendAs.List(gmailUserMe).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to list mail send-as aliases: %s", userEmail))
+	}
+	return resp, nil
+}
+
+// CreateMailDelegate lets delegateEmail read, send and delete mail in
+// userEmail's mailbox. Delegates added by an admin are accepted immediately.
+func (c *GoogleWorkspaceClient) CreateMailDelegate(ctx context.Context, userEmail, delegateEmail string) (*gmail.Delegate, error) {
+	srv, err := c.gmailProvisioningService(ctx, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := srv.Users.Settings.Delegates.Create(gmailUserMe, &gmail.Delegate{DelegateEmail: delegateEmail}).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to add mail delegate %s: %s", delegateEmail, userEmail))
+	}
+	return resp, nil
+}
+
+func (c *GoogleWorkspaceClient) DeleteMailDelegate(ctx context.Context, userEmail, delegateEmail string) error {
+	srv, err := c.gmailProvisioningService(ctx, userEmail)
+	if err != nil {
+		return err
+	}
+	err = srv.Users.Settings.Delegates.Delete(gmailUserMe, delegateEmail).Context(ctx).Do()
+	if err != nil {
+		return wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to remove mail delegate %s: %s", delegateEmail, userEmail))
+	}
+	return nil
+}
+
+func (c *GoogleWorkspaceClient) DisableMailAutoForwarding(ctx context.Context, userEmail string) error {
+	srv, err := c.gmailProvisioningService(ctx, userEmail)
+	if err != nil {
+		return err
+	}
+	_, err = srv.Users.Settings.UpdateAutoForwarding(gmailUserMe, &gmail.AutoForwarding{
+		Enabled:         false,
+		ForceSendFields: []string{"Enabled"},
+	}).Context(ctx).Do()
+	if err != nil {
+		return wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to disable mail auto-forwarding: %s", userEmail))
+	}
+	return nil
+}
+
+func (c *GoogleWorkspaceClient) DeleteMailForwardingAddress(ctx context.Context, userEmail, forwardingEmail string) error {
+	srv, err := c.gmailProvisioningService(ctx, userEmail)
+	if err != nil {
+		return err
+	}
+	err = srv.Users.Settings.ForwardingAddresses.Delete(gmailUserMe, forwardingEmail).Context(ctx).Do()
+	if err != nil {
+		return wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to delete mail forwarding address %s: %s", forwardingEmail, userEmail))
+	}
+	return nil
+}
+
 // ---------------------------------------------------------------------------
 // Reports
 // ---------------------------------------------------------------------------
@@ -593,6 +1253,83 @@ func (c *GoogleWorkspaceClient) ListActivities(ctx context.Context, userKey, app
 	return resp, nil
 }
 
+// ---------------------------------------------------------------------------
+// Alert Center
+// ---------------------------------------------------------------------------
+
+// Alert Center calls leave customerId unset, so it is inferred from the
+// impersonated administrator.
+
+// ListAlerts lists one page of alerts matching filter, oldest first.
+func (c *GoogleWorkspaceClient) ListAlerts(ctx context.Context, filter, pageToken string, pageSize int64) (*alertcenter.ListAlertsResponse, error) {
+	if c.AlertCenterService == nil {
+		return nil, errServiceNotAvailable("alert center service")
+	}
+	r := c.AlertCenterService.Alerts.List().OrderBy("create_time asc")
+	if filter != "" {
+		r = r.Filter(filter)
+	}
+	if pageToken != "" {
+		r = r.PageToken(pageToken)
+	}
+	if pageSize > 0 {
+		r = r.PageSize(pageSize)
+	}
+	resp, err := r.Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, "failed to list alerts")
+	}
+	return resp, nil
+}
+
+// GetAlert gets an alert, including alerts marked for deletion.
+func (c *GoogleWorkspaceClient) GetAlert(ctx context.Context, alertID string) (*alertcenter.Alert, error) {
+	if c.AlertCenterService == nil {
+		return nil, errServiceNotAvailable("alert center service")
+	}
+	resp, err := c.AlertCenterService.Alerts.Get(alertID).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to get alert %s", alertID))
+	}
+	return resp, nil
+}
+
+// CreateAlertFeedback records feedbackType (NOT_USEFUL, SOMEWHAT_USEFUL or
+// VERY_USEFUL) for an alert.
+func (c *GoogleWorkspaceClient) CreateAlertFeedback(ctx context.Context, alertID, feedbackType string) (*alertcenter.AlertFeedback, error) {
+	if c.AlertCenterService == nil {
+		return nil, errServiceNotAvailable("alert center service")
+	}
+	resp, err := c.AlertCenterService.Alerts.Feedback.Create(alertID, &alertcenter.AlertFeedback{Type: feedbackType}).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to create feedback for alert %s", alertID))
+	}
+	return resp, nil
+}
+
+// DeleteAlert marks an alert for deletion. Google removes it after 30 days
+// unless it is undeleted.
+func (c *GoogleWorkspaceClient) DeleteAlert(ctx context.Context, alertID string) error {
+	if c.AlertCenterService == nil {
+		return errServiceNotAvailable("alert center service")
+	}
+	if _, err := c.AlertCenterService.Alerts.Delete(alertID).Context(ctx).Do(); err != nil {
+		return wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to delete alert %s", alertID))
+	}
+	return nil
+}
+
+// UndeleteAlert restores an alert marked for deletion.
+func (c *GoogleWorkspaceClient) UndeleteAlert(ctx context.Context, alertID string) error {
+	if c.AlertCenterService == nil {
+		return errServiceNotAvailable("alert center service")
+	}
+	if _, err := c.AlertCenterService.Alerts.Undelete(alertID, &alertcenter.UndeleteAlertRequest{}).Context(ctx).Do(); err != nil {
+		return wrapGoogleApiErrorWithContext(err, fmt.Sprintf("failed to undelete alert %s", alertID))
+	}
+	return nil
+}
+
 // ---------------------------------------------------------------------------
 // Cloud Identity
 // ---------------------------------------------------------------------------
@@ -617,6 +1354,23 @@ func (c *GoogleWorkspaceClient) ListInboundSamlProfiles(ctx context.Context, cus
 	return nil
 }
 
+// ListInboundSsoAssignments pages through the customer's inbound SSO
+// assignments, which pick the SSO profile each group or org unit signs in with.
+func (c *GoogleWorkspaceClient) ListInboundSsoAssignments(ctx context.Context, customerID string, fn func(*cloudidentity.ListInboundSsoAssignmentsResponse) error) error {
+	if c.CloudIdentityService == nil {
+		return errServiceNotAvailable("cloud identity service")
+	}
+	customerFilter := fmt.Sprintf(`customer=="customers/%s"`, customerID)
+	err := c.CloudIdentityService.InboundSsoAssignments.List().
+		Filter(customerFilter).
+		PageSize(100).
+		Pages(ctx, fn)
+	if err != nil {
+		return wrapGoogleApiErrorWithContext(err, "failed to list inbound SSO assignments")
+	}
+	return nil
+}
+
 // BuildSAMLProfileMap returns a displayName → profile.Name mapping for all Cloud Identity SAML
 // profiles. profile.Name is the stable server-assigned ID that survives admin renames.
 // OIDC profiles are excluded. Returns errServiceNotAvailable if CloudIdentityService is nil.
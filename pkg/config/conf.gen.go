@@ -6,9 +6,28 @@ import "reflect"
 type GoogleWorkspace struct {
 	CustomerId string `mapstructure:"customer-id"`
 	Domain string `mapstructure:"domain"`
+	Domains []string `mapstructure:"domains"`
+	AllDomains bool `mapstructure:"all-domains"`
+	AdditionalTenantsFilePath []byte `mapstructure:"additional-tenants-file-path"`
 	AdministratorEmail string `mapstructure:"administrator-email"`
 	CredentialsJsonFilePath []byte `mapstructure:"credentials-json-file-path"`
 	CredentialsJson string `mapstructure:"credentials-json"`
+	ServiceAccountEmail string `mapstructure:"service-account-email"`
+	ExternalAccountCredentialsFilePath []byte `mapstructure:"external-account-credentials-file-path"`
+	IncludeOrgUnits []string `mapstructure:"include-org-units"`
+	ExcludeOrgUnits []string `mapstructure:"exclude-org-units"`
+	UserQuery string `mapstructure:"user-query"`
+	ExcludeSuspendedUsers bool `mapstructure:"exclude-suspended-users"`
+	ExcludeArchivedUsers bool `mapstructure:"exclude-archived-users"`
+	GroupEmailPatterns []string `mapstructure:"group-email-patterns"`
+	ExcludeGroupEmailPatterns []string `mapstructure:"exclude-group-email-patterns"`
+	SyncGroupSettings bool `mapstructure:"sync-group-settings"`
+	OauthAppDiscovery string `mapstructure:"oauth-app-discovery"`
+	ApiRateLimits []string `mapstructure:"api-rate-limits"`
+	WatchCallbackUrl string `mapstructure:"watch-callback-url"`
+	WatchListenAddress string `mapstructure:"watch-listen-address"`
+	WatchChannelToken string `mapstructure:"watch-channel-token"`
+	BaseUrl string `mapstructure:"base-url"`
 }
 
 func (c *GoogleWorkspace) findFieldByTag(tagValue string) (any, bool) {
@@ -22,6 +22,30 @@ var (
 		field.WithDescription("The domain for the Google Workspace account"),
 	)
 
+	// DomainsField defines several domains to sync, listed one domain at a time.
+	DomainsField = field.StringSliceField(
+		"domains",
+		field.WithDisplayName("Domains"),
+		field.WithDescription("Domains of the Google Workspace account to sync. Users, groups and mailboxes are listed one domain at a time and tagged with their domain"),
+	)
+
+	// AllDomainsField syncs every verified domain of the customer, one at a time.
+	AllDomainsField = field.BoolField(
+		"all-domains",
+		field.WithDisplayName("All domains"),
+		field.WithDescription("Sync every verified domain of the Google Workspace account one at a time, as enumerated by the Directory API"),
+	)
+
+	// AdditionalTenantsFilePathField defines the path to a JSON list of
+	// further Google Workspace customers synced by the same connector.
+	AdditionalTenantsFilePathField = field.FileUploadField(
+		"additional-tenants-file-path",
+		[]string{".json"},
+		field.WithDisplayName("Additional tenants file"),
+		field.WithDescription("JSON array of additional Google Workspace customers to sync, each with customer_id, administrator_email, credentials or service_account_email, and optional domains or all_domains"),
+		field.WithIsSecret(true),
+	)
+
 	// AdministratorEmailField defines an administrator email for the Google Workspace account.
 	AdministratorEmailField = field.StringField(
 		"administrator-email",
@@ -48,11 +72,148 @@ var (
 		field.WithIsSecret(true),
 	)
 
+	// ServiceAccountEmailField enables keyless authentication: the
+	// domain-wide-delegated service account to impersonate via IAM signJwt.
+	ServiceAccountEmailField = field.StringField(
+		"service-account-email",
+		field.WithDisplayName("Service account email"),
+		field.WithDescription("Domain-wide-delegated service account to impersonate with workload identity federation or application default credentials instead of a JSON key"),
+	)
+
+	// ExternalAccountCredentialsFilePathField defines the path to a workload
+	// identity federation (external_account) credential configuration.
+	ExternalAccountCredentialsFilePathField = field.FileUploadField(
+		"external-account-credentials-file-path",
+		[]string{".json"},
+		field.WithDisplayName("External account credentials file"),
+		field.WithDescription("Workload identity federation credential configuration used to impersonate the service account. Application default credentials are used when omitted"),
+	)
+
+	// IncludeOrgUnitsField limits synced users to the given org units.
+	IncludeOrgUnitsField = field.StringSliceField(
+		"include-org-units",
+		field.WithDisplayName("Include org units"),
+		field.WithDescription("Only sync users in these org unit paths (for example /Engineering), including their sub-org units"),
+	)
+
+	// ExcludeOrgUnitsField skips users in the given org units.
+	ExcludeOrgUnitsField = field.StringSliceField(
+		"exclude-org-units",
+		field.WithDisplayName("Exclude org units"),
+		field.WithDescription("Skip users in these org unit paths, including their sub-org units"),
+	)
+
+	// UserQueryField defines a raw Directory API user search query.
+	UserQueryField = field.StringField(
+		"user-query",
+		field.WithDisplayName("User query"),
+		field.WithDescription("Directory API search query that synced users must match, for example isSuspended=false or a custom schema attribute match"),
+	)
+
+	// ExcludeSuspendedUsersField skips suspended users.
+	ExcludeSuspendedUsersField = field.BoolField(
+		"exclude-suspended-users",
+		field.WithDisplayName("Exclude suspended users"),
+		field.WithDescription("Skip suspended users"),
+	)
+
+	// ExcludeArchivedUsersField skips archived users.
+	ExcludeArchivedUsersField = field.BoolField(
+		"exclude-archived-users",
+		field.WithDisplayName("Exclude archived users"),
+		field.WithDescription("Skip archived users"),
+	)
+
+	// GroupEmailPatternsField limits synced groups by email.
+	GroupEmailPatternsField = field.StringSliceField(
+		"group-email-patterns",
+		field.WithDisplayName("Group email patterns"),
+		field.WithDescription("Only sync groups whose email matches one of these patterns, where * matches any characters (for example eng-*@example.com)"),
+	)
+
+	// ExcludeGroupEmailPatternsField skips groups by email.
+	ExcludeGroupEmailPatternsField = field.StringSliceField(
+		"exclude-group-email-patterns",
+		field.WithDisplayName("Exclude group email patterns"),
+		field.WithDescription("Skip groups whose email matches one of these patterns"),
+	)
+
+	// SyncGroupSettingsField adds Groups Settings to group profiles.
+	SyncGroupSettingsField = field.BoolField(
+		"sync-group-settings",
+		field.WithDisplayName("Sync group settings"),
+		field.WithDescription("Add each group's settings to its profile, with one Groups Settings API call per group"),
+	)
+
+	// OAuthAppDiscoveryField selects the data source OAuth apps are discovered from.
+	OAuthAppDiscoveryField = field.SelectField(
+		"oauth-app-discovery",
+		[]string{"tokens", "reports", "merged"},
+		field.WithDisplayName("OAuth app discovery"),
+		field.WithDescription("Where OAuth apps are discovered: tokens lists each user's current tokens, reports reads token authorizations from the last 180 days of the audit log, and merged combines both"),
+		field.WithDefaultValue("tokens"),
+	)
+
+	// APIRateLimitsField sets per-API call budgets.
+	APIRateLimitsField = field.StringSliceField(
+		"api-rate-limits",
+		field.WithDisplayName("API rate limits"),
+		field.WithDescription("Call budgets per Google API, one per entry as <api>=<n>/min or <api>=<n>/day, for example directory=1500/min. APIs: directory, reports, data_transfer, groups_settings, licensing, cloud_identity, drive, gmail, alert_center. Reports defaults to 220/min"),
+	)
+
+	// WatchCallbackURLField enables push mode: the public HTTPS URL Google
+	// delivers Directory API watch notifications to.
+	WatchCallbackURLField = field.StringField(
+		"watch-callback-url",
+		field.WithDisplayName("Watch callback URL"),
+		field.WithDescription("Public HTTPS URL that forwards to the watch receiver. Enables push notifications for user changes"),
+	)
+
+	// WatchListenAddressField defines the address the embedded watch receiver listens on.
+	WatchListenAddressField = field.StringField(
+		"watch-listen-address",
+		field.WithDisplayName("Watch listen address"),
+		field.WithDescription("Address the embedded watch receiver listens on"),
+		field.WithDefaultValue(":8080"),
+	)
+
+	// WatchChannelTokenField defines the token Google echoes on every watch notification.
+	WatchChannelTokenField = field.StringField(
+		"watch-channel-token",
+		field.WithDisplayName("Watch channel token"),
+		field.WithDescription("Shared secret attached to watch channels; notifications without it are rejected"),
+		field.WithIsSecret(true),
+	)
+
+	// BaseURLField sends every Google API request to another server, such as
+	// the fake server in pkg/fake.
+	BaseURLField = field.StringField(
+		"base-url",
+		field.WithDisplayName("Base URL"),
+		field.WithDescription("Send all Google API requests to this URL instead of Google's endpoints, for testing against a local fake server. Tokens are still requested from the credentials' token_uri"),
+		field.WithExportTarget(field.ExportTargetCLIOnly),
+		field.WithHidden(true),
+	)
+
 	// Field relationships define constraints between fields.
 	fieldRelationships = []field.SchemaFieldRelationship{
+		field.FieldsMutuallyExclusive(
+			DomainField,
+			DomainsField,
+			AllDomainsField,
+		),
 		field.FieldsMutuallyExclusive(
 			CredentialsJSONFilePathField,
 			CredentialsJSONField,
+			ServiceAccountEmailField,
+		),
+		field.FieldsDependentOn(
+			[]field.SchemaField{ExternalAccountCredentialsFilePathField},
+			[]field.SchemaField{ServiceAccountEmailField},
+		),
+		field.FieldsRequiredTogether(
+			WatchCallbackURLField,
+			WatchChannelTokenField,
 		),
 	}
 
@@ -60,9 +221,28 @@ var (
 	ConfigurationFields = []field.SchemaField{
 		CustomerIDField,
 		DomainField,
+		DomainsField,
+		AllDomainsField,
+		AdditionalTenantsFilePathField,
 		AdministratorEmailField,
 		CredentialsJSONFilePathField,
 		CredentialsJSONField,
+		ServiceAccountEmailField,
+		ExternalAccountCredentialsFilePathField,
+		IncludeOrgUnitsField,
+		ExcludeOrgUnitsField,
+		UserQueryField,
+		ExcludeSuspendedUsersField,
+		ExcludeArchivedUsersField,
+		GroupEmailPatternsField,
+		ExcludeGroupEmailPatternsField,
+		SyncGroupSettingsField,
+		OAuthAppDiscoveryField,
+		APIRateLimitsField,
+		WatchCallbackURLField,
+		WatchListenAddressField,
+		WatchChannelTokenField,
+		BaseURLField,
 	}
 
 	// Configuration combines fields into a single configuration object with connector metadata.
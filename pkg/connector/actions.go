@@ -8,10 +8,12 @@ import (
 
 	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
 	datatransferAdmin "google.golang.org/api/admin/datatransfer/v1"
 	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	alertcenter "google.golang.org/api/alertcenter/v1beta1"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/types/known/structpb"
 )
@@ -22,6 +24,13 @@ const (
 	appIdGoogleCalendar           = int64(435070579839)
 )
 
+// dataTransferOwnerField names what a transfer of each application changes,
+// for dry runs.
+var dataTransferOwnerField = map[int64]string{
+	appIdGoogleDocsAndGoogleDrive: "drive_files_owner",
+	appIdGoogleCalendar:           "calendar_owner",
+}
+
 // Argument, return-field, and display-name literals shared across the action
 // schemas and handlers (extracted to satisfy goconst).
 const (
@@ -43,12 +52,20 @@ const (
 	fieldSuspended     = "Suspended"
 	fieldResource      = "resource"
 	fieldSkippedFields = "skipped_fields"
+	argAlertID         = "alert_id"
+	argFeedback        = "feedback"
 	// descriptionSkippedFields is shared by update_user_profile and
 	// update_user's skipped_fields return field, both backed by the same
 	// applyUserProfilePatch.
 	descriptionSkippedFields = "Comma-separated list of provided fields that were not applied, with the reason why (e.g. an invalid manager_email)."
 )
 
+// update_alert status values.
+const (
+	alertStatusDeleted = "DELETED"
+	alertStatusActive  = "ACTIVE"
+)
+
 // Global (account-level) connector action schemas. Their handlers live in this
 // same file; they are registered in GlobalActions (connector.go).
 var (
@@ -266,6 +283,65 @@ var (
 		},
 		ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_ACCOUNT_ENABLE},
 	}
+	updateAlertActionSchema = &v2.BatonActionSchema{
+		Name:        "update_alert",
+		DisplayName: "Update Alert",
+		Description: "Record feedback on a Google Workspace Alert Center alert, or mark it deleted or active. The Alert Center API does not expose the alert's investigation status (Not started, In progress, Closed), so deleting is the only way to dismiss an alert.",
+		Arguments: []*config.Field{
+			{
+				Name:        argAlertID,
+				DisplayName: "Alert ID",
+				Description: "ID of the Alert Center alert, as reported by the alert center event feed.",
+				Field:       &config.Field_StringField{},
+				IsRequired:  true,
+			},
+			{
+				Name:        argFeedback,
+				DisplayName: "Feedback",
+				Description: "Feedback to record on the alert.",
+				Field: &config.Field_StringField{
+					StringField: &config.StringField{
+						Rules: &config.StringRules{
+							In: []string{"NOT_USEFUL", "SOMEWHAT_USEFUL", "VERY_USEFUL"},
+						},
+					},
+				},
+			},
+			{
+				Name:        fieldStatus,
+				DisplayName: "Status",
+				Description: "DELETED marks the alert for deletion (Google removes it after 30 days); ACTIVE restores a deleted alert.",
+				Field: &config.Field_StringField{
+					StringField: &config.StringField{
+						Rules: &config.StringRules{
+							In: []string{alertStatusDeleted, alertStatusActive},
+						},
+					},
+				},
+			},
+		},
+		ReturnTypes: []*config.Field{
+			{
+				Name:        fieldSuccess,
+				DisplayName: displaySuccess,
+				Description: "Whether the alert was updated successfully.",
+				Field:       &config.Field_BoolField{},
+			},
+			{
+				Name:        "feedback_id",
+				DisplayName: "Feedback ID",
+				Description: "ID of the recorded feedback, when feedback was given.",
+				Field:       &config.Field_StringField{},
+			},
+			{
+				Name:        fieldStatus,
+				DisplayName: "Status",
+				Description: "The alert's status after the update: DELETED or ACTIVE.",
+				Field:       &config.Field_StringField{},
+			},
+		},
+		ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_DYNAMIC},
+	}
 )
 
 func (c *GoogleWorkspace) updateUserStatus(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
@@ -287,6 +363,16 @@ func (c *GoogleWorkspace) updateUserStatus(ctx context.Context, args *structpb.S
 		return nil, nil, err
 	}
 
+	if isDryRun(args) {
+		u, err := withRateLimitWaitValue(ctx, func() (*directoryAdmin.User, error) {
+			return client.GetUserForProvisioning(ctx, userId)
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("google-workspace: failed to get user %s for updateUserStatus: %w", userId, err)
+		}
+		return dryRunResult(diffBoolField("suspended", u.Suspended, isSuspended)), nil, nil
+	}
+
 	// update user.isSuspended state
 	err = withRateLimitWait(ctx, func() error {
 		_, err := client.UpdateUser(ctx, userId, &directoryAdmin.User{
@@ -330,6 +416,9 @@ func (c *GoogleWorkspace) disableUserActionHandler(ctx context.Context, args *st
 	if err != nil {
 		return nil, nil, fmt.Errorf("google-workspace: failed to get user %s for disableUser: %w", userId, err)
 	}
+	if isDryRun(args) {
+		return dryRunResult(diffBoolField("suspended", u.Suspended, true)), nil, nil
+	}
 	if u.Suspended { // already suspended
 		response := structpb.Struct{Fields: map[string]*structpb.Value{
 			fieldSuccess: {Kind: &structpb.Value_BoolValue{BoolValue: true}},
@@ -374,6 +463,9 @@ func (c *GoogleWorkspace) enableUserActionHandler(ctx context.Context, args *str
 	if err != nil {
 		return nil, nil, fmt.Errorf("google-workspace: failed to get user %s for enableUser: %w", userId, err)
 	}
+	if isDryRun(args) {
+		return dryRunResult(diffBoolField("suspended", u.Suspended, false)), nil, nil
+	}
 	if !u.Suspended { // already active
 		response := structpb.Struct{Fields: map[string]*structpb.Value{
 			fieldSuccess: {Kind: &structpb.Value_BoolValue{BoolValue: true}},
@@ -430,6 +522,13 @@ func (c *GoogleWorkspace) changeUserPrimaryEmail(ctx context.Context, args *stru
 		return nil, nil, fmt.Errorf("google-workspace: failed to get user %s for changeUserPrimaryEmail: %w", userId, err)
 	}
 	prev := u.PrimaryEmail
+	if isDryRun(args) {
+		var changes []fieldChange
+		if !emailsEqual(prev, newPrimary) {
+			changes = diffField("primary_email", prev, newPrimary)
+		}
+		return dryRunResult(changes, actions.NewStringReturnField(fieldPreviousEmail, prev)), nil, nil
+	}
 	if emailsEqual(prev, newPrimary) { // Already primary email
 		response := structpb.Struct{Fields: map[string]*structpb.Value{
 			fieldSuccess:       {Kind: &structpb.Value_BoolValue{BoolValue: true}},
@@ -487,7 +586,7 @@ func (c *GoogleWorkspace) transferUserDriveFiles(ctx context.Context, args *stru
 	}
 	params = append(params, &datatransferAdmin.ApplicationTransferParam{Key: "PRIVACY_LEVEL", Value: levels})
 
-	return c.dataTransferInsert(ctx, appIdGoogleDocsAndGoogleDrive, sourceField.StringValue, targetField.StringValue, params)
+	return c.dataTransferInsert(ctx, appIdGoogleDocsAndGoogleDrive, sourceField.StringValue, targetField.StringValue, params, isDryRun(args))
 }
 
 // transferUserCalendar initiates a Calendar transfer using Data Transfer API.
@@ -518,16 +617,19 @@ func (c *GoogleWorkspace) transferUserCalendar(ctx context.Context, args *struct
 		params = append(params, p)
 	}
 
-	return c.dataTransferInsert(ctx, appIdGoogleCalendar, sourceField.StringValue, targetField.StringValue, params)
+	return c.dataTransferInsert(ctx, appIdGoogleCalendar, sourceField.StringValue, targetField.StringValue, params, isDryRun(args))
 }
 
 // dataTransferInsert encapsulates idempotency and insert logic for Data Transfer API.
+// A dry run reports the transfer it would create, or none when one is already
+// in progress.
 func (c *GoogleWorkspace) dataTransferInsert(
 	ctx context.Context,
 	appID int64,
 	oldOwnerUserId,
 	newOwnerUserId string,
 	params []*datatransferAdmin.ApplicationTransferParam,
+	dryRun bool,
 ) (*structpb.Struct, annotations.Annotations, error) {
 	client, err := c.getClient(ctx)
 	if err != nil {
@@ -552,6 +654,11 @@ func (c *GoogleWorkspace) dataTransferInsert(
 			if strings.EqualFold(t.OverallTransferStatusCode, "new") || strings.EqualFold(t.OverallTransferStatusCode, "inProgress") {
 				for _, adt := range t.ApplicationDataTransfers {
 					if adt.ApplicationId == appID {
+						if dryRun {
+							return dryRunResult(nil,
+								actions.NewStringReturnField(fieldTransferID, t.Id),
+								actions.NewStringReturnField(fieldStatus, t.OverallTransferStatusCode)), nil, nil
+						}
 						resp := &structpb.Struct{Fields: map[string]*structpb.Value{
 							fieldSuccess:    {Kind: &structpb.Value_BoolValue{BoolValue: true}},
 							fieldTransferID: {Kind: &structpb.Value_StringValue{StringValue: t.Id}},
@@ -568,6 +675,10 @@ func (c *GoogleWorkspace) dataTransferInsert(
 		pageToken = transfers.NextPageToken
 	}
 
+	if dryRun {
+		return dryRunResult(diffField(dataTransferOwnerField[appID], oldOwnerUserId, newOwnerUserId)), nil, nil
+	}
+
 	// If no transfer is in progress, create a new transfer.
 	transfer := &datatransferAdmin.DataTransfer{
 		OldOwnerUserId: oldOwnerUserId,
@@ -651,3 +762,85 @@ func parseDrivePrivacyLevels(args *structpb.Struct) ([]string, error) {
 	}
 	return normalized, nil
 }
+
+// updateAlertActionHandler records feedback on an alert and/or marks it
+// deleted or active (idempotent: the status is only changed when it differs).
+func (c *GoogleWorkspace) updateAlertActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	alertID := getStringField(args, argAlertID)
+	if alertID == "" {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "missing alert ID")
+	}
+	feedback := getStringField(args, argFeedback)
+	status := getStringField(args, fieldStatus)
+	if feedback == "" && status == "" {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "at least one of feedback or status is required")
+	}
+	if status != "" && status != alertStatusDeleted && status != alertStatusActive {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("invalid status %q", status))
+	}
+
+	client, err := c.getClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if client.AlertCenterService == nil {
+		return nil, nil, uhttp.WrapErrors(codes.FailedPrecondition,
+			fmt.Sprintf("google-workspace: alert center service not available - requires %s scope", alertcenter.AppsAlertsScope))
+	}
+
+	alert, err := withRateLimitWaitValue(ctx, func() (*alertcenter.Alert, error) {
+		return client.GetAlert(ctx, alertID)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: update_alert: %w", err)
+	}
+
+	if isDryRun(args) {
+		currentStatus := alertStatusActive
+		if alert.Deleted {
+			currentStatus = alertStatusDeleted
+		}
+		changes := diffField(argFeedback, "", feedback)
+		if status != "" {
+			changes = append(changes, diffField(fieldStatus, currentStatus, status)...)
+		}
+		return dryRunResult(changes), nil, nil
+	}
+
+	var feedbackID string
+	if feedback != "" {
+		created, err := withRateLimitWaitValue(ctx, func() (*alertcenter.AlertFeedback, error) {
+			return client.CreateAlertFeedback(ctx, alertID, feedback)
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("google-workspace: update_alert: %w", err)
+		}
+		feedbackID = created.FeedbackId
+	}
+
+	deleted := alert.Deleted
+	switch {
+	case status == alertStatusDeleted && !deleted:
+		err = withRateLimitWait(ctx, func() error {
+			return client.DeleteAlert(ctx, alertID)
+		})
+		deleted = true
+	case status == alertStatusActive && deleted:
+		err = withRateLimitWait(ctx, func() error {
+			return client.UndeleteAlert(ctx, alertID)
+		})
+		deleted = false
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: update_alert: %w", err)
+	}
+
+	newStatus := alertStatusActive
+	if deleted {
+		newStatus = alertStatusDeleted
+	}
+	return actions.NewReturnValues(true,
+		actions.NewStringReturnField("feedback_id", feedbackID),
+		actions.NewStringReturnField(fieldStatus, newStatus),
+	), nil, nil
+}
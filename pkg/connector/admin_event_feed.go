@@ -7,7 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,16 +27,16 @@ import (
 	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
 )
 
-// maxEventFeedLookback caps how far back the admin event feed queries the Google Reports API.
+// maxEventFeedLookback caps how far back the audit log event feeds query the Google Reports API.
 // Google page tokens expire after ~24h, so a cursor left mid-pagination (e.g. after a connector
 // restart or a transient timeout) would otherwise keep requesting the full historical window on
 // every retry, causing HTTP timeout death spirals on large orgs. 90 days balances sufficient
 // event history against query size; Google retains Reports data for 6 months so there is
 // headroom if the window needs to grow.
 //
-// Note: this cursor/lookback scheme is specific to the admin event feed (resource-change/grant
-// events). The usage-tracking feeds (usage_event_feed.go, google_login_event_feed.go,
-// saml_event_feed.go) no longer replay bulk activity history at all — see event_feed_common.go.
+// Note: this cursor/lookback scheme is shared by the admin and Drive audit event feeds, which
+// page through a whole application's audit log. The usage-tracking feeds (usage_event_feed.go,
+// google_login_event_feed.go, saml_event_feed.go) no longer replay bulk activity history at all — see event_feed_common.go.
 const maxEventFeedLookback = 90 * 24 * time.Hour
 
 type adminEventFeedPageToken struct {
@@ -45,25 +47,40 @@ type adminEventFeedPageToken struct {
 }
 
 // resolveAdminEventFeedPageToken decodes the incoming page token and applies the admin event
-// feed's lookback/staleness policy: picking a starting point for a fresh cursor, and resetting a
-// resumed cursor's StartAt when it is unparseable or older than maxEventFeedLookback (but only
-// when not mid-pagination — see the pt.NextPageToken == "" case below).
+// feed's lookback/staleness policy (see applyLookback).
 func resolveAdminEventFeedPageToken(ctx context.Context, token *pagination.StreamToken, defaultStart *timestamppb.Timestamp) (*adminEventFeedPageToken, error) {
-	l := ctxzap.Extract(ctx)
-
 	pt := &adminEventFeedPageToken{}
+	if err := decodeEventFeedCursor(token, pt); err != nil {
+		return nil, err
+	}
 	if token != nil && token.Cursor != "" {
-		data, err := base64.StdEncoding.DecodeString(token.Cursor)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode page token: %w", err)
-		}
-
-		if err := json.Unmarshal(data, pt); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal page token JSON: %w", err)
-		}
-
 		pt.PageSize = token.Size
 	}
+	pt.applyLookback(ctx, defaultStart)
+	return pt, nil
+}
+
+// decodeEventFeedCursor unmarshals the base64 JSON cursor of token into pt. A nil token or
+// empty cursor leaves pt untouched.
+func decodeEventFeedCursor(token *pagination.StreamToken, pt any) error {
+	if token == nil || token.Cursor == "" {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(token.Cursor)
+	if err != nil {
+		return fmt.Errorf("failed to decode page token: %w", err)
+	}
+	if err := json.Unmarshal(data, pt); err != nil {
+		return fmt.Errorf("failed to unmarshal page token JSON: %w", err)
+	}
+	return nil
+}
+
+// applyLookback picks a starting point for a fresh cursor, and resets a resumed cursor's
+// StartAt when it is unparseable or older than maxEventFeedLookback (but only when not
+// mid-pagination — see the pt.NextPageToken == "" case below).
+func (pt *adminEventFeedPageToken) applyLookback(ctx context.Context, defaultStart *timestamppb.Timestamp) {
+	l := ctxzap.Extract(ctx)
 
 	cutoff := time.Now().Add(-maxEventFeedLookback)
 
@@ -82,10 +99,10 @@ func resolveAdminEventFeedPageToken(ctx context.Context, token *pagination.Strea
 		// completed page-walk long ago) against the lookback cap.
 		cursorStart, parseErr := time.Parse(time.RFC3339, pt.StartAt)
 		if parseErr != nil {
-			l.Debug("google-workspace: admin event feed cursor start_at was unparseable, resetting to lookback cutoff",
+			l.Debug("google-workspace: event feed cursor start_at was unparseable, resetting to lookback cutoff",
 				zap.String("start_at", pt.StartAt), zap.Error(parseErr))
 		} else if cursorStart.Before(cutoff) {
-			l.Debug("google-workspace: admin event feed cursor start_at is stale, resetting to lookback cutoff",
+			l.Debug("google-workspace: event feed cursor start_at is stale, resetting to lookback cutoff",
 				zap.String("start_at", pt.StartAt))
 		}
 		if parseErr != nil || cursorStart.Before(cutoff) {
@@ -97,8 +114,6 @@ func resolveAdminEventFeedPageToken(ctx context.Context, token *pagination.Strea
 	if pt.LatestEventSeen == "" {
 		pt.LatestEventSeen = pt.StartAt
 	}
-
-	return pt, nil
 }
 
 func (pt *adminEventFeedPageToken) marshal() (string, error) {
@@ -109,9 +124,17 @@ func (pt *adminEventFeedPageToken) marshal() (string, error) {
 	return base64.StdEncoding.EncodeToString(data), nil
 }
 
-// eventTypeGroupSettings is the Directory API activity event Type for group
-// settings changes (extracted to satisfy goconst).
-const eventTypeGroupSettings = "GROUP_SETTINGS"
+// Admin audit activity event Types handled by the admin event feed
+// (extracted to satisfy goconst).
+const (
+	eventTypeGroupSettings          = "GROUP_SETTINGS"
+	eventTypeUserSettings           = "USER_SETTINGS"
+	eventTypeDelegatedAdminSettings = "DELEGATED_ADMIN_SETTINGS"
+)
+
+// superAdminRoleCacheKey keys the super admin role in the role cache, for
+// GRANT_ADMIN_PRIVILEGE and REVOKE_ADMIN_PRIVILEGE, which name no role.
+const superAdminRoleCacheKey = "\x00super_admin"
 
 type cacheEntry struct {
 	Id          string
@@ -120,14 +143,21 @@ type cacheEntry struct {
 
 type cacheMap map[string]cacheEntry
 
+// adminEventFeed turns admin audit activities into resource change, grant
+// and revoke events. Its caches map emails (and role names) to IDs. Deleted
+// users and roles are resolved through the API; a deleted group, which can't
+// be, only when an earlier event of the connector's feed looked it up.
 type adminEventFeed struct {
-	client *gwclient.GoogleWorkspaceClient
+	client     *gwclient.GoogleWorkspaceClient
+	customerID string
 
 	groupCache cacheMap
 	userCache  cacheMap
+	roleCache  cacheMap
 
 	groupMtx sync.Mutex
 	userMtx  sync.Mutex
+	roleMtx  sync.Mutex
 }
 
 func (f *adminEventFeed) ListEvents(ctx context.Context, startAt *timestamppb.Timestamp, pToken *pagination.StreamToken) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
@@ -150,6 +180,8 @@ func (f *adminEventFeed) ListEvents(ctx context.Context, startAt *timestamppb.Ti
 		return nil, nil, nil, fmt.Errorf("failed to parse latest event time in admin event feed: %w", err)
 	}
 
+	f.prefetch(ctx, r.Items)
+
 	events := make([]*v2.Event, 0)
 	for _, activity := range r.Items {
 		occurredAt := convertIdTimeToTimestamp(activity.Id.Time)
@@ -172,13 +204,20 @@ func (f *adminEventFeed) ListEvents(ctx context.Context, startAt *timestamppb.Ti
 					continue
 				}
 				events = append(events, changeEvents...)
-			case "USER_SETTINGS":
+			case eventTypeUserSettings:
 				changeEvents, err := f.handleUserEvent(ctx, activity.Id.UniqueQualifier, occurredAt, evt)
 				if err != nil {
 					l.Error("failed to handle user event", zap.Error(err))
 					continue
 				}
 				events = append(events, changeEvents...)
+			case eventTypeDelegatedAdminSettings:
+				changeEvents, err := f.handleRoleEvent(ctx, activity.Id.UniqueQualifier, occurredAt, evt)
+				if err != nil {
+					l.Error("failed to handle role event", zap.Error(err))
+					continue
+				}
+				events = append(events, changeEvents...)
 			default:
 				l.Debug("google-workspace-event-feed: skipping event", zap.String("event", evt.Name), zap.String("type", evt.Type))
 				continue
@@ -244,14 +283,17 @@ func (f *adminEventFeed) handleGroupEvent(ctx context.Context, uniqueQualifier i
 		}
 		events = append(events, evt)
 	case "ADD_GROUP_MEMBER":
-		evt, err := f.newGroupMemberGrantEvent(ctx, uniqueQualifier, occurredAt, "GROUP_EMAIL", "USER_EMAIL", activityEvt)
+		grantEvents, err := f.newGroupMemberGrantEvents(ctx, uniqueQualifier, occurredAt, "GROUP_EMAIL", "USER_EMAIL", "MEMBER_ROLE", activityEvt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create group member grant event: %w", err)
 		}
-		if evt == nil {
-			return nil, nil
+		events = append(events, grantEvents...)
+	case "REMOVE_GROUP_MEMBER":
+		revokeEvents, err := f.newGroupMemberRevokeEvents(ctx, uniqueQualifier, occurredAt, activityEvt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create group member revoke event: %w", err)
 		}
-		events = append(events, evt)
+		events = append(events, revokeEvents...)
 	case "UPDATE_GROUP_MEMBER":
 		evt, err := f.newGroupChangedEvent(ctx, uniqueQualifier, occurredAt, "GROUP_EMAIL", activityEvt)
 		if err != nil {
@@ -261,8 +303,13 @@ func (f *adminEventFeed) handleGroupEvent(ctx context.Context, uniqueQualifier i
 			return nil, nil
 		}
 		events = append(events, evt)
-	// We're unable to look up the id for a deleted group, so we skip it
 	case "DELETE_GROUP":
+		// The group can no longer be looked up, so its ID comes from the
+		// activity or, failing that, an earlier lookup.
+		if evt := f.newDeletedResourceEvent(ctx, uniqueQualifier, occurredAt, resourceTypeGroup,
+			deletedEntry(activityEvt, "GROUP_EMAIL", "GROUP_ID", f.takeCachedGroup)); evt != nil {
+			events = append(events, evt)
+		}
 	default:
 		l.Debug("google-workspace-event-feed: skipping group event", zap.String("event", activityEvt.Type))
 	}
@@ -270,12 +317,20 @@ func (f *adminEventFeed) handleGroupEvent(ctx context.Context, uniqueQualifier i
 	return events, nil
 }
 
+// userChangedEventNames are the user events reported as a change of the user.
+var userChangedEventNames = []string{
+	"ACCEPT_USER_INVITATION", "CHANGE_USER_ORGANIZATION", "ADD_DISPLAY_NAME", "CHANGE_DISPLAY_NAME", "CHANGE_FIRST_NAME", "CHANGE_LAST_NAME", "CREATE_USER", "RENAME_USER",
+	"SUSPEND_USER", "UNSUSPEND_USER", "ARCHIVE_USER", "UNARCHIVE_USER", "UNDELETE_USER",
+	"CHANGE_PASSWORD", "CHANGE_PASSWORD_ON_NEXT_LOGIN",
+	"USER_ENROLLED_IN_TWO_STEP_VERIFICATION", "USER_PUT_IN_TWO_STEP_VERIFICATION_GRACE_PERIOD", "TURN_OFF_2_STEP_VERIFICATION",
+}
+
 func (f *adminEventFeed) handleUserEvent(ctx context.Context, uniqueQualifier int64, occurredAt *timestamppb.Timestamp, activityEvt *reports.ActivityEvents) ([]*v2.Event, error) {
 	l := ctxzap.Extract(ctx)
 
 	events := make([]*v2.Event, 0)
-	switch activityEvt.Name {
-	case "ACCEPT_USER_INVITATION", "CHANGE_USER_ORGANIZATION", "ADD_DISPLAY_NAME", "CHANGE_DISPLAY_NAME", "CHANGE_FIRST_NAME", "CHANGE_LAST_NAME", "CREATE_USER", "RENAME_USER":
+	switch {
+	case slices.Contains(userChangedEventNames, activityEvt.Name):
 		evt, err := f.newUserChangedEvent(ctx, uniqueQualifier, occurredAt, "USER_EMAIL", activityEvt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create user changed event: %w", err)
@@ -284,12 +339,166 @@ func (f *adminEventFeed) handleUserEvent(ctx context.Context, uniqueQualifier in
 			return nil, nil
 		}
 		events = append(events, evt)
+	case activityEvt.Name == "DELETE_USER":
+		// The user can no longer be looked up by email, so its ID comes from
+		// the activity or the deleted users prefetch found it in.
+		if evt := f.newDeletedResourceEvent(ctx, uniqueQualifier, occurredAt, resourceTypeUser,
+			deletedEntry(activityEvt, "USER_EMAIL", "USER_ID", f.takeCachedUser)); evt != nil {
+			events = append(events, evt)
+		}
+	case activityEvt.Name == "GRANT_ADMIN_PRIVILEGE" || activityEvt.Name == "REVOKE_ADMIN_PRIVILEGE":
+		roleEvents, err := f.newRoleMemberEvents(ctx, uniqueQualifier, occurredAt, superAdminRoleCacheKey, activityEvt,
+			activityEvt.Name == "REVOKE_ADMIN_PRIVILEGE")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create super admin role event: %w", err)
+		}
+		events = append(events, roleEvents...)
 	default:
 		l.Debug("google-workspace-event-feed: skipping user event", zap.String("event", activityEvt.Type))
 	}
 	return events, nil
 }
 
+// handleRoleEvent maps admin role assignments to role grant and revoke
+// events.
+func (f *adminEventFeed) handleRoleEvent(ctx context.Context, uniqueQualifier int64, occurredAt *timestamppb.Timestamp, activityEvt *reports.ActivityEvents) ([]*v2.Event, error) {
+	l := ctxzap.Extract(ctx)
+
+	switch activityEvt.Name {
+	case "ASSIGN_ROLE", "UNASSIGN_ROLE":
+		roleName := getValueFromParameters("ROLE_NAME", activityEvt.Parameters)
+		if roleName == "" {
+			return nil, nil
+		}
+		events, err := f.newRoleMemberEvents(ctx, uniqueQualifier, occurredAt, roleName, activityEvt, activityEvt.Name == "UNASSIGN_ROLE")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create role member event: %w", err)
+		}
+		return events, nil
+	default:
+		l.Debug("google-workspace-event-feed: skipping role event", zap.String("event", activityEvt.Name))
+	}
+	return nil, nil
+}
+
+// newRoleMemberEvents returns the grant (or revoke) event of the role's
+// customer-wide "member" entitlement for the user the activity names. An
+// assignment scoped to an org unit names the org unit by path rather than by
+// the ID its entitlement is keyed on, so it is reported as a change of the
+// role instead, whose grants are then re-synced.
+func (f *adminEventFeed) newRoleMemberEvents(
+	ctx context.Context,
+	uniqueQualifier int64,
+	occurredAt *timestamppb.Timestamp,
+	roleKey string,
+	activityEvent *reports.ActivityEvents,
+	revoke bool,
+) ([]*v2.Event, error) {
+	role, err := f.resolveRole(ctx, roleKey, activityEvent)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	if orgUnit := getValueFromParameters("ORG_UNIT_NAME", activityEvent.Parameters); orgUnit != "" && orgUnit != "/" {
+		return []*v2.Event{newResourceChangeEvent(uniqueQualifier, occurredAt, resourceTypeRole, role.Id)}, nil
+	}
+
+	userEmail := getValueFromParameters("USER_EMAIL", activityEvent.Parameters)
+	if userEmail == "" {
+		return nil, nil
+	}
+	user, err := f.lookupUser(ctx, userEmail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup user %s in newRoleMemberEvents: %w", userEmail, err)
+	}
+	if user == nil || user.Id == "" {
+		return nil, nil
+	}
+
+	roleResource, err := sdkResource.NewRoleResource(role.DisplayName, resourceTypeRole, role.Id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create role resource for role event: %w", err)
+	}
+	userResource, err := newEventUserResource(user)
+	if err != nil {
+		return nil, err
+	}
+	entitlement := sdkEntitlement.NewAssignmentEntitlement(roleResource, roleMemberEntitlement, sdkEntitlement.WithGrantableTo(resourceTypeUser, resourceTypeGroup))
+	return []*v2.Event{newGrantOrRevokeEvent(uniqueQualifier, occurredAt, entitlement, userResource, revoke)}, nil
+}
+
+// newResourceChangeEvent reports that the resource changed. A resource that
+// was deleted is reported the same way: re-fetching it finds it gone. An
+// activity can hold several events, so the event ID qualifies the activity's
+// with the resource.
+func newResourceChangeEvent(uniqueQualifier int64, occurredAt *timestamppb.Timestamp, resourceType *v2.ResourceType, id string) *v2.Event {
+	return &v2.Event{
+		Id:         strconv.FormatInt(uniqueQualifier, 10) + ":" + resourceType.Id + ":" + id,
+		OccurredAt: occurredAt,
+		Event: &v2.Event_ResourceChangeEvent{
+			ResourceChangeEvent: &v2.ResourceChangeEvent{
+				ResourceId: &v2.ResourceId{
+					ResourceType: resourceType.Id,
+					Resource:     id,
+				},
+			},
+		},
+	}
+}
+
+// newDeletedResourceEvent reports the deletion of a resource, or returns nil
+// when the deleted resource could not be resolved.
+func (f *adminEventFeed) newDeletedResourceEvent(ctx context.Context, uniqueQualifier int64, occurredAt *timestamppb.Timestamp, resourceType *v2.ResourceType, entry *cacheEntry) *v2.Event {
+	if entry == nil || entry.Id == "" {
+		ctxzap.Extract(ctx).Debug("google-workspace-event-feed: deleted resource could not be resolved, skipping",
+			zap.String("resource_type", resourceType.Id))
+		return nil
+	}
+	return newResourceChangeEvent(uniqueQualifier, occurredAt, resourceType, entry.Id)
+}
+
+// newGrantOrRevokeEvent grants (or revokes) entitlement to principal. One
+// activity can grant several entitlements, such as a group's "member" and
+// "owner", so the event ID qualifies the activity's with the entitlement's
+// slug and the principal.
+func newGrantOrRevokeEvent(uniqueQualifier int64, occurredAt *timestamppb.Timestamp, entitlement *v2.Entitlement, principal *v2.Resource, revoke bool) *v2.Event {
+	evt := &v2.Event{
+		Id:         strconv.FormatInt(uniqueQualifier, 10) + ":" + entitlement.Slug + ":" + principal.Id.Resource,
+		OccurredAt: occurredAt,
+	}
+	if revoke {
+		evt.Event = &v2.Event_CreateRevokeEvent{
+			CreateRevokeEvent: &v2.CreateRevokeEvent{Entitlement: entitlement, Principal: principal},
+		}
+	} else {
+		evt.Event = &v2.Event_CreateGrantEvent{
+			CreateGrantEvent: &v2.CreateGrantEvent{Entitlement: entitlement, Principal: principal},
+		}
+	}
+	return evt
+}
+
+func newEventUserResource(user *cacheEntry) (*v2.Resource, error) {
+	userResource, err := sdkResource.NewUserResource(
+		user.DisplayName,
+		resourceTypeUser,
+		user.Id,
+		nil,
+		sdkResource.WithAnnotation(
+			&v2.V1Identifier{
+				Id: user.Id,
+			},
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user resource for grant event: %w", err)
+	}
+	return userResource, nil
+}
+
 func (f *adminEventFeed) newGroupChangedEvent(
 	ctx context.Context,
 	uniqueQualifier int64,
@@ -311,28 +520,51 @@ func (f *adminEventFeed) newGroupChangedEvent(
 		return nil, nil
 	}
 
-	return &v2.Event{
-		Id:         strconv.FormatInt(uniqueQualifier, 10),
-		OccurredAt: occurredAt,
-		Event: &v2.Event_ResourceChangeEvent{
-			ResourceChangeEvent: &v2.ResourceChangeEvent{
-				ResourceId: &v2.ResourceId{
-					ResourceType: resourceTypeGroup.Id,
-					Resource:     group.Id,
-				},
-			},
-		},
-	}, nil
+	return newResourceChangeEvent(uniqueQualifier, occurredAt, resourceTypeGroup, group.Id), nil
 }
 
-func (f *adminEventFeed) newGroupMemberGrantEvent(
+// newGroupMemberGrantEvents returns the "member" grant event for a user added
+// to a group, plus the "owner" or "manager" grant event when the member was
+// added with that role, matching the grants the group syncer emits.
+func (f *adminEventFeed) newGroupMemberGrantEvents(
 	ctx context.Context,
 	uniqueQualifier int64,
 	occurredAt *timestamppb.Timestamp,
 	groupEmailName string,
 	userEmailName string,
+	roleName string,
 	activityEvent *reports.ActivityEvents,
-) (*v2.Event, error) {
+) ([]*v2.Event, error) {
+	slugs := []string{groupMemberEntitlement}
+	if slug, ok := groupRoleEntitlements[strings.ToUpper(getValueFromParameters(roleName, activityEvent.Parameters))]; ok {
+		slugs = append(slugs, slug)
+	}
+	return f.newGroupMemberEvents(ctx, uniqueQualifier, occurredAt, groupEmailName, userEmailName, slugs, activityEvent, false)
+}
+
+// newGroupMemberRevokeEvents returns revoke events for a user removed from a
+// group: its "member" entitlement and, since the activity doesn't say which
+// role the member held, both role entitlements.
+func (f *adminEventFeed) newGroupMemberRevokeEvents(
+	ctx context.Context,
+	uniqueQualifier int64,
+	occurredAt *timestamppb.Timestamp,
+	activityEvent *reports.ActivityEvents,
+) ([]*v2.Event, error) {
+	slugs := []string{groupMemberEntitlement, groupOwnerEntitlement, groupManagerEntitlement}
+	return f.newGroupMemberEvents(ctx, uniqueQualifier, occurredAt, "GROUP_EMAIL", "USER_EMAIL", slugs, activityEvent, true)
+}
+
+func (f *adminEventFeed) newGroupMemberEvents(
+	ctx context.Context,
+	uniqueQualifier int64,
+	occurredAt *timestamppb.Timestamp,
+	groupEmailName string,
+	userEmailName string,
+	slugs []string,
+	activityEvent *reports.ActivityEvents,
+	revoke bool,
+) ([]*v2.Event, error) {
 	groupEmail := getValueFromParameters(groupEmailName, activityEvent.Parameters)
 
 	if groupEmail == "" {
@@ -355,7 +587,7 @@ func (f *adminEventFeed) newGroupMemberGrantEvent(
 
 	user, err := f.lookupUser(ctx, userEmail)
 	if err != nil {
-		return nil, fmt.Errorf("failed to lookup user %s in newGroupMemberGrantEvent: %w", userEmail, err)
+		return nil, fmt.Errorf("failed to lookup user %s in newGroupMemberEvents: %w", userEmail, err)
 	}
 
 	if user == nil || user.Id == "" {
@@ -375,33 +607,17 @@ func (f *adminEventFeed) newGroupMemberGrantEvent(
 	if err != nil {
 		return nil, fmt.Errorf("failed to create group resource for grant event: %w", err)
 	}
-	entitlement := sdkEntitlement.NewAssignmentEntitlement(groupResource, groupMemberEntitlement, sdkEntitlement.WithGrantableTo(resourceTypeUser))
-
-	userResource, err := sdkResource.NewUserResource(
-		user.DisplayName,
-		resourceTypeUser,
-		user.Id,
-		nil,
-		sdkResource.WithAnnotation(
-			&v2.V1Identifier{
-				Id: user.Id,
-			},
-		),
-	)
+	userResource, err := newEventUserResource(user)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user resource for grant event: %w", err)
+		return nil, err
 	}
 
-	return &v2.Event{
-		Id:         strconv.FormatInt(uniqueQualifier, 10),
-		OccurredAt: occurredAt,
-		Event: &v2.Event_CreateGrantEvent{
-			CreateGrantEvent: &v2.CreateGrantEvent{
-				Entitlement: entitlement,
-				Principal:   userResource,
-			},
-		},
-	}, nil
+	events := make([]*v2.Event, 0, len(slugs))
+	for _, slug := range slugs {
+		entitlement := sdkEntitlement.NewAssignmentEntitlement(groupResource, slug, sdkEntitlement.WithGrantableTo(resourceTypeUser))
+		events = append(events, newGrantOrRevokeEvent(uniqueQualifier, occurredAt, entitlement, userResource, revoke))
+	}
+	return events, nil
 }
 
 func (f *adminEventFeed) newUserChangedEvent(
@@ -426,24 +642,14 @@ func (f *adminEventFeed) newUserChangedEvent(
 		return nil, nil
 	}
 
-	return &v2.Event{
-		Id:         strconv.FormatInt(uniqueQualifier, 10),
-		OccurredAt: occurredAt,
-		Event: &v2.Event_ResourceChangeEvent{
-			ResourceChangeEvent: &v2.ResourceChangeEvent{
-				ResourceId: &v2.ResourceId{
-					ResourceType: resourceTypeUser.Id,
-					Resource:     user.Id,
-				},
-			},
-		},
-	}, nil
+	return newResourceChangeEvent(uniqueQualifier, occurredAt, resourceTypeUser, user.Id), nil
 }
 
 func (f *adminEventFeed) lookupUser(ctx context.Context, email string) (*cacheEntry, error) {
 	f.userMtx.Lock()
 	defer f.userMtx.Unlock()
 
+	email = strings.ToLower(email)
 	if entry, ok := f.userCache[email]; ok {
 		return &entry, nil
 	}
@@ -480,6 +686,7 @@ func (f *adminEventFeed) lookupGroup(ctx context.Context, email string) (*cacheE
 	f.groupMtx.Lock()
 	defer f.groupMtx.Unlock()
 
+	email = strings.ToLower(email)
 	if entry, ok := f.groupCache[email]; ok {
 		return &entry, nil
 	}
@@ -512,20 +719,247 @@ func (f *adminEventFeed) lookupGroup(ctx context.Context, email string) (*cacheE
 	return &entry, nil
 }
 
+// prefetch looks up the users and groups that handling activities looks up,
+// in batches, so that the handlers find them cached. It mirrors the handlers'
+// lookups. Lookups that fail here are left to the handlers, which report them.
+func (f *adminEventFeed) prefetch(ctx context.Context, activities []*reports.Activity) {
+	var userEmails, groupEmails, deletedUserEmails []string
+	for _, activity := range activities {
+		for _, evt := range activity.Events {
+			param := func(name string) string { return getValueFromParameters(name, evt.Parameters) }
+			switch evt.Type {
+			case eventTypeGroupSettings:
+				switch evt.Name {
+				case "CREATE_GROUP", "CHANGE_GROUP_DESCRIPTION", "CHANGE_GROUP_NAME", "UPDATE_GROUP_MEMBER":
+					groupEmails = append(groupEmails, param("GROUP_EMAIL"))
+				case "CHANGE_GROUP_EMAIL":
+					groupEmails = append(groupEmails, param("GROUP_EMAIL"), param("NEW_VALUE"))
+				case "ADD_GROUP_MEMBER", "REMOVE_GROUP_MEMBER":
+					groupEmails = append(groupEmails, param("GROUP_EMAIL"))
+					userEmails = append(userEmails, param("USER_EMAIL"))
+				}
+			case eventTypeUserSettings:
+				if slices.Contains(userChangedEventNames, evt.Name) || evt.Name == "GRANT_ADMIN_PRIVILEGE" || evt.Name == "REVOKE_ADMIN_PRIVILEGE" {
+					userEmails = append(userEmails, param("USER_EMAIL"))
+				}
+				if evt.Name == "DELETE_USER" && param("USER_ID") == "" {
+					deletedUserEmails = append(deletedUserEmails, param("USER_EMAIL"))
+				}
+			case eventTypeDelegatedAdminSettings:
+				if evt.Name == "ASSIGN_ROLE" || evt.Name == "UNASSIGN_ROLE" {
+					userEmails = append(userEmails, param("USER_EMAIL"))
+				}
+			}
+		}
+	}
+	if f.client.UserService != nil {
+		f.prefetchUsers(ctx, userEmails)
+		f.prefetchDeletedUsers(ctx, deletedUserEmails)
+	}
+	if f.client.GroupService != nil {
+		f.prefetchGroups(ctx, groupEmails)
+	}
+}
+
+func (f *adminEventFeed) prefetchUsers(ctx context.Context, emails []string) {
+	f.userMtx.Lock()
+	defer f.userMtx.Unlock()
+	emails = uncachedEmails(f.userCache, emails)
+	if len(emails) == 0 {
+		return
+	}
+	results, err := f.client.BatchGetUsers(ctx, emails)
+	if err != nil {
+		ctxzap.Extract(ctx).Debug("google-workspace-event-feed: failed to look up users in a batch", zap.Error(err))
+		return
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		entry := cacheEntry{Id: r.Value.Id}
+		if r.Value.Name != nil {
+			entry.DisplayName = r.Value.Name.DisplayName
+		}
+		f.userCache[emails[i]] = entry
+	}
+}
+
+// prefetchDeletedUsers caches the IDs of the deleted users emails name, found
+// among the customer's recently deleted users. Of several deleted users with
+// one email, the most recently deleted is kept.
+func (f *adminEventFeed) prefetchDeletedUsers(ctx context.Context, emails []string) {
+	if len(emails) == 0 {
+		return
+	}
+	wanted := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		wanted[strings.ToLower(email)] = true
+	}
+
+	f.userMtx.Lock()
+	defer f.userMtx.Unlock()
+	deletedAt := make(map[string]string, len(emails))
+	pageToken := ""
+	for {
+		users, err := f.client.ListDeletedUsers(ctx, f.customerID, pageToken)
+		if err != nil {
+			ctxzap.Extract(ctx).Debug("google-workspace-event-feed: failed to list deleted users", zap.Error(err))
+			return
+		}
+		for _, u := range users.Users {
+			email := strings.ToLower(u.PrimaryEmail)
+			// Deletion times are RFC 3339 in UTC, so they sort as strings.
+			if !wanted[email] || u.Id == "" || u.DeletionTime < deletedAt[email] {
+				continue
+			}
+			deletedAt[email] = u.DeletionTime
+			f.userCache[email] = cacheEntry{Id: u.Id}
+		}
+		if users.NextPageToken == "" {
+			return
+		}
+		pageToken = users.NextPageToken
+	}
+}
+
+func (f *adminEventFeed) prefetchGroups(ctx context.Context, emails []string) {
+	f.groupMtx.Lock()
+	defer f.groupMtx.Unlock()
+	emails = uncachedEmails(f.groupCache, emails)
+	if len(emails) == 0 {
+		return
+	}
+	results, err := f.client.BatchGetGroups(ctx, emails)
+	if err != nil {
+		ctxzap.Extract(ctx).Debug("google-workspace-event-feed: failed to look up groups in a batch", zap.Error(err))
+		return
+	}
+	for i, r := range results {
+		if r.Err == nil {
+			f.groupCache[emails[i]] = cacheEntry{Id: r.Value.Id, DisplayName: r.Value.Name}
+		}
+	}
+}
+
+// uncachedEmails returns the distinct, lower-cased emails missing from cache.
+func uncachedEmails(cache cacheMap, emails []string) []string {
+	rv := make([]string, 0, len(emails))
+	seen := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		email = strings.ToLower(email)
+		if _, ok := cache[email]; ok || email == "" || seen[email] {
+			continue
+		}
+		seen[email] = true
+		rv = append(rv, email)
+	}
+	return rv
+}
+
+// deletedEntry resolves the user or group an activity deleted from its ID
+// parameter when it has one, and otherwise takes the cache entry of its
+// email, which for users prefetchDeletedUsers fills from the deleted users.
+func deletedEntry(activityEvt *reports.ActivityEvents, emailName, idName string, take func(email string) *cacheEntry) *cacheEntry {
+	entry := take(getValueFromParameters(emailName, activityEvt.Parameters))
+	if id := getValueFromParameters(idName, activityEvt.Parameters); id != "" {
+		return &cacheEntry{Id: id}
+	}
+	return entry
+}
+
+// takeCachedUser returns and forgets the cached entry of a deleted user, so
+// a user later created with the same email is looked up afresh.
+func (f *adminEventFeed) takeCachedUser(email string) *cacheEntry {
+	f.userMtx.Lock()
+	defer f.userMtx.Unlock()
+	return takeCacheEntry(f.userCache, email)
+}
+
+// takeCachedGroup is takeCachedUser for groups.
+func (f *adminEventFeed) takeCachedGroup(email string) *cacheEntry {
+	f.groupMtx.Lock()
+	defer f.groupMtx.Unlock()
+	return takeCacheEntry(f.groupCache, email)
+}
+
+func takeCacheEntry(cache cacheMap, email string) *cacheEntry {
+	email = strings.ToLower(email)
+	entry, ok := cache[email]
+	if !ok {
+		return nil
+	}
+	delete(cache, email)
+	return &entry
+}
+
+// resolveRole resolves the role an activity names. Role activities carry the
+// role's ID, which identifies even a role deleted since; only activities
+// without it are resolved by name.
+func (f *adminEventFeed) resolveRole(ctx context.Context, roleKey string, activityEvt *reports.ActivityEvents) (*cacheEntry, error) {
+	if id := getValueFromParameters("ROLE_ID", activityEvt.Parameters); id != "" {
+		return &cacheEntry{Id: id, DisplayName: getValueFromParameters("ROLE_NAME", activityEvt.Parameters)}, nil
+	}
+	return f.lookupRole(ctx, roleKey)
+}
+
+// lookupRole resolves a role by name, or the super admin role by
+// superAdminRoleCacheKey. Audit activities name roles rather than identify
+// them, so a cache miss reloads every role of the customer.
+func (f *adminEventFeed) lookupRole(ctx context.Context, name string) (*cacheEntry, error) {
+	f.roleMtx.Lock()
+	defer f.roleMtx.Unlock()
+
+	if entry, ok := f.roleCache[name]; ok {
+		return &entry, nil
+	}
+	if f.client.RoleService == nil {
+		ctxzap.Extract(ctx).Debug("google-workspace-event-feed: role service unavailable, skipping role event")
+		return nil, nil
+	}
+
+	pageToken := ""
+	for {
+		roles, err := f.client.ListRoles(ctx, f.customerID, pageToken)
+		if err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to list roles in admin event feed: %w", err)
+		}
+		for _, r := range roles.Items {
+			entry := cacheEntry{Id: strconv.FormatInt(r.RoleId, 10), DisplayName: r.RoleName}
+			f.roleCache[r.RoleName] = entry
+			if r.IsSuperAdminRole {
+				f.roleCache[superAdminRoleCacheKey] = entry
+			}
+		}
+		if roles.NextPageToken == "" {
+			break
+		}
+		pageToken = roles.NextPageToken
+	}
+
+	if entry, ok := f.roleCache[name]; ok {
+		return &entry, nil
+	}
+	return nil, nil
+}
+
 func (f *adminEventFeed) EventFeedMetadata(ctx context.Context) *v2.EventFeedMetadata {
 	return &v2.EventFeedMetadata{
 		Id: "admin_event_feed",
 		SupportedEventTypes: []v2.EventType{
 			v2.EventType_EVENT_TYPE_RESOURCE_CHANGE,
 			v2.EventType_EVENT_TYPE_CREATE_GRANT,
+			v2.EventType_EVENT_TYPE_CREATE_REVOKE,
 		},
 	}
 }
 
-func newAdminEventFeed(client *gwclient.GoogleWorkspaceClient) *adminEventFeed {
+func newAdminEventFeed(client *gwclient.GoogleWorkspaceClient, customerID string) *adminEventFeed {
 	return &adminEventFeed{
 		client:     client,
+		customerID: customerID,
 		groupCache: make(cacheMap),
 		userCache:  make(cacheMap),
+		roleCache:  make(cacheMap),
 	}
 }
@@ -42,10 +42,21 @@ func newAdminFeedTestServer(users map[string]*directoryAdmin.User, groups map[st
 		_ = json.NewEncoder(w).Encode(activities)
 	})
 
+	// Users with a deletion time are deleted: only listed with showDeleted.
+	mux.HandleFunc("/admin/directory/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		var deleted []*directoryAdmin.User
+		for _, u := range users {
+			if u.DeletionTime != "" && r.URL.Query().Get("showDeleted") == "true" {
+				deleted = append(deleted, &directoryAdmin.User{Id: u.Id, PrimaryEmail: u.PrimaryEmail, DeletionTime: u.DeletionTime})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.Users{Users: deleted})
+	})
+
 	mux.HandleFunc("/admin/directory/v1/users/", func(w http.ResponseWriter, r *http.Request) {
 		key := strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/users/")
 		u := users[key]
-		if u == nil {
+		if u == nil || u.DeletionTime != "" {
 			http.Error(w, "not found", http.StatusNotFound)
 			return
 		}
@@ -62,6 +73,13 @@ func newAdminFeedTestServer(users map[string]*directoryAdmin.User, groups map[st
 		_ = json.NewEncoder(w).Encode(g)
 	})
 
+	mux.HandleFunc("/admin/directory/v1/customer/customer/roles", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.Roles{Items: []*directoryAdmin.Role{
+			{RoleId: 1, RoleName: "_SEED_ADMIN_ROLE", IsSuperAdminRole: true},
+			{RoleId: 2, RoleName: "Help Desk Admin"},
+		}})
+	})
+
 	return httptest.NewServer(mux)
 }
 
@@ -128,7 +146,7 @@ func TestAdminEventFeed_GroupAndUserEvents(t *testing.T) {
 		ReportService: rep,
 	}
 
-	feed := newAdminEventFeed(client)
+	feed := newAdminEventFeed(client, "customer")
 	start := timestamppb.Now()
 	st := &pagination.StreamToken{Size: 100}
 	events, state, _, err := feed.ListEvents(context.Background(), start, st)
@@ -163,3 +181,214 @@ func TestAdminEventFeed_GroupAndUserEvents(t *testing.T) {
 		t.Fatalf("expected group change, grant, and user change events")
 	}
 }
+
+func TestAdminEventFeed_AddGroupOwnerEmitsRoleGrant(t *testing.T) {
+	users := map[string]*directoryAdmin.User{
+		testUserEmail: {Id: "user-1", Name: &directoryAdmin.UserName{FullName: "User One"}, PrimaryEmail: testUserEmail},
+	}
+	groups := map[string]*directoryAdmin.Group{
+		"group@example.com": {Id: "group-1", Name: "Group One", Email: "group@example.com"},
+	}
+	acts := &reportsAdmin.Activities{
+		Items: []*reportsAdmin.Activity{
+			{
+				Id: &reportsAdmin.ActivityId{Time: time.Now().UTC().Format(time.RFC3339), UniqueQualifier: 789},
+				Events: []*reportsAdmin.ActivityEvents{
+					{
+						Type: eventTypeGroupSettings, Name: "ADD_GROUP_MEMBER",
+						Parameters: []*reportsAdmin.ActivityEventsParameters{
+							{Name: "GROUP_EMAIL", Value: "group@example.com"},
+							{Name: "USER_EMAIL", Value: testUserEmail},
+							{Name: "MEMBER_ROLE", Value: "OWNER"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	server := newAdminFeedTestServer(users, groups, acts)
+	defer server.Close()
+
+	dir := newTestDirectoryService(t, server.URL, server.Client())
+	feed := newAdminEventFeed(&gwclient.GoogleWorkspaceClient{
+		UserService:   dir,
+		GroupService:  dir,
+		ReportService: newReportsService(t, server.URL, server.Client()),
+	}, "customer")
+	events, _, _, err := feed.ListEvents(context.Background(), timestamppb.Now(), &pagination.StreamToken{Size: 100})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+
+	var slugs, ids []string
+	for _, e := range events {
+		if cge := e.GetCreateGrantEvent(); cge != nil {
+			slugs = append(slugs, cge.GetEntitlement().GetSlug())
+			ids = append(ids, e.GetId())
+		}
+	}
+	if len(slugs) != 2 || slugs[0] != groupMemberEntitlement || slugs[1] != groupOwnerEntitlement {
+		t.Fatalf("expected member and owner grant events, got %v", slugs)
+	}
+	// Both events come from one activity, so they must not share its ID.
+	if ids[0] != "789:member:user-1" || ids[1] != "789:owner:user-1" {
+		t.Fatalf("expected distinct event IDs per entitlement, got %v", ids)
+	}
+}
+
+func TestAdminEventFeed_DeletionsRevocationsAndRoleEvents(t *testing.T) {
+	users := map[string]*directoryAdmin.User{
+		testUserEmail:      {Id: "user-1", Name: &directoryAdmin.UserName{DisplayName: "User One"}, PrimaryEmail: testUserEmail},
+		"gone@example.com": {Id: "user-2", Name: &directoryAdmin.UserName{DisplayName: "Gone"}, PrimaryEmail: "gone@example.com"},
+	}
+	groups := map[string]*directoryAdmin.Group{
+		"group@example.com": {Id: "group-1", Name: "Group One", Email: "group@example.com"},
+	}
+	param := func(name, value string) *reportsAdmin.ActivityEventsParameters {
+		return &reportsAdmin.ActivityEventsParameters{Name: name, Value: value}
+	}
+	activity := func(qualifier int64, events ...*reportsAdmin.ActivityEvents) *reportsAdmin.Activity {
+		return &reportsAdmin.Activity{Id: &reportsAdmin.ActivityId{Time: time.Now().UTC().Format(time.RFC3339), UniqueQualifier: qualifier}, Events: events}
+	}
+	acts := &reportsAdmin.Activities{Items: []*reportsAdmin.Activity{
+		activity(1, &reportsAdmin.ActivityEvents{Type: eventTypeGroupSettings, Name: "CHANGE_GROUP_NAME", Parameters: []*reportsAdmin.ActivityEventsParameters{param("GROUP_EMAIL", "group@example.com")}}),
+		activity(2, &reportsAdmin.ActivityEvents{Type: eventTypeUserSettings, Name: "SUSPEND_USER", Parameters: []*reportsAdmin.ActivityEventsParameters{param("USER_EMAIL", "Gone@example.com")}}),
+	}}
+
+	server := newAdminFeedTestServer(users, groups, acts)
+	defer server.Close()
+	dir := newTestDirectoryService(t, server.URL, server.Client())
+	feed := newAdminEventFeed(&gwclient.GoogleWorkspaceClient{
+		UserService:   dir,
+		GroupService:  dir,
+		RoleService:   dir,
+		ReportService: newReportsService(t, server.URL, server.Client()),
+	}, "customer")
+
+	events, _, _, err := feed.ListEvents(context.Background(), timestamppb.Now(), &pagination.StreamToken{Size: 100})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(events) != 2 || events[1].GetResourceChangeEvent().GetResourceId().GetResource() != "user-2" {
+		t.Fatalf("expected group and suspended user change events, got %v", events)
+	}
+
+	// The group and user are deleted after the first page looked them up.
+	delete(groups, "group@example.com")
+	delete(users, "gone@example.com")
+	acts.Items = []*reportsAdmin.Activity{
+		activity(3, &reportsAdmin.ActivityEvents{Type: eventTypeGroupSettings, Name: "REMOVE_GROUP_MEMBER", Parameters: []*reportsAdmin.ActivityEventsParameters{
+			param("GROUP_EMAIL", "group@example.com"), param("USER_EMAIL", "gone@example.com"),
+		}}),
+		activity(4, &reportsAdmin.ActivityEvents{Type: eventTypeGroupSettings, Name: "DELETE_GROUP", Parameters: []*reportsAdmin.ActivityEventsParameters{param("GROUP_EMAIL", "group@example.com")}}),
+		activity(5, &reportsAdmin.ActivityEvents{Type: eventTypeUserSettings, Name: "DELETE_USER", Parameters: []*reportsAdmin.ActivityEventsParameters{param("USER_EMAIL", "gone@example.com")}}),
+		activity(6, &reportsAdmin.ActivityEvents{Type: eventTypeDelegatedAdminSettings, Name: "ASSIGN_ROLE", Parameters: []*reportsAdmin.ActivityEventsParameters{
+			param("ROLE_NAME", "Help Desk Admin"), param("USER_EMAIL", testUserEmail),
+		}}),
+		activity(7, &reportsAdmin.ActivityEvents{Type: eventTypeDelegatedAdminSettings, Name: "UNASSIGN_ROLE", Parameters: []*reportsAdmin.ActivityEventsParameters{
+			param("ROLE_NAME", "Help Desk Admin"), param("USER_EMAIL", testUserEmail), param("ORG_UNIT_NAME", "/Sales"),
+		}}),
+		activity(8, &reportsAdmin.ActivityEvents{Type: eventTypeUserSettings, Name: "REVOKE_ADMIN_PRIVILEGE", Parameters: []*reportsAdmin.ActivityEventsParameters{param("USER_EMAIL", testUserEmail)}}),
+		// Never looked up before its deletion, so it can't be resolved.
+		activity(9, &reportsAdmin.ActivityEvents{Type: eventTypeGroupSettings, Name: "DELETE_GROUP", Parameters: []*reportsAdmin.ActivityEventsParameters{param("GROUP_EMAIL", "unknown@example.com")}}),
+	}
+
+	events, _, _, err = feed.ListEvents(context.Background(), timestamppb.Now(), &pagination.StreamToken{Size: 100})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	var got []string
+	ids := map[string]bool{}
+	for _, e := range events {
+		if ids[e.GetId()] {
+			t.Fatalf("duplicate event ID %s", e.GetId())
+		}
+		ids[e.GetId()] = true
+		switch {
+		case e.GetCreateRevokeEvent() != nil:
+			got = append(got, "revoke "+e.GetCreateRevokeEvent().GetEntitlement().GetId()+" "+e.GetCreateRevokeEvent().GetPrincipal().GetId().GetResource())
+		case e.GetCreateGrantEvent() != nil:
+			got = append(got, "grant "+e.GetCreateGrantEvent().GetEntitlement().GetId()+" "+e.GetCreateGrantEvent().GetPrincipal().GetId().GetResource())
+		case e.GetResourceChangeEvent() != nil:
+			id := e.GetResourceChangeEvent().GetResourceId()
+			got = append(got, "change "+id.GetResourceType()+" "+id.GetResource())
+		}
+	}
+	want := []string{
+		"revoke group:group-1:member user-2",
+		"revoke group:group-1:owner user-2",
+		"revoke group:group-1:manager user-2",
+		"change group group-1",
+		"change user user-2",
+		"grant role:2:member user-1",
+		"change role 2",
+		"revoke role:1:member user-1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected events:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+// TestAdminEventFeed_ResolvesDeletionsWithoutEarlierLookups checks that a
+// feed that never looked up a deleted user or role, as after a restart,
+// resolves them from the deleted users and the activity's role ID.
+func TestAdminEventFeed_ResolvesDeletionsWithoutEarlierLookups(t *testing.T) {
+	users := map[string]*directoryAdmin.User{
+		testUserEmail: {Id: "user-1", Name: &directoryAdmin.UserName{DisplayName: "User One"}, PrimaryEmail: testUserEmail},
+		// The same email was deleted twice; the later deletion is the one reported.
+		"gone@example.com": {Id: "user-2", PrimaryEmail: "gone@example.com", DeletionTime: "2026-01-02T00:00:00.000Z"},
+		"gone-2025":        {Id: "user-0", PrimaryEmail: "gone@example.com", DeletionTime: "2025-06-01T00:00:00.000Z"},
+	}
+	param := func(name, value string) *reportsAdmin.ActivityEventsParameters {
+		return &reportsAdmin.ActivityEventsParameters{Name: name, Value: value}
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	acts := &reportsAdmin.Activities{Items: []*reportsAdmin.Activity{
+		{Id: &reportsAdmin.ActivityId{Time: now, UniqueQualifier: 1}, Events: []*reportsAdmin.ActivityEvents{
+			{Type: eventTypeUserSettings, Name: "DELETE_USER", Parameters: []*reportsAdmin.ActivityEventsParameters{param("USER_EMAIL", "Gone@example.com")}},
+		}},
+		// The role was deleted since, so listing roles no longer finds it.
+		{Id: &reportsAdmin.ActivityId{Time: now, UniqueQualifier: 2}, Events: []*reportsAdmin.ActivityEvents{
+			{Type: eventTypeDelegatedAdminSettings, Name: "UNASSIGN_ROLE", Parameters: []*reportsAdmin.ActivityEventsParameters{
+				param("ROLE_ID", "7"), param("ROLE_NAME", "Retired Admin"), param("USER_EMAIL", testUserEmail),
+			}},
+		}},
+		// Deleted groups can't be looked up at all.
+		{Id: &reportsAdmin.ActivityId{Time: now, UniqueQualifier: 3}, Events: []*reportsAdmin.ActivityEvents{
+			{Type: eventTypeGroupSettings, Name: "DELETE_GROUP", Parameters: []*reportsAdmin.ActivityEventsParameters{param("GROUP_EMAIL", "old@example.com")}},
+		}},
+	}}
+
+	server := newAdminFeedTestServer(users, map[string]*directoryAdmin.Group{}, acts)
+	defer server.Close()
+	dir := newTestDirectoryService(t, server.URL, server.Client())
+	feed := newAdminEventFeed(&gwclient.GoogleWorkspaceClient{
+		UserService:   dir,
+		GroupService:  dir,
+		RoleService:   dir,
+		ReportService: newReportsService(t, server.URL, server.Client()),
+	}, "customer")
+
+	events, _, _, err := feed.ListEvents(context.Background(), timestamppb.Now(), &pagination.StreamToken{Size: 100})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	var got []string
+	for _, e := range events {
+		switch {
+		case e.GetCreateRevokeEvent() != nil:
+			got = append(got, "revoke "+e.GetCreateRevokeEvent().GetEntitlement().GetId()+" "+e.GetCreateRevokeEvent().GetPrincipal().GetId().GetResource())
+		case e.GetResourceChangeEvent() != nil:
+			id := e.GetResourceChangeEvent().GetResourceId()
+			got = append(got, "change "+id.GetResourceType()+" "+id.GetResource())
+		}
+	}
+	want := []string{
+		"change user user-2",
+		"revoke role:7:member user-1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected events:\n%s", strings.Join(got, "\n"))
+	}
+}
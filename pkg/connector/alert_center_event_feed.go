@@ -0,0 +1,269 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	alertcenter "google.golang.org/api/alertcenter/v1beta1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+// alertResourceType types the alert in the feed's usage events. Alerts are
+// not synced, so the resource only identifies the alert to the event's reader.
+const alertResourceType = "alert_center_alert"
+
+// alertUserEmailFields are the alert data fields that name the affected user,
+// in order of preference. Alert types carry the user in different fields,
+// e.g. AccountWarning and SuspiciousActivity in email, SuperAdminPasswordResetEvent
+// in userEmail and GoogleOperations in affectedUserEmails.
+var alertUserEmailFields = []string{"email", "userEmail", "affectedUserEmails", "actorEmail"}
+
+// alertCenterEventFeed emits a UsageEvent for every Alert Center alert that
+// names a user, such as compromised accounts, leaked passwords, suspicious
+// device activity and government-backed attacks. The actor is the affected
+// user and the target is the alert, described by its type, source, severity
+// and status. Alerts that name no known user are skipped.
+type alertCenterEventFeed struct {
+	client *gwclient.GoogleWorkspaceClient
+
+	// userIDs caches email to user ID lookups for the feed's lifetime; an
+	// empty ID records a user that was not found. The connector shares one
+	// feed between concurrent ListEvents calls, so userMtx guards it.
+	userIDs map[string]string
+	userMtx sync.Mutex
+}
+
+func newAlertCenterEventFeed(client *gwclient.GoogleWorkspaceClient) *alertCenterEventFeed {
+	return &alertCenterEventFeed{client: client, userIDs: make(map[string]string)}
+}
+
+func (f *alertCenterEventFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetadata {
+	return &v2.EventFeedMetadata{
+		Id: "alert_center_event_feed",
+		SupportedEventTypes: []v2.EventType{
+			v2.EventType_EVENT_TYPE_USAGE,
+		},
+	}
+}
+
+// ListEvents pages through alerts created since the cursor's StartAt, oldest
+// first, with the admin event feed's cursor and lookback.
+func (f *alertCenterEventFeed) ListEvents(ctx context.Context, startAt *timestamppb.Timestamp, pToken *pagination.StreamToken) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	cursor, err := resolveAdminEventFeedPageToken(ctx, pToken, startAt)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to unmarshal page token: %w", err)
+	}
+
+	var size int64
+	if pToken != nil {
+		size = int64(pToken.Size)
+	}
+	filter := fmt.Sprintf("createTime >= %q", cursor.StartAt)
+	resp, err := f.client.ListAlerts(ctx, filter, cursor.NextPageToken, size)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("google-workspace: failed to list alerts: %w", err)
+	}
+
+	latestEvent, err := time.Parse(time.RFC3339, cursor.LatestEventSeen)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse latest event time in alert center event feed: %w", err)
+	}
+
+	if err := f.prefetchUserIDs(ctx, resp.Alerts); err != nil {
+		return nil, nil, nil, err
+	}
+
+	events := make([]*v2.Event, 0)
+	for _, alert := range resp.Alerts {
+		occurredAt := rfc3339ToTimestamp(alert.CreateTime)
+		if occurredAt == nil {
+			continue
+		}
+		if occurredAt.AsTime().After(latestEvent) {
+			cursor.LatestEventSeen = occurredAt.AsTime().Format(time.RFC3339)
+			latestEvent = occurredAt.AsTime()
+		}
+
+		email := alertUserEmail(alert)
+		if email == "" {
+			l.Debug("google-workspace-event-feed: skipping alert without a user",
+				zap.String("alert_id", alert.AlertId), zap.String("type", alert.Type))
+			continue
+		}
+		userID, err := f.lookupUserID(ctx, email)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if userID == "" {
+			l.Debug("google-workspace-event-feed: skipping alert for unknown user",
+				zap.String("alert_id", alert.AlertId), zap.String("email", email))
+			continue
+		}
+
+		evt, err := newAlertEvent(alert, userID, email, occurredAt)
+		if err != nil {
+			l.Error("failed to create alert event", zap.Error(err))
+			continue
+		}
+		events = append(events, evt)
+	}
+
+	l.Debug("google-workspace-event-feed: listed alerts",
+		zap.Int("count", len(resp.Alerts)),
+		zap.String("next_page_token", resp.NextPageToken),
+		zap.String("start_at", cursor.StartAt),
+		zap.String("latest_event", cursor.LatestEventSeen),
+	)
+
+	cursor.NextPageToken = resp.NextPageToken
+	if resp.NextPageToken == "" {
+		cursor.StartAt = cursor.LatestEventSeen
+		cursor.LatestEventSeen = ""
+	}
+
+	cursorToken, err := cursor.marshal()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal cursor token in alert center event feed: %w", err)
+	}
+	return events, &pagination.StreamState{
+		Cursor:  cursorToken,
+		HasMore: resp.NextPageToken != "",
+	}, nil, nil
+}
+
+// prefetchUserIDs looks up the users the alerts name in batches, so that
+// lookupUserID finds them cached. A lookup that fails for another reason than
+// the user not existing is left to lookupUserID, which reports it.
+func (f *alertCenterEventFeed) prefetchUserIDs(ctx context.Context, alerts []*alertcenter.Alert) error {
+	f.userMtx.Lock()
+	defer f.userMtx.Unlock()
+	var emails []string
+	seen := map[string]bool{}
+	for _, alert := range alerts {
+		email := strings.ToLower(alertUserEmail(alert))
+		if _, ok := f.userIDs[email]; ok || email == "" || seen[email] {
+			continue
+		}
+		seen[email] = true
+		emails = append(emails, email)
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+	results, err := f.client.BatchGetUsers(ctx, emails)
+	if err != nil {
+		return fmt.Errorf("google-workspace: failed to look up users for alerts: %w", err)
+	}
+	for i, r := range results {
+		var gerr *googleapi.Error
+		switch {
+		case r.Err == nil:
+			f.userIDs[emails[i]] = r.Value.Id
+		case errors.As(r.Err, &gerr) && gerr.Code == http.StatusNotFound:
+			f.userIDs[emails[i]] = ""
+		}
+	}
+	return nil
+}
+
+// lookupUserID resolves email to a user ID, returning "" for a user that does
+// not exist (any more).
+func (f *alertCenterEventFeed) lookupUserID(ctx context.Context, email string) (string, error) {
+	f.userMtx.Lock()
+	defer f.userMtx.Unlock()
+	email = strings.ToLower(email)
+	if id, ok := f.userIDs[email]; ok {
+		return id, nil
+	}
+	user, err := f.client.GetUser(ctx, email)
+	if err != nil {
+		var gerr *googleapi.Error
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			f.userIDs[email] = ""
+			return "", nil
+		}
+		return "", fmt.Errorf("google-workspace: failed to look up user for alert: %w", err)
+	}
+	f.userIDs[email] = user.Id
+	return user.Id, nil
+}
+
+// alertUserEmail returns the affected user's email from the alert's data.
+func alertUserEmail(alert *alertcenter.Alert) string {
+	if len(alert.Data) == 0 {
+		return ""
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(alert.Data, &data); err != nil {
+		return ""
+	}
+	for _, field := range alertUserEmailFields {
+		raw, ok := data[field]
+		if !ok {
+			continue
+		}
+		var email string
+		if err := json.Unmarshal(raw, &email); err == nil && email != "" {
+			return email
+		}
+		var emails []string
+		if err := json.Unmarshal(raw, &emails); err == nil && len(emails) > 0 {
+			return emails[0]
+		}
+	}
+	return ""
+}
+
+func newAlertEvent(alert *alertcenter.Alert, userID, email string, occurredAt *timestamppb.Timestamp) (*v2.Event, error) {
+	actor, err := eventActorResource(userID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	description := alert.Type
+	if alert.Source != "" {
+		description += " from " + alert.Source
+	}
+	if alert.Metadata != nil {
+		if alert.Metadata.Severity != "" {
+			description += ", severity " + alert.Metadata.Severity
+		}
+		if alert.Metadata.Status != "" {
+			description += ", status " + alert.Metadata.Status
+		}
+	}
+
+	return &v2.Event{
+		Id:         alert.AlertId,
+		OccurredAt: occurredAt,
+		Event: &v2.Event_UsageEvent{
+			UsageEvent: &v2.UsageEvent{
+				TargetResource: &v2.Resource{
+					Id: &v2.ResourceId{
+						ResourceType: alertResourceType,
+						Resource:     alert.AlertId,
+					},
+					DisplayName: alert.Type,
+					Description: description,
+				},
+				ActorResource: actor,
+			},
+		},
+	}, nil
+}
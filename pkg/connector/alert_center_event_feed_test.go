@@ -0,0 +1,209 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	alertcenter "google.golang.org/api/alertcenter/v1beta1"
+	"google.golang.org/api/option"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+func newTestAlertCenterService(t *testing.T, baseURL string, hc *http.Client) *alertcenter.Service {
+	t.Helper()
+	srv, err := alertcenter.NewService(context.Background(), option.WithEndpoint(baseURL+"/"), option.WithHTTPClient(hc))
+	if err != nil {
+		t.Fatalf("newTestAlertCenterService: %v", err)
+	}
+	return srv
+}
+
+// TestAlertCenterEventFeed_LinksAlertsToAffectedUsers checks that alerts are
+// linked to the user named in their data, whatever field names it, and that
+// alerts without a known user are skipped.
+func TestAlertCenterEventFeed_LinksAlertsToAffectedUsers(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	alerts := []*alertcenter.Alert{
+		{AlertId: "a1", Type: "Leaked password", Source: "Google identity", CreateTime: created.Format(time.RFC3339),
+			Data:     []byte(`{"@type":"type.googleapis.com/google.apps.alertcenter.type.AccountWarning","email":"Alice@example.com"}`),
+			Metadata: &alertcenter.AlertMetadata{Severity: "HIGH", Status: "NOT_STARTED"}},
+		{AlertId: "a2", Type: "Google Operations", CreateTime: created.Add(time.Minute).Format(time.RFC3339),
+			Data: []byte(`{"affectedUserEmails":["bob@example.com"]}`)},
+		{AlertId: "a3", Type: "Apps outage", CreateTime: created.Add(2 * time.Minute).Format(time.RFC3339),
+			Data: []byte(`{"status":"NEW"}`)},
+		{AlertId: "a4", Type: "Suspicious login", CreateTime: created.Add(3 * time.Minute).Format(time.RFC3339),
+			Data: []byte(`{"email":"gone@example.com"}`)},
+	}
+
+	var mu sync.Mutex
+	var filters []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1beta1/alerts", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		filters = append(filters, r.URL.Query().Get("filter"))
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(&alertcenter.ListAlertsResponse{Alerts: alerts})
+	})
+	mux.HandleFunc("/admin/directory/v1/users/", func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/users/") {
+		case "alice@example.com":
+			_ = json.NewEncoder(w).Encode(&directoryAdmin.User{Id: "alice-id", PrimaryEmail: "alice@example.com"})
+		case "bob@example.com":
+			_ = json.NewEncoder(w).Encode(&directoryAdmin.User{Id: "bob-id", PrimaryEmail: "bob@example.com"})
+		default:
+			http.Error(w, `{"error":{"code":404,"message":"Resource Not Found: userKey"}}`, http.StatusNotFound)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	feed := newAlertCenterEventFeed(&gwclient.GoogleWorkspaceClient{
+		AlertCenterService: newTestAlertCenterService(t, server.URL, server.Client()),
+		UserService:        newTestDirectoryService(t, server.URL, server.Client()),
+	})
+	start := created.Add(-time.Hour)
+	events, state, _, err := feed.ListEvents(context.Background(), nil, &pagination.StreamToken{
+		Size:   100,
+		Cursor: mustMarshalCursor(t, &adminEventFeedPageToken{StartAt: start.Format(time.RFC3339)}),
+	})
+	require.NoError(t, err)
+	require.False(t, state.HasMore)
+	require.Equal(t, []string{`createTime >= "` + start.Format(time.RFC3339) + `"`}, filters)
+
+	linked := map[string]string{}
+	for _, e := range events {
+		usage := e.GetUsageEvent()
+		require.Equal(t, alertResourceType, usage.GetTargetResource().GetId().GetResourceType())
+		linked[e.GetId()] = usage.GetActorResource().GetId().GetResource()
+	}
+	require.Equal(t, map[string]string{"a1": "alice-id", "a2": "bob-id"}, linked)
+	require.Equal(t, "Leaked password from Google identity, severity HIGH, status NOT_STARTED", events[0].GetUsageEvent().GetTargetResource().GetDescription())
+
+	cursor, err := resolveAdminEventFeedPageToken(context.Background(), &pagination.StreamToken{Cursor: state.Cursor}, nil)
+	require.NoError(t, err)
+	require.Equal(t, created.Add(3*time.Minute).Format(time.RFC3339), cursor.StartAt)
+}
+
+// TestAlertCenterEventFeed_ConcurrentListEvents checks that concurrent
+// ListEvents calls of one feed share its user cache safely.
+func TestAlertCenterEventFeed_ConcurrentListEvents(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	var alerts []*alertcenter.Alert
+	for i := range 20 {
+		alerts = append(alerts, &alertcenter.Alert{
+			AlertId:    "a" + strconv.Itoa(i),
+			CreateTime: created.Add(time.Duration(i) * time.Second).Format(time.RFC3339),
+			Data:       []byte(`{"email":"user` + strconv.Itoa(i%5) + `@example.com"}`),
+		})
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1beta1/alerts", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&alertcenter.ListAlertsResponse{Alerts: alerts})
+	})
+	mux.HandleFunc("/admin/directory/v1/users/", func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/users/")
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.User{Id: strings.TrimSuffix(key, "@example.com") + "-id", PrimaryEmail: key})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	feed := newAlertCenterEventFeed(&gwclient.GoogleWorkspaceClient{
+		AlertCenterService: newTestAlertCenterService(t, server.URL, server.Client()),
+		UserService:        newTestDirectoryService(t, server.URL, server.Client()),
+	})
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			events, _, _, err := feed.ListEvents(context.Background(), timestamppb.New(created.Add(-time.Hour)), &pagination.StreamToken{Size: 100})
+			assert.NoError(t, err)
+			assert.Len(t, events, len(alerts))
+		}()
+	}
+	wg.Wait()
+}
+
+func mustMarshalCursor(t *testing.T, pt *adminEventFeedPageToken) string {
+	t.Helper()
+	cursor, err := pt.marshal()
+	require.NoError(t, err)
+	return cursor
+}
+
+func TestUpdateAlertAction_FeedbackAndIdempotentStatus(t *testing.T) {
+	var mu sync.Mutex
+	deleted := false
+	var calls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1beta1/alerts/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, r.Method+" "+strings.TrimPrefix(r.URL.Path, "/v1beta1/alerts/"))
+		switch {
+		case r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(&alertcenter.Alert{AlertId: "a1", Deleted: deleted})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/feedback"):
+			var fb alertcenter.AlertFeedback
+			_ = json.NewDecoder(r.Body).Decode(&fb)
+			_ = json.NewEncoder(w).Encode(&alertcenter.AlertFeedback{FeedbackId: "fb-1", Type: fb.Type})
+		case r.Method == http.MethodDelete:
+			deleted = true
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, ":undelete"):
+			deleted = false
+			_ = json.NewEncoder(w).Encode(&alertcenter.Alert{AlertId: "a1"})
+		default:
+			http.Error(w, "unexpected", http.StatusBadRequest)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newTestConnector()
+	c.client = &gwclient.GoogleWorkspaceClient{AlertCenterService: newTestAlertCenterService(t, server.URL, server.Client())}
+
+	args := func(fields map[string]string) *structpb.Struct {
+		s := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+		for k, v := range fields {
+			s.Fields[k] = strArg(v)
+		}
+		return s
+	}
+
+	rv, _, err := c.updateAlertActionHandler(context.Background(), args(map[string]string{
+		argAlertID: "a1", argFeedback: "VERY_USEFUL", fieldStatus: alertStatusDeleted,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "fb-1", rv.Fields["feedback_id"].GetStringValue())
+	require.Equal(t, alertStatusDeleted, rv.Fields[fieldStatus].GetStringValue())
+	require.Equal(t, []string{"GET a1", "POST a1/feedback", "DELETE a1"}, calls)
+
+	calls = nil
+	_, _, err = c.updateAlertActionHandler(context.Background(), args(map[string]string{argAlertID: "a1", fieldStatus: alertStatusDeleted}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"GET a1"}, calls, "an already deleted alert is not deleted again")
+
+	calls = nil
+	rv, _, err = c.updateAlertActionHandler(context.Background(), args(map[string]string{argAlertID: "a1", fieldStatus: alertStatusActive}))
+	require.NoError(t, err)
+	require.Equal(t, alertStatusActive, rv.Fields[fieldStatus].GetStringValue())
+	require.Equal(t, []string{"GET a1", "POST a1:undelete"}, calls)
+
+	_, _, err = c.updateAlertActionHandler(context.Background(), args(map[string]string{argAlertID: "a1"}))
+	require.Error(t, err)
+}
@@ -1,6 +1,8 @@
 // app_login.go discovers which OAuth and Google Workspace apps users have accessed.
 // It uses two data sources: the Directory API's token list (OAuth apps a user has granted access to)
 // and the Admin Reports audit log (actual login events for OAuth, SAML, and Google Workspace apps).
+// OAuth apps come from the token list, the token audit log's "authorize" events, or both,
+// depending on the configured oauthAppDiscovery.
 // Results are stored in the session so applicationResource.List() and Grants() can read them
 // without re-fetching across sync phases.
 //
@@ -16,6 +18,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/conductorone/baton-sdk/pkg/session"
@@ -30,6 +34,7 @@ import (
 const (
 	reportsAppLogin = "login"
 	reportsAppSAML  = "saml"
+	reportsAppToken = "token"
 
 	samlAppIDPrefix               = "saml:"
 	googleWorkspaceAppID          = "google_workspace"
@@ -43,10 +48,49 @@ const (
 	// Tokens.List() but no Reports timestamp is available. Epoch ensures any real timestamp
 	// from the Reports API takes precedence.
 	oauthPresenceValue = "1970-01-01T00:00:00Z"
+	// oauthAuthorizeLookupMaxResults bounds a user's token "authorize" lookup to one Reports
+	// page, the API's maximum, so each user costs a single filter-query.
+	oauthAuthorizeLookupMaxResults = 1000
 )
 
+// oauthAppDiscovery is the data source OAuth apps are discovered from.
+type oauthAppDiscovery string
+
+const (
+	// oauthAppDiscoveryTokens lists each user's current tokens with Tokens.list. It sees every
+	// app a user still has authorized, however long ago, at one Directory call per user.
+	oauthAppDiscoveryTokens oauthAppDiscovery = "tokens"
+	// oauthAppDiscoveryReports reads each user's token "authorize" events from the Reports
+	// audit log. It only reaches back over the log's 180-day retention, keeps apps whose token
+	// was revoked since, and costs a filter-query from the shared Reports budget per user.
+	oauthAppDiscoveryReports oauthAppDiscovery = "reports"
+	// oauthAppDiscoveryMerged uses both sources and combines their apps and scopes.
+	oauthAppDiscoveryMerged oauthAppDiscovery = "merged"
+)
+
+// parseOAuthAppDiscovery validates the oauth-app-discovery setting, defaulting to tokens.
+func parseOAuthAppDiscovery(s string) (oauthAppDiscovery, error) {
+	switch d := oauthAppDiscovery(strings.ToLower(strings.TrimSpace(s))); d {
+	case "":
+		return oauthAppDiscoveryTokens, nil
+	case oauthAppDiscoveryTokens, oauthAppDiscoveryReports, oauthAppDiscoveryMerged:
+		return d, nil
+	default:
+		return "", fmt.Errorf("google-workspace: invalid oauth app discovery %q: must be tokens, reports or merged", s)
+	}
+}
+
+func (d oauthAppDiscovery) usesTokens() bool {
+	return d != oauthAppDiscoveryReports
+}
+
+func (d oauthAppDiscovery) usesReports() bool {
+	return d == oauthAppDiscoveryReports || d == oauthAppDiscoveryMerged
+}
+
 var (
 	appLoginOAuthAppsNamespace     = sessions.WithPrefix("app_login_oauth_apps")
+	appOAuthTokenFlagsNamespace    = sessions.WithPrefix("app_oauth_token_flags")
 	appLoginDirectoryUserNamespace = sessions.WithPrefix("app_login_directory_user")
 	appLoginEmittedAppNamespace    = sessions.WithPrefix("app_login_emitted_app")
 	samlProfileMapNamespace        = sessions.WithPrefix("saml_profile_map")
@@ -57,17 +101,38 @@ func appLoginLoginsNamespace(appID string) sessions.SessionStoreOption {
 	return sessions.WithPrefix("app_login_logins:" + appID)
 }
 
-// oauthAppEntry describes one OAuth app a user has authorized, as discovered via Tokens.list.
+// appOAuthScopesNamespace holds, per user, the scopes an OAuth app's token
+// for that user grants.
+func appOAuthScopesNamespace(appID string) sessions.SessionStoreOption {
+	return sessions.WithPrefix("app_oauth_scopes:" + appID)
+}
+
+// oauthAppEntry describes one OAuth app a user has authorized, as discovered via Tokens.list
+// and/or the token audit log.
 type oauthAppEntry struct {
 	clientID    string
 	displayText string
+	scopes      []string
+	// hasToken is set when the entry came from Tokens.list, which alone reports the flags.
+	hasToken bool
+	flags    oauthTokenFlags
+	// authorizedAt is the RFC 3339 time of the latest "authorize" event, when known.
+	authorizedAt string
+}
+
+// oauthTokenFlags are the properties Tokens.list reports for an app's tokens. They are stored
+// per app, set when any user's token has them.
+type oauthTokenFlags struct {
+	Anonymous bool `json:"anonymous"`
+	NativeApp bool `json:"native_app"`
 }
 
 // scanAppLoginsPage advances one bounded step of the rolling user-directory walk, discovering
 // OAuth/SAML apps and recording each user's latest login timestamp per app in the session.
-// It returns any apps discovered on this page that have not been returned by a prior call
-// (appID -> displayName, oauthApp or samlApp), plus the next page token (empty when the walk
-// has completed a full pass).
+// It returns the SAML apps discovered on this page (appID -> displayName), plus the next page
+// token (empty when the walk has completed a full pass). OAuth apps, with each user's scopes,
+// are only recorded in the session: their profile summarizes every user's scopes, so they are
+// returned once the walk is complete.
 func scanAppLoginsPage(
 	ctx context.Context,
 	ss sessions.SessionStore,
@@ -75,16 +140,17 @@ func scanAppLoginsPage(
 	customerID, domain string,
 	pageToken string,
 	samlProfileMap map[string]string,
-) (map[string]string, map[string]string, string, error) {
+	discovery oauthAppDiscovery,
+) (map[string]string, string, error) {
 	cursor, err := unmarshalUserScanCursorFromString(pageToken)
 	if err != nil {
-		return nil, nil, "", err
+		return nil, "", err
 	}
 
 	if len(cursor.PendingUsers) == 0 {
-		usersResp, err := client.ListUserIDsPage(ctx, customerID, domain, cursor.DirectoryPageToken)
+		usersResp, err := client.ListUserIDsPage(ctx, customerID, domain, "", cursor.DirectoryPageToken)
 		if err != nil {
-			return nil, nil, "", fmt.Errorf("google-workspace-connector: failed to list users for applications: %w", err)
+			return nil, "", fmt.Errorf("google-workspace-connector: failed to list users for applications: %w", err)
 		}
 		cursor.DirectoryPageToken = usersResp.NextPageToken
 
@@ -100,13 +166,13 @@ func scanAppLoginsPage(
 		}
 		if len(dirUserBatch) > 0 {
 			if err := session.SetManyJSON(ctx, ss, dirUserBatch, appLoginDirectoryUserNamespace); err != nil {
-				return nil, nil, "", fmt.Errorf("google-workspace-connector: failed to store directory user IDs in session: %w", err)
+				return nil, "", fmt.Errorf("google-workspace-connector: failed to store directory user IDs in session: %w", err)
 			}
 		}
 
 		if len(cursor.PendingUsers) == 0 && cursor.DirectoryPageToken == "" {
-			// Return empty (not nil) maps: callers range over and write into these.
-			return map[string]string{}, map[string]string{}, "", nil
+			// Return an empty (not nil) map: callers range over and write into it.
+			return map[string]string{}, "", nil
 		}
 	}
 
@@ -116,24 +182,33 @@ func scanAppLoginsPage(
 	}
 	cursor.PendingUsers = cursor.PendingUsers[len(batch):]
 
-	newOAuthApps := map[string]string{}
 	newSAMLApps := map[string]string{}
 	for _, u := range batch {
-		oauthApps, err := fetchUserOAuthApps(ctx, client, u)
-		if err != nil {
-			return nil, nil, "", err
+		var oauthApps []oauthAppEntry
+		if discovery.usesTokens() {
+			oauthApps, err = fetchUserOAuthApps(ctx, client, u)
+			if err != nil {
+				return nil, "", err
+			}
+		}
+		if discovery.usesReports() && client.ReportService != nil {
+			authorized, err := fetchUserAuthorizedOAuthApps(ctx, client, u)
+			if err != nil {
+				return nil, "", err
+			}
+			oauthApps = mergeOAuthApps(oauthApps, authorized)
 		}
-		if err := storeOAuthLogins(ctx, ss, u.ID, oauthApps, newOAuthApps); err != nil {
-			return nil, nil, "", err
+		if err := storeOAuthLogins(ctx, ss, u.ID, oauthApps); err != nil {
+			return nil, "", err
 		}
 
 		if client.ReportService != nil {
 			if err := recordLatestGoogleLogin(ctx, ss, client, u); err != nil {
-				return nil, nil, "", err
+				return nil, "", err
 			}
 
 			if err := recordLatestSAMLLogins(ctx, ss, client, u, samlProfileMap, newSAMLApps); err != nil {
-				return nil, nil, "", err
+				return nil, "", err
 			}
 		}
 	}
@@ -144,13 +219,13 @@ func scanAppLoginsPage(
 	}
 	nextPageToken, err := cursor.marshal()
 	if err != nil {
-		return nil, nil, "", fmt.Errorf("failed to marshal cursor token in app login scan: %w", err)
+		return nil, "", fmt.Errorf("failed to marshal cursor token in app login scan: %w", err)
 	}
 	if !hasMore {
 		nextPageToken = ""
 	}
 
-	return newOAuthApps, newSAMLApps, nextPageToken, nil
+	return newSAMLApps, nextPageToken, nil
 }
 
 // fetchUserOAuthApps lists OAuth tokens for a single user.
@@ -180,32 +255,146 @@ func fetchUserOAuthApps(ctx context.Context, client *gwclient.GoogleWorkspaceCli
 		if t.ClientId == t.DisplayText && privateAppIDRegex.MatchString(t.ClientId) {
 			continue
 		}
-		filtered = append(filtered, oauthAppEntry{clientID: t.ClientId, displayText: t.DisplayText})
+		filtered = append(filtered, oauthAppEntry{
+			clientID:    t.ClientId,
+			displayText: t.DisplayText,
+			scopes:      t.Scopes,
+			hasToken:    true,
+			flags:       oauthTokenFlags{Anonymous: t.Anonymous, NativeApp: t.NativeApp},
+		})
 	}
 	return filtered, nil
 }
 
-func storeOAuthLogins(ctx context.Context, ss sessions.SessionStore, userID string, apps []oauthAppEntry, newApps map[string]string) error {
+// fetchUserAuthorizedOAuthApps reads a single user's OAuth authorizations from the token audit
+// log, one entry per app with the union of the scopes it was authorized for. Errors are not
+// tolerated, for the same reason as in fetchUserOAuthApps.
+func fetchUserAuthorizedOAuthApps(ctx context.Context, client *gwclient.GoogleWorkspaceClient, u pendingUser) ([]oauthAppEntry, error) {
+	r, err := listActivitiesRateLimited(ctx, client, u.Email, reportsAppToken, "authorize", "", "", oauthAuthorizeLookupMaxResults)
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace-connector: failed to fetch token activity for %s: %w", u.Email, err)
+	}
+
+	var apps []oauthAppEntry
+	index := map[string]int{}
+	for _, activity := range r.Items {
+		if activity.Id == nil {
+			continue
+		}
+		ts := convertIdTimeToTimestamp(activity.Id.Time)
+		if ts == nil {
+			continue
+		}
+		at := ts.AsTime().UTC().Format(time.RFC3339)
+		for _, e := range activity.Events {
+			clientID := getValueFromParameters("client_id", e.Parameters)
+			appName := getValueFromParameters("app_name", e.Parameters)
+			if clientID == "" || appName == "" {
+				continue
+			}
+			if clientID == appName && privateAppIDRegex.MatchString(clientID) {
+				continue
+			}
+			i, ok := index[clientID]
+			if !ok {
+				i = len(apps)
+				index[clientID] = i
+				apps = append(apps, oauthAppEntry{clientID: clientID, displayText: appName})
+			}
+			apps[i].scopes = append(apps[i].scopes, getValuesFromParameters("scope", e.Parameters)...)
+			if at > apps[i].authorizedAt {
+				apps[i].authorizedAt = at
+			}
+		}
+	}
+	for i := range apps {
+		slices.Sort(apps[i].scopes)
+		apps[i].scopes = slices.Compact(apps[i].scopes)
+	}
+	return apps, nil
+}
+
+// mergeOAuthApps combines one user's apps from Tokens.list and the token audit log. An app
+// found in both keeps the token's display name and flags, with the union of both scope sets.
+func mergeOAuthApps(tokens, authorized []oauthAppEntry) []oauthAppEntry {
+	merged := slices.Clone(tokens)
+	for _, a := range authorized {
+		i := slices.IndexFunc(merged, func(t oauthAppEntry) bool { return t.clientID == a.clientID })
+		if i < 0 {
+			merged = append(merged, a)
+			continue
+		}
+		scopes := slices.Concat(merged[i].scopes, a.scopes)
+		slices.Sort(scopes)
+		merged[i].scopes = slices.Compact(scopes)
+		merged[i].authorizedAt = a.authorizedAt
+	}
+	return merged
+}
+
+func storeOAuthLogins(ctx context.Context, ss sessions.SessionStore, userID string, apps []oauthAppEntry) error {
 	if len(apps) == 0 {
 		return nil
 	}
 	appsBatch := make(map[string]string, len(apps))
 	for _, a := range apps {
 		appsBatch[a.clientID] = a.displayText
-		newApps[a.clientID] = a.displayText
 	}
 	if err := session.SetManyJSON(ctx, ss, appsBatch, appLoginOAuthAppsNamespace); err != nil {
 		return fmt.Errorf("google-workspace-connector: failed to store oauth apps in session: %w", err)
 	}
 
 	for _, a := range apps {
-		if err := storeLoginIfNewer(ctx, ss, a.clientID, userID, oauthPresenceValue); err != nil {
+		loginTime := oauthPresenceValue
+		if a.authorizedAt != "" {
+			loginTime = a.authorizedAt
+		}
+		if err := storeLoginIfNewer(ctx, ss, a.clientID, userID, loginTime); err != nil {
 			return err
 		}
+		if err := session.SetJSON(ctx, ss, userID, a.scopes, appOAuthScopesNamespace(a.clientID)); err != nil {
+			return fmt.Errorf("google-workspace-connector: failed to store oauth scopes for app %s user %s: %w", a.clientID, userID, err)
+		}
+		if a.hasToken {
+			if err := storeOAuthTokenFlags(ctx, ss, a.clientID, a.flags); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
+// storeOAuthTokenFlags ORs flags into the flags stored for appID.
+func storeOAuthTokenFlags(ctx context.Context, ss sessions.SessionStore, appID string, flags oauthTokenFlags) error {
+	existing, found, err := session.GetJSON[oauthTokenFlags](ctx, ss, appID, appOAuthTokenFlagsNamespace)
+	if err != nil {
+		return fmt.Errorf("google-workspace-connector: failed to read oauth token flags for app %s: %w", appID, err)
+	}
+	merged := oauthTokenFlags{Anonymous: existing.Anonymous || flags.Anonymous, NativeApp: existing.NativeApp || flags.NativeApp}
+	if found && merged == existing {
+		return nil
+	}
+	if err := session.SetJSON(ctx, ss, appID, merged, appOAuthTokenFlagsNamespace); err != nil {
+		return fmt.Errorf("google-workspace-connector: failed to store oauth token flags for app %s: %w", appID, err)
+	}
+	return nil
+}
+
+// loadOAuthAppScopes returns the scopes each user's token for an OAuth app
+// grants, and the sorted union of those scopes.
+func loadOAuthAppScopes(ctx context.Context, ss sessions.SessionStore, appID string) (map[string][]string, []string, error) {
+	userScopes, err := session.GetAllJSON[[]string](ctx, ss, appOAuthScopesNamespace(appID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace-connector: failed to read oauth scopes from session for app %s: %w", appID, err)
+	}
+	var all []string
+	for _, scopes := range userScopes {
+		all = append(all, scopes...)
+	}
+	slices.Sort(all)
+	return userScopes, slices.Compact(all), nil
+}
+
 // storeLoginIfNewer writes loginTime for (appID, userID) only if it is newer than what is
 // already stored, so a later page's stale/earlier timestamp never clobbers a fresher one.
 func storeLoginIfNewer(ctx context.Context, ss sessions.SessionStore, appID, userID, loginTime string) error {
@@ -5,13 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/session"
 	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-sdk/pkg/types/sessions"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	admin "google.golang.org/api/admin/directory/v1"
 	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
 
 	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
 )
@@ -23,6 +29,9 @@ const applicationAccessEntitlement = "access"
 const errorReasonAccessNotConfigured = "accessNotConfigured"
 
 type applicationResource struct {
+	tenantPartitions
+	syncFilters
+	syncOptions
 	client     *gwclient.GoogleWorkspaceClient
 	customerID string
 	domain     string
@@ -40,7 +49,11 @@ func (ar *applicationResource) ResourceType(_ context.Context) *v2.ResourceType
 	return resourceTypeEnterpriseApplication
 }
 
-func (ar *applicationResource) List(ctx context.Context, _ *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+func (ar *applicationResource) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	if ar.partitioned() {
+		return ar.listPartitions(ctx, parentResourceID, attrs)
+	}
+
 	isFirstPage := attrs.PageToken.Token == ""
 
 	var samlProfileMap map[string]string
@@ -64,9 +77,14 @@ func (ar *applicationResource) List(ctx context.Context, _ *v2.ResourceId, attrs
 		if err != nil {
 			return nil, nil, err
 		}
+		if isFirstPage {
+			if err := loadSAMLAppAssignments(ctx, attrs.Session, ar.client, ar.customerID, ar.domain, ar.userFilter); err != nil {
+				return nil, nil, err
+			}
+		}
 	}
 
-	newOAuthApps, newSAMLApps, nextPageToken, err := scanAppLoginsPage(ctx, attrs.Session, ar.client, ar.customerID, ar.domain, attrs.PageToken.Token, samlProfileMap)
+	newSAMLApps, nextPageToken, err := scanAppLoginsPage(ctx, attrs.Session, ar.client, ar.customerID, ar.domain, attrs.PageToken.Token, samlProfileMap, ar.oauthAppDiscovery)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -79,7 +97,7 @@ func (ar *applicationResource) List(ctx context.Context, _ *v2.ResourceId, attrs
 		}
 	}
 
-	resources := make([]*v2.Resource, 0, len(newOAuthApps)+len(newSAMLApps)+1)
+	resources := make([]*v2.Resource, 0, len(newSAMLApps)+1)
 	// emittedThisCall accumulates emitted-app markers locally and is only persisted once, right
 	// before the successful return below. Writing markers per-app mid-loop (as before) let an
 	// error on a later app in the same call abort List() with `resources` discarded by the SDK,
@@ -87,10 +105,7 @@ func (ar *applicationResource) List(ctx context.Context, _ *v2.ResourceId, attrs
 	// the SDK's retry with the same page token, since they'd read back as already emitted.
 	emittedThisCall := make(map[string]string)
 
-	for appID, displayName := range newOAuthApps {
-		if _, isSAML := newSAMLApps[appID]; isSAML {
-			continue
-		}
+	for appID, displayName := range newSAMLApps {
 		alreadyEmitted, err := isAppEmitted(ctx, attrs.Session, appID)
 		if err != nil {
 			return nil, nil, err
@@ -99,7 +114,7 @@ func (ar *applicationResource) List(ctx context.Context, _ *v2.ResourceId, attrs
 			continue
 		}
 		r, err := rs.NewAppResource(displayName, resourceTypeEnterpriseApplication, appID, nil,
-			rs.WithNHIType(v2.NonHumanIdentityTrait_NHI_TYPE_APP_REGISTRATION, "gws.oauth_app"))
+			rs.WithNHIType(v2.NonHumanIdentityTrait_NHI_TYPE_APP_REGISTRATION, "gws.saml_app"))
 		if err != nil {
 			return nil, nil, fmt.Errorf("google-workspace-connector: failed to create application resource %s: %w", appID, err)
 		}
@@ -107,25 +122,30 @@ func (ar *applicationResource) List(ctx context.Context, _ *v2.ResourceId, attrs
 		emittedThisCall[appID] = "1"
 	}
 
-	for appID, displayName := range newSAMLApps {
-		alreadyEmitted, err := isAppEmitted(ctx, attrs.Session, appID)
+	if nextPageToken == "" {
+		// Final page of this pass: every user's OAuth tokens have been read, so each OAuth
+		// app's profile can summarize the scopes granted across all of its users.
+		oauthApps, err := session.GetAllJSON[string](ctx, attrs.Session, appLoginOAuthAppsNamespace)
 		if err != nil {
-			return nil, nil, err
-		}
-		if alreadyEmitted {
-			continue
+			return nil, nil, fmt.Errorf("google-workspace-connector: failed to read oauth apps from session: %w", err)
 		}
-		r, err := rs.NewAppResource(displayName, resourceTypeEnterpriseApplication, appID, nil,
-			rs.WithNHIType(v2.NonHumanIdentityTrait_NHI_TYPE_APP_REGISTRATION, "gws.saml_app"))
-		if err != nil {
-			return nil, nil, fmt.Errorf("google-workspace-connector: failed to create application resource %s: %w", appID, err)
+		for appID, displayName := range oauthApps {
+			alreadyEmitted, err := isAppEmitted(ctx, attrs.Session, appID)
+			if err != nil {
+				return nil, nil, err
+			}
+			if alreadyEmitted {
+				continue
+			}
+			r, err := oauthAppResource(ctx, attrs.Session, appID, displayName)
+			if err != nil {
+				return nil, nil, err
+			}
+			resources = append(resources, r)
+			emittedThisCall[appID] = "1"
 		}
-		resources = append(resources, r)
-		emittedThisCall[appID] = "1"
-	}
 
-	if nextPageToken == "" {
-		// Final page of this pass: Google Workspace itself is always an app — sign-in events
+		// Google Workspace itself is always an app — sign-in events
 		// from googleLoginEventFeed target this resource. Emit it once, only here, so it is
 		// never returned more than once across pages.
 		alreadyEmitted, err := isAppEmitted(ctx, attrs.Session, googleWorkspaceAppID)
@@ -152,28 +172,88 @@ func (ar *applicationResource) List(ctx context.Context, _ *v2.ResourceId, attrs
 	return resources, &rs.SyncOpResults{NextPageToken: nextPageToken}, nil
 }
 
-func (ar *applicationResource) Entitlements(_ context.Context, resource *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
-	return []*v2.Entitlement{
-		entitlement.NewAssignmentEntitlement(
+func (ar *applicationResource) Entitlements(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	if ar.partitioned() {
+		return ar.entitlementsPartitions(ctx, resource, attrs)
+	}
+
+	if resource.Id.Resource == googleWorkspaceAppID {
+		return []*v2.Entitlement{
+			entitlement.NewAssignmentEntitlement(
+				resource,
+				applicationAccessEntitlement,
+				entitlement.WithDisplayName("Has Access"),
+				entitlement.WithDescription("User has logged in to this application"),
+				entitlement.WithAnnotation(&v2.EntitlementImmutable{}),
+				entitlement.WithGrantableTo(resourceTypeUser),
+			),
+		}, &rs.SyncOpResults{}, nil
+	}
+
+	// SAML apps are also granted to the groups and org units whose SSO
+	// assignment uses them.
+	if !isOAuthAppID(resource.Id.Resource) {
+		return []*v2.Entitlement{
+			entitlement.NewAssignmentEntitlement(
+				resource,
+				applicationAccessEntitlement,
+				entitlement.WithDisplayName("Has Access"),
+				entitlement.WithDescription("User has logged in to this application, or is in a group or org unit assigned to it"),
+				entitlement.WithAnnotation(&v2.EntitlementImmutable{}),
+				entitlement.WithGrantableTo(resourceTypeUser, resourceTypeGroup, resourceTypeOrgUnit),
+			),
+		}, &rs.SyncOpResults{}, nil
+	}
+
+	// OAuth app grants can be revoked by deleting the user's token, so
+	// they are not immutable. Each scope granted to any user gets its own
+	// entitlement.
+	_, scopes, err := loadOAuthAppScopes(ctx, attrs.Session, resource.Id.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+	rv := make([]*v2.Entitlement, 0, len(scopes)+1)
+	rv = append(rv, entitlement.NewAssignmentEntitlement(
+		resource,
+		applicationAccessEntitlement,
+		entitlement.WithDisplayName("Has Access"),
+		entitlement.WithDescription("User has authorized or logged in to this application"),
+		entitlement.WithGrantableTo(resourceTypeUser),
+	))
+	for _, scope := range scopes {
+		rv = append(rv, entitlement.NewPermissionEntitlement(
 			resource,
-			applicationAccessEntitlement,
-			entitlement.WithDisplayName("Has Access"),
-			entitlement.WithDescription("User has logged in to this application"),
-			entitlement.WithAnnotation(&v2.EntitlementImmutable{}),
+			oauthScopeShortName(scope),
+			entitlement.WithDisplayName(fmt.Sprintf("%s Scope (%s)", oauthScopeShortName(scope), oauthScopeRisk(scope))),
+			entitlement.WithDescription(fmt.Sprintf("User has granted %s the %s OAuth scope, a %s scope", resource.DisplayName, scope, oauthScopeRisk(scope))),
 			entitlement.WithGrantableTo(resourceTypeUser),
-		),
-	}, &rs.SyncOpResults{}, nil
+		))
+	}
+	return rv, &rs.SyncOpResults{}, nil
 }
 
 func (ar *applicationResource) Grants(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	if ar.partitioned() {
+		return ar.grantsPartitions(ctx, resource, attrs)
+	}
+
 	appID := resource.Id.Resource
 
+	var grants []*v2.Grant
+	if strings.HasPrefix(appID, samlAppIDPrefix) {
+		var err error
+		grants, err = samlAppAssignmentGrants(ctx, attrs.Session, resource)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	userLogins, err := session.GetAllJSON[string](ctx, attrs.Session, appLoginLoginsNamespace(appID))
 	if err != nil {
 		return nil, nil, fmt.Errorf("google-workspace-connector: failed to read app logins from session: %w", err)
 	}
 	if len(userLogins) == 0 {
-		return nil, &rs.SyncOpResults{}, nil
+		return grants, &rs.SyncOpResults{}, nil
 	}
 
 	directoryUsers, err := session.GetAllJSON[string](ctx, attrs.Session, appLoginDirectoryUserNamespace)
@@ -181,10 +261,9 @@ func (ar *applicationResource) Grants(ctx context.Context, resource *v2.Resource
 		return nil, nil, fmt.Errorf("google-workspace-connector: failed to read directory users from session: %w", err)
 	}
 	if len(directoryUsers) == 0 {
-		return nil, &rs.SyncOpResults{}, nil
+		return grants, &rs.SyncOpResults{}, nil
 	}
 
-	grants := make([]*v2.Grant, 0, len(userLogins))
 	for profileID := range userLogins {
 		if _, isDirectoryUser := directoryUsers[profileID]; !isDirectoryUser {
 			continue
@@ -198,9 +277,99 @@ func (ar *applicationResource) Grants(ctx context.Context, resource *v2.Resource
 		grants = append(grants, g)
 	}
 
+	if isOAuthAppID(appID) {
+		userScopes, _, err := loadOAuthAppScopes(ctx, attrs.Session, appID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for userID, scopes := range userScopes {
+			if _, isDirectoryUser := directoryUsers[userID]; !isDirectoryUser {
+				continue
+			}
+			principal := &v2.ResourceId{Resource: userID, ResourceType: resourceTypeUser.Id}
+			for _, scope := range scopes {
+				grants = append(grants, grant.NewGrant(resource, oauthScopeShortName(scope), principal))
+			}
+		}
+	}
+
 	return grants, &rs.SyncOpResults{}, nil
 }
 
+// oauthAppResource builds an OAuth app's resource, with the scopes its users
+// have granted, their highest risk tier and its token flags on its profile.
+func oauthAppResource(ctx context.Context, ss sessions.SessionStore, appID, displayName string) (*v2.Resource, error) {
+	userScopes, scopes, err := loadOAuthAppScopes(ctx, ss, appID)
+	if err != nil {
+		return nil, err
+	}
+	profileScopes := make([]interface{}, 0, len(scopes))
+	for _, scope := range scopes {
+		profileScopes = append(profileScopes, scope)
+	}
+	profile := map[string]interface{}{
+		"client_id":             appID,
+		"scopes":                profileScopes,
+		"risk_tier":             oauthScopesRisk(scopes),
+		"authorized_user_count": len(userScopes),
+	}
+	// Only apps seen through Tokens.list have flags.
+	flags, found, err := session.GetJSON[oauthTokenFlags](ctx, ss, appID, appOAuthTokenFlagsNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace-connector: failed to read oauth token flags for app %s: %w", appID, err)
+	}
+	if found {
+		profile["anonymous"] = flags.Anonymous
+		profile["native_app"] = flags.NativeApp
+	}
+	r, err := rs.NewAppResource(displayName, resourceTypeEnterpriseApplication, appID,
+		[]rs.AppTraitOption{rs.WithAppProfile(profile)},
+		rs.WithNHIType(v2.NonHumanIdentityTrait_NHI_TYPE_APP_REGISTRATION, "gws.oauth_app"))
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace-connector: failed to create application resource %s: %w", appID, err)
+	}
+	return r, nil
+}
+
+// Grant is not supported: users authorize OAuth apps themselves, and SAML app
+// access comes from Google's SSO configuration.
+func (ar *applicationResource) Grant(_ context.Context, _ *v2.Resource, _ *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	return nil, nil, uhttp.WrapErrors(codes.Unimplemented,
+		"google-workspace: application access cannot be granted; users authorize OAuth apps themselves")
+}
+
+// Revoke deletes the user's OAuth token for the app. Tokens can't be narrowed,
+// so revoking a scope grant removes the app's access, and all its scopes,
+// for that user.
+func (ar *applicationResource) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	if p, ok := ar.provisioner(g.GetEntitlement().GetResource()).(*applicationResource); ok {
+		return p.Revoke(ctx, g)
+	}
+
+	appID := g.GetEntitlement().GetResource().GetId().GetResource()
+	if !isOAuthAppID(appID) {
+		return nil, uhttp.WrapErrors(codes.Unimplemented,
+			"google-workspace: only OAuth application grants can be revoked; SAML and Google Workspace access is managed in the Admin console")
+	}
+	if g.GetPrincipal().GetId().GetResourceType() != resourceTypeUser.Id {
+		return nil, uhttp.WrapErrors(codes.InvalidArgument, "user principal is required")
+	}
+	if ar.client.UserSecurityService == nil {
+		return nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", admin.AdminDirectoryUserSecurityScope))
+	}
+
+	userID := g.GetPrincipal().GetId().GetResource()
+	if err := ar.client.DeleteToken(ctx, userID, appID); err != nil {
+		var gerr *googleapi.Error
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			// Token already deleted, return success (idempotent).
+			return nil, nil
+		}
+		return nil, fmt.Errorf("google-workspace: failed to revoke oauth token: %w", err)
+	}
+	return nil, nil
+}
+
 // isCloudIdentityAPIDisabledError reports whether err is Google's permanent
 // "this API is not enabled for the project" failure: HTTP 403 with reason
 // SERVICE_DISABLED (structured google.rpc.ErrorInfo) or accessNotConfigured
@@ -56,16 +56,15 @@ func (f *fakeSessionStore) GetMany(ctx context.Context, keys []string, opt ...se
 	if err != nil {
 		return nil, nil, err
 	}
+	// Absent keys are left out of the result; the second return value is
+	// for keys the store could not process, which this fake never has.
 	result := map[string][]byte{}
-	var missing []string
 	for _, k := range keys {
 		if v, ok := f.data[f.storageKey(bag, k)]; ok {
 			result[k] = v
-		} else {
-			missing = append(missing, k)
 		}
 	}
-	return result, missing, nil
+	return result, nil, nil
 }
 
 func (f *fakeSessionStore) Set(ctx context.Context, key string, value []byte, opt ...sessions.SessionStoreOption) error {
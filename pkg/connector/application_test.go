@@ -4,13 +4,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/session"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	reportsAdmin "google.golang.org/api/admin/reports/v1"
 	cloudidentity "google.golang.org/api/cloudidentity/v1"
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
 )
@@ -138,3 +151,292 @@ func TestBuildSAMLProfileMapOtherErrorsNotClassified(t *testing.T) {
 		})
 	}
 }
+
+func TestOAuthScopeRisk(t *testing.T) {
+	for scope, want := range map[string]string{
+		"https://mail.google.com/":                             oauthRiskRestricted,
+		"https://www.googleapis.com/auth/drive":                oauthRiskRestricted,
+		"https://www.googleapis.com/auth/gmail.readonly":       oauthRiskRestricted,
+		"https://www.googleapis.com/auth/calendar.readonly":    oauthRiskSensitive,
+		"https://www.googleapis.com/auth/admin.directory.user": oauthRiskSensitive,
+		"https://example.com/auth/unknown":                     oauthRiskSensitive,
+		"https://www.googleapis.com/auth/drive.file":           oauthRiskBasic,
+		"https://www.googleapis.com/auth/userinfo.email":       oauthRiskBasic,
+		"openid": oauthRiskBasic,
+	} {
+		require.Equal(t, want, oauthScopeRisk(scope), scope)
+	}
+	require.Equal(t, "mail.google.com", oauthScopeShortName("https://mail.google.com/"))
+	require.Equal(t, oauthRiskBasic, oauthScopesRisk(nil))
+	require.Equal(t, oauthRiskSensitive, oauthScopesRisk([]string{"openid", "https://www.googleapis.com/auth/calendar"}))
+}
+
+// TestApplicationResource_OAuthScopes syncs OAuth apps from users' tokens and
+// checks the scope entitlements and grants, the profile's risk tier, and that
+// revoking a grant deletes the user's token.
+func TestApplicationResource_OAuthScopes(t *testing.T) {
+	srv := newFakeWorkspace(t)
+	const (
+		drive    = "https://www.googleapis.com/auth/drive"
+		calendar = "https://www.googleapis.com/auth/calendar.readonly"
+		email    = "https://www.googleapis.com/auth/userinfo.email"
+	)
+	srv.AddUserToken("alice@example.com", &directoryAdmin.Token{ClientId: "client-1", DisplayText: "Backup Tool", Scopes: []string{drive, email}})
+	srv.AddUserToken("bob@example.com", &directoryAdmin.Token{ClientId: "client-1", DisplayText: "Backup Tool", Scopes: []string{calendar, email}})
+	srv.AddUserToken("bob@example.com", &directoryAdmin.Token{ClientId: "client-2", DisplayText: "Sign-in Only", Scopes: []string{email}})
+	alice, bob := srv.User("alice@example.com"), srv.User("bob@example.com")
+
+	c := newFakeWorkspaceConnector(t, srv)
+	client, err := c.getClient(context.Background())
+	require.NoError(t, err)
+	ar := newApplicationResource(client, c.customerID, c.domain)
+
+	resources, grants := syncAll(t, []connectorbuilder.ResourceSyncerV2{ar})
+	apps := map[string]*v2.Resource{}
+	for _, r := range resources[resourceTypeEnterpriseApplication.Id] {
+		apps[r.Id.Resource] = r
+	}
+	require.Contains(t, apps, "client-1")
+	require.Contains(t, apps, "client-2")
+
+	trait, err := rs.GetAppTrait(apps["client-1"])
+	require.NoError(t, err)
+	profile := trait.GetProfile().AsMap()
+	require.Equal(t, oauthRiskRestricted, profile["risk_tier"])
+	require.ElementsMatch(t, []interface{}{drive, calendar, email}, profile["scopes"])
+	require.Equal(t, float64(2), profile["authorized_user_count"])
+
+	trait, err = rs.GetAppTrait(apps["client-2"])
+	require.NoError(t, err)
+	require.Equal(t, oauthRiskBasic, trait.GetProfile().AsMap()["risk_tier"])
+
+	ents, _, err := ar.Entitlements(context.Background(), apps["client-1"], rs.SyncOpAttrs{Session: newFakeSessionStore()})
+	require.NoError(t, err)
+	require.Len(t, ents, 1, "scope entitlements come from the sync session")
+
+	require.ElementsMatch(t, []string{alice.Id, bob.Id}, grants["enterprise_application:client-1:access"])
+	require.Equal(t, []string{alice.Id}, grants["enterprise_application:client-1:drive"])
+	require.Equal(t, []string{bob.Id}, grants["enterprise_application:client-1:calendar.readonly"])
+	require.ElementsMatch(t, []string{alice.Id, bob.Id}, grants["enterprise_application:client-1:userinfo.email"])
+
+	// Revoking a scope grant deletes the whole token for that user only.
+	revoke := grant.NewGrant(apps["client-1"], "drive", &v2.ResourceId{ResourceType: resourceTypeUser.Id, Resource: alice.Id})
+	_, err = ar.Revoke(context.Background(), revoke)
+	require.NoError(t, err)
+	tokens, err := client.ListTokens(context.Background(), alice.Id)
+	require.NoError(t, err)
+	require.Empty(t, tokens.Items)
+	tokens, err = client.ListTokens(context.Background(), bob.Id)
+	require.NoError(t, err)
+	require.Len(t, tokens.Items, 2)
+
+	// Revoking again is a no-op.
+	_, err = ar.Revoke(context.Background(), revoke)
+	require.NoError(t, err)
+
+	workspace := grant.NewGrant(apps[googleWorkspaceAppID], applicationAccessEntitlement, &v2.ResourceId{ResourceType: resourceTypeUser.Id, Resource: alice.Id})
+	_, err = ar.Revoke(context.Background(), workspace)
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+// TestApplicationResource_OAuthAppDiscovery checks which apps each discovery
+// mode finds when alice still holds a token for one app and the audit log
+// also shows her authorizing another app she has since revoked.
+func TestApplicationResource_OAuthAppDiscovery(t *testing.T) {
+	const (
+		drive = "https://www.googleapis.com/auth/drive"
+		email = "https://www.googleapis.com/auth/userinfo.email"
+	)
+	for _, tc := range []struct {
+		discovery oauthAppDiscovery
+		apps      []string
+	}{
+		{oauthAppDiscoveryTokens, []string{"client-1"}},
+		{oauthAppDiscoveryReports, []string{"client-1", "client-old"}},
+		{oauthAppDiscoveryMerged, []string{"client-1", "client-old"}},
+	} {
+		t.Run(string(tc.discovery), func(t *testing.T) {
+			srv := newFakeWorkspace(t)
+			srv.AddUserToken("alice@example.com", &directoryAdmin.Token{ClientId: "client-1", DisplayText: "Backup Tool", Scopes: []string{email}, NativeApp: true})
+			alice := srv.User("alice@example.com")
+			for clientID, scope := range map[string]string{"client-1": drive, "client-old": email} {
+				srv.AddActivity(reportsAppToken, &reportsAdmin.Activity{
+					Id:    &reportsAdmin.ActivityId{Time: "2026-01-02T03:04:05Z"},
+					Actor: &reportsAdmin.ActivityActor{Email: alice.PrimaryEmail, ProfileId: alice.Id},
+					Events: []*reportsAdmin.ActivityEvents{{Name: "authorize", Parameters: []*reportsAdmin.ActivityEventsParameters{
+						{Name: "client_id", Value: clientID},
+						{Name: "app_name", Value: "App " + clientID},
+						{Name: "scope", MultiValue: []string{scope}},
+					}}},
+				})
+			}
+
+			c := newFakeWorkspaceConnector(t, srv)
+			client, err := c.getClient(context.Background())
+			require.NoError(t, err)
+			ar := newApplicationResource(client, c.customerID, c.domain)
+			ar.oauthAppDiscovery = tc.discovery
+
+			resources, grants := syncAll(t, []connectorbuilder.ResourceSyncerV2{ar})
+			apps := map[string]*v2.Resource{}
+			for _, r := range resources[resourceTypeEnterpriseApplication.Id] {
+				if isOAuthAppID(r.Id.Resource) {
+					apps[r.Id.Resource] = r
+				}
+			}
+			require.ElementsMatch(t, tc.apps, slices.Collect(maps.Keys(apps)))
+
+			trait, err := rs.GetAppTrait(apps["client-1"])
+			require.NoError(t, err)
+			profile := trait.GetProfile().AsMap()
+			if tc.discovery.usesTokens() {
+				require.Equal(t, true, profile["native_app"])
+				require.Equal(t, false, profile["anonymous"])
+			} else {
+				require.NotContains(t, profile, "native_app", "only tokens report flags")
+			}
+			if tc.discovery == oauthAppDiscoveryMerged {
+				require.ElementsMatch(t, []interface{}{drive, email}, profile["scopes"])
+				require.Equal(t, "Backup Tool", apps["client-1"].DisplayName)
+			}
+			if tc.discovery.usesReports() {
+				require.Equal(t, []string{alice.Id}, grants["enterprise_application:client-old:userinfo.email"])
+			}
+		})
+	}
+
+	_, err := NewConnector(context.Background(), Config{OAuthAppDiscovery: "audit"})
+	require.Error(t, err, "unknown discovery sources are rejected when the connector is configured")
+	c, err := NewConnector(context.Background(), Config{})
+	require.NoError(t, err)
+	require.Equal(t, oauthAppDiscoveryTokens, c.options.oauthAppDiscovery)
+}
+
+func TestSAMLAppTargetsFromAssignments(t *testing.T) {
+	const profile = "inboundSamlSsoProfiles/p1"
+	assignments := []*cloudidentity.InboundSsoAssignment{
+		{TargetOrgUnit: "orgUnits/eng", SsoMode: ssoModeSAML, SamlSsoInfo: &cloudidentity.SamlSsoInfo{InboundSamlSsoProfile: profile}},
+		{TargetOrgUnit: "orgUnits/contractors", SsoMode: "SSO_OFF"},
+		{TargetGroup: "groups/g1", SsoMode: ssoModeSAML, SamlSsoInfo: &cloudidentity.SamlSsoInfo{InboundSamlSsoProfile: profile}},
+		{TargetGroup: "groups/g2", SsoMode: "SSO_OFF"},
+	}
+	orgUnits := []*directoryAdmin.OrgUnit{
+		{OrgUnitId: "id:eng", OrgUnitPath: "/Eng", ParentOrgUnitId: "id:root", ParentOrgUnitPath: "/"},
+		{OrgUnitId: "id:backend", OrgUnitPath: "/Eng/Backend", ParentOrgUnitId: "id:eng", ParentOrgUnitPath: "/Eng"},
+		{OrgUnitId: "id:contractors", OrgUnitPath: "/Eng/Contractors", ParentOrgUnitId: "id:eng", ParentOrgUnitPath: "/Eng"},
+		{OrgUnitId: "id:temps", OrgUnitPath: "/Eng/Contractors/Temps", ParentOrgUnitId: "id:contractors", ParentOrgUnitPath: "/Eng/Contractors"},
+		{OrgUnitId: "id:sales", OrgUnitPath: "/Sales", ParentOrgUnitId: "id:root", ParentOrgUnitPath: "/"},
+	}
+
+	targets := samlAppTargetsFromAssignments(assignments, orgUnits)
+	require.Equal(t, map[string]samlAppTargets{
+		samlAppIDPrefix + profile: {Groups: []string{"g1"}, OrgUnits: []string{"id:backend", "id:eng"}},
+	}, targets)
+	require.False(t, targets[samlAppIDPrefix+profile].rootOrgUnit)
+
+	// Without the org unit tree, only the assigned org unit itself is a target.
+	targets = samlAppTargetsFromAssignments(assignments, nil)
+	require.Equal(t, []string{"id:eng"}, targets[samlAppIDPrefix+profile].OrgUnits)
+
+	// An assignment to the root is inherited by every org unit.
+	assignments = append(assignments, &cloudidentity.InboundSsoAssignment{
+		TargetOrgUnit: "orgUnits/root", SsoMode: ssoModeSAML, SamlSsoInfo: &cloudidentity.SamlSsoInfo{InboundSamlSsoProfile: "inboundSamlSsoProfiles/p2"},
+	})
+	targets = samlAppTargetsFromAssignments(assignments, orgUnits)
+	require.Equal(t, []string{"id:sales"}, targets[samlAppIDPrefix+"inboundSamlSsoProfiles/p2"].OrgUnits)
+	// The root isn't a resource, so its own users are listed instead.
+	require.True(t, targets[samlAppIDPrefix+"inboundSamlSsoProfiles/p2"].rootOrgUnit)
+	require.False(t, targets[samlAppIDPrefix+profile].rootOrgUnit)
+}
+
+// TestSAMLAppAssignmentGrants_RootOrgUnitUsers checks that the users directly
+// in an assigned root org unit are granted the SAML app one by one, and that
+// users in other org units or left out by the sync filters are not.
+func TestSAMLAppAssignmentGrants_RootOrgUnitUsers(t *testing.T) {
+	srv := newFakeWorkspace(t)
+	srv.AddUser(&directoryAdmin.User{PrimaryEmail: "carol@example.com", OrgUnitPath: "/Sales"})
+	srv.AddUser(&directoryAdmin.User{PrimaryEmail: "dave@example.com", Suspended: true})
+	c := newFakeWorkspaceConnector(t, srv)
+	client, err := c.getClient(context.Background())
+	require.NoError(t, err)
+
+	users, err := rootOrgUnitUsers(context.Background(), client, c.customerID, "", userFilter{excludeSuspended: true})
+	require.NoError(t, err)
+	expected := []string{srv.User("admin@example.com").Id, srv.User("alice@example.com").Id, srv.User("bob@example.com").Id}
+	slices.Sort(expected)
+	require.Equal(t, expected, users)
+
+	app, err := rs.NewResource("Partner IdP", resourceTypeEnterpriseApplication, samlAppIDPrefix+"inboundSamlSsoProfiles/p1")
+	require.NoError(t, err)
+	ss := newFakeSessionStore()
+	require.NoError(t, session.SetJSON(context.Background(), ss, app.Id.Resource, samlAppTargets{Users: users}, samlAppAssignmentsNamespace))
+	grants, err := samlAppAssignmentGrants(context.Background(), ss, app)
+	require.NoError(t, err)
+	var principals []string
+	for _, g := range grants {
+		require.Equal(t, resourceTypeUser.Id, g.Principal.Id.ResourceType)
+		require.Empty(t, g.Annotations, "user grants don't expand")
+		principals = append(principals, g.Principal.Id.Resource)
+	}
+	require.Equal(t, expected, principals)
+}
+
+// TestApplicationResource_SAMLAssignments checks that a SAML app is granted to
+// the groups and org units assigned to its profile, expanding to their members.
+func TestApplicationResource_SAMLAssignments(t *testing.T) {
+	srv := newFakeWorkspace(t)
+	profile := srv.AddSAMLProfile(&cloudidentity.InboundSamlSsoProfile{DisplayName: "Partner IdP"})
+	eng := srv.Group("eng@example.com")
+	srv.AddSSOAssignment(&cloudidentity.InboundSsoAssignment{
+		TargetGroup: "groups/" + eng.Id, SsoMode: ssoModeSAML, SamlSsoInfo: &cloudidentity.SamlSsoInfo{InboundSamlSsoProfile: profile.Name},
+	})
+	srv.AddSSOAssignment(&cloudidentity.InboundSsoAssignment{
+		TargetOrgUnit: "orgUnits/03ph8a2z1", SsoMode: ssoModeSAML, SamlSsoInfo: &cloudidentity.SamlSsoInfo{InboundSamlSsoProfile: profile.Name},
+	})
+
+	c := newFakeWorkspaceConnector(t, srv)
+	client, err := c.getClient(context.Background())
+	require.NoError(t, err)
+	ar := newApplicationResource(client, c.customerID, c.domain)
+
+	appID := samlAppIDPrefix + profile.Name
+	resources, syncedGrants := syncAll(t, []connectorbuilder.ResourceSyncerV2{ar})
+	require.ElementsMatch(t, []string{eng.Id, "id:03ph8a2z1"}, syncedGrants["enterprise_application:"+appID+":access"])
+	var app *v2.Resource
+	for _, r := range resources[resourceTypeEnterpriseApplication.Id] {
+		if r.Id.Resource == appID {
+			app = r
+		}
+	}
+	require.NotNil(t, app)
+
+	// Grants read the assignments List stored in syncAll's session; store
+	// them in a session of our own to inspect the grants' expansions.
+	ss := newFakeSessionStore()
+	require.NoError(t, loadSAMLAppAssignments(context.Background(), ss, client, c.customerID, c.domain, userFilter{}))
+	grants, _, err := ar.Grants(context.Background(), app, rs.SyncOpAttrs{Session: ss})
+	require.NoError(t, err)
+	require.Len(t, grants, 2)
+	expansions := map[string][]string{}
+	for _, g := range grants {
+		expandable := &v2.GrantExpandable{}
+		annos := annotations.Annotations(g.Annotations)
+		ok, err := annos.Pick(expandable)
+		require.NoError(t, err)
+		require.True(t, ok)
+		expansions[g.Principal.Id.ResourceType+"/"+g.Principal.Id.Resource] = expandable.EntitlementIds
+	}
+	require.Equal(t, map[string][]string{
+		"group/" + eng.Id:       {"group:" + eng.Id + ":member"},
+		"org_unit/id:03ph8a2z1": {"org_unit:id:03ph8a2z1:member"},
+	}, expansions)
+
+	ents, _, err := ar.Entitlements(context.Background(), app, rs.SyncOpAttrs{Session: ss})
+	require.NoError(t, err)
+	require.Len(t, ents, 1)
+	var grantableTo []string
+	for _, rt := range ents[0].GrantableTo {
+		grantableTo = append(grantableTo, rt.Id)
+	}
+	require.ElementsMatch(t, []string{resourceTypeUser.Id, resourceTypeGroup.Id, resourceTypeOrgUnit.Id}, grantableTo)
+}
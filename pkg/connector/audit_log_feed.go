@@ -0,0 +1,142 @@
+package connector
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	reportsAdmin "google.golang.org/api/admin/reports/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+// auditLogPageToken is the admin event feed cursor plus the position in the
+// walked event names. activities.list takes a single eventName, so a feed
+// interested in several walks each window once per name, and StartAt only
+// moves on once every name has been walked.
+type auditLogPageToken struct {
+	adminEventFeedPageToken
+	EventIndex int `json:"event_index,omitempty"`
+}
+
+func (pt *auditLogPageToken) marshal() (string, error) {
+	data, err := json.Marshal(pt)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal page token: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// auditLogPageConverter turns one page of activities for eventName into events.
+type auditLogPageConverter func(ctx context.Context, eventName string, activities []*reportsAdmin.Activity) ([]*v2.Event, error)
+
+// listAuditLogPage lists the next page of an application's audit log for the
+// cursor's event name through the shared Reports API rate limiter, converts it
+// and returns the advanced cursor.
+func listAuditLogPage(
+	ctx context.Context,
+	client *gwclient.GoogleWorkspaceClient,
+	applicationName string,
+	eventNames []string,
+	startAt *timestamppb.Timestamp,
+	pToken *pagination.StreamToken,
+	convert auditLogPageConverter,
+) ([]*v2.Event, *pagination.StreamState, error) {
+	l := ctxzap.Extract(ctx)
+
+	cursor := &auditLogPageToken{}
+	if err := decodeEventFeedCursor(pToken, cursor); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal page token: %w", err)
+	}
+	if cursor.EventIndex < 0 || cursor.EventIndex >= len(eventNames) {
+		cursor.EventIndex = 0
+	}
+	// Past the first event name the window is being walked again for the next
+	// name, which, like mid-pagination, must keep its StartAt.
+	if cursor.EventIndex == 0 || cursor.StartAt == "" {
+		cursor.applyLookback(ctx, startAt)
+	}
+	eventName := eventNames[cursor.EventIndex]
+
+	var size int64
+	if pToken != nil {
+		size = int64(pToken.Size)
+	}
+	r, err := listActivitiesRateLimited(ctx, client, "all", applicationName, eventName, cursor.StartAt, cursor.NextPageToken, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to list %s %s activities: %w", applicationName, eventName, err)
+	}
+
+	latestEvent, err := time.Parse(time.RFC3339, cursor.LatestEventSeen)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse latest event time in %s event feed: %w", applicationName, err)
+	}
+	for _, activity := range r.Items {
+		occurredAt := convertIdTimeToTimestamp(activity.Id.Time)
+		if occurredAt != nil && occurredAt.AsTime().After(latestEvent) {
+			cursor.LatestEventSeen = occurredAt.AsTime().Format(time.RFC3339)
+			latestEvent = occurredAt.AsTime()
+		}
+	}
+
+	events, err := convert(ctx, eventName, r.Items)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l.Debug("google-workspace-event-feed: listed audit log events",
+		zap.String("application", applicationName),
+		zap.String("event_name", eventName),
+		zap.Int("count", len(r.Items)),
+		zap.String("next_page_token", r.NextPageToken),
+		zap.String("start_at", cursor.StartAt),
+		zap.String("latest_event", cursor.LatestEventSeen),
+	)
+
+	cursor.NextPageToken = r.NextPageToken
+	if r.NextPageToken == "" {
+		cursor.EventIndex++
+		if cursor.EventIndex == len(eventNames) {
+			cursor.EventIndex = 0
+			cursor.StartAt = cursor.LatestEventSeen
+			cursor.LatestEventSeen = ""
+		}
+	}
+
+	cursorToken, err := cursor.marshal()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal cursor token in %s event feed: %w", applicationName, err)
+	}
+	return events, &pagination.StreamState{
+		Cursor:  cursorToken,
+		HasMore: r.NextPageToken != "" || cursor.EventIndex != 0,
+	}, nil
+}
+
+// eventActorResource is the user resource for the actor of a usage event.
+func eventActorResource(userID, email string) (*v2.Resource, error) {
+	userTrait, err := resource.NewUserTrait(
+		resource.WithEmail(email, true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user trait: %w", err)
+	}
+	return &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: resourceTypeUser.Id,
+			Resource:     userID,
+		},
+		DisplayName: email,
+		Status:      &v2.Status{Status: v2.Status_RESOURCE_STATUS_ENABLED},
+		Annotations: annotations.New(userTrait),
+	}, nil
+}
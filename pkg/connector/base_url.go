@@ -0,0 +1,43 @@
+package connector
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// baseURLTransport sends every request to one base URL, keeping its path and
+// query. The Google API clients each have their own host, but their paths
+// don't overlap, so a single server such as pkg/fake can serve them all.
+type baseURLTransport struct {
+	base    http.RoundTripper
+	baseURL *url.URL
+}
+
+func newBaseURLTransport(base http.RoundTripper, baseURL string) (*baseURLTransport, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("base URL %q is not absolute", baseURL)
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &baseURLTransport{base: base, baseURL: u}, nil
+}
+
+func (t *baseURLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	prefix := strings.TrimSuffix(t.baseURL.Path, "/")
+	req.URL.Scheme = t.baseURL.Scheme
+	req.URL.Host = t.baseURL.Host
+	req.URL.Path = prefix + req.URL.Path
+	if req.URL.RawPath != "" {
+		req.URL.RawPath = prefix + req.URL.RawPath
+	}
+	req.Host = t.baseURL.Host
+	return t.base.RoundTrip(req)
+}
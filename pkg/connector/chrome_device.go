@@ -0,0 +1,134 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	admin "google.golang.org/api/admin/directory/v1"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+type chromeDeviceResourceType struct {
+	tenantPartitions
+	syncFilters
+	resourceType *v2.ResourceType
+	client       *gwclient.GoogleWorkspaceClient
+	customerId   string
+	domain       string
+}
+
+func (o *chromeDeviceResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return o.resourceType
+}
+
+func (o *chromeDeviceResourceType) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.listPartitions(ctx, parentResourceID, attrs)
+	}
+
+	devices, err := o.client.ListChromeOSDevices(ctx, o.customerId, attrs.PageToken.Token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to list chrome os devices: %w", err)
+	}
+
+	rv := make([]*v2.Resource, 0, len(devices.Chromeosdevices))
+	for _, d := range devices.Chromeosdevices {
+		deviceResource, err := chromeDeviceToResource(d)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create chrome device resource in List: %w", err)
+		}
+		rv = append(rv, deviceResource)
+	}
+	return rv, &rs.SyncOpResults{NextPageToken: devices.NextPageToken}, nil
+}
+
+func (o *chromeDeviceResourceType) Entitlements(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.entitlementsPartitions(ctx, resource, attrs)
+	}
+
+	return deviceOwnerEntitlements(resource), nil, nil
+}
+
+// Grants returns an "owner" grant for the device's annotated user. Chrome OS
+// devices are owned by the organization; the annotated user is the person an
+// admin assigned the device to, and is usually their email.
+func (o *chromeDeviceResourceType) Grants(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.grantsPartitions(ctx, resource, attrs)
+	}
+
+	return deviceOwnerGrants(ctx, o.userEmails(), resource, attrs)
+}
+
+func (o *chromeDeviceResourceType) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	device, err := o.client.GetChromeOSDevice(ctx, o.customerId, resourceId.Resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to get chrome os device: %w", err)
+	}
+	deviceResource, err := chromeDeviceToResource(device)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create chrome device resource in Get: %w", err)
+	}
+	return deviceResource, nil, nil
+}
+
+func (o *chromeDeviceResourceType) userEmails() userEmailIndex {
+	return userEmailIndex{client: o.client, customerId: o.customerId, domain: o.domain, domains: o.tenantDomains, filter: o.userFilter}
+}
+
+func chromeDeviceBuilder(client *gwclient.GoogleWorkspaceClient, customerId string, domain string) *chromeDeviceResourceType {
+	return &chromeDeviceResourceType{
+		resourceType: resourceTypeChromeDevice,
+		client:       client,
+		customerId:   customerId,
+		domain:       domain,
+	}
+}
+
+func chromeDeviceProfile(device *admin.ChromeOsDevice) map[string]interface{} {
+	profile := make(map[string]interface{})
+	profile["device_id"] = device.DeviceId
+	profile["status"] = device.Status
+	profile["model"] = device.Model
+	profile["os_version"] = device.OsVersion
+	profile["platform_version"] = device.PlatformVersion
+	profile["serial_number"] = device.SerialNumber
+	profile["last_sync"] = device.LastSync
+	profile["last_enrollment_time"] = device.LastEnrollmentTime
+	profile["org_unit_path"] = device.OrgUnitPath
+	profile["annotated_asset_id"] = device.AnnotatedAssetId
+	profile["annotated_location"] = device.AnnotatedLocation
+	profile["annotated_user"] = device.AnnotatedUser
+	profile[profileKeyOwnerEmails] = ownerEmailsValue([]string{device.AnnotatedUser})
+	return profile
+}
+
+// chromeDeviceDisplayName prefers the admin-assigned asset ID, then the
+// model and serial number.
+func chromeDeviceDisplayName(device *admin.ChromeOsDevice) string {
+	switch {
+	case device.AnnotatedAssetId != "":
+		return device.AnnotatedAssetId
+	case device.Model != "" && device.SerialNumber != "":
+		return fmt.Sprintf("%s (%s)", device.Model, device.SerialNumber)
+	case device.SerialNumber != "":
+		return device.SerialNumber
+	default:
+		return device.DeviceId
+	}
+}
+
+func chromeDeviceToResource(device *admin.ChromeOsDevice) (*v2.Resource, error) {
+	if device.DeviceId == "" {
+		return nil, fmt.Errorf("google-workspace: chrome os device %s has no device id", device.SerialNumber)
+	}
+	return rs.NewResource(chromeDeviceDisplayName(device), resourceTypeChromeDevice, device.DeviceId,
+		rs.WithAnnotation(&v2.RawId{Id: device.DeviceId}),
+		rs.WithResourceProfile(chromeDeviceProfile(device)),
+	)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"reflect"
 	"strings"
 	"sync"
@@ -23,12 +24,15 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 	datatransferAdmin "google.golang.org/api/admin/datatransfer/v1"
 	directoryAdmin "google.golang.org/api/admin/directory/v1"
 	reportsAdmin "google.golang.org/api/admin/reports/v1"
+	alertcenter "google.golang.org/api/alertcenter/v1beta1"
 	cloudidentity "google.golang.org/api/cloudidentity/v1"
+	drive "google.golang.org/api/drive/v3"
+	gmail "google.golang.org/api/gmail/v1"
 	groupssettings "google.golang.org/api/groupssettings/v1"
+	licensing "google.golang.org/api/licensing/v1"
 	"google.golang.org/api/option"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -45,19 +49,81 @@ type Config struct {
 	CustomerID         string
 	AdministratorEmail string
 	Domain             string
-	Credentials        []byte
+	// Domains or AllDomains list users, groups and mailboxes one domain at a
+	// time (see tenant.go). AdditionalTenants are further customers synced
+	// alongside this one.
+	Domains           []string
+	AllDomains        bool
+	AdditionalTenants []Config
+	// Credentials is a service account JSON key. When empty, the connector
+	// authenticates without a key by impersonating ServiceAccountEmail with
+	// ExternalAccountCredentials (or ambient ADC when that is empty too).
+	Credentials                []byte
+	ServiceAccountEmail        string
+	ExternalAccountCredentials []byte
+
+	// IncludeOrgUnits through ExcludeGroupEmailPatterns narrow the synced
+	// users and groups (see sync_filter.go).
+	IncludeOrgUnits           []string
+	ExcludeOrgUnits           []string
+	UserQuery                 string
+	ExcludeSuspendedUsers     bool
+	ExcludeArchivedUsers      bool
+	GroupEmailPatterns        []string
+	ExcludeGroupEmailPatterns []string
+
+	// SyncGroupSettings adds Groups Settings to group profiles (see
+	// sync_options.go).
+	SyncGroupSettings bool
+
+	// OAuthAppDiscovery is the source OAuth apps are discovered from:
+	// "tokens" (the default), "reports" or "merged" (see app_login.go).
+	OAuthAppDiscovery string
+
+	// APIRateLimits are per-API call budgets such as "directory=1500/min",
+	// shared with the additional tenants (see rate_limiter.go).
+	APIRateLimits []string
+
+	// WatchCallbackURL, when set, enables push mode for user changes (see directory_watch.go).
+	WatchCallbackURL   string
+	WatchListenAddress string
+	WatchChannelToken  string
+
+	// BaseURL, when set, replaces the scheme and host of every Google API
+	// request (see base_url.go).
+	BaseURL string
 }
 
 type GoogleWorkspace struct {
 	customerID         string
 	domain             string
+	domains            []string
+	allDomains         bool
+	additionalTenants  []*GoogleWorkspace
+	filters            syncFilters
+	options            syncOptions
 	administratorEmail string
-	credentials        []byte
+	credentials        delegatedCredentials
+	baseURL            string
+	rateLimiters       *rateLimiterRegistry
 	mtx                sync.Mutex
 	serviceCache       map[string]any
 
 	reportService *reportsAdmin.Service
 
+	watchCallbackURL   string
+	watchListenAddress string
+	watchChannelToken  string
+	// watcher is created on the first EventFeeds call when push mode is enabled.
+	watcherMtx sync.Mutex
+	watcher    *directoryWatcher
+	// adminFeed is created on the first EventFeeds call and kept for its caches.
+	adminFeedMtx sync.Mutex
+	adminFeed    *adminEventFeed
+	// driveFeed is created on the first EventFeeds call and keeps the listed domains.
+	driveFeedMtx sync.Mutex
+	driveFeed    *driveEventFeed
+
 	// client is lazily initialised on first use via getClient().
 	clientMtx sync.Mutex
 	client    *gwclient.GoogleWorkspaceClient
@@ -111,21 +177,24 @@ func oauthErrorCode(oe *oauth2.RetrieveError) string {
 	return body.Error
 }
 
-func newGWSAdminServiceForScopes[T any](ctx context.Context, credentials []byte, email string, newService newService[T], scopes ...string) (*T, error) {
+// requestTimeout bounds each Google API call once it is sent, and each token
+// request.
+const requestTimeout = 30 * time.Second
+
+func newGWSAdminServiceForScopes[T any](ctx context.Context, credentials delegatedCredentials, baseURL string, rateLimiters *rateLimiterRegistry, email string, newService newService[T], scopes ...string) (*T, error) {
 	l := ctxzap.Extract(ctx)
-	httpClient, err := uhttp.NewClient(ctx, uhttp.WithLogger(true, l))
+	// The token source fetches tokens with this client, outside the rate
+	// limit transport that times out API calls, so it keeps its own timeout.
+	httpClient, err := uhttp.NewClient(ctx, uhttp.WithLogger(true, l), uhttp.WithTimeout(requestTimeout))
 	if err != nil {
 		return nil, uhttp.WrapErrors(codes.Internal, "failed to create HTTP client", err)
 	}
 
-	config, err := google.JWTConfigFromJSON(credentials, scopes...)
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	tokenSrc, err := credentials.tokenSource(ctx, email, scopes...)
 	if err != nil {
-		return nil, uhttp.WrapErrors(codes.InvalidArgument, "failed to parse JWT config from credentials", err)
+		return nil, uhttp.WrapErrors(codes.InvalidArgument, "failed to load credentials", err)
 	}
-	config.Subject = email
-
-	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
-	tokenSrc := config.TokenSource(ctx)
 	token, err := tokenSrc.Token()
 	if err != nil {
 		l.Debug("google-workspace: failed fetching token", zap.Error(err))
@@ -136,10 +205,16 @@ func newGWSAdminServiceForScopes[T any](ctx context.Context, credentials []byte,
 		return nil, err
 	}
 
+	base := httpClient.Transport
+	if baseURL != "" {
+		base, err = newBaseURLTransport(base, baseURL)
+		if err != nil {
+			return nil, uhttp.WrapErrors(codes.InvalidArgument, "invalid base URL", err)
+		}
+	}
 	httpClient = &http.Client{
-		Timeout: 30 * time.Second,
 		Transport: &oauth2.Transport{
-			Base:   httpClient.Transport,
+			Base:   newRateLimitTransport(base, rateLimiters, requestTimeout),
 			Source: oauth2.ReuseTokenSource(token, tokenSrc),
 		},
 	}
@@ -154,7 +229,7 @@ func (c *GoogleWorkspace) getReportService(ctx context.Context) (*reportsAdmin.S
 	if c.reportService != nil {
 		return c.reportService, nil
 	}
-	srv, err := newGWSAdminServiceForScopes(ctx, c.credentials, c.administratorEmail, reportsAdmin.NewService, reportsAdmin.AdminReportsAuditReadonlyScope)
+	srv, err := newGWSAdminServiceForScopes(ctx, c.credentials, c.baseURL, c.rateLimiters, c.administratorEmail, reportsAdmin.NewService, reportsAdmin.AdminReportsAuditReadonlyScope)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create report service: %w", err)
 	}
@@ -166,11 +241,55 @@ func (c *GoogleWorkspace) getDirectoryService(ctx context.Context, scope string)
 	return getService(ctx, c, scope, directoryAdmin.NewService)
 }
 
+// getDirectoryBatcher returns a batcher authorized like service, the
+// Directory service for scope. It returns nil, so that batch methods make
+// each call on its own, when service is nil or the batcher can't be built.
+func (c *GoogleWorkspace) getDirectoryBatcher(ctx context.Context, service *directoryAdmin.Service, scope string) *gwclient.DirectoryBatcher {
+	if service == nil {
+		return nil
+	}
+	batcher, err := getService(ctx, c, scope, gwclient.NewDirectoryBatcher)
+	if err != nil {
+		ctxzap.Extract(ctx).Debug("google-workspace: directory batch requests unavailable, calls are made one at a time",
+			zap.String("scope", scope), zap.Error(err))
+		return nil
+	}
+	return batcher
+}
+
 func (c *GoogleWorkspace) getGroupsSettingsService(ctx context.Context) (*groupssettings.Service, error) {
 	const groupsSettingsScope = "https://www.googleapis.com/auth/apps.groups.settings"
 	return getService(ctx, c, groupsSettingsScope, groupssettings.NewService)
 }
 
+// getGmailServiceFactory checks that scope is authorized by building a Gmail
+// service for the administrator, then returns a factory that impersonates
+// each mailbox owner with that scope. Like the administrator's services, each
+// mailbox owner's service is cached, so the calls for one mailbox share a
+// token instead of fetching one each.
+func (c *GoogleWorkspace) getGmailServiceFactory(ctx context.Context, scope string) (gwclient.GmailServiceFactory, error) {
+	if _, err := getService(ctx, c, scope, gmail.NewService); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, userEmail string) (*gmail.Service, error) {
+		key := serviceCacheKey[gmail.Service](scope) + ":" + strings.ToLower(userEmail)
+		c.mtx.Lock()
+		cached, ok := c.serviceCache[key].(*gmail.Service)
+		c.mtx.Unlock()
+		if ok {
+			return cached, nil
+		}
+		srv, err := newGWSAdminServiceForScopes(ctx, c.credentials, c.baseURL, c.rateLimiters, userEmail, gmail.NewService, scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gmail service for %s: %w", userEmail, err)
+		}
+		c.mtx.Lock()
+		c.serviceCache[key] = srv
+		c.mtx.Unlock()
+		return srv, nil
+	}, nil
+}
+
 func (c *GoogleWorkspace) getDataTransferService(ctx context.Context, scope string) (*datatransferAdmin.Service, error) {
 	return getService(ctx, c, scope, datatransferAdmin.NewService)
 }
@@ -183,15 +302,63 @@ func New(ctx context.Context, config *cfg.GoogleWorkspace, opts *cli.ConnectorOp
 		credentialBytes = []byte(config.CredentialsJson)
 	case len(config.CredentialsJsonFilePath) > 0:
 		credentialBytes = config.CredentialsJsonFilePath
+	case config.ServiceAccountEmail != "":
+		// Keyless: impersonate the service account via IAM signJwt.
 	default:
-		return nil, nil, fmt.Errorf("credentials-json or credentials-json-file-path is required")
+		return nil, nil, fmt.Errorf("credentials-json, credentials-json-file-path or service-account-email is required")
+	}
+
+	var additionalTenants []Config
+	if len(config.AdditionalTenantsFilePath) > 0 {
+		var err error
+		additionalTenants, err = parseAdditionalTenants(config.AdditionalTenantsFilePath, config.ExternalAccountCredentialsFilePath)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if config.WatchCallbackUrl != "" {
+		u, err := url.Parse(config.WatchCallbackUrl)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, nil, fmt.Errorf("watch-callback-url must be an absolute https URL")
+		}
+	}
+
+	if _, err := parseOAuthAppDiscovery(config.OauthAppDiscovery); err != nil {
+		return nil, nil, fmt.Errorf("oauth-app-discovery must be tokens, reports or merged, got %q", config.OauthAppDiscovery)
+	}
+
+	if config.BaseUrl != "" {
+		u, err := url.Parse(config.BaseUrl)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, nil, fmt.Errorf("base-url must be an absolute http or https URL")
+		}
 	}
 
 	connector, err := NewConnector(ctx, Config{
-		CustomerID:         config.CustomerId,
-		AdministratorEmail: config.AdministratorEmail,
-		Domain:             config.Domain,
-		Credentials:        credentialBytes,
+		CustomerID:                 config.CustomerId,
+		AdministratorEmail:         config.AdministratorEmail,
+		Domain:                     config.Domain,
+		Domains:                    config.Domains,
+		AllDomains:                 config.AllDomains,
+		AdditionalTenants:          additionalTenants,
+		Credentials:                credentialBytes,
+		ServiceAccountEmail:        config.ServiceAccountEmail,
+		ExternalAccountCredentials: config.ExternalAccountCredentialsFilePath,
+		IncludeOrgUnits:            config.IncludeOrgUnits,
+		ExcludeOrgUnits:            config.ExcludeOrgUnits,
+		UserQuery:                  config.UserQuery,
+		ExcludeSuspendedUsers:      config.ExcludeSuspendedUsers,
+		ExcludeArchivedUsers:       config.ExcludeArchivedUsers,
+		GroupEmailPatterns:         config.GroupEmailPatterns,
+		ExcludeGroupEmailPatterns:  config.ExcludeGroupEmailPatterns,
+		SyncGroupSettings:          config.SyncGroupSettings,
+		OAuthAppDiscovery:          config.OauthAppDiscovery,
+		APIRateLimits:              config.ApiRateLimits,
+		WatchCallbackURL:           config.WatchCallbackUrl,
+		WatchListenAddress:         config.WatchListenAddress,
+		WatchChannelToken:          config.WatchChannelToken,
+		BaseURL:                    config.BaseUrl,
 	})
 	if err != nil {
 		return nil, nil, err
@@ -202,12 +369,53 @@ func New(ctx context.Context, config *cfg.GoogleWorkspace, opts *cli.ConnectorOp
 
 // NewConnector creates a new Google Workspace connector instance (internal constructor).
 func NewConnector(ctx context.Context, config Config) (*GoogleWorkspace, error) {
+	var credentials delegatedCredentials = serviceAccountKeyCredentials(config.Credentials)
+	if len(config.Credentials) == 0 && config.ServiceAccountEmail != "" {
+		credentials = newSignJWTCredentials(config.ServiceAccountEmail, config.ExternalAccountCredentials)
+	}
+	filters, err := newSyncFilters(config)
+	if err != nil {
+		return nil, err
+	}
+	options, err := newSyncOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	rateLimits, err := parseAPIRateLimits(config.APIRateLimits)
+	if err != nil {
+		return nil, err
+	}
 	rv := &GoogleWorkspace{
 		customerID:         config.CustomerID,
 		administratorEmail: config.AdministratorEmail,
-		credentials:        config.Credentials,
+		credentials:        credentials,
+		baseURL:            config.BaseURL,
+		rateLimiters:       newRateLimiterRegistry(rateLimitMetrics, rateLimits),
 		serviceCache:       map[string]any{},
 		domain:             config.Domain,
+		domains:            config.Domains,
+		allDomains:         config.AllDomains,
+		filters:            filters,
+		options:            options,
+		watchCallbackURL:   config.WatchCallbackURL,
+		watchListenAddress: config.WatchListenAddress,
+		watchChannelToken:  config.WatchChannelToken,
+	}
+	for _, t := range config.AdditionalTenants {
+		// Additional tenants are synced with the same filters and options.
+		t.IncludeOrgUnits, t.ExcludeOrgUnits, t.UserQuery = config.IncludeOrgUnits, config.ExcludeOrgUnits, config.UserQuery
+		t.ExcludeSuspendedUsers, t.ExcludeArchivedUsers = config.ExcludeSuspendedUsers, config.ExcludeArchivedUsers
+		t.GroupEmailPatterns, t.ExcludeGroupEmailPatterns = config.GroupEmailPatterns, config.ExcludeGroupEmailPatterns
+		t.SyncGroupSettings, t.OAuthAppDiscovery = config.SyncGroupSettings, config.OAuthAppDiscovery
+		t.APIRateLimits = config.APIRateLimits
+		t.BaseURL = config.BaseURL
+		tenant, err := NewConnector(ctx, t)
+		if err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to configure tenant %s: %w", t.CustomerID, err)
+		}
+		// Tenants spend from this connector's budgets.
+		tenant.rateLimiters = rv.rateLimiters
+		rv.additionalTenants = append(rv.additionalTenants, tenant)
 	}
 	return rv, nil
 }
@@ -308,13 +516,14 @@ func (c *GoogleWorkspace) Validate(ctx context.Context) (annotations.Annotations
 		domains = append(domains, d.DomainName)
 	}
 
-	if c.domain != "" {
-		for _, d := range domains {
-			if strings.EqualFold(c.domain, d) {
-				return nil, nil
-			}
+	if err := c.validateDomains(domains); err != nil {
+		return nil, err
+	}
+
+	for _, t := range c.additionalTenants {
+		if _, err := t.Validate(ctx); err != nil {
+			return nil, fmt.Errorf("google-workspace: tenant %s: %w", t.customerID, err)
 		}
-		return nil, fmt.Errorf("domain '%s' is not a valid domain for customer '%s'", c.domain, c.customerID)
 	}
 
 	return nil, nil
@@ -379,6 +588,11 @@ func (c *GoogleWorkspace) newClient(ctx context.Context) (*gwclient.GoogleWorksp
 		return nil, err
 	}
 
+	client.OrgUnitService, err = c.getDirectoryService(ctx, directoryAdmin.AdminDirectoryOrgunitReadonlyScope)
+	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryOrgunitReadonlyScope, "org unit resource synchronization", &skippedServices); err != nil {
+		return nil, err
+	}
+
 	client.UserService, err = c.getDirectoryService(ctx, directoryAdmin.AdminDirectoryUserReadonlyScope)
 	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryUserReadonlyScope, "user resource synchronization", &skippedServices); err != nil {
 		return nil, err
@@ -387,6 +601,7 @@ func (c *GoogleWorkspace) newClient(ctx context.Context) (*gwclient.GoogleWorksp
 	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryUserScope, "user resource provisioning", &skippedServices); err != nil {
 		return nil, err
 	}
+	client.UserBatcher = c.getDirectoryBatcher(ctx, client.UserService, directoryAdmin.AdminDirectoryUserReadonlyScope)
 	client.UserSecurityService, err = c.getDirectoryService(ctx, directoryAdmin.AdminDirectoryUserSecurityScope)
 	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryUserSecurityScope, "user security operations", &skippedServices); err != nil {
 		return nil, err
@@ -396,14 +611,17 @@ func (c *GoogleWorkspace) newClient(ctx context.Context) (*gwclient.GoogleWorksp
 	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryGroupReadonlyScope, "group resource synchronization", &skippedServices); err != nil {
 		return nil, err
 	}
+	client.GroupBatcher = c.getDirectoryBatcher(ctx, client.GroupService, directoryAdmin.AdminDirectoryGroupReadonlyScope)
 	client.GroupMemberService, err = c.getDirectoryService(ctx, directoryAdmin.AdminDirectoryGroupMemberReadonlyScope)
 	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryGroupMemberReadonlyScope, "group membership synchronization", &skippedServices); err != nil {
 		return nil, err
 	}
+	client.GroupMemberBatcher = c.getDirectoryBatcher(ctx, client.GroupMemberService, directoryAdmin.AdminDirectoryGroupMemberReadonlyScope)
 	client.GroupMemberProvisioningService, err = c.getDirectoryService(ctx, directoryAdmin.AdminDirectoryGroupMemberScope)
 	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryGroupMemberScope, "group membership provisioning", &skippedServices); err != nil {
 		return nil, err
 	}
+	client.GroupMemberProvisioningBatcher = c.getDirectoryBatcher(ctx, client.GroupMemberProvisioningService, directoryAdmin.AdminDirectoryGroupMemberScope)
 	client.GroupProvisioningService, err = c.getDirectoryService(ctx, directoryAdmin.AdminDirectoryGroupScope)
 	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryGroupScope, "group resource provisioning", &skippedServices); err != nil {
 		return nil, err
@@ -428,6 +646,51 @@ func (c *GoogleWorkspace) newClient(ctx context.Context) (*gwclient.GoogleWorksp
 		return nil, err
 	}
 
+	client.LicensingService, err = getService(ctx, c, licensing.AppsLicensingScope, licensing.NewService)
+	if err := recordServiceInit(l, err, licensing.AppsLicensingScope, "license resource synchronization", &skippedServices); err != nil {
+		return nil, err
+	}
+
+	client.MobileDeviceService, err = c.getDirectoryService(ctx, directoryAdmin.AdminDirectoryDeviceMobileReadonlyScope)
+	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryDeviceMobileReadonlyScope, "mobile device resource synchronization", &skippedServices); err != nil {
+		return nil, err
+	}
+	client.MobileDeviceActionService, err = c.getDirectoryService(ctx, directoryAdmin.AdminDirectoryDeviceMobileActionScope)
+	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryDeviceMobileActionScope, "mobile device actions", &skippedServices); err != nil {
+		return nil, err
+	}
+	client.ChromeDeviceService, err = c.getDirectoryService(ctx, directoryAdmin.AdminDirectoryDeviceChromeosReadonlyScope)
+	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryDeviceChromeosReadonlyScope, "chrome device resource synchronization", &skippedServices); err != nil {
+		return nil, err
+	}
+	client.ChromeDeviceProvisioningService, err = c.getDirectoryService(ctx, directoryAdmin.AdminDirectoryDeviceChromeosScope)
+	if err := recordServiceInit(l, err, directoryAdmin.AdminDirectoryDeviceChromeosScope, "chrome device actions", &skippedServices); err != nil {
+		return nil, err
+	}
+
+	client.DriveService, err = getService(ctx, c, drive.DriveReadonlyScope, drive.NewService)
+	if err := recordServiceInit(l, err, drive.DriveReadonlyScope, "shared drive resource synchronization", &skippedServices); err != nil {
+		return nil, err
+	}
+	client.DriveProvisioningService, err = getService(ctx, c, drive.DriveScope, drive.NewService)
+	if err := recordServiceInit(l, err, drive.DriveScope, "shared drive provisioning", &skippedServices); err != nil {
+		return nil, err
+	}
+
+	client.GmailService, err = c.getGmailServiceFactory(ctx, gmail.GmailSettingsBasicScope)
+	if err := recordServiceInit(l, err, gmail.GmailSettingsBasicScope, "mailbox resource synchronization", &skippedServices); err != nil {
+		return nil, err
+	}
+	client.GmailProvisioningService, err = c.getGmailServiceFactory(ctx, gmail.GmailSettingsSharingScope)
+	if err := recordServiceInit(l, err, gmail.GmailSettingsSharingScope, "mailbox delegate provisioning", &skippedServices); err != nil {
+		return nil, err
+	}
+
+	client.AlertCenterService, err = getService(ctx, c, alertcenter.AppsAlertsScope, alertcenter.NewService)
+	if err := recordServiceInit(l, err, alertcenter.AppsAlertsScope, "alert center event feed", &skippedServices); err != nil {
+		return nil, err
+	}
+
 	// One categorized Debug-level summary, in addition to the per-service
 	// Debug log above: a missing resource-type syncer (a whole resource type
 	// absent from sync) is a different operator problem than a missing
@@ -462,6 +725,7 @@ var runtimeOAuthScopes = []string{
 	directoryAdmin.AdminDirectoryDomainReadonlyScope,
 	directoryAdmin.AdminDirectoryRolemanagementReadonlyScope,
 	directoryAdmin.AdminDirectoryRolemanagementScope,
+	directoryAdmin.AdminDirectoryOrgunitReadonlyScope,
 	directoryAdmin.AdminDirectoryUserReadonlyScope,
 	directoryAdmin.AdminDirectoryUserScope,
 	directoryAdmin.AdminDirectoryUserSecurityScope,
@@ -473,6 +737,16 @@ var runtimeOAuthScopes = []string{
 	datatransferAdmin.AdminDatatransferScope,
 	reportsAdmin.AdminReportsAuditReadonlyScope,
 	cloudidentity.CloudIdentityInboundssoReadonlyScope,
+	licensing.AppsLicensingScope,
+	directoryAdmin.AdminDirectoryDeviceMobileReadonlyScope,
+	directoryAdmin.AdminDirectoryDeviceMobileActionScope,
+	directoryAdmin.AdminDirectoryDeviceChromeosReadonlyScope,
+	directoryAdmin.AdminDirectoryDeviceChromeosScope,
+	drive.DriveReadonlyScope,
+	drive.DriveScope,
+	gmail.GmailSettingsBasicScope,
+	gmail.GmailSettingsSharingScope,
+	alertcenter.AppsAlertsScope,
 }
 
 // subsumedByBroaderScope maps a runtime readonly scope to the broader write
@@ -483,18 +757,66 @@ var subsumedByBroaderScope = map[string]string{
 	directoryAdmin.AdminDirectoryGroupReadonlyScope:          "admin.directory.group",
 	directoryAdmin.AdminDirectoryGroupMemberReadonlyScope:    "admin.directory.group.member",
 	directoryAdmin.AdminDirectoryRolemanagementReadonlyScope: "admin.directory.rolemanagement",
+	directoryAdmin.AdminDirectoryDeviceChromeosReadonlyScope: "admin.directory.device.chromeos",
+	drive.DriveReadonlyScope:                                 "drive",
 }
 
 // syncGatingPurposes are recordServiceInit purposes whose service gates
-// whether a resource type is registered at all, vs. every other purpose
-// (provisioning/actions/enrichment), which fails per-invocation instead.
+// whether a resource type or event feed is registered at all, vs. every other
+// purpose (provisioning/actions/enrichment), which fails per-invocation instead.
 var syncGatingPurposes = map[string]bool{
-	"role resource synchronization":    true,
-	"user resource synchronization":    true,
-	"group resource synchronization":   true,
-	"group membership synchronization": true,
-	"user security operations":         true,
-	"report service":                   true,
+	"role resource synchronization":          true,
+	"org unit resource synchronization":      true,
+	"user resource synchronization":          true,
+	"group resource synchronization":         true,
+	"group membership synchronization":       true,
+	"user security operations":               true,
+	"report service":                         true,
+	"license resource synchronization":       true,
+	"mobile device resource synchronization": true,
+	"chrome device resource synchronization": true,
+	"shared drive resource synchronization":  true,
+	"mailbox resource synchronization":       true,
+	"alert center event feed":                true,
+}
+
+// resourceSyncerSpecs lists every resource type's syncer in registration
+// order, with the services it needs and how it is partitioned across tenants
+// and domains. Licenses and applications are keyed by IDs shared by every
+// customer (SKUs, OAuth client IDs), so they are synced from the primary tenant only.
+var resourceSyncerSpecs = []resourceSyncerSpec{
+	newResourceSyncerSpec(partitionByTenant, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.RoleService != nil
+	}, func(client *gwclient.GoogleWorkspaceClient, customerID, _ string) *roleResourceType {
+		return roleBuilder(client, customerID)
+	}),
+	newResourceSyncerSpec(partitionByDomain, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.UserService != nil
+	}, userBuilder),
+	newResourceSyncerSpec(partitionByDomain, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.GroupService != nil && client.GroupMemberService != nil
+	}, groupBuilder),
+	newResourceSyncerSpec(partitionByTenant, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.OrgUnitService != nil && client.UserService != nil
+	}, orgUnitBuilder),
+	newResourceSyncerSpec(partitionPrimaryTenant, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.LicensingService != nil && client.UserService != nil
+	}, licenseBuilder),
+	newResourceSyncerSpec(partitionByTenant, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.MobileDeviceService != nil && client.UserService != nil
+	}, mobileDeviceBuilder),
+	newResourceSyncerSpec(partitionByTenant, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.ChromeDeviceService != nil && client.UserService != nil
+	}, chromeDeviceBuilder),
+	newResourceSyncerSpec(partitionByTenant, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.DriveService != nil && client.UserService != nil
+	}, sharedDriveBuilder),
+	newResourceSyncerSpec(partitionByDomain, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.GmailService != nil && client.UserService != nil
+	}, mailboxBuilder),
+	newResourceSyncerSpec(partitionPrimaryTenant, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.UserService != nil && client.UserSecurityService != nil && client.ReportService != nil
+	}, newApplicationResource),
 }
 
 func (c *GoogleWorkspace) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncerV2 {
@@ -502,24 +824,17 @@ func (c *GoogleWorkspace) ResourceSyncers(ctx context.Context) []connectorbuilde
 	if err != nil {
 		return failedResourceSyncers(err)
 	}
-	rs := []connectorbuilder.ResourceSyncerV2{}
-
-	if client.RoleService != nil {
-		rs = append(rs, roleBuilder(client, c.customerID))
-	}
-
-	if client.UserService != nil {
-		rs = append(rs, userBuilder(client, c.customerID, c.domain))
-	}
-
-	if client.GroupService != nil && client.GroupMemberService != nil {
-		rs = append(rs, groupBuilder(client, c.customerID, c.domain))
+	tenants, err := c.syncTenants(ctx, client)
+	if err != nil {
+		return failedResourceSyncers(err)
 	}
 
-	if client.UserService != nil && client.UserSecurityService != nil && client.ReportService != nil {
-		rs = append(rs, newApplicationResource(client, c.customerID, c.domain))
+	rs := []connectorbuilder.ResourceSyncerV2{}
+	for _, spec := range resourceSyncerSpecs {
+		if spec.available(client) {
+			rs = append(rs, spec.syncer(tenants, c.partitioned()))
+		}
 	}
-
 	return rs
 }
 
@@ -533,6 +848,12 @@ func failedResourceSyncers(err error) []connectorbuilder.ResourceSyncerV2 {
 		&failedResourceSyncer{resourceType: resourceTypeRole, err: err},
 		&failedResourceSyncer{resourceType: resourceTypeUser, err: err},
 		&failedResourceSyncer{resourceType: resourceTypeGroup, err: err},
+		&failedResourceSyncer{resourceType: resourceTypeOrgUnit, err: err},
+		&failedResourceSyncer{resourceType: resourceTypeLicense, err: err},
+		&failedResourceSyncer{resourceType: resourceTypeMobileDevice, err: err},
+		&failedResourceSyncer{resourceType: resourceTypeChromeDevice, err: err},
+		&failedResourceSyncer{resourceType: resourceTypeSharedDrive, err: err},
+		&failedResourceSyncer{resourceType: resourceTypeMailbox, err: err},
 		&failedResourceSyncer{resourceType: resourceTypeEnterpriseApplication, err: err},
 	}
 }
@@ -553,10 +874,19 @@ func (f *failedResourceSyncer) Grants(_ context.Context, _ *v2.Resource, _ rs.Sy
 	return nil, nil, f.err
 }
 
+// serviceCacheKey keys T's service for scope in serviceCache. A Directory
+// batcher shares its scope with a Directory service, so it has its own key.
+func serviceCacheKey[T any](scope string) string {
+	if _, ok := any((*T)(nil)).(*gwclient.DirectoryBatcher); ok {
+		return "batch:" + scope
+	}
+	return scope
+}
+
 func getFromCache[T any](c *GoogleWorkspace, scope string) (*T, error) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
-	service, ok := c.serviceCache[scope]
+	service, ok := c.serviceCache[serviceCacheKey[T](scope)]
 	if ok {
 		if service, ok := service.(*T); ok {
 			return service, nil
@@ -591,7 +921,7 @@ func getService[T any](ctx context.Context, c *GoogleWorkspace, scope string, ne
 		}
 	}
 
-	service, err = newGWSAdminServiceForScopes(ctx, c.credentials, c.administratorEmail, newService, scope)
+	service, err = newGWSAdminServiceForScopes(ctx, c.credentials, c.baseURL, c.rateLimiters, c.administratorEmail, newService, scope)
 	if err != nil {
 		var ae *GoogleWorkspaceOAuthUnauthorizedError
 		if errors.As(err, &ae) {
@@ -610,7 +940,7 @@ func getService[T any](ctx context.Context, c *GoogleWorkspace, scope string, ne
 	}
 
 	c.mtx.Lock()
-	c.serviceCache[scope] = service
+	c.serviceCache[serviceCacheKey[T](scope)] = service
 	c.mtx.Unlock()
 	return service, nil
 }
@@ -649,17 +979,60 @@ func (c *GoogleWorkspace) EventFeeds(ctx context.Context) []connectorbuilder.Eve
 
 	feeds := []connectorbuilder.EventFeed{
 		newUsageEventFeed(client, c.customerID, c.domain),
-		newAdminEventFeed(client),
+		c.getAdminEventFeed(client),
 	}
 
 	if client.ReportService != nil {
 		feeds = append(feeds, newSamlEventFeed(client, c.customerID, c.domain))
 		feeds = append(feeds, newGoogleLoginEventFeed(client, c.customerID, c.domain))
+		feeds = append(feeds, c.getDriveEventFeed(client))
+		feeds = append(feeds, newLoginRiskEventFeed(client, c.customerID, c.domain))
+	}
+
+	if client.AlertCenterService != nil && client.UserService != nil {
+		feeds = append(feeds, newAlertCenterEventFeed(client))
+	}
+
+	if c.watchCallbackURL != "" && client.UserService != nil {
+		feeds = append(feeds, newDirectoryWatchEventFeed(c.getDirectoryWatcher(client)))
 	}
 
 	return feeds
 }
 
+// getAdminEventFeed returns the connector's single admin event feed, so its
+// ID caches outlive each EventFeeds call and can resolve later deletions.
+func (c *GoogleWorkspace) getAdminEventFeed(client *gwclient.GoogleWorkspaceClient) *adminEventFeed {
+	c.adminFeedMtx.Lock()
+	defer c.adminFeedMtx.Unlock()
+	if c.adminFeed == nil || c.adminFeed.client != client {
+		c.adminFeed = newAdminEventFeed(client, c.customerID)
+	}
+	return c.adminFeed
+}
+
+// getDriveEventFeed returns the connector's single Drive audit event feed, so
+// the customer's domains are listed once rather than on every EventFeeds call.
+func (c *GoogleWorkspace) getDriveEventFeed(client *gwclient.GoogleWorkspaceClient) *driveEventFeed {
+	c.driveFeedMtx.Lock()
+	defer c.driveFeedMtx.Unlock()
+	if c.driveFeed == nil || c.driveFeed.client != client {
+		c.driveFeed = newDriveEventFeed(client, c.customerID, c.domain)
+	}
+	return c.driveFeed
+}
+
+// getDirectoryWatcher returns the connector's single directory watcher, so
+// repeated EventFeeds calls share one receiver and one set of channels.
+func (c *GoogleWorkspace) getDirectoryWatcher(client *gwclient.GoogleWorkspaceClient) *directoryWatcher {
+	c.watcherMtx.Lock()
+	defer c.watcherMtx.Unlock()
+	if c.watcher == nil {
+		c.watcher = newDirectoryWatcher(client, c.customerID, c.domain, c.watchCallbackURL, c.watchListenAddress, c.watchChannelToken)
+	}
+	return c.watcher
+}
+
 type failedEventFeed struct {
 	metadata *v2.EventFeedMetadata
 	err      error
@@ -668,9 +1041,13 @@ type failedEventFeed struct {
 func failedEventFeeds(err error) []connectorbuilder.EventFeed {
 	return []connectorbuilder.EventFeed{
 		&failedEventFeed{metadata: newUsageEventFeed(nil, "", "").EventFeedMetadata(context.Background()), err: err},
-		&failedEventFeed{metadata: newAdminEventFeed(nil).EventFeedMetadata(context.Background()), err: err},
+		&failedEventFeed{metadata: newAdminEventFeed(nil, "").EventFeedMetadata(context.Background()), err: err},
 		&failedEventFeed{metadata: newSamlEventFeed(nil, "", "").EventFeedMetadata(context.Background()), err: err},
 		&failedEventFeed{metadata: newGoogleLoginEventFeed(nil, "", "").EventFeedMetadata(context.Background()), err: err},
+		&failedEventFeed{metadata: newDriveEventFeed(nil, "", "").EventFeedMetadata(context.Background()), err: err},
+		&failedEventFeed{metadata: newLoginRiskEventFeed(nil, "", "").EventFeedMetadata(context.Background()), err: err},
+		&failedEventFeed{metadata: newAlertCenterEventFeed(nil).EventFeedMetadata(context.Background()), err: err},
+		&failedEventFeed{metadata: newDirectoryWatchEventFeed(nil).EventFeedMetadata(context.Background()), err: err},
 	}
 }
 
@@ -685,27 +1062,33 @@ func (f *failedEventFeed) ListEvents(_ context.Context, _ *timestamppb.Timestamp
 var _ connectorbuilder.GlobalActionProvider = (*GoogleWorkspace)(nil)
 
 func (c *GoogleWorkspace) GlobalActions(ctx context.Context, registry actions.ActionRegistry) error {
-	if err := registry.Register(ctx, updateUserStatusActionSchema, c.updateUserStatus); err != nil {
+	if err := registry.Register(ctx, withDryRun(updateUserStatusActionSchema), c.updateUserStatus); err != nil {
 		return fmt.Errorf("google-workspace: failed to register update_user_status action: %w", err)
 	}
-	if err := registry.Register(ctx, transferUserDriveFilesActionSchema, c.transferUserDriveFiles); err != nil {
+	if err := registry.Register(ctx, withDryRun(transferUserDriveFilesActionSchema), c.transferUserDriveFiles); err != nil {
 		return fmt.Errorf("google-workspace: failed to register transfer_user_drive_files action: %w", err)
 	}
-	if err := registry.Register(ctx, changeUserPrimaryEmailActionSchema, c.changeUserPrimaryEmail); err != nil {
+	if err := registry.Register(ctx, withDryRun(changeUserPrimaryEmailActionSchema), c.changeUserPrimaryEmail); err != nil {
 		return fmt.Errorf("google-workspace: failed to register change_user_primary_email action: %w", err)
 	}
-	if err := registry.Register(ctx, disableUserActionSchema, c.disableUserActionHandler); err != nil {
+	if err := registry.Register(ctx, withDryRun(disableUserActionSchema), c.disableUserActionHandler); err != nil {
 		return fmt.Errorf("google-workspace: failed to register disable_user action: %w", err)
 	}
-	if err := registry.Register(ctx, enableUserActionSchema, c.enableUserActionHandler); err != nil {
+	if err := registry.Register(ctx, withDryRun(enableUserActionSchema), c.enableUserActionHandler); err != nil {
 		return fmt.Errorf("google-workspace: failed to register enable_user action: %w", err)
 	}
-	if err := registry.Register(ctx, transferUserCalendarActionSchema, c.transferUserCalendar); err != nil {
+	if err := registry.Register(ctx, withDryRun(transferUserCalendarActionSchema), c.transferUserCalendar); err != nil {
 		return fmt.Errorf("google-workspace: failed to register transfer_user_calendar action: %w", err)
 	}
-	if err := registry.Register(ctx, updateUserGlobalActionSchema, c.updateUserActionHandler); err != nil {
+	if err := registry.Register(ctx, withDryRun(updateUserGlobalActionSchema), c.updateUserActionHandler); err != nil {
 		return fmt.Errorf("google-workspace: failed to register update_user action: %w", err)
 	}
+	if err := registry.Register(ctx, withDryRun(updateAlertActionSchema), c.updateAlertActionHandler); err != nil {
+		return fmt.Errorf("google-workspace: failed to register update_alert action: %w", err)
+	}
+	if err := registry.Register(ctx, withDryRun(offboardUserActionSchema), c.offboardUserActionHandler); err != nil {
+		return fmt.Errorf("google-workspace: failed to register offboard_user action: %w", err)
+	}
 
 	return nil
 }
@@ -731,6 +1114,12 @@ func (d *defaultCapabilitiesBuilder) ResourceSyncers(_ context.Context) []connec
 		roleBuilder(nil, ""),
 		userBuilder(nil, "", ""),
 		groupBuilder(nil, "", ""),
+		orgUnitBuilder(nil, "", ""),
+		licenseBuilder(nil, "", ""),
+		mobileDeviceBuilder(nil, "", ""),
+		chromeDeviceBuilder(nil, "", ""),
+		sharedDriveBuilder(nil, "", ""),
+		mailboxBuilder(nil, "", ""),
 		newApplicationResource(nil, "", ""),
 	}
 }
@@ -738,8 +1127,12 @@ func (d *defaultCapabilitiesBuilder) ResourceSyncers(_ context.Context) []connec
 func (d *defaultCapabilitiesBuilder) EventFeeds(_ context.Context) []connectorbuilder.EventFeed {
 	return []connectorbuilder.EventFeed{
 		newUsageEventFeed(nil, "", ""),
-		newAdminEventFeed(nil),
+		newAdminEventFeed(nil, ""),
 		newSamlEventFeed(nil, "", ""),
 		newGoogleLoginEventFeed(nil, "", ""),
+		newDriveEventFeed(nil, "", ""),
+		newLoginRiskEventFeed(nil, "", ""),
+		newAlertCenterEventFeed(nil),
+		newDirectoryWatchEventFeed(nil),
 	}
 }
@@ -33,7 +33,7 @@ func testPrivateKey(t *testing.T) string {
 	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
 }
 
-func testCredentials(t *testing.T, tokenURL string) []byte {
+func testCredentials(t *testing.T, tokenURL string) serviceAccountKeyCredentials {
 	t.Helper()
 
 	creds := map[string]string{
@@ -72,7 +72,7 @@ func TestResourceSyncersReturnFailingSyncersForNonAuthorizationInitError(t *test
 	}
 
 	syncers := c.ResourceSyncers(context.Background())
-	if len(syncers) != 4 {
+	if len(syncers) != 10 {
 		t.Fatalf("expected failing syncers for all resource types, got %d", len(syncers))
 	}
 
@@ -0,0 +1,214 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	iamcredentials "google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+// delegatedCredentials mints OAuth tokens that act as a Workspace user
+// (the domain-wide delegation subject) with the given scopes.
+type delegatedCredentials interface {
+	tokenSource(ctx context.Context, subject string, scopes ...string) (oauth2.TokenSource, error)
+}
+
+// serviceAccountKeyCredentials is a service account JSON key. The delegation
+// JWT is signed locally with its private key.
+type serviceAccountKeyCredentials []byte
+
+func (k serviceAccountKeyCredentials) tokenSource(ctx context.Context, subject string, scopes ...string) (oauth2.TokenSource, error) {
+	config, err := google.JWTConfigFromJSON(k, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	config.Subject = subject
+	// Services are cached, so their tokens refresh long after the request
+	// that built them is done.
+	return config.TokenSource(context.WithoutCancel(ctx)), nil
+}
+
+const (
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+
+	// jwtBearerGrantType is the RFC 7523 grant that exchanges a signed
+	// delegation JWT for an access token.
+	jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+	// delegationJWTLifetime is the longest assertion lifetime Google accepts.
+	delegationJWTLifetime = time.Hour
+)
+
+// signJWTCredentials authenticates without a service account key. Source
+// credentials - a workload identity federation (external_account) config, or
+// ambient Application Default Credentials - call the IAM Credentials signJwt
+// endpoint to have Google sign the delegation JWT as serviceAccountEmail,
+// which must be set up for domain-wide delegation and grant the source
+// identity roles/iam.serviceAccountTokenCreator.
+type signJWTCredentials struct {
+	serviceAccountEmail string
+	// externalAccountJSON is an external_account config; nil uses ADC.
+	externalAccountJSON []byte
+
+	// iamEndpoint and tokenURL override Google's IAM Credentials and OAuth
+	// token endpoints, for tests against a local fake.
+	iamEndpoint string
+	tokenURL    string
+
+	mtx    sync.Mutex
+	signer *iamcredentials.Service
+}
+
+func newSignJWTCredentials(serviceAccountEmail string, externalAccountJSON []byte) *signJWTCredentials {
+	return &signJWTCredentials{
+		serviceAccountEmail: serviceAccountEmail,
+		externalAccountJSON: externalAccountJSON,
+		tokenURL:            googleTokenURL,
+	}
+}
+
+// iamService returns the IAM Credentials service, authenticated with the
+// source credentials on first use. It outlives the calling request, so its
+// token refreshes run on a context detached from ctx's cancellation.
+func (s *signJWTCredentials) iamService(ctx context.Context) (*iamcredentials.Service, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.signer != nil {
+		return s.signer, nil
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	var creds *google.Credentials
+	var err error
+	if len(s.externalAccountJSON) > 0 {
+		creds, err = google.CredentialsFromJSONWithType(ctx, s.externalAccountJSON, google.ExternalAccount, iamcredentials.CloudPlatformScope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load external account credentials: %w", err)
+		}
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, iamcredentials.CloudPlatformScope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find application default credentials: %w", err)
+		}
+	}
+
+	opts := []option.ClientOption{option.WithTokenSource(creds.TokenSource)}
+	if s.iamEndpoint != "" {
+		opts = append(opts, option.WithEndpoint(s.iamEndpoint))
+	}
+	signer, err := iamcredentials.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IAM credentials service: %w", err)
+	}
+	s.signer = signer
+	return signer, nil
+}
+
+func (s *signJWTCredentials) tokenSource(ctx context.Context, subject string, scopes ...string) (oauth2.TokenSource, error) {
+	signer, err := s.iamService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, _ := ctx.Value(oauth2.HTTPClient).(*http.Client)
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &signJWTTokenSource{
+		ctx:        context.WithoutCancel(ctx),
+		creds:      s,
+		signer:     signer,
+		httpClient: httpClient,
+		subject:    subject,
+		scopes:     scopes,
+	}, nil
+}
+
+// signJWTTokenSource mints one delegated token per Token call; callers wrap
+// it in oauth2.ReuseTokenSource.
+type signJWTTokenSource struct {
+	// ctx is detached from the cancellation of the request that built the
+	// token source: its service is cached and refreshes its token for as long
+	// as the connector runs.
+	ctx        context.Context
+	creds      *signJWTCredentials
+	signer     *iamcredentials.Service
+	httpClient *http.Client
+	subject    string
+	scopes     []string
+}
+
+func (ts *signJWTTokenSource) Token() (*oauth2.Token, error) {
+	now := time.Now()
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   ts.creds.serviceAccountEmail,
+		"sub":   ts.subject,
+		"scope": strings.Join(ts.scopes, " "),
+		"aud":   ts.creds.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(delegationJWTLifetime).Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode delegation JWT claims: %w", err)
+	}
+
+	name := "projects/-/serviceAccounts/" + ts.creds.serviceAccountEmail
+	signed, err := ts.signer.Projects.ServiceAccounts.SignJwt(name, &iamcredentials.SignJwtRequest{Payload: string(claims)}).Context(ts.ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign delegation JWT as %s: %w", ts.creds.serviceAccountEmail, err)
+	}
+	return ts.exchange(signed.SignedJwt)
+}
+
+// exchange trades a signed delegation JWT for an access token. Failures are
+// returned as *oauth2.RetrieveError, like the key-based JWT flow, so that
+// isScopeUnauthorized classifies them the same way.
+func (ts *signJWTTokenSource) exchange(assertion string) (*oauth2.Token, error) {
+	form := url.Values{
+		"grant_type": {jwtBearerGrantType},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ts.ctx, http.MethodPost, ts.creds.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := ts.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: cannot fetch token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: cannot fetch token: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &oauth2.RetrieveError{Response: resp, Body: body}
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return nil, fmt.Errorf("oauth2: cannot parse token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2: server response missing access_token")
+	}
+	token := &oauth2.Token{AccessToken: tokenResp.AccessToken, TokenType: tokenResp.TokenType}
+	if tokenResp.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,146 @@
+package connector
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	iamcredentials "google.golang.org/api/iamcredentials/v1"
+)
+
+const testDelegatedServiceAccount = "dwd@example.iam.gserviceaccount.com"
+
+// newKeylessTestServer fakes the three endpoints of the keyless flow: the STS
+// token exchange, IAM Credentials signJwt, and the OAuth token endpoint.
+// Delegated token requests are rejected with tokenStatus when it is non-zero.
+func newKeylessTestServer(t *testing.T, tokenStatus int) (*httptest.Server, *map[string]interface{}) {
+	t.Helper()
+	var claims map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sts", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.PostForm.Get("subject_token") != "workload-token" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"federated-token","issued_token_type":"urn:ietf:params:oauth:token-type:access_token","token_type":"Bearer","expires_in":3600}`))
+	})
+	mux.HandleFunc("/v1/projects/-/serviceAccounts/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer federated-token" {
+			http.Error(w, `{"error":{"code":401,"message":"unauthenticated"}}`, http.StatusUnauthorized)
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, testDelegatedServiceAccount+":signJwt") {
+			http.Error(w, `{"error":{"code":404,"message":"unknown service account"}}`, http.StatusNotFound)
+			return
+		}
+		var req iamcredentials.SignJwtRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		signed := "header." + base64.RawURLEncoding.EncodeToString([]byte(req.Payload)) + ".signature"
+		_ = json.NewEncoder(w).Encode(&iamcredentials.SignJwtResponse{KeyId: "key", SignedJwt: signed})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		if r.PostForm.Get("grant_type") != jwtBearerGrantType || len(parts) != 3 {
+			http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+			return
+		}
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		_ = json.Unmarshal(payload, &claims)
+		w.Header().Set("Content-Type", "application/json")
+		if tokenStatus != 0 {
+			w.WriteHeader(tokenStatus)
+			_, _ = w.Write([]byte(`{"error":"access_denied","error_description":"Requested client not authorized."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"delegated-token","token_type":"Bearer","expires_in":3600}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &claims
+}
+
+func testExternalAccountCredentials(t *testing.T, stsURL string) []byte {
+	t.Helper()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("workload-token"), 0o600))
+	rv, err := json.Marshal(map[string]interface{}{
+		"type":               "external_account",
+		"audience":           "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/provider",
+		"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+		"token_url":          stsURL,
+		"credential_source":  map[string]string{"file": tokenFile},
+	})
+	require.NoError(t, err)
+	return rv
+}
+
+func newTestSignJWTCredentials(t *testing.T, server *httptest.Server) *signJWTCredentials {
+	t.Helper()
+	creds := newSignJWTCredentials(testDelegatedServiceAccount, testExternalAccountCredentials(t, server.URL+"/sts"))
+	creds.iamEndpoint = server.URL + "/"
+	creds.tokenURL = server.URL + "/token"
+	return creds
+}
+
+func TestSignJWTCredentials_MintsDelegatedToken(t *testing.T) {
+	server, claims := newKeylessTestServer(t, 0)
+	creds := newTestSignJWTCredentials(t, server)
+
+	ts, err := creds.tokenSource(context.Background(), "admin@example.com", directoryAdmin.AdminDirectoryUserReadonlyScope, directoryAdmin.AdminDirectoryGroupReadonlyScope)
+	require.NoError(t, err)
+	token, err := ts.Token()
+	require.NoError(t, err)
+	require.Equal(t, "delegated-token", token.AccessToken)
+	require.False(t, token.Expiry.IsZero())
+
+	require.Equal(t, testDelegatedServiceAccount, (*claims)["iss"])
+	require.Equal(t, "admin@example.com", (*claims)["sub"])
+	require.Equal(t, server.URL+"/token", (*claims)["aud"])
+	require.Equal(t, directoryAdmin.AdminDirectoryUserReadonlyScope+" "+directoryAdmin.AdminDirectoryGroupReadonlyScope, (*claims)["scope"])
+}
+
+// TestSignJWTCredentials_RefreshesAfterBuildContextEnds checks that a cached
+// service keeps refreshing its token once the request that built it is done.
+func TestSignJWTCredentials_RefreshesAfterBuildContextEnds(t *testing.T) {
+	server, _ := newKeylessTestServer(t, 0)
+	creds := newTestSignJWTCredentials(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ts, err := creds.tokenSource(ctx, "admin@example.com", directoryAdmin.AdminDirectoryUserReadonlyScope)
+	require.NoError(t, err)
+	_, err = ts.Token()
+	require.NoError(t, err)
+	cancel()
+
+	// An expired token makes ReuseTokenSource mint a new one.
+	reuse := oauth2.ReuseTokenSource(&oauth2.Token{AccessToken: "expired", Expiry: time.Now().Add(-time.Minute)}, ts)
+	token, err := reuse.Token()
+	require.NoError(t, err)
+	require.Equal(t, "delegated-token", token.AccessToken)
+}
+
+func TestSignJWTCredentials_UnauthorizedScope(t *testing.T) {
+	server, _ := newKeylessTestServer(t, http.StatusForbidden)
+
+	_, err := newGWSAdminServiceForScopes(context.Background(), newTestSignJWTCredentials(t, server), "", nil, "admin@example.com",
+		directoryAdmin.NewService, directoryAdmin.AdminDirectoryRolemanagementReadonlyScope)
+	var unauthorized *GoogleWorkspaceOAuthUnauthorizedError
+	require.True(t, errors.As(err, &unauthorized), "expected unauthorized error, got %v", err)
+}
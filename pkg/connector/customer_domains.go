@@ -0,0 +1,70 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/conductorone/baton-sdk/pkg/session"
+	"github.com/conductorone/baton-sdk/pkg/types/sessions"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+// customerDomainsNamespace holds the lower-cased domains and domain aliases
+// of a customer, keyed by customer ID, listed once per sync.
+var customerDomainsNamespace = sessions.WithPrefix("customer_domains")
+
+// customerDomains returns the customer's verified domains and their aliases,
+// for telling the customer's own principals apart from external ones. Without
+// the domain service it falls back to domain, when one is configured.
+func customerDomains(ctx context.Context, ss sessions.SessionStore, client *gwclient.GoogleWorkspaceClient, customerID, domain string) ([]string, error) {
+	if client.DomainService == nil {
+		if domain == "" {
+			return nil, nil
+		}
+		return []string{strings.ToLower(domain)}, nil
+	}
+	if ss != nil {
+		cached, ok, err := session.GetJSON[[]string](ctx, ss, customerID, customerDomainsNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to read customer domains from session: %w", err)
+		}
+		if ok {
+			return cached, nil
+		}
+	}
+
+	resp, err := client.ListDomains(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace: failed to list domains of customer %s: %w", customerID, err)
+	}
+	domains := []string{}
+	for _, d := range resp.Domains {
+		if !d.Verified {
+			continue
+		}
+		domains = append(domains, strings.ToLower(d.DomainName))
+		for _, alias := range d.DomainAliases {
+			if alias.Verified {
+				domains = append(domains, strings.ToLower(alias.DomainAliasName))
+			}
+		}
+	}
+	if ss != nil {
+		if err := session.SetJSON(ctx, ss, customerID, domains, customerDomainsNamespace); err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to store customer domains in session: %w", err)
+		}
+	}
+	return domains, nil
+}
+
+// emailInDomains reports whether email's domain is one of domains.
+func emailInDomains(email string, domains []string) bool {
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return false
+	}
+	return slices.ContainsFunc(domains, func(d string) bool { return strings.EqualFold(d, domain) })
+}
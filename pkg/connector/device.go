@@ -0,0 +1,122 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const (
+	deviceOwnerEntitlement = "owner"
+
+	// profileKeyOwnerEmails holds the emails of the users a device belongs
+	// to; device Grants resolve them to user IDs.
+	profileKeyOwnerEmails = "owner_emails"
+)
+
+// deviceOwnerEntitlements returns the read-only "owner" entitlement shared by
+// the mobile and Chrome OS device resource types.
+func deviceOwnerEntitlements(resource *v2.Resource) []*v2.Entitlement {
+	owner := sdkEntitlement.NewAssignmentEntitlement(resource, deviceOwnerEntitlement, sdkEntitlement.WithGrantableTo(resourceTypeUser))
+	owner.Description = fmt.Sprintf("Owns the %s device in Google Workspace", resource.DisplayName)
+	owner.DisplayName = fmt.Sprintf("%s Owner", resource.DisplayName)
+	return []*v2.Entitlement{owner}
+}
+
+// deviceOwnerEmails returns the lower-cased owner emails stored on a device
+// resource's profile.
+func deviceOwnerEmails(resource *v2.Resource) []string {
+	profile := rs.GetProfile(resource)
+	if profile == nil {
+		return nil
+	}
+	var rv []string
+	for _, v := range profile.GetFields()[profileKeyOwnerEmails].GetListValue().GetValues() {
+		if email := strings.TrimSpace(v.GetStringValue()); email != "" {
+			rv = append(rv, strings.ToLower(email))
+		}
+	}
+	return rv
+}
+
+// deviceOwnerGrants grants "owner" on a device to each of its owners. Devices
+// name their owners by email, so unless an earlier call of this sync built it,
+// the user directory is first walked (one page per call) into the session's
+// user email index. Devices without owners never touch the index.
+func deviceOwnerGrants(ctx context.Context, emailIndex userEmailIndex, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	emails := deviceOwnerEmails(resource)
+	if len(emails) == 0 {
+		return nil, nil, nil
+	}
+
+	bag := &pagination.Bag{}
+	err := bag.Unmarshal(attrs.PageToken.Token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal pagination token in device Grants: %w", err)
+	}
+	if err := emailIndex.begin(ctx, attrs.Session, bag, resource); err != nil {
+		return nil, nil, err
+	}
+
+	if bag.ResourceTypeID() == resourceTypeUser.Id {
+		nextPage, err := emailIndex.indexPage(ctx, attrs.Session, bag)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+	}
+
+	userIDs, leftOut, err := emailIndex.resolve(ctx, attrs.Session, emails)
+	if err != nil {
+		return nil, nil, err
+	}
+	l := ctxzap.Extract(ctx)
+	var rv []*v2.Grant
+	for _, email := range emails {
+		userID, ok := userIDs[email]
+		if !ok && leftOut[email] {
+			l.Debug("google-workspace: device owned by a user left out by the sync filters or synced domains, skipping",
+				zap.String("resource_type", resource.Id.ResourceType),
+				zap.String("device", resource.Id.Resource),
+				zap.String("email", email))
+			continue
+		}
+		if !ok {
+			l.Debug("google-workspace: device owned by a user outside the directory, skipping",
+				zap.String("resource_type", resource.Id.ResourceType),
+				zap.String("device", resource.Id.Resource),
+				zap.String("email", email))
+			continue
+		}
+		principalID, err := rs.NewResourceID(resourceTypeUser, userID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create user resource ID in device Grants: %w", err)
+		}
+		rv = append(rv, sdkGrant.NewGrant(resource, deviceOwnerEntitlement, principalID))
+	}
+
+	nextPage, err := bag.NextToken("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate next page token in device Grants: %w", err)
+	}
+	return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+}
+
+// ownerEmailsValue converts emails to a profile list value.
+func ownerEmailsValue(emails []string) []interface{} {
+	rv := make([]interface{}, 0, len(emails))
+	for _, e := range emails {
+		if e != "" {
+			rv = append(rv, e)
+		}
+	}
+	return rv
+}
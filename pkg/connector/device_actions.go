@@ -0,0 +1,218 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+var (
+	_ connectorbuilder.ResourceActionProvider = (*mobileDeviceResourceType)(nil)
+	_ connectorbuilder.ResourceActionProvider = (*chromeDeviceResourceType)(nil)
+)
+
+const (
+	argDeviceID          = "device_id"
+	argDeprovisionReason = "deprovision_reason"
+	displayDeviceID      = "Device ID"
+)
+
+// deprovisionReasons maps the deprovision_reason argument to the API's
+// DEPROVISION_REASON_* values that an admin may choose.
+var deprovisionReasons = map[string]string{
+	"same_model_replacement":      "DEPROVISION_REASON_SAME_MODEL_REPLACEMENT",
+	"different_model_replacement": "DEPROVISION_REASON_DIFFERENT_MODEL_REPLACEMENT",
+	"retiring_device":             "DEPROVISION_REASON_RETIRING_DEVICE",
+	"upgrade_transfer":            "DEPROVISION_REASON_UPGRADE_TRANSFER",
+}
+
+// deviceActionSchema builds the schema shared by the device actions: a device
+// ID argument, any extra arguments, and a success return value.
+func deviceActionSchema(name, displayName, description, deviceIDDescription string, extraArgs ...*config.Field) *v2.BatonActionSchema {
+	args := []*config.Field{
+		{
+			Name:        argDeviceID,
+			DisplayName: displayDeviceID,
+			Description: deviceIDDescription,
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+	}
+	return &v2.BatonActionSchema{
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		Arguments:   append(args, extraArgs...),
+		ReturnTypes: []*config.Field{
+			{
+				Name:        fieldSuccess,
+				DisplayName: displaySuccess,
+				Description: "Whether the action was accepted by Google Workspace.",
+				Field:       &config.Field_BoolField{},
+			},
+		},
+		ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_DYNAMIC},
+	}
+}
+
+const (
+	mobileDeviceIDDescription = "Resource ID of the mobile device (the mobile_device resource ID)."
+	chromeDeviceIDDescription = "Device ID of the Chrome OS device (the chrome_device resource ID)."
+)
+
+// mobileDeviceAction pairs an action schema with the mobiledevices.action
+// value it sends.
+type mobileDeviceAction struct {
+	schema *v2.BatonActionSchema
+	action string
+}
+
+var mobileDeviceActions = []mobileDeviceAction{
+	{
+		schema: deviceActionSchema("approve_mobile_device", "Approve Mobile Device",
+			"Approves a mobile device so it can sync Google Workspace data.", mobileDeviceIDDescription),
+		action: "approve",
+	},
+	{
+		schema: deviceActionSchema("block_mobile_device", "Block Mobile Device",
+			"Blocks a mobile device from syncing Google Workspace data.", mobileDeviceIDDescription),
+		action: "block",
+	},
+	{
+		schema: deviceActionSchema("wipe_mobile_device_account", "Wipe Mobile Device Account",
+			"Removes the user's Google Workspace account and its data from a mobile device, leaving personal data in place.", mobileDeviceIDDescription),
+		action: "admin_account_wipe",
+	},
+	{
+		schema: deviceActionSchema("wipe_mobile_device", "Wipe Mobile Device",
+			"Factory resets a mobile device, erasing all of its data. This cannot be undone.", mobileDeviceIDDescription),
+		action: "admin_remote_wipe",
+	},
+}
+
+var (
+	disableChromeDeviceActionSchema = deviceActionSchema("disable_chrome_device", "Disable Chrome OS Device",
+		"Disables a Chrome OS device, e.g. one that was lost or stolen. The device stays managed but cannot be used until re-enabled.",
+		chromeDeviceIDDescription)
+
+	deprovisionChromeDeviceActionSchema = deviceActionSchema("deprovision_chrome_device", "Deprovision Chrome OS Device",
+		"Deprovisions a Chrome OS device, removing its policies. The device must be wiped before it can be re-enrolled.",
+		chromeDeviceIDDescription,
+		&config.Field{
+			Name:        argDeprovisionReason,
+			DisplayName: "Deprovision Reason",
+			Description: "Why the device is being deprovisioned: same_model_replacement, different_model_replacement, retiring_device, or upgrade_transfer.",
+			Field: &config.Field_StringField{
+				StringField: &config.StringField{
+					Rules: &config.StringRules{
+						In: []string{
+							"same_model_replacement",
+							"different_model_replacement",
+							"retiring_device",
+							"upgrade_transfer",
+						},
+					},
+				},
+			},
+			IsRequired: true,
+		})
+)
+
+// ResourceActions implements the ResourceActionProvider interface for mobile device actions.
+func (o *mobileDeviceResourceType) ResourceActions(ctx context.Context, registry actions.ActionRegistry) error {
+	for _, a := range mobileDeviceActions {
+		if err := registry.Register(ctx, a.schema, o.mobileDeviceActionHandler(a)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *mobileDeviceResourceType) mobileDeviceActionHandler(a mobileDeviceAction) actions.ActionHandler {
+	return func(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+		l := ctxzap.Extract(ctx)
+		if o.client.MobileDeviceActionService == nil {
+			return nil, nil, uhttp.WrapErrors(codes.FailedPrecondition,
+				fmt.Sprintf("google-workspace: mobile device action service not available - requires %s scope", admin.AdminDirectoryDeviceMobileActionScope))
+		}
+		deviceID := getStringField(args, argDeviceID)
+		if deviceID == "" {
+			return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("google-workspace: %s: missing %s argument", a.schema.Name, argDeviceID))
+		}
+
+		err := withRateLimitWait(ctx, func() error {
+			return o.client.ActOnMobileDevice(ctx, o.customerId, deviceID, a.action)
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("google-workspace: %s: %w", a.schema.Name, err)
+		}
+		l.Debug("google-workspace: mobile device action handler: applied action",
+			zap.String("action", a.action),
+			zap.String(argDeviceID, deviceID))
+
+		return actions.NewReturnValues(true), nil, nil
+	}
+}
+
+// ResourceActions implements the ResourceActionProvider interface for Chrome OS device actions.
+func (o *chromeDeviceResourceType) ResourceActions(ctx context.Context, registry actions.ActionRegistry) error {
+	if err := registry.Register(ctx, disableChromeDeviceActionSchema, o.disableChromeDeviceActionHandler); err != nil {
+		return err
+	}
+	if err := registry.Register(ctx, deprovisionChromeDeviceActionSchema, o.deprovisionChromeDeviceActionHandler); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *chromeDeviceResourceType) disableChromeDeviceActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	return o.changeChromeDeviceStatus(ctx, args, disableChromeDeviceActionSchema.Name, gwclient.ChromeOSDeviceActionDisable, "")
+}
+
+func (o *chromeDeviceResourceType) deprovisionChromeDeviceActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	reason := strings.ToLower(getStringField(args, argDeprovisionReason))
+	apiReason, ok := deprovisionReasons[reason]
+	if !ok {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument,
+			fmt.Sprintf("google-workspace: deprovision_chrome_device: invalid %s %q", argDeprovisionReason, reason))
+	}
+	return o.changeChromeDeviceStatus(ctx, args, deprovisionChromeDeviceActionSchema.Name, gwclient.ChromeOSDeviceActionDeprovision, apiReason)
+}
+
+func (o *chromeDeviceResourceType) changeChromeDeviceStatus(ctx context.Context, args *structpb.Struct, actionName, action, deprovisionReason string) (*structpb.Struct, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	if o.client.ChromeDeviceProvisioningService == nil {
+		return nil, nil, uhttp.WrapErrors(codes.FailedPrecondition,
+			fmt.Sprintf("google-workspace: chrome device provisioning service not available - requires %s scope", admin.AdminDirectoryDeviceChromeosScope))
+	}
+	deviceID := getStringField(args, argDeviceID)
+	if deviceID == "" {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("google-workspace: %s: missing %s argument", actionName, argDeviceID))
+	}
+
+	err := withRateLimitWait(ctx, func() error {
+		return o.client.ChangeChromeOSDeviceStatus(ctx, o.customerId, deviceID, action, deprovisionReason)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: %s: %w", actionName, err)
+	}
+	l.Debug("google-workspace: chrome device action handler: changed device status",
+		zap.String("action", actionName),
+		zap.String(argDeviceID, deviceID))
+
+	return actions.NewReturnValues(true), nil, nil
+}
@@ -0,0 +1,231 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+const testDevicesPath = "/admin/directory/v1/customer/test-customer/devices/"
+
+type testDeviceServerState struct {
+	mtx           sync.Mutex
+	users         []*directoryAdmin.User
+	userPages     int
+	mobile        []*directoryAdmin.MobileDevice
+	chrome        []*directoryAdmin.ChromeOsDevice
+	mobileActions map[string]string
+	chromeChanges []*directoryAdmin.BatchChangeChromeOsDeviceStatusRequest
+}
+
+func newDeviceTestServer(t *testing.T, state *testDeviceServerState) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/directory/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		state.userPages++
+		// Serve one user per page so the index walk spans several calls.
+		resp := &directoryAdmin.Users{}
+		i, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		resp.Users = []*directoryAdmin.User{state.users[i]}
+		if i+1 < len(state.users) {
+			resp.NextPageToken = strconv.Itoa(i + 1)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc(testDevicesPath+"mobile", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.MobileDevices{Mobiledevices: state.mobile})
+	})
+	mux.HandleFunc(testDevicesPath+"mobile/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		resourceID, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, testDevicesPath+"mobile/"), "/action")
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		var body directoryAdmin.MobileDeviceAction
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		state.mobileActions[resourceID] = body.Action
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc(testDevicesPath+"chromeos", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.ChromeOsDevices{Chromeosdevices: state.chrome})
+	})
+	mux.HandleFunc(testDevicesPath+"chromeos:batchChangeStatus", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		var body directoryAdmin.BatchChangeChromeOsDeviceStatusRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		state.chromeChanges = append(state.chromeChanges, &body)
+		resp := &directoryAdmin.BatchChangeChromeOsDeviceStatusResponse{}
+		for _, id := range body.DeviceIds {
+			result := &directoryAdmin.ChangeChromeOsDeviceStatusResult{DeviceId: id}
+			if id == "missing-device" {
+				result.Error = &directoryAdmin.Status{Code: 5, Message: "Device not found"}
+			}
+			resp.ChangeChromeOsDeviceStatusResults = append(resp.ChangeChromeOsDeviceStatusResults, result)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestDeviceClient(t *testing.T, server *httptest.Server) *gwclient.GoogleWorkspaceClient {
+	t.Helper()
+	directory := newTestDirectoryService(t, server.URL, server.Client())
+	return &gwclient.GoogleWorkspaceClient{
+		UserService:                     directory,
+		MobileDeviceService:             directory,
+		MobileDeviceActionService:       directory,
+		ChromeDeviceService:             directory,
+		ChromeDeviceProvisioningService: directory,
+	}
+}
+
+// collectDeviceGrants pages through Grants until the page token runs out.
+func collectDeviceGrants(t *testing.T, syncer interface {
+	Grants(context.Context, *v2.Resource, rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error)
+}, resource *v2.Resource, attrs rs.SyncOpAttrs) []*v2.Grant {
+	t.Helper()
+	var grants []*v2.Grant
+	token := ""
+	for calls := 0; ; calls++ {
+		require.Less(t, calls, 10, "grants pagination did not terminate")
+		attrs.PageToken = pagination.Token{Token: token}
+		page, results, err := syncer.Grants(context.Background(), resource, attrs)
+		require.NoError(t, err)
+		grants = append(grants, page...)
+		if results == nil || results.NextPageToken == "" {
+			return grants
+		}
+		token = results.NextPageToken
+	}
+}
+
+func TestDeviceList_GrantsOwnershipToUsers(t *testing.T) {
+	state := &testDeviceServerState{
+		users: []*directoryAdmin.User{
+			{Id: "alice-id", PrimaryEmail: "alice@example.com"},
+			{Id: "bob-id", PrimaryEmail: "bob@example.com"},
+		},
+		mobile: []*directoryAdmin.MobileDevice{
+			{ResourceId: "mobile-1", Model: "Pixel 8", Os: "Android 14", Status: "APPROVED", LastSync: "2026-10-01T10:00:00.000Z", Email: []string{"Alice@example.com", "outsider@other.com"}},
+			{ResourceId: "mobile-2", Model: "iPhone", Status: "PENDING"},
+		},
+		chrome: []*directoryAdmin.ChromeOsDevice{
+			{DeviceId: "chrome-1", Model: "Chromebook 14", SerialNumber: "SN123", OsVersion: "128.0", Status: "ACTIVE", AnnotatedUser: "bob@example.com"},
+		},
+	}
+	server := newDeviceTestServer(t, state)
+	defer server.Close()
+	client := newTestDeviceClient(t, server)
+	mobile := mobileDeviceBuilder(client, "test-customer", "")
+	chrome := chromeDeviceBuilder(client, "test-customer", "")
+	ss := newFakeSessionStore()
+
+	mobiles, _, err := mobile.List(context.Background(), nil, rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, mobiles, 2)
+	require.Equal(t, "Pixel 8 (Alice@example.com)", mobiles[0].DisplayName)
+	profile := mobiles[0].GetProfile().AsMap()
+	require.Equal(t, "APPROVED", profile["status"])
+	require.Equal(t, "Android 14", profile["os"])
+	require.Equal(t, "2026-10-01T10:00:00.000Z", profile["last_sync"])
+
+	// A device without owners never walks the directory.
+	require.Empty(t, collectDeviceGrants(t, mobile, mobiles[1], rs.SyncOpAttrs{Session: ss}))
+	require.Zero(t, state.userPages)
+
+	grants := collectDeviceGrants(t, mobile, mobiles[0], rs.SyncOpAttrs{Session: ss})
+	require.Len(t, grants, 1, "owners outside the directory must be skipped")
+	require.Equal(t, "alice-id", grants[0].Principal.Id.Resource)
+	require.Equal(t, sdkEntitlement.NewEntitlementID(mobiles[0], deviceOwnerEntitlement), grants[0].Entitlement.Id)
+	require.Equal(t, 2, state.userPages)
+
+	chromes, _, err := chrome.List(context.Background(), nil, rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, chromes, 1)
+	require.Equal(t, "Chromebook 14 (SN123)", chromes[0].DisplayName)
+	require.Equal(t, "128.0", chromes[0].GetProfile().AsMap()["os_version"])
+
+	// Chrome devices reuse the index the mobile grants built.
+	grants = collectDeviceGrants(t, chrome, chromes[0], rs.SyncOpAttrs{Session: ss})
+	require.Len(t, grants, 1)
+	require.Equal(t, "bob-id", grants[0].Principal.Id.Resource)
+	require.Equal(t, 2, state.userPages)
+}
+
+func deviceActionArgs(t *testing.T, args map[string]interface{}) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(args)
+	require.NoError(t, err)
+	return s
+}
+
+func TestDeviceActions(t *testing.T) {
+	state := &testDeviceServerState{mobileActions: map[string]string{}}
+	server := newDeviceTestServer(t, state)
+	defer server.Close()
+	client := newTestDeviceClient(t, server)
+	mobile := mobileDeviceBuilder(client, "test-customer", "")
+	chrome := chromeDeviceBuilder(client, "test-customer", "")
+	ctx := context.Background()
+
+	for _, a := range mobileDeviceActions {
+		deviceID := "device-" + a.schema.Name
+		rv, _, err := mobile.mobileDeviceActionHandler(a)(ctx, deviceActionArgs(t, map[string]interface{}{argDeviceID: deviceID}))
+		require.NoError(t, err)
+		require.True(t, rv.GetFields()[fieldSuccess].GetBoolValue())
+		require.Equal(t, a.action, state.mobileActions[deviceID])
+	}
+	_, _, err := mobile.mobileDeviceActionHandler(mobileDeviceActions[0])(ctx, deviceActionArgs(t, map[string]interface{}{}))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, _, err = chrome.disableChromeDeviceActionHandler(ctx, deviceActionArgs(t, map[string]interface{}{argDeviceID: "chrome-1"}))
+	require.NoError(t, err)
+	_, _, err = chrome.deprovisionChromeDeviceActionHandler(ctx, deviceActionArgs(t, map[string]interface{}{
+		argDeviceID:          "chrome-1",
+		argDeprovisionReason: "retiring_device",
+	}))
+	require.NoError(t, err)
+	require.Len(t, state.chromeChanges, 2)
+	require.Equal(t, gwclient.ChromeOSDeviceActionDisable, state.chromeChanges[0].ChangeChromeOsDeviceStatusAction)
+	require.Empty(t, state.chromeChanges[0].DeprovisionReason)
+	require.Equal(t, gwclient.ChromeOSDeviceActionDeprovision, state.chromeChanges[1].ChangeChromeOsDeviceStatusAction)
+	require.Equal(t, "DEPROVISION_REASON_RETIRING_DEVICE", state.chromeChanges[1].DeprovisionReason)
+	require.Equal(t, []string{"chrome-1"}, state.chromeChanges[1].DeviceIds)
+
+	_, _, err = chrome.deprovisionChromeDeviceActionHandler(ctx, deviceActionArgs(t, map[string]interface{}{
+		argDeviceID:          "chrome-1",
+		argDeprovisionReason: "because",
+	}))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Len(t, state.chromeChanges, 2)
+
+	// A per-device failure inside a successful batch response is an error.
+	_, _, err = chrome.disableChromeDeviceActionHandler(ctx, deviceActionArgs(t, map[string]interface{}{argDeviceID: "missing-device"}))
+	require.ErrorContains(t, err, "Device not found")
+}
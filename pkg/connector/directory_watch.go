@@ -0,0 +1,303 @@
+// directory_watch.go implements the optional push mode for user changes. When a watch
+// callback URL is configured, the connector opens Directory API users.watch channels (one
+// per user event type) and runs an embedded HTTP receiver that Google posts notifications
+// to. The receiver validates the channel token and records the changed user IDs; the
+// directory watch event feed drains them as ResourceChangeEvents, so continuous sync
+// re-syncs only the users that changed instead of re-listing the directory.
+//
+// The Directory API has no equivalent watch for group membership, so membership changes
+// keep arriving through the admin event feed (ADD_GROUP_MEMBER / UPDATE_GROUP_MEMBER).
+//
+// Channels expire (Google caps users.watch channels at a few hours), so every ListEvents
+// call renews channels nearing expiry: the replacement is opened first and the old one
+// stopped afterwards, so no notification window is lost.
+package connector
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+const (
+	// watchChannelTTL is the expiration requested for a new channel. Google may
+	// grant a shorter one; renewal is driven by the expiration it returns.
+	watchChannelTTL = 6 * time.Hour
+	// watchChannelRenewBefore is how long before expiry a channel is replaced.
+	watchChannelRenewBefore = 30 * time.Minute
+	// maxWatchNotificationBytes bounds the notification body the receiver reads.
+	maxWatchNotificationBytes = 1 << 20
+
+	headerGoogChannelID     = "X-Goog-Channel-ID"
+	headerGoogChannelToken  = "X-Goog-Channel-Token"
+	headerGoogResourceState = "X-Goog-Resource-State"
+	headerGoogMessageNumber = "X-Goog-Message-Number"
+
+	// watchResourceStateSync is the handshake Google sends when a channel opens.
+	watchResourceStateSync = "sync"
+)
+
+// directoryWatchUserEvents are the users.watch event types a channel is opened for.
+var directoryWatchUserEvents = []string{"add", "delete", "makeAdmin", "undelete", "update"}
+
+// watchedChange is a user reported changed by a watch notification.
+type watchedChange struct {
+	UserID     string
+	Event      string
+	OccurredAt time.Time
+}
+
+// directoryWatchReceiver is the HTTP handler Google posts watch notifications to.
+// Repeated notifications for one user collapse into the latest one until drained.
+type directoryWatchReceiver struct {
+	token string
+	now   func() time.Time
+
+	mtx     sync.Mutex
+	changed map[string]watchedChange
+}
+
+func newDirectoryWatchReceiver(token string) *directoryWatchReceiver {
+	return &directoryWatchReceiver{
+		token:   token,
+		now:     time.Now,
+		changed: make(map[string]watchedChange),
+	}
+}
+
+func (r *directoryWatchReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	l := ctxzap.Extract(req.Context())
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.token == "" || subtle.ConstantTimeCompare([]byte(req.Header.Get(headerGoogChannelToken)), []byte(r.token)) != 1 {
+		l.Warn("google-workspace: rejecting watch notification with an invalid channel token",
+			zap.String("channel_id", req.Header.Get(headerGoogChannelID)))
+		http.Error(w, "invalid channel token", http.StatusForbidden)
+		return
+	}
+
+	state := req.Header.Get(headerGoogResourceState)
+	if state == watchResourceStateSync {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var payload struct {
+		Id string `json:"id"`
+	}
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxWatchNotificationBytes)).Decode(&payload); err != nil || payload.Id == "" {
+		l.Debug("google-workspace: watch notification had no user id",
+			zap.String("channel_id", req.Header.Get(headerGoogChannelID)),
+			zap.String("message_number", req.Header.Get(headerGoogMessageNumber)),
+			zap.Error(err))
+		http.Error(w, "missing user id", http.StatusBadRequest)
+		return
+	}
+
+	r.mtx.Lock()
+	r.changed[payload.Id] = watchedChange{UserID: payload.Id, Event: state, OccurredAt: r.now()}
+	r.mtx.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+// drain returns the recorded changes, oldest first, and forgets them.
+func (r *directoryWatchReceiver) drain() []watchedChange {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	rv := make([]watchedChange, 0, len(r.changed))
+	for _, c := range r.changed {
+		rv = append(rv, c)
+	}
+	r.changed = make(map[string]watchedChange)
+	sort.Slice(rv, func(i, j int) bool {
+		if rv[i].OccurredAt.Equal(rv[j].OccurredAt) {
+			return rv[i].UserID < rv[j].UserID
+		}
+		return rv[i].OccurredAt.Before(rv[j].OccurredAt)
+	})
+	return rv
+}
+
+// directoryWatcher owns the users.watch channels and the embedded receiver.
+type directoryWatcher struct {
+	client        *gwclient.GoogleWorkspaceClient
+	customerID    string
+	domain        string
+	callbackURL   string
+	listenAddress string
+	receiver      *directoryWatchReceiver
+	now           func() time.Time
+
+	serveOnce sync.Once
+	serveErr  error
+
+	mtx      sync.Mutex
+	channels map[string]*directoryAdmin.Channel // keyed by user event
+}
+
+func newDirectoryWatcher(client *gwclient.GoogleWorkspaceClient, customerID, domain, callbackURL, listenAddress, token string) *directoryWatcher {
+	return &directoryWatcher{
+		client:        client,
+		customerID:    customerID,
+		domain:        domain,
+		callbackURL:   callbackURL,
+		listenAddress: listenAddress,
+		receiver:      newDirectoryWatchReceiver(token),
+		now:           time.Now,
+		channels:      make(map[string]*directoryAdmin.Channel),
+	}
+}
+
+// serve starts the receiver once. The listener lives for the rest of the process.
+func (w *directoryWatcher) serve(ctx context.Context) error {
+	w.serveOnce.Do(func() {
+		lis, err := net.Listen("tcp", w.listenAddress)
+		if err != nil {
+			w.serveErr = fmt.Errorf("google-workspace: failed to listen for watch notifications on %s: %w", w.listenAddress, err)
+			return
+		}
+		l := ctxzap.Extract(ctx)
+		srv := &http.Server{
+			Handler:           w.receiver,
+			ReadHeaderTimeout: 10 * time.Second,
+			BaseContext:       func(net.Listener) context.Context { return ctxzap.ToContext(context.Background(), l) },
+		}
+		go func() {
+			if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				l.Error("google-workspace: watch receiver stopped", zap.Error(err))
+			}
+		}()
+		l.Info("google-workspace: watch receiver listening", zap.String("address", lis.Addr().String()))
+	})
+	return w.serveErr
+}
+
+// ensureChannels opens a channel for every user event that has none, and replaces
+// channels that expire within watchChannelRenewBefore.
+func (w *directoryWatcher) ensureChannels(ctx context.Context) error {
+	l := ctxzap.Extract(ctx)
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	now := w.now()
+	for _, event := range directoryWatchUserEvents {
+		old := w.channels[event]
+		if old != nil && time.UnixMilli(old.Expiration).After(now.Add(watchChannelRenewBefore)) {
+			continue
+		}
+		id, err := newWatchChannelID()
+		if err != nil {
+			return err
+		}
+		ch, err := w.client.WatchUsers(ctx, w.customerID, w.domain, event, &directoryAdmin.Channel{
+			Id:         id,
+			Type:       "web_hook",
+			Address:    w.callbackURL,
+			Token:      w.receiver.token,
+			Expiration: now.Add(watchChannelTTL).UnixMilli(),
+		})
+		if err != nil {
+			return fmt.Errorf("google-workspace: failed to open watch channel for user %s events: %w", event, err)
+		}
+		w.channels[event] = ch
+		l.Debug("google-workspace: opened watch channel",
+			zap.String("event", event),
+			zap.String("channel_id", ch.Id),
+			zap.Time("expires", time.UnixMilli(ch.Expiration)))
+
+		if old == nil {
+			continue
+		}
+		if err := w.client.StopChannel(ctx, old); err != nil {
+			// The old channel expires on its own; a failed stop only means a
+			// short overlap of duplicate notifications.
+			gerr := &googleapi.Error{}
+			if !errors.As(err, &gerr) || gerr.Code != http.StatusNotFound {
+				l.Warn("google-workspace: failed to stop renewed watch channel", zap.String("channel_id", old.Id), zap.Error(err))
+			}
+		}
+	}
+	return nil
+}
+
+func newWatchChannelID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("google-workspace: failed to generate watch channel id: %w", err)
+	}
+	return "baton-gws-" + hex.EncodeToString(b), nil
+}
+
+// directoryWatchEventFeed turns watch notifications into ResourceChangeEvents.
+// Without a configured watcher it returns no events.
+type directoryWatchEventFeed struct {
+	watcher *directoryWatcher
+}
+
+func newDirectoryWatchEventFeed(watcher *directoryWatcher) *directoryWatchEventFeed {
+	return &directoryWatchEventFeed{watcher: watcher}
+}
+
+func (f *directoryWatchEventFeed) EventFeedMetadata(ctx context.Context) *v2.EventFeedMetadata {
+	return &v2.EventFeedMetadata{
+		Id: "directory_watch_feed",
+		SupportedEventTypes: []v2.EventType{
+			v2.EventType_EVENT_TYPE_RESOURCE_CHANGE,
+		},
+	}
+}
+
+func (f *directoryWatchEventFeed) ListEvents(ctx context.Context, _ *timestamppb.Timestamp, _ *pagination.StreamToken) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	done := &pagination.StreamState{Cursor: "", HasMore: false}
+	if f.watcher == nil {
+		return []*v2.Event{}, done, nil, nil
+	}
+	if err := f.watcher.serve(ctx); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := f.watcher.ensureChannels(ctx); err != nil {
+		return nil, nil, nil, err
+	}
+
+	changes := f.watcher.receiver.drain()
+	events := make([]*v2.Event, 0, len(changes))
+	for _, c := range changes {
+		events = append(events, &v2.Event{
+			Id:         fmt.Sprintf("%s:%s:%d", c.UserID, c.Event, c.OccurredAt.UnixNano()),
+			OccurredAt: timestamppb.New(c.OccurredAt),
+			Event: &v2.Event_ResourceChangeEvent{
+				ResourceChangeEvent: &v2.ResourceChangeEvent{
+					ResourceId: &v2.ResourceId{
+						ResourceType: resourceTypeUser.Id,
+						Resource:     c.UserID,
+					},
+				},
+			},
+		})
+	}
+	ctxzap.Extract(ctx).Debug("google-workspace: drained watch notifications", zap.Int("count", len(events)))
+	return events, done, nil, nil
+}
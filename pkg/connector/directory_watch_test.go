@@ -0,0 +1,185 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+// fakeWatchNotifier posts notifications shaped like Google's web_hook
+// deliveries to a watch receiver.
+type fakeWatchNotifier struct {
+	t         *testing.T
+	url       string
+	token     string
+	channelID string
+	messages  int
+}
+
+func (n *fakeWatchNotifier) notify(state, userID string) int {
+	n.t.Helper()
+	n.messages++
+	var body []byte
+	if userID != "" {
+		body, _ = json.Marshal(map[string]string{"kind": "admin#directory#user", "id": userID, "primaryEmail": userID + "@example.com"})
+	}
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	require.NoError(n.t, err)
+	req.Header.Set(headerGoogChannelID, n.channelID)
+	req.Header.Set(headerGoogChannelToken, n.token)
+	req.Header.Set(headerGoogResourceState, state)
+	req.Header.Set(headerGoogMessageNumber, strconv.Itoa(n.messages))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(n.t, err)
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
+
+type testWatchServerState struct {
+	mtx sync.Mutex
+	// grantedTTL, when set, caps the expiration returned for new channels.
+	grantedTTL time.Duration
+	now        func() time.Time
+	opened     []*directoryAdmin.Channel
+	stopped    []string
+}
+
+func newWatchTestServer(t *testing.T, state *testWatchServerState) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/directory/v1/users/watch", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		var ch directoryAdmin.Channel
+		_ = json.NewDecoder(r.Body).Decode(&ch)
+		ch.ResourceId = "resource-" + r.URL.Query().Get("event")
+		if state.grantedTTL > 0 {
+			ch.Expiration = state.now().Add(state.grantedTTL).UnixMilli()
+		}
+		state.opened = append(state.opened, &ch)
+		_ = json.NewEncoder(w).Encode(&ch)
+	})
+	mux.HandleFunc("/admin/directory_v1/channels/stop", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		var ch directoryAdmin.Channel
+		_ = json.NewDecoder(r.Body).Decode(&ch)
+		state.stopped = append(state.stopped, ch.Id)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestDirectoryWatchReceiver_ValidatesTokenAndCollapsesChanges(t *testing.T) {
+	receiver := newDirectoryWatchReceiver("s3cret")
+	tick := time.Unix(1700000000, 0)
+	receiver.now = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	notifier := &fakeWatchNotifier{t: t, url: server.URL, token: "s3cret", channelID: "ch-1"}
+
+	require.Equal(t, http.StatusOK, notifier.notify(watchResourceStateSync, ""))
+	require.Equal(t, http.StatusOK, notifier.notify("update", "alice"))
+	require.Equal(t, http.StatusOK, notifier.notify("add", "bob"))
+	require.Equal(t, http.StatusOK, notifier.notify("makeAdmin", "alice"))
+	require.Equal(t, http.StatusBadRequest, notifier.notify("update", ""))
+
+	forged := &fakeWatchNotifier{t: t, url: server.URL, token: "guess", channelID: "ch-1"}
+	require.Equal(t, http.StatusForbidden, forged.notify("delete", "mallory"))
+
+	changes := receiver.drain()
+	require.Len(t, changes, 2)
+	require.Equal(t, "bob", changes[0].UserID)
+	require.Equal(t, "alice", changes[1].UserID)
+	require.Equal(t, "makeAdmin", changes[1].Event)
+	require.Empty(t, receiver.drain())
+}
+
+func TestDirectoryWatcher_RenewsExpiringChannels(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+	state := &testWatchServerState{grantedTTL: time.Hour, now: clock}
+	server := newWatchTestServer(t, state)
+	defer server.Close()
+	client := &gwclient.GoogleWorkspaceClient{UserService: newTestDirectoryService(t, server.URL, server.Client())}
+	w := newDirectoryWatcher(client, "test-customer", "", "https://hooks.example.com/gws", "127.0.0.1:0", "s3cret")
+	w.now = clock
+	ctx := context.Background()
+
+	require.NoError(t, w.ensureChannels(ctx))
+	require.Len(t, state.opened, len(directoryWatchUserEvents))
+	require.Empty(t, state.stopped)
+	for _, ch := range state.opened {
+		require.Equal(t, "web_hook", ch.Type)
+		require.Equal(t, "s3cret", ch.Token)
+		require.Equal(t, "https://hooks.example.com/gws", ch.Address)
+	}
+	first := state.opened[0].Id
+
+	// Well before expiry nothing changes.
+	now = now.Add(20 * time.Minute)
+	require.NoError(t, w.ensureChannels(ctx))
+	require.Len(t, state.opened, len(directoryWatchUserEvents))
+
+	// Inside the renewal window every channel is replaced, then the old one stopped.
+	now = now.Add(20 * time.Minute)
+	require.NoError(t, w.ensureChannels(ctx))
+	require.Len(t, state.opened, 2*len(directoryWatchUserEvents))
+	require.Len(t, state.stopped, len(directoryWatchUserEvents))
+	require.Contains(t, state.stopped, first)
+}
+
+func TestDirectoryWatchEventFeed_EmitsResourceChangeEvents(t *testing.T) {
+	state := &testWatchServerState{now: time.Now}
+	server := newWatchTestServer(t, state)
+	defer server.Close()
+	client := &gwclient.GoogleWorkspaceClient{UserService: newTestDirectoryService(t, server.URL, server.Client())}
+	w := newDirectoryWatcher(client, "test-customer", "", "https://hooks.example.com/gws", "127.0.0.1:0", "s3cret")
+	feed := newDirectoryWatchEventFeed(w)
+	ctx := context.Background()
+
+	events, st, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+	require.NoError(t, err)
+	require.Empty(t, events)
+	require.False(t, st.HasMore)
+
+	receiverServer := httptest.NewServer(w.receiver)
+	defer receiverServer.Close()
+	notifier := &fakeWatchNotifier{t: t, url: receiverServer.URL, token: "s3cret", channelID: state.opened[0].Id}
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusOK, notifier.notify("update", fmt.Sprintf("user-%d", i)))
+	}
+
+	events, _, _, err = feed.ListEvents(ctx, nil, &pagination.StreamToken{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	for _, e := range events {
+		rce := e.GetResourceChangeEvent()
+		require.NotNil(t, rce)
+		require.Equal(t, resourceTypeUser.Id, rce.GetResourceId().GetResourceType())
+	}
+	require.Len(t, state.opened, len(directoryWatchUserEvents), "live channels must not be reopened")
+}
+
+func TestDirectoryWatchEventFeed_DisabledWithoutWatcher(t *testing.T) {
+	events, st, _, err := newDirectoryWatchEventFeed(nil).ListEvents(context.Background(), nil, &pagination.StreamToken{})
+	require.NoError(t, err)
+	require.Empty(t, events)
+	require.False(t, st.HasMore)
+}
@@ -0,0 +1,238 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	reportsAdmin "google.golang.org/api/admin/reports/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+const reportsAppDrive = "drive"
+
+// driveSharingEventNames are the Drive audit events that can share a file
+// outside the domain.
+var driveSharingEventNames = []string{
+	"change_user_access",
+	"change_document_visibility",
+	"change_acl_editors",
+}
+
+// driveExternalVisibilities are the Drive audit visibility values that expose
+// a file beyond the domain.
+var driveExternalVisibilities = []string{
+	"people_with_link",
+	"public_on_the_web",
+	"shared_externally",
+}
+
+// driveFileResourceType types the file in the feed's usage events. Files are
+// not synced, so the resource only identifies the file to the event's reader.
+const driveFileResourceType = "drive_file"
+
+// driveEventFeed emits a UsageEvent for every Drive audit sharing change that
+// exposes a file outside the customer's domains. The actor is the user who
+// changed the sharing and the target is the file, described by the change.
+type driveEventFeed struct {
+	client     *gwclient.GoogleWorkspaceClient
+	customerID string
+	domain     string
+
+	domainsMtx sync.Mutex
+	domains    []string
+}
+
+func newDriveEventFeed(client *gwclient.GoogleWorkspaceClient, customerID, domain string) *driveEventFeed {
+	return &driveEventFeed{client: client, customerID: customerID, domain: domain}
+}
+
+func (f *driveEventFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetadata {
+	return &v2.EventFeedMetadata{
+		Id: "drive_event_feed",
+		SupportedEventTypes: []v2.EventType{
+			v2.EventType_EVENT_TYPE_USAGE,
+		},
+	}
+}
+
+func (f *driveEventFeed) ListEvents(ctx context.Context, startAt *timestamppb.Timestamp, pToken *pagination.StreamToken) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	events, streamState, err := listAuditLogPage(ctx, f.client, reportsAppDrive, driveSharingEventNames, startAt, pToken, f.convert)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return events, streamState, nil, nil
+}
+
+func (f *driveEventFeed) convert(ctx context.Context, eventName string, activities []*reportsAdmin.Activity) ([]*v2.Event, error) {
+	l := ctxzap.Extract(ctx)
+
+	internal, err := f.internalDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*v2.Event, 0)
+	for _, activity := range activities {
+		occurredAt := convertIdTimeToTimestamp(activity.Id.Time)
+		if occurredAt == nil || activity.Actor == nil || activity.Actor.ProfileId == "" {
+			continue
+		}
+		for i, e := range activity.Events {
+			if e.Name != eventName || !sharesExternally(e, internal) {
+				continue
+			}
+			evt, err := newDriveSharingEvent(activity, i, occurredAt)
+			if err != nil {
+				l.Error("failed to create drive sharing event", zap.Error(err))
+				continue
+			}
+			events = append(events, evt)
+		}
+	}
+	return events, nil
+}
+
+// internalDomains returns the customer's domains and domain aliases, listed
+// once per feed. Without the domain service it falls back to the configured
+// domain.
+func (f *driveEventFeed) internalDomains(ctx context.Context) ([]string, error) {
+	f.domainsMtx.Lock()
+	defer f.domainsMtx.Unlock()
+	if f.domains != nil {
+		return f.domains, nil
+	}
+
+	domains := []string{}
+	if f.client.DomainService == nil {
+		if f.domain != "" {
+			domains = append(domains, strings.ToLower(f.domain))
+		}
+		f.domains = domains
+		return domains, nil
+	}
+
+	resp, err := f.client.ListDomains(ctx, f.customerID)
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace: failed to list domains for drive event feed: %w", err)
+	}
+	for _, d := range resp.Domains {
+		domains = append(domains, strings.ToLower(d.DomainName))
+		for _, alias := range d.DomainAliases {
+			domains = append(domains, strings.ToLower(alias.DomainAliasName))
+		}
+	}
+	f.domains = domains
+	return domains, nil
+}
+
+// sharesExternally reports whether a Drive sharing event itself exposes the
+// file outside internal: a visibility change to a public or external
+// visibility, or access given to a target user or domain that is not
+// internal. The file's visibility before the change is not judged, so
+// removals and internal shares of an already external file are not reported.
+// With no known internal domains, only visibility changes are judged.
+func sharesExternally(e *reportsAdmin.ActivityEvents, internal []string) bool {
+	if e.Name == "change_document_visibility" {
+		visibility := driveEventParameter("visibility", e.Parameters)
+		return slices.Contains(driveExternalVisibilities, visibility) && visibility != driveEventParameter("old_visibility", e.Parameters)
+	}
+	if len(internal) == 0 || !grantsDriveAccess(getValuesFromParameters("new_value", e.Parameters)) {
+		return false
+	}
+	if target := driveEventParameter("target_user", e.Parameters); target != "" {
+		_, domain, ok := strings.Cut(target, "@")
+		if ok && !slices.Contains(internal, strings.ToLower(domain)) {
+			return true
+		}
+	}
+	if domain := strings.ToLower(driveEventParameter("target_domain", e.Parameters)); domain != "" && domain != "all" {
+		return !slices.Contains(internal, domain)
+	}
+	return false
+}
+
+// grantsDriveAccess reports whether the new_value roles of an access change
+// leave its target any access. Removing access sets them to "none".
+func grantsDriveAccess(roles []string) bool {
+	return slices.ContainsFunc(roles, func(r string) bool { return r != "" && r != "none" })
+}
+
+// driveEventParameter returns the values of a Drive audit parameter joined
+// with commas. Roles such as old_value and new_value are multiValue lists.
+func driveEventParameter(name string, params []*reportsAdmin.ActivityEventsParameters) string {
+	return strings.Join(getValuesFromParameters(name, params), ",")
+}
+
+// newDriveSharingEvent converts the index-th event of activity. The event ID
+// joins the activity's unique qualifier with the index, since one activity
+// can record several changes.
+func newDriveSharingEvent(activity *reportsAdmin.Activity, index int, occurredAt *timestamppb.Timestamp) (*v2.Event, error) {
+	e := activity.Events[index]
+	actor, err := eventActorResource(activity.Actor.ProfileId, activity.Actor.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	docID := getValueFromParameters("doc_id", e.Parameters)
+	if docID == "" {
+		return nil, fmt.Errorf("google-workspace: drive %s event %d has no doc_id", e.Name, activity.Id.UniqueQualifier)
+	}
+
+	return &v2.Event{
+		Id:         strconv.FormatInt(activity.Id.UniqueQualifier, 10) + ":" + strconv.Itoa(index),
+		OccurredAt: occurredAt,
+		Event: &v2.Event_UsageEvent{
+			UsageEvent: &v2.UsageEvent{
+				TargetResource: &v2.Resource{
+					Id: &v2.ResourceId{
+						ResourceType: driveFileResourceType,
+						Resource:     docID,
+					},
+					DisplayName: getValueFromParameters("doc_title", e.Parameters),
+					Description: describeDriveSharingChange(e),
+				},
+				ActorResource: actor,
+			},
+		},
+	}, nil
+}
+
+// describeDriveSharingChange summarizes the visibility change of a Drive
+// sharing event, e.g. "change_user_access: bob@partner.com none -> can_edit".
+func describeDriveSharingChange(e *reportsAdmin.ActivityEvents) string {
+	var parts []string
+	if target := driveEventParameter("target_user", e.Parameters); target != "" {
+		parts = append(parts, target)
+	} else if domain := driveEventParameter("target_domain", e.Parameters); domain != "" {
+		parts = append(parts, domain)
+	}
+	oldValue := driveEventParameter("old_value", e.Parameters)
+	newValue := driveEventParameter("new_value", e.Parameters)
+	if oldValue != "" || newValue != "" {
+		parts = append(parts, orNone(oldValue)+" -> "+orNone(newValue))
+	}
+	oldVisibility := driveEventParameter("old_visibility", e.Parameters)
+	visibility := driveEventParameter("visibility", e.Parameters)
+	if visibility != "" && visibility != oldVisibility {
+		parts = append(parts, "visibility "+orNone(oldVisibility)+" -> "+visibility)
+	}
+	return e.Name + ": " + strings.Join(parts, " ")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -0,0 +1,133 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	reportsAdmin "google.golang.org/api/admin/reports/v1"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+// driveActivity builds a Drive audit activity the way the Reports API returns
+// it: old_value and new_value hold lists of roles, as multiValue parameters,
+// given here comma-separated.
+func driveActivity(uniqueQualifier int64, occurredAt time.Time, name string, params map[string]string) *reportsAdmin.Activity {
+	e := &reportsAdmin.ActivityEvents{Type: "acl_change", Name: name}
+	for k, v := range params {
+		p := &reportsAdmin.ActivityEventsParameters{Name: k, Value: v}
+		if k == "old_value" || k == "new_value" {
+			p = &reportsAdmin.ActivityEventsParameters{Name: k, MultiValue: strings.Split(v, ",")}
+		}
+		e.Parameters = append(e.Parameters, p)
+	}
+	return &reportsAdmin.Activity{
+		Id:     &reportsAdmin.ActivityId{Time: occurredAt.UTC().Format(time.RFC3339), UniqueQualifier: uniqueQualifier},
+		Actor:  &reportsAdmin.ActivityActor{Email: "alice@example.com", ProfileId: "alice-id"},
+		Events: []*reportsAdmin.ActivityEvents{e},
+	}
+}
+
+// TestDriveEventFeed_EmitsExternalSharing walks one window of the feed and
+// checks that only changes exposing a file outside the customer's domains
+// (and aliases) become usage events, and that the cursor moves on afterwards.
+func TestDriveEventFeed_EmitsExternalSharing(t *testing.T) {
+	latest := time.Now().Add(-time.Hour).Truncate(time.Second)
+	activities := map[string][]*reportsAdmin.Activity{
+		"change_user_access": {
+			driveActivity(1, latest.Add(-time.Minute), "change_user_access", map[string]string{
+				"doc_id": "doc-1", "doc_title": "Roadmap", "target_user": "bob@Partner.com", "old_value": "none", "new_value": "can_edit",
+			}),
+			driveActivity(2, latest, "change_user_access", map[string]string{
+				"doc_id": "doc-2", "doc_title": "Payroll", "target_user": "carol@example.org", "new_value": "can_view",
+			}),
+			// Changes to a file already shared externally that share it no further.
+			driveActivity(6, latest.Add(-5*time.Minute), "change_user_access", map[string]string{
+				"doc_id": "doc-6", "target_user": "dave@partner.com", "old_value": "can_edit", "new_value": "none", "visibility": "shared_externally",
+			}),
+			driveActivity(7, latest.Add(-6*time.Minute), "change_user_access", map[string]string{
+				"doc_id": "doc-7", "target_user": "erin@example.com", "old_value": "none", "new_value": "can_view", "visibility": "people_with_link",
+			}),
+		},
+		"change_document_visibility": {
+			driveActivity(3, latest.Add(-2*time.Minute), "change_document_visibility", map[string]string{
+				"doc_id": "doc-3", "doc_title": "Launch", "old_visibility": "private", "visibility": "people_with_link",
+			}),
+			driveActivity(4, latest.Add(-3*time.Minute), "change_document_visibility", map[string]string{
+				"doc_id": "doc-4", "visibility": "shared_internally",
+			}),
+			driveActivity(8, latest.Add(-7*time.Minute), "change_document_visibility", map[string]string{
+				"doc_id": "doc-8", "old_visibility": "people_with_link", "visibility": "people_within_domain_with_link",
+			}),
+		},
+		"change_acl_editors": {
+			driveActivity(5, latest.Add(-4*time.Minute), "change_acl_editors", map[string]string{
+				"doc_id": "doc-5", "target_domain": "example.com", "new_value": "can_edit",
+			}),
+			driveActivity(9, latest.Add(-8*time.Minute), "change_acl_editors", map[string]string{
+				"doc_id": "doc-9", "target_domain": "partner.com", "old_value": "can_comment,can_view", "new_value": "can_edit,can_view",
+			}),
+		},
+	}
+
+	var mu sync.Mutex
+	var queried []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/reports/v1/activity/users/all/applications/drive", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("eventName")
+		mu.Lock()
+		queried = append(queried, name)
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(&reportsAdmin.Activities{Items: activities[name]})
+	})
+	mux.HandleFunc("/admin/directory/v1/customer/customer/domains", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.Domains2{Domains: []*directoryAdmin.Domains{
+			{DomainName: "example.com", DomainAliases: []*directoryAdmin.DomainAlias{{DomainAliasName: "example.org"}}},
+		}})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	feed := newDriveEventFeed(&gwclient.GoogleWorkspaceClient{
+		ReportService: newReportsService(t, server.URL, server.Client()),
+		DomainService: newTestDirectoryService(t, server.URL, server.Client()),
+	}, "customer", "example.com")
+
+	descriptions := map[string]string{}
+	token := &pagination.StreamToken{Size: 100}
+	for range 10 {
+		events, state, _, err := feed.ListEvents(context.Background(), nil, token)
+		require.NoError(t, err)
+		for _, e := range events {
+			usage := e.GetUsageEvent()
+			require.Equal(t, "alice-id", usage.GetActorResource().GetId().GetResource())
+			require.Equal(t, driveFileResourceType, usage.GetTargetResource().GetId().GetResourceType())
+			descriptions[usage.GetTargetResource().GetId().GetResource()] = usage.GetTargetResource().GetDescription()
+		}
+		token = &pagination.StreamToken{Size: 100, Cursor: state.Cursor}
+		if !state.HasMore {
+			break
+		}
+	}
+
+	require.Equal(t, map[string]string{
+		"doc-1": "change_user_access: bob@Partner.com none -> can_edit",
+		"doc-3": "change_document_visibility: visibility private -> people_with_link",
+		"doc-9": "change_acl_editors: partner.com can_comment,can_view -> can_edit,can_view",
+	}, descriptions)
+	require.Equal(t, []string{"change_user_access", "change_document_visibility", "change_acl_editors"}, queried)
+
+	cursor := &auditLogPageToken{}
+	require.NoError(t, decodeEventFeedCursor(token, cursor))
+	require.Equal(t, latest.UTC().Format(time.RFC3339), cursor.StartAt)
+	require.Zero(t, cursor.EventIndex)
+}
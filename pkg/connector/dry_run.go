@@ -0,0 +1,127 @@
+package connector
+
+import (
+	"encoding/json"
+	"strconv"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Every user, group and global action accepts dry_run. A dry run reads the
+// current state, computes what the action would write and returns it as
+// changes, without calling any write API.
+const (
+	argDryRun    = "dry_run"
+	fieldChanges = "changes"
+)
+
+var dryRunArgument = &config.Field{
+	Name:        argDryRun,
+	DisplayName: "Dry Run",
+	Description: "Return the changes the action would make, without making them.",
+	Field:       &config.Field_BoolField{},
+}
+
+var dryRunReturnTypes = []*config.Field{
+	{
+		Name:        argDryRun,
+		DisplayName: "Dry Run",
+		Description: "Whether this was a dry run, in which case nothing was changed.",
+		Field:       &config.Field_BoolField{},
+	},
+	{
+		Name:        fieldChanges,
+		DisplayName: "Changes",
+		Description: "On a dry run, the changes the action would make: a list of JSON objects with field, old_value and new_value.",
+		Field:       &config.Field_StringSliceField{},
+	},
+}
+
+// fieldChange is one change a dry run reports.
+type fieldChange struct {
+	field    string
+	oldValue string
+	newValue string
+}
+
+// fieldChangeJSON is how a fieldChange is returned: the changes return field
+// is a string list, so each change is a JSON object in a string.
+type fieldChangeJSON struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// withDryRun returns a copy of schema that accepts dry_run and declares its
+// return fields. Action registration wraps every schema with it, so the
+// schema variables stay as the actions define them.
+func withDryRun(schema *v2.BatonActionSchema) *v2.BatonActionSchema {
+	s, _ := proto.Clone(schema).(*v2.BatonActionSchema)
+	s.Arguments = append(s.Arguments, dryRunArgument)
+	s.ReturnTypes = append(s.ReturnTypes, dryRunReturnTypes...)
+	return s
+}
+
+func isDryRun(args *structpb.Struct) bool {
+	dryRun, _ := getBoolField(args, argDryRun)
+	return dryRun
+}
+
+// dryRunResult is the successful result of a dry run that would make changes,
+// plus any fields the action returns on a real run that are known up front.
+func dryRunResult(changes []fieldChange, fields ...actions.ReturnField) *structpb.Struct {
+	values := make([]*structpb.Value, 0, len(changes))
+	for _, c := range changes {
+		// A struct of strings always marshals.
+		b, _ := json.Marshal(fieldChangeJSON{Field: c.field, OldValue: c.oldValue, NewValue: c.newValue})
+		values = append(values, structpb.NewStringValue(string(b)))
+	}
+	fields = append(fields,
+		actions.NewBoolReturnField(argDryRun, true),
+		actions.NewListReturnField(fieldChanges, values),
+	)
+	return actions.NewReturnValues(true, fields...)
+}
+
+// dryRunChanges reads the changes back from a dryRunResult.
+func dryRunChanges(rv *structpb.Struct) []fieldChange {
+	var changes []fieldChange
+	for _, v := range rv.GetFields()[fieldChanges].GetListValue().GetValues() {
+		var c fieldChangeJSON
+		if err := json.Unmarshal([]byte(v.GetStringValue()), &c); err != nil {
+			continue
+		}
+		changes = append(changes, fieldChange{field: c.Field, oldValue: c.OldValue, newValue: c.NewValue})
+	}
+	return changes
+}
+
+// diffField is the change of field from oldValue to newValue, or none when
+// they are equal.
+func diffField(field, oldValue, newValue string) []fieldChange {
+	if oldValue == newValue {
+		return nil
+	}
+	return []fieldChange{{field: field, oldValue: oldValue, newValue: newValue}}
+}
+
+func diffBoolField(field string, oldValue, newValue bool) []fieldChange {
+	return diffField(field, strconv.FormatBool(oldValue), strconv.FormatBool(newValue))
+}
+
+// jsonValue renders a structured API value, such as a user's addresses, for a
+// fieldChange. Empty values render as "".
+func jsonValue(v any) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" || string(b) == "[]" || string(b) == "{}" {
+		return ""
+	}
+	return string(b)
+}
@@ -109,7 +109,7 @@ func scanUsersForEvents(
 	}
 
 	if len(cursor.PendingUsers) == 0 {
-		usersResp, err := client.ListUserIDsPage(ctx, customerID, domain, cursor.DirectoryPageToken)
+		usersResp, err := client.ListUserIDsPage(ctx, customerID, domain, "", cursor.DirectoryPageToken)
 		if err != nil {
 			// Preserve the cursor as-is so a transient Directory API failure does not rewind
 			// the walk back to the start on retry.
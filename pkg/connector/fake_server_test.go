@@ -0,0 +1,133 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/conductorone/baton-google-workspace/pkg/fake"
+)
+
+func newFakeWorkspace(t *testing.T) *fake.Server {
+	t.Helper()
+	srv := fake.New("C0fake")
+	t.Cleanup(srv.Close)
+	srv.AddDomain("example.com", true)
+	srv.AddUser(&directoryAdmin.User{PrimaryEmail: "admin@example.com", IsAdmin: true, Name: &directoryAdmin.UserName{GivenName: "Ada", FamilyName: "Admin"}})
+	alice := srv.AddUser(&directoryAdmin.User{PrimaryEmail: "alice@example.com", Name: &directoryAdmin.UserName{GivenName: "Alice", FamilyName: "A"}})
+	srv.AddUser(&directoryAdmin.User{PrimaryEmail: "bob@example.com", Name: &directoryAdmin.UserName{GivenName: "Bob", FamilyName: "B"}})
+	srv.AddGroup(&directoryAdmin.Group{Email: "eng@example.com", Name: "Engineering"})
+	srv.AddMember("eng@example.com", &directoryAdmin.Member{Email: "alice@example.com", Role: "OWNER"})
+	srv.AddMember("eng@example.com", &directoryAdmin.Member{Email: "bob@example.com"})
+	role := srv.AddRole(&directoryAdmin.Role{RoleName: "Helpdesk", RolePrivileges: []*directoryAdmin.RoleRolePrivileges{{PrivilegeName: "USERS_RETRIEVE", ServiceId: "00haapch16h1ysv"}}})
+	srv.AddRoleAssignment(&directoryAdmin.RoleAssignment{RoleId: role.RoleId, AssignedTo: alice.Id})
+	return srv
+}
+
+func newFakeWorkspaceConnector(t *testing.T, srv *fake.Server) *GoogleWorkspace {
+	t.Helper()
+	c, err := NewConnector(context.Background(), Config{
+		CustomerID:         srv.CustomerID,
+		AdministratorEmail: "admin@example.com",
+		Credentials:        srv.CredentialsJSON(),
+		BaseURL:            srv.URL(),
+	})
+	require.NoError(t, err)
+	return c
+}
+
+// syncAll lists every resource of each syncer, then each resource's grants,
+// returning grant principals by entitlement ID.
+func syncAll(t *testing.T, syncers []connectorbuilder.ResourceSyncerV2) (map[string][]*v2.Resource, map[string][]string) {
+	t.Helper()
+	ctx := context.Background()
+	ss := newFakeSessionStore()
+	resources := map[string][]*v2.Resource{}
+	grants := map[string][]string{}
+	for _, syncer := range syncers {
+		typ := syncer.ResourceType(ctx).GetId()
+		token := ""
+		for {
+			page, results, err := syncer.List(ctx, nil, rs.SyncOpAttrs{Session: ss, PageToken: pagination.Token{Token: token}})
+			require.NoError(t, err, typ)
+			resources[typ] = append(resources[typ], page...)
+			if results == nil || results.NextPageToken == "" {
+				break
+			}
+			token = results.NextPageToken
+		}
+		for _, r := range resources[typ] {
+			token := ""
+			for {
+				page, results, err := syncer.Grants(ctx, r, rs.SyncOpAttrs{Session: ss, PageToken: pagination.Token{Token: token}})
+				require.NoError(t, err, typ)
+				for _, g := range page {
+					grants[g.Entitlement.Id] = append(grants[g.Entitlement.Id], g.Principal.Id.Resource)
+				}
+				if results == nil || results.NextPageToken == "" {
+					break
+				}
+				token = results.NextPageToken
+			}
+		}
+	}
+	return resources, grants
+}
+
+// TestFakeServer_SyncAndActions runs the connector end to end against the
+// fake server: services are authorized through its token endpoint, syncs
+// read its state and actions change it.
+func TestFakeServer_SyncAndActions(t *testing.T) {
+	srv := newFakeWorkspace(t)
+	c := newFakeWorkspaceConnector(t, srv)
+	ctx := context.Background()
+
+	_, err := c.Validate(ctx)
+	require.NoError(t, err)
+
+	resources, grants := syncAll(t, c.ResourceSyncers(ctx))
+	require.Len(t, resources[resourceTypeUser.Id], 3)
+	require.Len(t, resources[resourceTypeGroup.Id], 1)
+	group := resources[resourceTypeGroup.Id][0]
+	alice := srv.User("alice@example.com")
+	bob := srv.User("bob@example.com")
+	require.ElementsMatch(t, []string{alice.Id, bob.Id}, grants["group:"+group.Id.Resource+":member"])
+	require.Equal(t, []string{alice.Id}, grants["group:"+group.Id.Resource+":owner"])
+
+	_, _, err = c.disableUserActionHandler(ctx, &structpb.Struct{Fields: map[string]*structpb.Value{argUserID: strArg(bob.Id)}})
+	require.NoError(t, err)
+	require.True(t, srv.User(bob.Id).Suspended)
+
+	_, _, err = c.transferUserDriveFiles(ctx, &structpb.Struct{Fields: map[string]*structpb.Value{
+		argResourceID: strArg(bob.Id), argTargetResourceID: strArg(alice.Id),
+	}})
+	require.NoError(t, err)
+	require.Len(t, srv.Transfers(), 1)
+
+	groups := groupBuilder(c.client, c.customerID, c.domain)
+	args := &structpb.Struct{Fields: map[string]*structpb.Value{"email": strArg("ops@example.com"), "name": strArg("Ops")}}
+	_, _, err = groups.createGroupActionHandler(ctx, args)
+	require.NoError(t, err)
+	require.NotNil(t, srv.Group("ops@example.com"))
+	_, _, err = groups.createGroupActionHandler(ctx, args)
+	require.Error(t, err, "creating an existing group fails with the server's 409")
+}
+
+func TestFakeServer_UngrantedScopesSkipServices(t *testing.T) {
+	srv := newFakeWorkspace(t)
+	srv.GrantScopes(directoryAdmin.AdminDirectoryDomainReadonlyScope, directoryAdmin.AdminDirectoryUserReadonlyScope)
+	c := newFakeWorkspaceConnector(t, srv)
+
+	client, err := c.getClient(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, client.UserService)
+	require.Nil(t, client.GroupService)
+	require.Nil(t, client.UserProvisioningService)
+}
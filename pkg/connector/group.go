@@ -10,14 +10,17 @@ import (
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/session"
 	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-sdk/pkg/types/sessions"
 	uhttp "github.com/conductorone/baton-sdk/pkg/uhttp"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
 	admin "google.golang.org/api/admin/directory/v1"
 	"google.golang.org/api/googleapi"
+	groupssettings "google.golang.org/api/groupssettings/v1"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -25,10 +28,40 @@ import (
 )
 
 const (
-	groupMemberEntitlement = "member"
+	groupMemberEntitlement  = "member"
+	groupOwnerEntitlement   = "owner"
+	groupManagerEntitlement = "manager"
 )
 
+// Directory API Member.Role values.
+const (
+	groupRoleMember  = "MEMBER"
+	groupRoleOwner   = "OWNER"
+	groupRoleManager = "MANAGER"
+)
+
+// groupRoleEntitlements maps an elevated Member.Role to its entitlement slug.
+// Every membership, whatever its role, also carries the "member" entitlement.
+var groupRoleEntitlements = map[string]string{
+	groupRoleOwner:   groupOwnerEntitlement,
+	groupRoleManager: groupManagerEntitlement,
+}
+
+// groupEntitlementRoles is the inverse of groupRoleEntitlements, plus "member".
+var groupEntitlementRoles = map[string]string{
+	groupMemberEntitlement:  groupRoleMember,
+	groupOwnerEntitlement:   groupRoleOwner,
+	groupManagerEntitlement: groupRoleManager,
+}
+
+// groupMembersFirstPageNamespace maps a group ID to the first page of its
+// members, listed in a batch by List and taken by Grants.
+var groupMembersFirstPageNamespace = sessions.WithPrefix("group_members_first_page")
+
 type groupResourceType struct {
+	tenantPartitions
+	syncFilters
+	syncOptions
 	resourceType *v2.ResourceType
 	client       *gwclient.GoogleWorkspaceClient
 	customerId   string
@@ -40,6 +73,10 @@ func (o *groupResourceType) ResourceType(_ context.Context) *v2.ResourceType {
 }
 
 func (o *groupResourceType) List(ctx context.Context, resourceId *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.listPartitions(ctx, resourceId, attrs)
+	}
+
 	bag := &pagination.Bag{}
 	err := bag.Unmarshal(attrs.PageToken.Token)
 	if err != nil {
@@ -53,7 +90,7 @@ func (o *groupResourceType) List(ctx context.Context, resourceId *v2.ResourceId,
 	l := ctxzap.Extract(ctx)
 	// https://developers.google.com/admin-sdk/directory/v1/limits
 	// Groups and group members – A default and maximum of 200 entries per page.
-	groups, err := o.client.ListGroups(ctx, o.customerId, o.domain, bag.PageToken())
+	groups, err := o.client.ListGroups(ctx, o.customerId, o.domain, o.groupFilter.apiQuery(), bag.PageToken())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -69,16 +106,24 @@ func (o *groupResourceType) List(ctx context.Context, resourceId *v2.ResourceId,
 	}
 
 	rv := make([]*v2.Resource, 0, len(groups.Groups))
+	groupIDs := make([]string, 0, len(groups.Groups))
 	for _, g := range groups.Groups {
 		if g.Id == "" {
 			l.Error("group had no id", zap.String("name", g.Name))
 			continue
 		}
-		groupResource, err := groupToResource(ctx, g)
+		if !o.groupFilter.matches(g.Email) {
+			continue
+		}
+		groupResource, err := groupToResource(ctx, g, o.groupSettingsForProfile(ctx, g.Email))
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to create group resource in List: %w", err)
 		}
 		rv = append(rv, groupResource)
+		groupIDs = append(groupIDs, g.Id)
+	}
+	if err := o.prefetchMembers(ctx, attrs.Session, groupIDs); err != nil {
+		return nil, nil, err
 	}
 	nextPage, err := bag.NextToken(groups.NextPageToken)
 	if err != nil {
@@ -87,7 +132,11 @@ func (o *groupResourceType) List(ctx context.Context, resourceId *v2.ResourceId,
 	return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
 }
 
-func (o *groupResourceType) Entitlements(ctx context.Context, resource *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+func (o *groupResourceType) Entitlements(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.entitlementsPartitions(ctx, resource, attrs)
+	}
+
 	var annos annotations.Annotations
 	annos.Update(&v2.V1Identifier{
 		Id: V1MembershipEntitlementID(resource.Id.Resource),
@@ -96,24 +145,51 @@ func (o *groupResourceType) Entitlements(ctx context.Context, resource *v2.Resou
 	member.Description = fmt.Sprintf("Is member of the %s group in Google Workspace", resource.DisplayName)
 	member.Annotations = annos
 	member.DisplayName = fmt.Sprintf("%s Group Member", resource.DisplayName)
-	return []*v2.Entitlement{member}, nil, nil
+
+	owner := sdkEntitlement.NewAssignmentEntitlement(resource, groupOwnerEntitlement, sdkEntitlement.WithGrantableTo(resourceTypeUser))
+	owner.Description = fmt.Sprintf("Is an owner of the %s group in Google Workspace", resource.DisplayName)
+	owner.DisplayName = fmt.Sprintf("%s Group Owner", resource.DisplayName)
+
+	manager := sdkEntitlement.NewAssignmentEntitlement(resource, groupManagerEntitlement, sdkEntitlement.WithGrantableTo(resourceTypeUser))
+	manager.Description = fmt.Sprintf("Is a manager of the %s group in Google Workspace", resource.DisplayName)
+	manager.DisplayName = fmt.Sprintf("%s Group Manager", resource.DisplayName)
+
+	return []*v2.Entitlement{member, owner, manager}, nil, nil
 }
 
 func (o *groupResourceType) Grants(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.grantsPartitions(ctx, resource, attrs)
+	}
+
 	bag := &pagination.Bag{}
 	err := bag.Unmarshal(attrs.PageToken.Token)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to unmarshal pagination token in group Grants: %w", err)
 	}
 
-	if bag.Current() == nil {
+	// With user filters, members the filters leave out are dropped. They
+	// are told apart through the user email index, built first unless an
+	// earlier call of this sync built it.
+	if o.userFilter.active() {
+		if err := o.userEmails().begin(ctx, attrs.Session, bag, resource); err != nil {
+			return nil, nil, err
+		}
+		if bag.ResourceTypeID() == resourceTypeUser.Id {
+			nextPage, err := o.userEmails().indexPage(ctx, attrs.Session, bag)
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+		}
+	} else if bag.Current() == nil {
 		bag.Push(pagination.PageState{
 			ResourceTypeID: resource.Id.ResourceType,
 			ResourceID:     resource.Id.Resource,
 		})
 	}
 
-	members, err := o.client.ListMembers(ctx, resource.Id.Resource, bag.PageToken())
+	members, err := o.listMembers(ctx, attrs.Session, resource.Id.Resource, bag.PageToken())
 	if err != nil {
 		gerr := &googleapi.Error{}
 		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
@@ -123,6 +199,11 @@ func (o *groupResourceType) Grants(ctx context.Context, resource *v2.Resource, a
 		return nil, nil, fmt.Errorf("google-workspace: failed to list group members: %w", err)
 	}
 
+	members.Members, err = o.syncedMembers(ctx, attrs.Session, members.Members)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var rv []*v2.Grant
 	for _, member := range members.Members {
 		opts := []sdkGrant.GrantOption{}
@@ -151,6 +232,18 @@ func (o *groupResourceType) Grants(ctx context.Context, resource *v2.Resource, a
 
 		grant := sdkGrant.NewGrant(resource, groupMemberEntitlement, gmID, opts...)
 		rv = append(rv, grant)
+
+		// Owners and managers keep their "member" grant above (they are
+		// members too) and additionally get the entitlement for their role.
+		// The role entitlements are grantable to users only, so a nested
+		// group or the whole customer holding a role is modelled through
+		// its "member" grant alone.
+		if !strings.EqualFold(member.Type, "user") {
+			continue
+		}
+		if slug, ok := groupRoleEntitlements[strings.ToUpper(member.Role)]; ok {
+			rv = append(rv, sdkGrant.NewGrant(resource, slug, gmID))
+		}
 	}
 
 	nextPage, err := bag.NextToken(members.NextPageToken)
@@ -161,6 +254,107 @@ func (o *groupResourceType) Grants(ctx context.Context, resource *v2.Resource, a
 	return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
 }
 
+// prefetchMembers lists the first page of members of each of groupIDs in a
+// batch and stores the pages in the session, so that Grants doesn't list them
+// one group at a time. Without a batcher, there are no round trips to save.
+// A group whose page fails is left out, for Grants to list and report.
+func (o *groupResourceType) prefetchMembers(ctx context.Context, ss sessions.SessionStore, groupIDs []string) error {
+	if ss == nil || o.client.GroupMemberService == nil || o.client.GroupMemberBatcher == nil || len(groupIDs) == 0 {
+		return nil
+	}
+	results, err := o.client.BatchListMembers(ctx, groupIDs)
+	if err != nil {
+		return fmt.Errorf("google-workspace: failed to list group members in a batch: %w", err)
+	}
+	pages := make(map[string]*admin.Members, len(results))
+	for i, r := range results {
+		if r.Err != nil {
+			ctxzap.Extract(ctx).Debug("google-workspace: failed to list group members in a batch, listing them in Grants",
+				zap.String("group_id", groupIDs[i]), zap.Error(r.Err))
+			continue
+		}
+		pages[groupIDs[i]] = r.Value
+	}
+	if err := session.SetManyJSON(ctx, ss, pages, groupMembersFirstPageNamespace); err != nil {
+		return fmt.Errorf("google-workspace: failed to store group members in session: %w", err)
+	}
+	return nil
+}
+
+// listMembers lists a page of a group's members, taking the first page from
+// the session when List prefetched it.
+func (o *groupResourceType) listMembers(ctx context.Context, ss sessions.SessionStore, groupID, pageToken string) (*admin.Members, error) {
+	if pageToken == "" && ss != nil {
+		page, found, err := session.GetJSON[*admin.Members](ctx, ss, groupID, groupMembersFirstPageNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to read group members from session: %w", err)
+		}
+		if found && page != nil {
+			if err := session.DeleteJSON(ctx, ss, groupID, groupMembersFirstPageNamespace); err != nil {
+				return nil, fmt.Errorf("google-workspace: failed to delete group members from session: %w", err)
+			}
+			return page, nil
+		}
+	}
+	return o.client.ListMembers(ctx, groupID, pageToken)
+}
+
+// syncedMembers drops the members that sync filters leave out of the sync:
+// nested groups whose email doesn't match the group filter and users of the
+// customer that the user filters leave out. Members the directory doesn't
+// know, such as external users, are kept as they are without filters.
+func (o *groupResourceType) syncedMembers(ctx context.Context, ss sessions.SessionStore, members []*admin.Member) ([]*admin.Member, error) {
+	if !o.userFilter.active() && !o.groupFilter.active() {
+		return members, nil
+	}
+	var leftOut map[string]bool
+	if o.userFilter.active() {
+		var emails []string
+		for _, m := range members {
+			if strings.EqualFold(m.Type, "user") {
+				emails = append(emails, m.Email)
+			}
+		}
+		var err error
+		_, leftOut, err = o.userEmails().resolve(ctx, ss, emails)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l := ctxzap.Extract(ctx)
+	rv := make([]*admin.Member, 0, len(members))
+	var skippedGroups, skippedUsers int
+	for _, m := range members {
+		switch {
+		case strings.EqualFold(m.Type, "group"):
+			if !o.groupFilter.matches(m.Email) {
+				l.Debug("google-workspace: group member outside the synced groups, skipping", zap.String("email", m.Email))
+				skippedGroups++
+				continue
+			}
+		case strings.EqualFold(m.Type, "user"):
+			if leftOut[strings.ToLower(m.Email)] {
+				l.Debug("google-workspace: group member left out by the user sync filters, skipping", zap.String("email", m.Email))
+				skippedUsers++
+				continue
+			}
+		}
+		rv = append(rv, m)
+	}
+	if skippedGroups > 0 || skippedUsers > 0 {
+		l.Info("google-workspace: skipped group members left out by sync filters",
+			zap.Int("users", skippedUsers),
+			zap.Int("groups", skippedGroups),
+		)
+	}
+	return rv, nil
+}
+
+func (o *groupResourceType) userEmails() userEmailIndex {
+	return userEmailIndex{client: o.client, customerId: o.customerId, domain: o.domain, domains: o.tenantDomains, filter: o.userFilter}
+}
+
 func groupBuilder(client *gwclient.GoogleWorkspaceClient, customerId string, domain string) *groupResourceType {
 	return &groupResourceType{
 		resourceType: resourceTypeGroup,
@@ -170,16 +364,41 @@ func groupBuilder(client *gwclient.GoogleWorkspaceClient, customerId string, dom
 	}
 }
 
-func groupProfile(group *admin.Group) map[string]interface{} {
+// groupProfile is the group's profile. settings, when set, adds every Groups
+// Settings value under the modify_group_settings argument names.
+func groupProfile(group *admin.Group, settings *groupssettings.Groups) map[string]interface{} {
 	profile := make(map[string]interface{})
+	if settings != nil {
+		profile = groupSettingsProfile(settings)
+	}
 	profile["group_id"] = group.Id
 	profile["group_name"] = group.Name
 	profile["group_email"] = group.Email
 	return profile
 }
 
-// groupToResource converts an admin.Group to a v2.Resource.
-func groupToResource(ctx context.Context, group *admin.Group) (*v2.Resource, error) {
+// groupSettingsForProfile fetches a group's settings for its profile when
+// the groupSettings option is on. It returns nil without the Groups Settings
+// service, and when the group's settings can't be read: the API answers 403
+// or 404 for groups it doesn't manage, 403 when it is disabled for the
+// project, and a failed call shouldn't fail the sync over optional profile
+// fields, so the group is synced without them.
+func (o *groupResourceType) groupSettingsForProfile(ctx context.Context, groupEmail string) *groupssettings.Groups {
+	if !o.groupSettings || o.client.GroupsSettingsService == nil {
+		return nil
+	}
+	settings, err := o.client.GetGroupSettings(ctx, groupEmail)
+	if err != nil {
+		ctxzap.Extract(ctx).Warn("google-workspace: skipping settings in group profile",
+			zap.String("group_email", groupEmail), zap.Error(err))
+		return nil
+	}
+	return settings
+}
+
+// groupToResource converts an admin.Group, and its settings when known, to a
+// v2.Resource.
+func groupToResource(ctx context.Context, group *admin.Group, settings *groupssettings.Groups) (*v2.Resource, error) {
 	l := ctxzap.Extract(ctx)
 	if group.Id == "" {
 		l.Error("google-workspace: group has no id", zap.String("name", group.Name))
@@ -187,7 +406,7 @@ func groupToResource(ctx context.Context, group *admin.Group) (*v2.Resource, err
 	}
 	var traitOpts []rs.GroupTraitOption
 	resourceOpts := []rs.ResourceOption{
-		rs.WithResourceProfile(groupProfile(group)),
+		rs.WithResourceProfile(groupProfile(group, settings)),
 		rs.WithAnnotation(&v2.V1Identifier{
 			Id: group.Id,
 		}),
@@ -198,34 +417,65 @@ func groupToResource(ctx context.Context, group *admin.Group) (*v2.Resource, err
 	return rs.NewGroupResource(group.Name, resourceTypeGroup, group.Id, traitOpts, resourceOpts...)
 }
 
+// Grant adds the user to the group with the role matching the entitlement.
+// Granting owner or manager to an existing member promotes them in place;
+// granting member to an existing owner or manager leaves their role alone.
 func (o *groupResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	if p, ok := o.provisioner(entitlement.GetResource()).(*groupResourceType); ok {
+		return p.Grant(ctx, principal, entitlement)
+	}
+
 	if o.client.GroupMemberProvisioningService == nil {
 		return nil, nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", admin.AdminDirectoryGroupMemberScope))
 	}
 	if principal.GetId().GetResourceType() != resourceTypeUser.Id {
 		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "user principal is required")
 	}
+	slug := entitlementSlug(entitlement)
+	if slug == "" {
+		slug = groupMemberEntitlement
+	}
+	role, ok := groupEntitlementRoles[slug]
+	if !ok {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("google-workspace: unknown group entitlement %q", slug))
+	}
+	groupId := entitlement.Resource.Id.Resource
+	memberKey := principal.GetId().GetResource()
 
-	assignment, err := o.client.InsertMember(ctx, entitlement.Resource.Id.Resource, &admin.Member{Id: principal.GetId().GetResource()})
+	assignment, err := o.client.InsertMember(ctx, groupId, &admin.Member{Id: memberKey, Role: role})
 	if err != nil {
 		gerr := &googleapi.Error{}
 		if errors.As(err, &gerr) && gerr.Code == http.StatusConflict {
 			// Member already exists, fetch it to return as grant (idempotency)
-			assignment, err = o.client.GetMember(ctx, entitlement.Resource.Id.Resource, principal.GetId().GetResource())
+			assignment, err = o.client.GetMember(ctx, groupId, memberKey)
 			if err != nil {
 				return nil, nil, fmt.Errorf("google-workspace: failed to get existing group member: %w", err)
 			}
+			if role != groupRoleMember && !strings.EqualFold(assignment.Role, role) {
+				assignment, err = o.client.PatchMember(ctx, groupId, memberKey, &admin.Member{Role: role})
+				if err != nil {
+					return nil, nil, fmt.Errorf("google-workspace: failed to update group member role: %w", err)
+				}
+			}
 		} else {
 			return nil, nil, fmt.Errorf("google-workspace: failed to insert group member: %w", err)
 		}
 	}
 
-	grant := sdkGrant.NewGrant(entitlement.Resource, groupMemberEntitlement, principal.GetId())
-	grant.Id = assignment.Id
+	grant := sdkGrant.NewGrant(entitlement.Resource, slug, principal.GetId())
+	if slug == groupMemberEntitlement {
+		grant.Id = assignment.Id
+	}
 	return []*v2.Grant{grant}, nil, nil
 }
 
+// Revoke removes the user from the group for "member", and demotes them back
+// to a plain member for "owner" or "manager".
 func (o *groupResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	if p, ok := o.provisioner(grant.GetEntitlement().GetResource()).(*groupResourceType); ok {
+		return p.Revoke(ctx, grant)
+	}
+
 	if o.client.GroupMemberProvisioningService == nil {
 		return nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", admin.AdminDirectoryGroupMemberScope))
 	}
@@ -233,15 +483,22 @@ func (o *groupResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annota
 		return nil, uhttp.WrapErrors(codes.InvalidArgument, "user principal is required")
 	}
 	l := ctxzap.Extract(ctx)
+	groupId := grant.Entitlement.Resource.Id.Resource
+	memberKey := grant.Principal.GetId().GetResource()
 
-	err := o.client.DeleteMember(ctx, grant.Entitlement.Resource.Id.Resource, grant.Principal.GetId().GetResource())
+	slug := entitlementSlug(grant.Entitlement)
+	if role, ok := groupEntitlementRoles[slug]; ok && role != groupRoleMember {
+		return nil, o.demoteMember(ctx, groupId, memberKey, role)
+	}
+
+	err := o.client.DeleteMember(ctx, groupId, memberKey)
 	if err != nil {
 		gerr := &googleapi.Error{}
 		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
 			// This should only hit if someone double-revokes, but I'd rather we log something about it
 			l.Info("google-workspace-v2: group member is being deleted but doesn't exist",
-				zap.String("group_id", grant.Entitlement.Resource.Id.Resource),
-				zap.String(argUserID, grant.Principal.GetId().GetResource()))
+				zap.String("group_id", groupId),
+				zap.String(argUserID, memberKey))
 			return nil, nil
 		}
 		return nil, fmt.Errorf("google-workspace: failed to delete group member: %w", err)
@@ -250,6 +507,32 @@ func (o *groupResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annota
 	return nil, nil
 }
 
+// demoteMember sets memberKey's role back to MEMBER if it currently holds
+// role. A member who no longer holds role (or is no longer in the group) is
+// treated as already revoked.
+func (o *groupResourceType) demoteMember(ctx context.Context, groupId, memberKey, role string) error {
+	l := ctxzap.Extract(ctx)
+	member, err := o.client.GetMember(ctx, groupId, memberKey)
+	if err != nil {
+		gerr := &googleapi.Error{}
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			l.Info("google-workspace: group member role is being revoked but the member doesn't exist",
+				zap.String("group_id", groupId),
+				zap.String(argUserID, memberKey),
+				zap.String("role", role))
+			return nil
+		}
+		return fmt.Errorf("google-workspace: failed to get group member: %w", err)
+	}
+	if !strings.EqualFold(member.Role, role) {
+		return nil
+	}
+	if _, err := o.client.PatchMember(ctx, groupId, memberKey, &admin.Member{Role: groupRoleMember}); err != nil {
+		return fmt.Errorf("google-workspace: failed to update group member role: %w", err)
+	}
+	return nil
+}
+
 func (o *groupResourceType) Get(ctx context.Context, resourceId *v2.ResourceId, parentResourceId *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
 	g, err := o.client.GetGroup(ctx, resourceId.Resource)
 	if err != nil {
@@ -259,7 +542,7 @@ func (o *groupResourceType) Get(ctx context.Context, resourceId *v2.ResourceId,
 	// TODO: If o.domainId is set, check if the group is still in the domain.
 	//       There is not a straight forward way to do this when getting a single group.
 
-	groupResource, err := groupToResource(ctx, g)
+	groupResource, err := groupToResource(ctx, g, o.groupSettingsForProfile(ctx, g.Email))
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create group resource in Get: %w", err)
 	}
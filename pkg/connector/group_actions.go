@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
 	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
@@ -72,8 +74,8 @@ var (
 	modifyGroupSettingsActionSchema = &v2.BatonActionSchema{
 		Name:        "modify_group_settings",
 		DisplayName: "Modify Group Settings",
-		Description: "Update settings for an existing Google Group.",
-		Arguments: []*config.Field{
+		Description: "Update settings for an existing Google Group. Every Groups Settings API setting is accepted; enum settings are validated against the values the API allows.",
+		Arguments: append([]*config.Field{
 			{
 				Name:        "group_key",
 				DisplayName: "Group Key",
@@ -81,60 +83,8 @@ var (
 				Field:       &config.Field_StringField{},
 				IsRequired:  true,
 			},
-			{
-				Name:        "allow_external_members",
-				DisplayName: "Allow External Members",
-				Description: "If true, allows external members to join the group. Defaults to false.",
-				Field:       &config.Field_BoolField{},
-				IsRequired:  false,
-			},
-			{
-				Name:        "allow_web_posting",
-				DisplayName: "Allow Web Posting",
-				Description: "If true, allows posting via email from external (non-member) addresses. Defaults to false.",
-				Field:       &config.Field_BoolField{},
-				IsRequired:  false,
-			},
-			{
-				Name:        "who_can_post_message",
-				DisplayName: "Who Can Post Messages",
-				Description: "Control who can post messages.",
-				Field: &config.Field_StringField{
-					StringField: &config.StringField{
-						Rules: &config.StringRules{
-							In: []string{
-								"ANYONE_CAN_POST",
-								"ALL_MEMBERS_CAN_POST",
-								"ALL_MANAGERS_CAN_POST",
-								"ALL_OWNERS_CAN_POST",
-								"NONE_CAN_POST",
-								"ALL_IN_DOMAIN_CAN_POST",
-							},
-						},
-					},
-				},
-				IsRequired: false,
-			},
-			{
-				Name:        "message_moderation_level",
-				DisplayName: "Message Moderation Level",
-				Description: "Control moderation.",
-				Field: &config.Field_StringField{
-					StringField: &config.StringField{
-						Rules: &config.StringRules{
-							In: []string{
-								"MODERATE_NONE",
-								"MODERATE_NON_MEMBERS",
-								"MODERATE_ALL_MESSAGES",
-								"MODERATE_NEW_MEMBERS",
-							},
-						},
-					},
-				},
-				IsRequired: false,
-			},
-		},
-		ReturnTypes: []*config.Field{
+		}, groupSettingArguments()...),
+		ReturnTypes: append([]*config.Field{
 			{
 				Name:        fieldSuccess,
 				DisplayName: displaySuccess,
@@ -153,58 +103,48 @@ var (
 				Description: "Whether any settings were changed.",
 				Field:       &config.Field_BoolField{},
 			},
-			{
-				Name:        "previous_allow_external_members",
-				DisplayName: "Previous Allow External Members",
-				Description: "Previous value of allow_external_members setting.",
-				Field:       &config.Field_StringField{},
-			},
-			{
-				Name:        "new_allow_external_members",
-				DisplayName: "New Allow External Members",
-				Description: "New value of allow_external_members setting.",
-				Field:       &config.Field_StringField{},
-			},
-			{
-				Name:        "previous_allow_web_posting",
-				DisplayName: "Previous Allow Web Posting",
-				Description: "Previous value of allow_web_posting setting.",
-				Field:       &config.Field_StringField{},
-			},
-			{
-				Name:        "new_allow_web_posting",
-				DisplayName: "New Allow Web Posting",
-				Description: "New value of allow_web_posting setting.",
-				Field:       &config.Field_StringField{},
-			},
-			{
-				Name:        "previous_who_can_post_message",
-				DisplayName: "Previous Who Can Post Messages",
-				Description: "Previous value of who_can_post_message setting.",
-				Field:       &config.Field_StringField{},
-			},
-			{
-				Name:        "new_who_can_post_message",
-				DisplayName: "New Who Can Post Messages",
-				Description: "New value of who_can_post_message setting.",
-				Field:       &config.Field_StringField{},
-			},
-			{
-				Name:        "previous_message_moderation_level",
-				DisplayName: "Previous Message Moderation Level",
-				Description: "Previous value of message_moderation_level setting.",
+		}, groupSettingReturnFields()...),
+		ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_DYNAMIC},
+	}
+)
+
+// groupSettingArguments are the modify_group_settings arguments for every
+// writable setting.
+func groupSettingArguments() []*config.Field {
+	var rv []*config.Field
+	for _, s := range groupSettings {
+		if !s.readOnly {
+			rv = append(rv, s.argumentField())
+		}
+	}
+	return rv
+}
+
+// groupSettingReturnFields are the previous_ and new_ return fields for every
+// writable setting. Only the settings passed to the action are returned.
+func groupSettingReturnFields() []*config.Field {
+	var rv []*config.Field
+	for _, s := range groupSettings {
+		if s.readOnly {
+			continue
+		}
+		rv = append(rv,
+			&config.Field{
+				Name:        "previous_" + s.name,
+				DisplayName: "Previous " + s.displayName,
+				Description: fmt.Sprintf("Previous value of %s setting.", s.name),
 				Field:       &config.Field_StringField{},
 			},
-			{
-				Name:        "new_message_moderation_level",
-				DisplayName: "New Message Moderation Level",
-				Description: "New value of message_moderation_level setting.",
+			&config.Field{
+				Name:        "new_" + s.name,
+				DisplayName: "New " + s.displayName,
+				Description: fmt.Sprintf("New value of %s setting.", s.name),
 				Field:       &config.Field_StringField{},
 			},
-		},
-		ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_DYNAMIC},
+		)
 	}
-)
+	return rv
+}
 
 // ResourceActions implements the ResourceActionProvider interface for group resource actions.
 func (o *groupResourceType) ResourceActions(ctx context.Context, registry actions.ActionRegistry) error {
@@ -218,11 +158,11 @@ func (o *groupResourceType) ResourceActions(ctx context.Context, registry action
 }
 
 func (o *groupResourceType) registerCreateGroupAction(ctx context.Context, registry actions.ActionRegistry) error {
-	return registry.Register(ctx, createGroupActionSchema, o.createGroupActionHandler)
+	return registry.Register(ctx, withDryRun(createGroupActionSchema), o.createGroupActionHandler)
 }
 
 func (o *groupResourceType) registerModifyGroupSettingsAction(ctx context.Context, registry actions.ActionRegistry) error {
-	return registry.Register(ctx, modifyGroupSettingsActionSchema, o.modifyGroupSettingsActionHandler)
+	return registry.Register(ctx, withDryRun(modifyGroupSettingsActionSchema), o.modifyGroupSettingsActionHandler)
 }
 
 func (o *groupResourceType) createGroupActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
@@ -263,6 +203,22 @@ func (o *groupResourceType) createGroupActionHandler(ctx context.Context, args *
 		}
 	}
 
+	if isDryRun(args) {
+		// Creating an existing group fails, so a dry run checks for one.
+		_, err := o.client.GetGroup(ctx, email)
+		if err == nil {
+			return nil, nil, fmt.Errorf("google-workspace: group %s already exists", email)
+		}
+		var gerr *googleapi.Error
+		if !errors.As(err, &gerr) || gerr.Code != http.StatusNotFound {
+			return nil, nil, fmt.Errorf("google-workspace: failed to look up group %s: %w", email, err)
+		}
+		changes := diffField("email", "", email)
+		changes = append(changes, diffField("name", "", name)...)
+		changes = append(changes, diffField("description", "", description)...)
+		return dryRunResult(changes), nil, nil
+	}
+
 	// Create the group
 	group := &admin.Group{
 		Email:       email,
@@ -282,7 +238,7 @@ func (o *groupResourceType) createGroupActionHandler(ctx context.Context, args *
 	}
 	l.Debug("google-workspace: group action handler: created group", zap.Any("createdGroup", createdGroup))
 	// Create the group resource
-	resource, err := groupToResource(ctx, createdGroup)
+	resource, err := groupToResource(ctx, createdGroup, nil)
 	if err != nil {
 		l := ctxzap.Extract(ctx)
 		l.Error("failed to create group resource", zap.Error(err))
@@ -300,16 +256,15 @@ func (o *groupResourceType) createGroupActionHandler(ctx context.Context, args *
 }
 
 // applyGroupSettingsWithTracking applies group settings and returns what changed.
+// desired maps setting names to API values ("true"/"false" for booleans).
 // Returns (settingsUpdated bool, previousSettings map, newSettings map, error).
+// A dry run does not write the settings, and settingsUpdated reports whether
+// they would be updated.
 func (o *groupResourceType) applyGroupSettingsWithTracking(
 	ctx context.Context,
 	groupEmail string,
-	allowExternalMembers bool,
-	allowWebPosting bool,
-	whoCanPostMessage string,
-	messageModerationLevel string,
-	hasAllowExternal bool,
-	hasAllowWebPosting bool,
+	desired map[string]string,
+	dryRun bool,
 ) (bool, map[string]string, map[string]string, error) {
 	previousSettings := make(map[string]string)
 	newSettings := make(map[string]string)
@@ -324,71 +279,29 @@ func (o *groupResourceType) applyGroupSettingsWithTracking(
 	needsUpdate := false
 	updatedSettings := &groupssettings.Groups{}
 
-	// Apply boolean settings
-	if hasAllowExternal {
-		result := applyBooleanGroupSetting(
-			currentSettings.AllowExternalMembers,
-			allowExternalMembers,
-			"AllowExternalMembers",
-		)
-		previousSettings["allow_external_members"] = result.PreviousValue
-		newSettings["allow_external_members"] = result.NewValue
-		if result.NeedsUpdate {
-			needsUpdate = true
-			updatedSettings.AllowExternalMembers = result.NewValue
-			updatedSettings.ForceSendFields = append(updatedSettings.ForceSendFields, result.ForceSendField)
+	for _, setting := range groupSettings {
+		value, ok := desired[setting.name]
+		if !ok {
+			continue
 		}
-	}
-
-	if hasAllowWebPosting {
-		result := applyBooleanGroupSetting(
-			currentSettings.AllowWebPosting,
-			allowWebPosting,
-			"AllowWebPosting",
-		)
-		previousSettings["allow_web_posting"] = result.PreviousValue
-		newSettings["allow_web_posting"] = result.NewValue
-		if result.NeedsUpdate {
-			needsUpdate = true
-			updatedSettings.AllowWebPosting = result.NewValue
-			updatedSettings.ForceSendFields = append(updatedSettings.ForceSendFields, result.ForceSendField)
-		}
-	}
-
-	// Apply string settings
-	if whoCanPostMessage != "" {
-		result := applyStringGroupSetting(
-			currentSettings.WhoCanPostMessage,
-			whoCanPostMessage,
-			"WhoCanPostMessage",
-		)
-		previousSettings["who_can_post_message"] = result.PreviousValue
-		newSettings["who_can_post_message"] = result.NewValue
-		if result.NeedsUpdate {
-			needsUpdate = true
-			updatedSettings.WhoCanPostMessage = result.NewValue
-			updatedSettings.ForceSendFields = append(updatedSettings.ForceSendFields, result.ForceSendField)
+		var result GroupSettingUpdateResult
+		if setting.kind == groupSettingBool {
+			result = applyBooleanGroupSetting(*setting.value(currentSettings), value == groupSettingTrue, setting.field)
+		} else {
+			result = applyStringGroupSetting(*setting.value(currentSettings), value, setting.field)
 		}
-	}
-
-	if messageModerationLevel != "" {
-		result := applyStringGroupSetting(
-			currentSettings.MessageModerationLevel,
-			messageModerationLevel,
-			"MessageModerationLevel",
-		)
-		previousSettings["message_moderation_level"] = result.PreviousValue
-		newSettings["message_moderation_level"] = result.NewValue
+		previousSettings[setting.name] = result.PreviousValue
+		newSettings[setting.name] = result.NewValue
 		if result.NeedsUpdate {
 			needsUpdate = true
-			updatedSettings.MessageModerationLevel = result.NewValue
+			*setting.value(updatedSettings) = result.NewValue
 			updatedSettings.ForceSendFields = append(updatedSettings.ForceSendFields, result.ForceSendField)
 		}
 	}
 
 	// If no updates needed, return success (idempotent)
-	if !needsUpdate {
-		return false, previousSettings, newSettings, nil
+	if !needsUpdate || dryRun {
+		return needsUpdate, previousSettings, newSettings, nil
 	}
 
 	// Update settings
@@ -400,6 +313,45 @@ func (o *groupResourceType) applyGroupSettingsWithTracking(
 	return true, previousSettings, newSettings, nil
 }
 
+// groupSettingsArgs returns the settings passed to modify_group_settings as
+// API values, validating enum values and text lengths.
+func groupSettingsArgs(args *structpb.Struct) (map[string]string, error) {
+	desired := make(map[string]string)
+	for _, setting := range groupSettings {
+		if setting.readOnly {
+			continue
+		}
+		switch setting.kind {
+		case groupSettingBool:
+			if v, ok := getBoolField(args, setting.name); ok {
+				desired[setting.name] = strconv.FormatBool(v)
+			}
+		case groupSettingEnum:
+			v := getStringField(args, setting.name)
+			if v == "" {
+				continue
+			}
+			if !slices.Contains(setting.values, v) {
+				return nil, fmt.Errorf("invalid %s value '%s': must be one of %s", setting.name, v, strings.Join(setting.values, ", "))
+			}
+			desired[setting.name] = v
+		case groupSettingText:
+			v, err := optionalStringField(args, setting.name)
+			if err != nil {
+				return nil, err
+			}
+			if v == nil {
+				continue
+			}
+			if setting.maxLength > 0 && len([]rune(*v)) > setting.maxLength {
+				return nil, fmt.Errorf("%s must be at most %d characters", setting.name, setting.maxLength)
+			}
+			desired[setting.name] = *v
+		}
+	}
+	return desired, nil
+}
+
 // modifyGroupSettingsActionHandler updates settings for an existing Google Group (idempotent: checks current settings before updating).
 func (o *groupResourceType) modifyGroupSettingsActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
 	// Extract and validate group_key parameter
@@ -417,48 +369,12 @@ func (o *groupResourceType) modifyGroupSettingsActionHandler(ctx context.Context
 		return nil, nil, fmt.Errorf("group_key must be non-empty")
 	}
 
-	// Extract optional settings parameters
-	allowExternalMembers, hasAllowExternal := getBoolField(args, "allow_external_members")
-	allowWebPosting, hasAllowWebPosting := getBoolField(args, "allow_web_posting")
-	whoCanPostMessage := getStringField(args, "who_can_post_message")
-	messageModerationLevel := getStringField(args, "message_moderation_level")
-
-	// Check if at least one setting parameter is provided
-	if !hasAllowExternal && !hasAllowWebPosting && whoCanPostMessage == "" && messageModerationLevel == "" {
-		return nil, nil, fmt.Errorf("at least one settings parameter must be provided")
-	}
-
-	// Validate settings values if provided
-	if whoCanPostMessage != "" {
-		validWhoCanPost := map[string]bool{
-			"ANYONE_CAN_POST":        true,
-			"ALL_MEMBERS_CAN_POST":   true,
-			"ALL_MANAGERS_CAN_POST":  true,
-			"ALL_OWNERS_CAN_POST":    true,
-			"NONE_CAN_POST":          true,
-			"ALL_IN_DOMAIN_CAN_POST": true,
-		}
-		if !validWhoCanPost[whoCanPostMessage] {
-			return nil, nil, fmt.Errorf(
-				"invalid who_can_post_message value '%s': must be one of ANYONE_CAN_POST, ALL_MEMBERS_CAN_POST, ALL_MANAGERS_CAN_POST, ALL_OWNERS_CAN_POST, NONE_CAN_POST, ALL_IN_DOMAIN_CAN_POST",
-				whoCanPostMessage,
-			)
-		}
+	desired, err := groupSettingsArgs(args)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	if messageModerationLevel != "" {
-		validModeration := map[string]bool{
-			"MODERATE_NONE":         true,
-			"MODERATE_NON_MEMBERS":  true,
-			"MODERATE_ALL_MESSAGES": true,
-			"MODERATE_NEW_MEMBERS":  true,
-		}
-		if !validModeration[messageModerationLevel] {
-			return nil, nil, fmt.Errorf(
-				"invalid message_moderation_level value '%s': must be one of MODERATE_NONE, MODERATE_NON_MEMBERS, MODERATE_ALL_MESSAGES, MODERATE_NEW_MEMBERS",
-				messageModerationLevel,
-			)
-		}
+	if len(desired) == 0 {
+		return nil, nil, fmt.Errorf("at least one settings parameter must be provided")
 	}
 
 	// Verify group exists and get its email
@@ -472,16 +388,7 @@ func (o *groupResourceType) modifyGroupSettingsActionHandler(ctx context.Context
 	}
 
 	// Apply settings with tracking
-	settingsUpdated, previousSettings, newSettings, err := o.applyGroupSettingsWithTracking(
-		ctx,
-		group.Email,
-		allowExternalMembers,
-		allowWebPosting,
-		whoCanPostMessage,
-		messageModerationLevel,
-		hasAllowExternal,
-		hasAllowWebPosting,
-	)
+	settingsUpdated, previousSettings, newSettings, err := o.applyGroupSettingsWithTracking(ctx, group.Email, desired, isDryRun(args))
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to update group settings: %w", err)
 	}
@@ -494,19 +401,22 @@ func (o *groupResourceType) modifyGroupSettingsActionHandler(ctx context.Context
 	}}
 
 	// Add previous and new values for settings that were provided
-	settingNames := []string{
-		"allow_external_members",
-		"allow_web_posting",
-		"who_can_post_message",
-		"message_moderation_level",
+	if isDryRun(args) {
+		var changes []fieldChange
+		for _, setting := range groupSettings {
+			if _, ok := previousSettings[setting.name]; ok {
+				changes = append(changes, diffField(setting.name, previousSettings[setting.name], newSettings[setting.name])...)
+			}
+		}
+		return dryRunResult(changes, actions.NewStringReturnField("group_email", group.Email)), nil, nil
 	}
 
-	for _, settingName := range settingNames {
-		if prevVal, ok := previousSettings[settingName]; ok {
-			response.Fields["previous_"+settingName] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: prevVal}}
+	for _, setting := range groupSettings {
+		if prevVal, ok := previousSettings[setting.name]; ok {
+			response.Fields["previous_"+setting.name] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: prevVal}}
 		}
-		if newVal, ok := newSettings[settingName]; ok {
-			response.Fields["new_"+settingName] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: newVal}}
+		if newVal, ok := newSettings[setting.name]; ok {
+			response.Fields["new_"+setting.name] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: newVal}}
 		}
 	}
 
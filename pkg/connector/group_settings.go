@@ -0,0 +1,219 @@
+package connector
+
+import (
+	"strings"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	groupssettings "google.golang.org/api/groupssettings/v1"
+)
+
+// groupSettingKind is how a Groups Settings field is typed in the action and
+// the group profile. The API itself sends every setting as a string.
+type groupSettingKind int
+
+const (
+	// groupSettingBool is a "true"/"false" setting, a bool argument and profile value.
+	groupSettingBool groupSettingKind = iota
+	// groupSettingEnum is a setting restricted to groupSetting.values.
+	groupSettingEnum
+	// groupSettingText is a free-text setting. An empty argument clears it.
+	groupSettingText
+)
+
+// groupSetting describes one field of the Groups Settings API resource.
+type groupSetting struct {
+	// name is the modify_group_settings argument and the group profile key.
+	name string
+	// field is the groupssettings.Groups field name, for ForceSendFields.
+	field       string
+	displayName string
+	description string
+	kind        groupSettingKind
+	values      []string
+	// maxLength bounds text settings; zero means unbounded.
+	maxLength int
+	// readOnly settings are synced but not accepted by modify_group_settings.
+	readOnly bool
+	value    func(*groupssettings.Groups) *string
+}
+
+var (
+	groupSettingsModerationValues = []string{"ALL_MEMBERS", "OWNERS_AND_MANAGERS", "OWNERS_ONLY", "NONE"}
+	groupSettingsTopicValues      = []string{"ALL_MEMBERS", "OWNERS_AND_MANAGERS", "MANAGERS_ONLY", "OWNERS_ONLY", "NONE"}
+)
+
+// groupSettings lists every Groups Settings field except the email, name and
+// description the Directory API owns. Settings Google has deprecated are kept
+// because the API still accepts them, merging them into whoCanModerateMembers,
+// whoCanModerateContent and whoCanAssistContent.
+//
+// https://developers.google.com/workspace/admin/groups-settings/v1/reference/groups
+var groupSettings = []groupSetting{
+	enumGroupSetting("who_can_join", "WhoCanJoin", "Who Can Join", "Who can join the group.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanJoin }, "ANYONE_CAN_JOIN", "ALL_IN_DOMAIN_CAN_JOIN", "INVITED_CAN_JOIN", "CAN_REQUEST_TO_JOIN"),
+	enumGroupSetting("who_can_view_membership", "WhoCanViewMembership", "Who Can View Membership", "Who can view the members of the group.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanViewMembership }, "ALL_IN_DOMAIN_CAN_VIEW", "ALL_MEMBERS_CAN_VIEW", "ALL_MANAGERS_CAN_VIEW", "ALL_OWNERS_CAN_VIEW"),
+	enumGroupSetting("who_can_view_group", "WhoCanViewGroup", "Who Can View Group", "Who can view the group's messages.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanViewGroup }, "ANYONE_CAN_VIEW", "ALL_IN_DOMAIN_CAN_VIEW", "ALL_MEMBERS_CAN_VIEW", "ALL_MANAGERS_CAN_VIEW", "ALL_OWNERS_CAN_VIEW"),
+	enumGroupSetting("who_can_discover_group", "WhoCanDiscoverGroup", "Who Can Discover Group", "Who can find the group in a search.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanDiscoverGroup }, "ANYONE_CAN_DISCOVER", "ALL_IN_DOMAIN_CAN_DISCOVER", "ALL_MEMBERS_CAN_DISCOVER"),
+	enumGroupSetting("who_can_post_message", "WhoCanPostMessage", "Who Can Post Messages", "Who can post messages to the group.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanPostMessage }, "NONE_CAN_POST", "ALL_MANAGERS_CAN_POST", "ALL_MEMBERS_CAN_POST", "ALL_OWNERS_CAN_POST", "ALL_IN_DOMAIN_CAN_POST", "ANYONE_CAN_POST"),
+	enumGroupSetting("who_can_contact_owner", "WhoCanContactOwner", "Who Can Contact Owner", "Who can contact the group's owners.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanContactOwner }, "ALL_IN_DOMAIN_CAN_CONTACT", "ALL_MANAGERS_CAN_CONTACT", "ALL_MEMBERS_CAN_CONTACT", "ANYONE_CAN_CONTACT"),
+	enumGroupSetting("who_can_leave_group", "WhoCanLeaveGroup", "Who Can Leave Group", "Who can leave the group.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanLeaveGroup }, "ALL_MANAGERS_CAN_LEAVE", "ALL_MEMBERS_CAN_LEAVE", "NONE_CAN_LEAVE"),
+	enumGroupSetting("who_can_moderate_members", "WhoCanModerateMembers", "Who Can Moderate Members", "Who can add, remove, approve and ban members.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanModerateMembers }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_moderate_content", "WhoCanModerateContent", "Who Can Moderate Content", "Who can approve, delete, lock and move messages and topics.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanModerateContent }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_assist_content", "WhoCanAssistContent", "Who Can Assist Content", "Who can tag, assign and mark topics.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanAssistContent }, groupSettingsTopicValues...),
+	enumGroupSetting("message_moderation_level", "MessageModerationLevel", "Message Moderation Level", "Which messages are held for moderation.",
+		func(g *groupssettings.Groups) *string { return &g.MessageModerationLevel }, "MODERATE_ALL_MESSAGES", "MODERATE_NON_MEMBERS", "MODERATE_NEW_MEMBERS", "MODERATE_NONE"),
+	enumGroupSetting("spam_moderation_level", "SpamModerationLevel", "Spam Moderation Level", "How messages detected as spam are handled.",
+		func(g *groupssettings.Groups) *string { return &g.SpamModerationLevel }, "ALLOW", "MODERATE", "SILENTLY_MODERATE", "REJECT"),
+	enumGroupSetting("reply_to", "ReplyTo", "Reply To", "Who receives replies to messages. REPLY_TO_CUSTOM uses custom_reply_to.",
+		func(g *groupssettings.Groups) *string { return &g.ReplyTo }, "REPLY_TO_CUSTOM", "REPLY_TO_SENDER", "REPLY_TO_LIST", "REPLY_TO_OWNER", "REPLY_TO_IGNORE", "REPLY_TO_MANAGERS"),
+	textGroupSetting("custom_reply_to", "CustomReplyTo", "Custom Reply To", "Email address replies go to when reply_to is REPLY_TO_CUSTOM.",
+		func(g *groupssettings.Groups) *string { return &g.CustomReplyTo }, 0),
+	enumGroupSetting("default_sender", "DefaultSender", "Default Sender", "Whether members post as themselves or as the group by default.",
+		func(g *groupssettings.Groups) *string { return &g.DefaultSender }, "DEFAULT_SELF", "GROUP"),
+	textGroupSetting("primary_language", "PrimaryLanguage", "Primary Language", "Language tag of the group, such as en-US.",
+		func(g *groupssettings.Groups) *string { return &g.PrimaryLanguage }, 0),
+	textGroupSetting("custom_footer_text", "CustomFooterText", "Custom Footer Text", "Footer added to messages when include_custom_footer is true. At most 1,000 characters.",
+		func(g *groupssettings.Groups) *string { return &g.CustomFooterText }, 1000),
+	textGroupSetting("default_message_deny_notification_text", "DefaultMessageDenyNotificationText", "Message Deny Notification Text", "Text of the notification sent when a message is rejected. At most 10,000 characters.",
+		func(g *groupssettings.Groups) *string { return &g.DefaultMessageDenyNotificationText }, 10000),
+	boolGroupSetting("allow_external_members", "AllowExternalMembers", "Allow External Members", "Whether users outside the domain can be members.",
+		func(g *groupssettings.Groups) *string { return &g.AllowExternalMembers }),
+	boolGroupSetting("allow_web_posting", "AllowWebPosting", "Allow Web Posting", "Whether members can post from the Groups web interface.",
+		func(g *groupssettings.Groups) *string { return &g.AllowWebPosting }),
+	boolGroupSetting("archive_only", "ArchiveOnly", "Archive Only", "Whether the group is read-only: no new messages can be posted.",
+		func(g *groupssettings.Groups) *string { return &g.ArchiveOnly }),
+	boolGroupSetting("is_archived", "IsArchived", "Is Archived", "Whether messages to the group are kept in its archive.",
+		func(g *groupssettings.Groups) *string { return &g.IsArchived }),
+	boolGroupSetting("members_can_post_as_the_group", "MembersCanPostAsTheGroup", "Members Can Post As The Group", "Whether members can post using the group's address.",
+		func(g *groupssettings.Groups) *string { return &g.MembersCanPostAsTheGroup }),
+	boolGroupSetting("include_in_global_address_list", "IncludeInGlobalAddressList", "Include In Global Address List", "Whether the group is listed in the Global Address List.",
+		func(g *groupssettings.Groups) *string { return &g.IncludeInGlobalAddressList }),
+	boolGroupSetting("enable_collaborative_inbox", "EnableCollaborativeInbox", "Enable Collaborative Inbox", "Whether the group is a collaborative inbox.",
+		func(g *groupssettings.Groups) *string { return &g.EnableCollaborativeInbox }),
+	boolGroupSetting("include_custom_footer", "IncludeCustomFooter", "Include Custom Footer", "Whether custom_footer_text is added to messages.",
+		func(g *groupssettings.Groups) *string { return &g.IncludeCustomFooter }),
+	boolGroupSetting("send_message_deny_notification", "SendMessageDenyNotification", "Send Message Deny Notification", "Whether authors of rejected messages are notified.",
+		func(g *groupssettings.Groups) *string { return &g.SendMessageDenyNotification }),
+	readOnlyGroupSetting(boolGroupSetting("custom_roles_enabled_for_settings_to_be_merged", "CustomRolesEnabledForSettingsToBeMerged", "Custom Roles Enabled For Settings To Be Merged", "Whether the group has custom roles that block merging deprecated settings. Read-only.",
+		func(g *groupssettings.Groups) *string { return &g.CustomRolesEnabledForSettingsToBeMerged })),
+	boolGroupSetting("allow_google_communication", "AllowGoogleCommunication", "Allow Google Communication", "Deprecated. Whether Google can contact the group's administrator.",
+		func(g *groupssettings.Groups) *string { return &g.AllowGoogleCommunication }),
+	boolGroupSetting("show_in_group_directory", "ShowInGroupDirectory", "Show In Group Directory", "Deprecated; merged into who_can_discover_group.",
+		func(g *groupssettings.Groups) *string { return &g.ShowInGroupDirectory }),
+	boolGroupSetting("favorite_replies_on_top", "FavoriteRepliesOnTop", "Favorite Replies On Top", "Deprecated. Whether favorite replies are shown above other replies.",
+		func(g *groupssettings.Groups) *string { return &g.FavoriteRepliesOnTop }),
+	enumGroupSetting("message_display_font", "MessageDisplayFont", "Message Display Font", "Deprecated. Font of the group's messages.",
+		func(g *groupssettings.Groups) *string { return &g.MessageDisplayFont }, "DEFAULT_FONT", "FIXED_WIDTH_FONT"),
+	enumGroupSetting("who_can_add", "WhoCanAdd", "Who Can Add", "Deprecated; merged into who_can_moderate_members.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanAdd }, "ALL_MEMBERS_CAN_ADD", "ALL_MANAGERS_CAN_ADD", "ALL_OWNERS_CAN_ADD", "NONE_CAN_ADD"),
+	enumGroupSetting("who_can_invite", "WhoCanInvite", "Who Can Invite", "Deprecated; merged into who_can_moderate_members.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanInvite }, "ALL_MEMBERS_CAN_INVITE", "ALL_MANAGERS_CAN_INVITE", "ALL_OWNERS_CAN_INVITE", "NONE_CAN_INVITE"),
+	enumGroupSetting("who_can_approve_members", "WhoCanApproveMembers", "Who Can Approve Members", "Deprecated; merged into who_can_moderate_members.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanApproveMembers }, "ALL_OWNERS_CAN_APPROVE", "ALL_MANAGERS_CAN_APPROVE", "ALL_MEMBERS_CAN_APPROVE", "NONE_CAN_APPROVE"),
+	enumGroupSetting("who_can_ban_users", "WhoCanBanUsers", "Who Can Ban Users", "Deprecated; merged into who_can_moderate_members.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanBanUsers }, "OWNERS_ONLY", "OWNERS_AND_MANAGERS", "NONE"),
+	enumGroupSetting("who_can_modify_members", "WhoCanModifyMembers", "Who Can Modify Members", "Deprecated; merged into who_can_moderate_members.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanModifyMembers }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_approve_messages", "WhoCanApproveMessages", "Who Can Approve Messages", "Deprecated; merged into who_can_moderate_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanApproveMessages }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_delete_any_post", "WhoCanDeleteAnyPost", "Who Can Delete Any Post", "Deprecated; merged into who_can_moderate_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanDeleteAnyPost }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_delete_topics", "WhoCanDeleteTopics", "Who Can Delete Topics", "Deprecated; merged into who_can_moderate_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanDeleteTopics }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_lock_topics", "WhoCanLockTopics", "Who Can Lock Topics", "Deprecated; merged into who_can_moderate_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanLockTopics }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_move_topics_in", "WhoCanMoveTopicsIn", "Who Can Move Topics In", "Deprecated; merged into who_can_moderate_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanMoveTopicsIn }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_move_topics_out", "WhoCanMoveTopicsOut", "Who Can Move Topics Out", "Deprecated; merged into who_can_moderate_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanMoveTopicsOut }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_post_announcements", "WhoCanPostAnnouncements", "Who Can Post Announcements", "Deprecated; merged into who_can_moderate_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanPostAnnouncements }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_make_topics_sticky", "WhoCanMakeTopicsSticky", "Who Can Make Topics Sticky", "Deprecated; merged into who_can_moderate_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanMakeTopicsSticky }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_hide_abuse", "WhoCanHideAbuse", "Who Can Hide Abuse", "Deprecated; merged into who_can_moderate_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanHideAbuse }, groupSettingsModerationValues...),
+	enumGroupSetting("who_can_add_references", "WhoCanAddReferences", "Who Can Add References", "Deprecated; merged into who_can_assist_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanAddReferences }, groupSettingsTopicValues...),
+	enumGroupSetting("who_can_assign_topics", "WhoCanAssignTopics", "Who Can Assign Topics", "Deprecated; merged into who_can_assist_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanAssignTopics }, groupSettingsTopicValues...),
+	enumGroupSetting("who_can_unassign_topic", "WhoCanUnassignTopic", "Who Can Unassign Topic", "Deprecated; merged into who_can_assist_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanUnassignTopic }, groupSettingsTopicValues...),
+	enumGroupSetting("who_can_take_topics", "WhoCanTakeTopics", "Who Can Take Topics", "Deprecated; merged into who_can_assist_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanTakeTopics }, groupSettingsTopicValues...),
+	enumGroupSetting("who_can_mark_duplicate", "WhoCanMarkDuplicate", "Who Can Mark Duplicate", "Deprecated; merged into who_can_assist_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanMarkDuplicate }, groupSettingsTopicValues...),
+	enumGroupSetting("who_can_mark_no_response_needed", "WhoCanMarkNoResponseNeeded", "Who Can Mark No Response Needed", "Deprecated; merged into who_can_assist_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanMarkNoResponseNeeded }, groupSettingsTopicValues...),
+	enumGroupSetting("who_can_mark_favorite_reply_on_any_topic", "WhoCanMarkFavoriteReplyOnAnyTopic", "Who Can Mark Favorite Reply On Any Topic", "Deprecated; merged into who_can_assist_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanMarkFavoriteReplyOnAnyTopic }, groupSettingsTopicValues...),
+	enumGroupSetting("who_can_mark_favorite_reply_on_own_topic", "WhoCanMarkFavoriteReplyOnOwnTopic", "Who Can Mark Favorite Reply On Own Topic", "Deprecated; merged into who_can_assist_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanMarkFavoriteReplyOnOwnTopic }, groupSettingsTopicValues...),
+	enumGroupSetting("who_can_unmark_favorite_reply_on_any_topic", "WhoCanUnmarkFavoriteReplyOnAnyTopic", "Who Can Unmark Favorite Reply On Any Topic", "Deprecated; merged into who_can_assist_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanUnmarkFavoriteReplyOnAnyTopic }, groupSettingsTopicValues...),
+	enumGroupSetting("who_can_enter_free_form_tags", "WhoCanEnterFreeFormTags", "Who Can Enter Free Form Tags", "Deprecated; merged into who_can_assist_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanEnterFreeFormTags }, groupSettingsTopicValues...),
+	enumGroupSetting("who_can_modify_tags_and_categories", "WhoCanModifyTagsAndCategories", "Who Can Modify Tags And Categories", "Deprecated; merged into who_can_assist_content.",
+		func(g *groupssettings.Groups) *string { return &g.WhoCanModifyTagsAndCategories }, groupSettingsTopicValues...),
+}
+
+func boolGroupSetting(name, field, displayName, description string, value func(*groupssettings.Groups) *string) groupSetting {
+	return groupSetting{name: name, field: field, displayName: displayName, description: description, kind: groupSettingBool, value: value}
+}
+
+func enumGroupSetting(name, field, displayName, description string, value func(*groupssettings.Groups) *string, values ...string) groupSetting {
+	return groupSetting{name: name, field: field, displayName: displayName, description: description, kind: groupSettingEnum, values: values, value: value}
+}
+
+func textGroupSetting(name, field, displayName, description string, value func(*groupssettings.Groups) *string, maxLength int) groupSetting {
+	return groupSetting{name: name, field: field, displayName: displayName, description: description, kind: groupSettingText, maxLength: maxLength, value: value}
+}
+
+func readOnlyGroupSetting(s groupSetting) groupSetting {
+	s.readOnly = true
+	return s
+}
+
+// argumentField is the modify_group_settings argument for the setting.
+func (s groupSetting) argumentField() *config.Field {
+	f := &config.Field{
+		Name:        s.name,
+		DisplayName: s.displayName,
+		Description: s.description,
+	}
+	switch s.kind {
+	case groupSettingBool:
+		f.Field = &config.Field_BoolField{}
+	case groupSettingEnum:
+		f.Field = &config.Field_StringField{StringField: &config.StringField{Rules: &config.StringRules{In: s.values}}}
+	default:
+		f.Field = &config.Field_StringField{}
+	}
+	return f
+}
+
+// groupSettingsProfile is the group profile entries for settings: booleans
+// as bools, other settings as the API's strings. Unset settings are omitted.
+func groupSettingsProfile(settings *groupssettings.Groups) map[string]interface{} {
+	profile := make(map[string]interface{})
+	for _, s := range groupSettings {
+		v := *s.value(settings)
+		if v == "" {
+			continue
+		}
+		if s.kind == groupSettingBool {
+			profile[s.name] = strings.EqualFold(v, groupSettingTrue)
+		} else {
+			profile[s.name] = v
+		}
+	}
+	return profile
+}
@@ -5,14 +5,22 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+	"github.com/conductorone/baton-google-workspace/pkg/fake"
 )
 
 func newGroupDeleteTestServer(t *testing.T, statusCode int, reason string) *httptest.Server {
@@ -75,3 +83,368 @@ func TestGroupDelete_NotFound_IsIdempotentSuccess(t *testing.T) {
 	_, err := o.Delete(context.Background(), &v2.ResourceId{ResourceType: resourceTypeGroup.Id, Resource: "group1"}, nil)
 	require.NoError(t, err)
 }
+
+type testGroupMembersState struct {
+	mtx       sync.Mutex
+	members   map[string]*directoryAdmin.Member // memberKey -> member
+	deleted   []string
+	patchRole []string
+}
+
+func newGroupMembersTestServer(t *testing.T, state *testGroupMembersState) *httptest.Server {
+	t.Helper()
+	const prefix = "/admin/directory/v1/groups/group1/members"
+	mux := http.NewServeMux()
+	mux.HandleFunc(prefix, func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			resp := &directoryAdmin.Members{}
+			for _, m := range state.members {
+				resp.Members = append(resp.Members, m)
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case http.MethodPost:
+			var m directoryAdmin.Member
+			_ = json.NewDecoder(r.Body).Decode(&m)
+			if _, ok := state.members[m.Id]; ok {
+				http.Error(w, "conflict", http.StatusConflict)
+				return
+			}
+			state.members[m.Id] = &m
+			_ = json.NewEncoder(w).Encode(&m)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc(prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, prefix+"/")
+		m := state.members[key]
+		if m == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(m)
+		case http.MethodPatch:
+			var patch directoryAdmin.Member
+			_ = json.NewDecoder(r.Body).Decode(&patch)
+			m.Role = patch.Role
+			state.patchRole = append(state.patchRole, patch.Role)
+			_ = json.NewEncoder(w).Encode(m)
+		case http.MethodDelete:
+			delete(state.members, key)
+			state.deleted = append(state.deleted, key)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestGroupMembersResourceType(t *testing.T, server *httptest.Server) *groupResourceType {
+	t.Helper()
+	dir := newTestDirectoryService(t, server.URL, server.Client())
+	return &groupResourceType{
+		resourceType: resourceTypeGroup,
+		client: &gwclient.GoogleWorkspaceClient{
+			GroupMemberService:             dir,
+			GroupMemberProvisioningService: dir,
+		},
+	}
+}
+
+func testGroupResource(t *testing.T) *v2.Resource {
+	t.Helper()
+	r, err := groupToResource(context.Background(), &directoryAdmin.Group{Id: "group1", Name: "Group One", Email: "group1@example.com"}, nil)
+	require.NoError(t, err)
+	return r
+}
+
+func testGroupEntitlement(t *testing.T, slug string) *v2.Entitlement {
+	t.Helper()
+	return sdkEntitlement.NewAssignmentEntitlement(testGroupResource(t), slug)
+}
+
+func testUserPrincipal(id string) *v2.Resource {
+	return &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeUser.Id, Resource: id}}
+}
+
+func TestGroupEntitlements_OwnerManagerMember(t *testing.T) {
+	o := groupBuilder(nil, "", "")
+	ents, _, err := o.Entitlements(context.Background(), testGroupResource(t), rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	slugs := make([]string, 0, len(ents))
+	for _, e := range ents {
+		slugs = append(slugs, e.Slug)
+	}
+	require.Equal(t, []string{groupMemberEntitlement, groupOwnerEntitlement, groupManagerEntitlement}, slugs)
+}
+
+func TestGroupGrants_MapsMemberRoles(t *testing.T) {
+	state := &testGroupMembersState{members: map[string]*directoryAdmin.Member{
+		"owner1":   {Id: "owner1", Role: groupRoleOwner, Type: "USER"},
+		"manager1": {Id: "manager1", Role: groupRoleManager, Type: "USER"},
+		"member1":  {Id: "member1", Role: groupRoleMember, Type: "USER"},
+	}}
+	server := newGroupMembersTestServer(t, state)
+	defer server.Close()
+	o := newTestGroupMembersResourceType(t, server)
+
+	grants, _, err := o.Grants(context.Background(), testGroupResource(t), rs.SyncOpAttrs{})
+	require.NoError(t, err)
+
+	got := map[string][]string{}
+	for _, g := range grants {
+		principal := g.Principal.Id.Resource
+		got[principal] = append(got[principal], entitlementSlug(g.Entitlement))
+	}
+	require.ElementsMatch(t, []string{groupMemberEntitlement, groupOwnerEntitlement}, got["owner1"])
+	require.ElementsMatch(t, []string{groupMemberEntitlement, groupManagerEntitlement}, got["manager1"])
+	require.ElementsMatch(t, []string{groupMemberEntitlement}, got["member1"])
+}
+
+func TestGroupGrants_RoleGrantsOnlyForUsers(t *testing.T) {
+	state := &testGroupMembersState{members: map[string]*directoryAdmin.Member{
+		"nested1":   {Id: "nested1", Role: groupRoleOwner, Type: "GROUP"},
+		"customer1": {Id: "customer1", Role: groupRoleManager, Type: "CUSTOMER"},
+	}}
+	server := newGroupMembersTestServer(t, state)
+	defer server.Close()
+	o := newTestGroupMembersResourceType(t, server)
+
+	grants, _, err := o.Grants(context.Background(), testGroupResource(t), rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, grants, 2)
+	for _, g := range grants {
+		require.Equal(t, groupMemberEntitlement, entitlementSlug(g.Entitlement),
+			"owner/manager are grantable to users only, so %s must get member alone", g.Principal.Id.Resource)
+	}
+}
+
+func TestGroupGrant_InsertsWithRole(t *testing.T) {
+	state := &testGroupMembersState{members: map[string]*directoryAdmin.Member{}}
+	server := newGroupMembersTestServer(t, state)
+	defer server.Close()
+	o := newTestGroupMembersResourceType(t, server)
+
+	grants, _, err := o.Grant(context.Background(), testUserPrincipal("alice"), testGroupEntitlement(t, groupOwnerEntitlement))
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	require.Equal(t, groupRoleOwner, state.members["alice"].Role)
+	require.Equal(t, "group:group1:owner", grants[0].Entitlement.Id)
+}
+
+func TestGroupGrant_PromotesExistingMember(t *testing.T) {
+	state := &testGroupMembersState{members: map[string]*directoryAdmin.Member{
+		"alice": {Id: "alice", Role: groupRoleMember},
+	}}
+	server := newGroupMembersTestServer(t, state)
+	defer server.Close()
+	o := newTestGroupMembersResourceType(t, server)
+
+	_, _, err := o.Grant(context.Background(), testUserPrincipal("alice"), testGroupEntitlement(t, groupManagerEntitlement))
+	require.NoError(t, err)
+	require.Equal(t, groupRoleManager, state.members["alice"].Role)
+	require.Equal(t, []string{groupRoleManager}, state.patchRole)
+}
+
+func TestGroupGrant_MemberDoesNotDemoteOwner(t *testing.T) {
+	state := &testGroupMembersState{members: map[string]*directoryAdmin.Member{
+		"alice": {Id: "alice", Role: groupRoleOwner},
+	}}
+	server := newGroupMembersTestServer(t, state)
+	defer server.Close()
+	o := newTestGroupMembersResourceType(t, server)
+
+	_, _, err := o.Grant(context.Background(), testUserPrincipal("alice"), testGroupEntitlement(t, groupMemberEntitlement))
+	require.NoError(t, err)
+	require.Equal(t, groupRoleOwner, state.members["alice"].Role)
+	require.Empty(t, state.patchRole)
+}
+
+func TestGroupRevoke_OwnerDemotesToMember(t *testing.T) {
+	state := &testGroupMembersState{members: map[string]*directoryAdmin.Member{
+		"alice": {Id: "alice", Role: groupRoleOwner},
+	}}
+	server := newGroupMembersTestServer(t, state)
+	defer server.Close()
+	o := newTestGroupMembersResourceType(t, server)
+
+	grant := sdkGrant.NewGrant(testGroupResource(t), groupOwnerEntitlement, testUserPrincipal("alice").Id)
+	_, err := o.Revoke(context.Background(), grant)
+	require.NoError(t, err)
+	require.Equal(t, groupRoleMember, state.members["alice"].Role)
+	require.Empty(t, state.deleted, "revoking owner must not remove the membership")
+
+	// A second revoke finds no owner role left and is a no-op.
+	_, err = o.Revoke(context.Background(), grant)
+	require.NoError(t, err)
+	require.Len(t, state.patchRole, 1)
+}
+
+func TestGroupRevoke_MemberRemovesMembership(t *testing.T) {
+	state := &testGroupMembersState{members: map[string]*directoryAdmin.Member{
+		"alice": {Id: "alice", Role: groupRoleManager},
+	}}
+	server := newGroupMembersTestServer(t, state)
+	defer server.Close()
+	o := newTestGroupMembersResourceType(t, server)
+
+	grant := sdkGrant.NewGrant(testGroupResource(t), groupMemberEntitlement, testUserPrincipal("alice").Id)
+	_, err := o.Revoke(context.Background(), grant)
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice"}, state.deleted)
+}
+
+func newFakeWorkspaceGroups(t *testing.T) (*fake.Server, *groupResourceType) {
+	t.Helper()
+	srv := newFakeWorkspace(t)
+	c := newFakeWorkspaceConnector(t, srv)
+	client, err := c.getClient(context.Background())
+	require.NoError(t, err)
+	return srv, groupBuilder(client, c.customerID, c.domain)
+}
+
+func TestGroupList_ProfileIncludesSettings(t *testing.T) {
+	srv, o := newFakeWorkspaceGroups(t)
+	o.groupSettings = true
+	settings := srv.GroupSettings("eng@example.com")
+	settings.WhoCanJoin = "INVITED_CAN_JOIN"
+	settings.AllowExternalMembers = "true"
+	srv.SetGroupSettings("eng@example.com", settings)
+
+	groups, _, err := o.List(context.Background(), nil, rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	profile := groups[0].GetProfile().AsMap()
+	require.Equal(t, "eng@example.com", profile["group_email"])
+	require.Equal(t, "INVITED_CAN_JOIN", profile["who_can_join"])
+	require.Equal(t, true, profile["allow_external_members"])
+	require.Equal(t, false, profile["enable_collaborative_inbox"])
+	require.Equal(t, false, profile["custom_roles_enabled_for_settings_to_be_merged"])
+	require.Equal(t, "en", profile["primary_language"])
+}
+
+func TestGroupList_ProfileSkipsSettingsWhenOff(t *testing.T) {
+	srv, o := newFakeWorkspaceGroups(t)
+
+	groups, _, err := o.List(context.Background(), nil, rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	profile := groups[0].GetProfile().AsMap()
+	require.Equal(t, "eng@example.com", profile["group_email"])
+	require.NotContains(t, profile, "who_can_join")
+	for _, req := range srv.Requests() {
+		require.NotContains(t, req, "/groups/v1/", "settings must not be fetched unless sync-group-settings is on")
+	}
+}
+
+func TestGroupList_SettingsAPIErrorKeepsGroup(t *testing.T) {
+	srv, o := newFakeWorkspaceGroups(t)
+	o.groupSettings = true
+	srv.InjectFault(http.MethodGet, "/groups/v1/groups/", http.StatusInternalServerError, 100)
+
+	groups, _, err := o.List(context.Background(), nil, rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	profile := groups[0].GetProfile().AsMap()
+	require.Equal(t, "eng@example.com", profile["group_email"])
+	require.NotContains(t, profile, "who_can_join")
+}
+
+func TestModifyGroupSettings_AllSettings(t *testing.T) {
+	srv, o := newFakeWorkspaceGroups(t)
+	ctx := context.Background()
+
+	rv, _, err := o.modifyGroupSettingsActionHandler(ctx, &structpb.Struct{Fields: map[string]*structpb.Value{
+		"group_key":                  strArg("eng@example.com"),
+		"who_can_join":               strArg("INVITED_CAN_JOIN"),
+		"who_can_hide_abuse":         strArg("OWNERS_ONLY"),
+		"enable_collaborative_inbox": structpb.NewBoolValue(true),
+		"allow_web_posting":          structpb.NewBoolValue(true),
+		"custom_footer_text":         strArg("Internal only"),
+	}})
+	require.NoError(t, err)
+	require.True(t, rv.Fields["settings_updated"].GetBoolValue())
+	require.Equal(t, "CAN_REQUEST_TO_JOIN", rv.Fields["previous_who_can_join"].GetStringValue())
+	require.Equal(t, "INVITED_CAN_JOIN", rv.Fields["new_who_can_join"].GetStringValue())
+	require.Equal(t, "true", rv.Fields["new_enable_collaborative_inbox"].GetStringValue())
+	require.Equal(t, "true", rv.Fields["previous_allow_web_posting"].GetStringValue(), "unchanged settings are still reported")
+
+	settings := srv.GroupSettings("eng@example.com")
+	require.Equal(t, "INVITED_CAN_JOIN", settings.WhoCanJoin)
+	require.Equal(t, "OWNERS_ONLY", settings.WhoCanHideAbuse)
+	require.Equal(t, "true", settings.EnableCollaborativeInbox)
+	require.Equal(t, "Internal only", settings.CustomFooterText)
+
+	// Applying the same settings again is a no-op.
+	srv.ResetRequests()
+	rv, _, err = o.modifyGroupSettingsActionHandler(ctx, &structpb.Struct{Fields: map[string]*structpb.Value{
+		"group_key":    strArg("eng@example.com"),
+		"who_can_join": strArg("INVITED_CAN_JOIN"),
+	}})
+	require.NoError(t, err)
+	require.False(t, rv.Fields["settings_updated"].GetBoolValue())
+	for _, req := range srv.Requests() {
+		require.True(t, strings.HasPrefix(req, http.MethodGet), req)
+	}
+}
+
+func TestModifyGroupSettings_Validation(t *testing.T) {
+	_, o := newFakeWorkspaceGroups(t)
+	ctx := context.Background()
+	args := func(kv map[string]*structpb.Value) *structpb.Struct {
+		kv["group_key"] = strArg("eng@example.com")
+		return &structpb.Struct{Fields: kv}
+	}
+
+	_, _, err := o.modifyGroupSettingsActionHandler(ctx, args(map[string]*structpb.Value{"who_can_view_membership": strArg("EVERYONE")}))
+	require.ErrorContains(t, err, "invalid who_can_view_membership value 'EVERYONE': must be one of ALL_IN_DOMAIN_CAN_VIEW")
+
+	_, _, err = o.modifyGroupSettingsActionHandler(ctx, args(map[string]*structpb.Value{"custom_footer_text": strArg(strings.Repeat("x", 1001))}))
+	require.ErrorContains(t, err, "custom_footer_text must be at most 1000 characters")
+
+	// Read-only settings are not arguments.
+	_, _, err = o.modifyGroupSettingsActionHandler(ctx, args(map[string]*structpb.Value{"custom_roles_enabled_for_settings_to_be_merged": structpb.NewBoolValue(true)}))
+	require.ErrorContains(t, err, "at least one settings parameter must be provided")
+}
+
+func TestGroupGrants_BatchedFirstMemberPages(t *testing.T) {
+	srv, o := newFakeWorkspaceGroups(t)
+	srv.AddGroup(&directoryAdmin.Group{Email: "ops@example.com", Name: "Operations"})
+	srv.AddMember("ops@example.com", &directoryAdmin.Member{Email: "alice@example.com"})
+	srv.AddGroup(&directoryAdmin.Group{Email: "empty@example.com", Name: "Empty"})
+	ctx := context.Background()
+	ss := newFakeSessionStore()
+
+	srv.ResetRequests()
+	groups, _, err := o.List(ctx, nil, rs.SyncOpAttrs{Session: ss})
+	require.NoError(t, err)
+	require.Len(t, groups, 3)
+	require.Equal(t, 1, countRequests(srv.Requests(), "POST /batch/admin/directory_v1"), "the first member pages are listed in one batch")
+
+	srv.ResetRequests()
+	members := map[string]int{}
+	for _, g := range groups {
+		grants, _, err := o.Grants(ctx, g, rs.SyncOpAttrs{Session: ss})
+		require.NoError(t, err)
+		for _, grant := range grants {
+			if strings.HasSuffix(grant.Entitlement.Id, ":"+groupMemberEntitlement) {
+				members[g.DisplayName]++
+			}
+		}
+	}
+	require.Equal(t, map[string]int{"Engineering": 2, "Operations": 1}, members)
+	require.Empty(t, srv.Requests(), "Grants takes the batched pages")
+
+	// A page is taken once; listing it again asks the server.
+	_, _, err = o.Grants(ctx, groups[0], rs.SyncOpAttrs{Session: ss})
+	require.NoError(t, err)
+	require.Len(t, srv.Requests(), 1)
+}
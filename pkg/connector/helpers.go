@@ -67,6 +67,19 @@ func V1MembershipEntitlementID(resourceID string) string {
 	return fmt.Sprintf(MembershipEntitlementIDTemplate, resourceID)
 }
 
+// entitlementSlug returns e's slug, falling back to the last segment of its
+// "<resource type>:<resource id>:<slug>" ID when the slug isn't populated.
+func entitlementSlug(e *v2.Entitlement) string {
+	if slug := e.GetSlug(); slug != "" {
+		return slug
+	}
+	id := e.GetId()
+	if i := strings.LastIndex(id, ":"); i >= 0 {
+		return id[i+1:]
+	}
+	return ""
+}
+
 // emailsEqual compares two email addresses after trimming whitespace and case-insensitive comparison.
 func emailsEqual(email1 string, email2 string) bool {
 	// Trim whitespace and use EqualFold for efficient case-insensitive comparison
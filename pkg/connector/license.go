@@ -0,0 +1,448 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+	licensing "google.golang.org/api/licensing/v1"
+	"google.golang.org/grpc/codes"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+const (
+	licenseAssignedEntitlement = "assigned"
+
+	// licenseAssignmentsPageSize is the Licensing API's maximum page size for
+	// licenseAssignments.listForProductAndSku.
+	licenseAssignmentsPageSize = 1000
+)
+
+// licenseSku is one SKU of an Enterprise License Manager product.
+type licenseSku struct {
+	ID   string
+	Name string
+}
+
+// licenseProduct is an Enterprise License Manager product and its SKUs.
+type licenseProduct struct {
+	ID   string
+	Name string
+	Skus []licenseSku
+}
+
+// licenseCatalog lists the products and SKUs probed during sync. The
+// Licensing API has no endpoint that enumerates a customer's subscriptions,
+// so only SKUs listed here can be discovered. IDs are from
+// https://developers.google.com/workspace/admin/licensing/v1/how-tos/products
+var licenseCatalog = []licenseProduct{
+	{
+		ID:   "Google-Apps",
+		Name: "Google Workspace",
+		Skus: []licenseSku{
+			{ID: "1010020027", Name: "Google Workspace Business Starter"},
+			{ID: "1010020028", Name: "Google Workspace Business Standard"},
+			{ID: "1010020025", Name: "Google Workspace Business Plus"},
+			{ID: "1010060003", Name: "Google Workspace Enterprise Essentials"},
+			{ID: "1010060005", Name: "Google Workspace Enterprise Essentials Plus"},
+			{ID: "1010020026", Name: "Google Workspace Enterprise Standard"},
+			{ID: "1010020020", Name: "Google Workspace Enterprise Plus"},
+			{ID: "1010060001", Name: "Google Workspace Essentials"},
+			{ID: "1010020030", Name: "Google Workspace Frontline Starter"},
+			{ID: "1010020031", Name: "Google Workspace Frontline Standard"},
+			{ID: "Google-Apps-For-Business", Name: "G Suite Basic"},
+			{ID: "Google-Apps-Unlimited", Name: "G Suite Business"},
+		},
+	},
+	{
+		ID:   "101031",
+		Name: "Google Workspace for Education",
+		Skus: []licenseSku{
+			{ID: "1010310005", Name: "Google Workspace for Education Standard"},
+			{ID: "1010310008", Name: "Google Workspace for Education Plus"},
+			{ID: "1010310010", Name: "Google Workspace for Education Teaching and Learning Upgrade"},
+		},
+	},
+	{
+		ID:   "101033",
+		Name: "Google Voice",
+		Skus: []licenseSku{
+			{ID: "1010330003", Name: "Google Voice Starter"},
+			{ID: "1010330004", Name: "Google Voice Standard"},
+			{ID: "1010330002", Name: "Google Voice Premier"},
+		},
+	},
+	{
+		ID:   "Google-Vault",
+		Name: "Google Vault",
+		Skus: []licenseSku{
+			{ID: "Google-Vault", Name: "Google Vault"},
+			{ID: "Google-Vault-Former-Employee", Name: "Google Vault - Former Employee"},
+		},
+	},
+	{
+		ID:   "101001",
+		Name: "Cloud Identity",
+		Skus: []licenseSku{
+			{ID: "1010010001", Name: "Cloud Identity"},
+		},
+	},
+	{
+		ID:   "101005",
+		Name: "Cloud Identity Premium",
+		Skus: []licenseSku{
+			{ID: "1010050001", Name: "Cloud Identity Premium"},
+		},
+	},
+	{
+		ID:   "101034",
+		Name: "Google Workspace Archived User",
+		Skus: []licenseSku{
+			{ID: "1010340001", Name: "Google Workspace Enterprise Plus - Archived User"},
+			{ID: "1010340002", Name: "Google Workspace Business Plus - Archived User"},
+			{ID: "1010340003", Name: "Google Workspace Enterprise Standard - Archived User"},
+			{ID: "1010340004", Name: "Google Workspace Business Standard - Archived User"},
+		},
+	},
+}
+
+type licenseResourceType struct {
+	tenantPartitions
+	syncFilters
+	resourceType *v2.ResourceType
+	client       *gwclient.GoogleWorkspaceClient
+	customerId   string
+	domain       string
+}
+
+func (o *licenseResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return o.resourceType
+}
+
+// List probes one product of licenseCatalog per call and returns a resource
+// for each of its SKUs the customer holds. The page token is the index of the
+// next product to probe.
+func (o *licenseResourceType) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.listPartitions(ctx, parentResourceID, attrs)
+	}
+
+	l := ctxzap.Extract(ctx)
+
+	productIndex := 0
+	if attrs.PageToken.Token != "" {
+		var err error
+		productIndex, err = strconv.Atoi(attrs.PageToken.Token)
+		if err != nil || productIndex < 0 || productIndex >= len(licenseCatalog) {
+			return nil, nil, fmt.Errorf("google-workspace: invalid page token in license List: %q", attrs.PageToken.Token)
+		}
+	}
+	product := licenseCatalog[productIndex]
+
+	var rv []*v2.Resource
+	for _, sku := range product.Skus {
+		_, err := o.client.ListLicenseAssignments(ctx, o.customerId, product.ID, sku.ID, "", 1)
+		if err != nil {
+			if isLicenseNotHeldError(err) {
+				l.Debug("google-workspace: license SKU not available to customer, skipping",
+					zap.String("product_id", product.ID),
+					zap.String("sku_id", sku.ID),
+					zap.Error(err))
+				continue
+			}
+			return nil, nil, fmt.Errorf("google-workspace: failed to probe license %s/%s: %w", product.ID, sku.ID, err)
+		}
+		licenseResource, err := licenseToResource(product, sku)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create license resource in List: %w", err)
+		}
+		rv = append(rv, licenseResource)
+	}
+
+	var nextPage string
+	if productIndex+1 < len(licenseCatalog) {
+		nextPage = strconv.Itoa(productIndex + 1)
+	}
+	return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+}
+
+func (o *licenseResourceType) Entitlements(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.entitlementsPartitions(ctx, resource, attrs)
+	}
+
+	assigned := sdkEntitlement.NewAssignmentEntitlement(resource, licenseAssignedEntitlement, sdkEntitlement.WithGrantableTo(resourceTypeUser))
+	assigned.Description = fmt.Sprintf("Is assigned a %s license in Google Workspace", resource.DisplayName)
+	assigned.DisplayName = fmt.Sprintf("%s License Assigned", resource.DisplayName)
+	return []*v2.Entitlement{assigned}, nil, nil
+}
+
+// Grants returns a grant for every user assigned the SKU. Assignments only
+// carry the user's email, so unless an earlier call of this sync built it,
+// Grants first walks the user directory (one page per call) into the
+// session's user email index.
+func (o *licenseResourceType) Grants(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.grantsPartitions(ctx, resource, attrs)
+	}
+
+	l := ctxzap.Extract(ctx)
+	bag := &pagination.Bag{}
+	err := bag.Unmarshal(attrs.PageToken.Token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal pagination token in license Grants: %w", err)
+	}
+	if err := o.userEmails().begin(ctx, attrs.Session, bag, resource); err != nil {
+		return nil, nil, err
+	}
+
+	if bag.ResourceTypeID() == resourceTypeUser.Id {
+		nextPage, err := o.userEmails().indexPage(ctx, attrs.Session, bag)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+	}
+
+	productID, skuID, err := parseLicenseResourceID(resource.Id.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+	assignments, err := o.client.ListLicenseAssignments(ctx, o.customerId, productID, skuID, bag.PageToken(), licenseAssignmentsPageSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to list license assignments for %s: %w", resource.Id.Resource, err)
+	}
+
+	emails := make([]string, 0, len(assignments.Items))
+	for _, a := range assignments.Items {
+		if a.UserId != "" {
+			emails = append(emails, strings.ToLower(a.UserId))
+		}
+	}
+	userIDs, leftOut, err := o.userEmails().resolve(ctx, attrs.Session, emails)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rv []*v2.Grant
+	for _, email := range emails {
+		userID, ok := userIDs[email]
+		if !ok && leftOut[email] {
+			l.Debug("google-workspace: license assigned to a user left out by the sync filters or synced domains, skipping",
+				zap.String("license", resource.Id.Resource),
+				zap.String("email", email))
+			continue
+		}
+		if !ok {
+			l.Debug("google-workspace: license assigned to a user outside the directory, skipping",
+				zap.String("license", resource.Id.Resource),
+				zap.String("email", email))
+			continue
+		}
+		principalID, err := rs.NewResourceID(resourceTypeUser, userID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create user resource ID in license Grants: %w", err)
+		}
+		rv = append(rv, sdkGrant.NewGrant(resource, licenseAssignedEntitlement, principalID))
+	}
+
+	nextPage, err := bag.NextToken(assignments.NextPageToken)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate next page token in license Grants: %w", err)
+	}
+	return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+}
+
+func (o *licenseResourceType) userEmails() userEmailIndex {
+	return userEmailIndex{client: o.client, customerId: o.customerId, domain: o.domain, domains: o.tenantDomains, filter: o.userFilter}
+}
+
+// Grant assigns the license to the user. The Licensing API identifies the
+// assignee by primary email, so the user is looked up first.
+func (o *licenseResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	if p, ok := o.provisioner(entitlement.GetResource()).(*licenseResourceType); ok {
+		return p.Grant(ctx, principal, entitlement)
+	}
+
+	if o.client.LicensingService == nil {
+		return nil, nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", licensing.AppsLicensingScope))
+	}
+	if principal.GetId().GetResourceType() != resourceTypeUser.Id {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "user principal is required")
+	}
+	productID, skuID, err := parseLicenseResourceID(entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := o.client.GetUser(ctx, principal.GetId().GetResource())
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to get user for license assignment: %w", err)
+	}
+
+	_, err = o.client.InsertLicenseAssignment(ctx, productID, skuID, user.PrimaryEmail)
+	if err != nil {
+		gerr := &googleapi.Error{}
+		if !errors.As(err, &gerr) || gerr.Code != http.StatusConflict {
+			return nil, nil, fmt.Errorf("google-workspace: failed to assign license: %w", err)
+		}
+		// Already assigned; fall through and report the grant.
+	}
+
+	grant := sdkGrant.NewGrant(entitlement.Resource, licenseAssignedEntitlement, principal.GetId())
+	return []*v2.Grant{grant}, nil, nil
+}
+
+// Revoke removes the license from the user. Like Grant, it resolves the
+// user's primary email first; a user that no longer exists holds no license.
+func (o *licenseResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	if p, ok := o.provisioner(grant.GetEntitlement().GetResource()).(*licenseResourceType); ok {
+		return p.Revoke(ctx, grant)
+	}
+
+	if o.client.LicensingService == nil {
+		return nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", licensing.AppsLicensingScope))
+	}
+	if grant.Principal.GetId().GetResourceType() != resourceTypeUser.Id {
+		return nil, uhttp.WrapErrors(codes.InvalidArgument, "user principal is required")
+	}
+	l := ctxzap.Extract(ctx)
+	productID, skuID, err := parseLicenseResourceID(grant.Entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	userID := grant.Principal.GetId().GetResource()
+
+	user, err := o.client.GetUser(ctx, userID)
+	if err != nil {
+		gerr := &googleapi.Error{}
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			l.Info("google-workspace: license is being revoked from a user that doesn't exist",
+				zap.String("license", grant.Entitlement.Resource.Id.Resource),
+				zap.String(argUserID, userID))
+			return nil, nil
+		}
+		return nil, fmt.Errorf("google-workspace: failed to get user for license removal: %w", err)
+	}
+
+	err = o.client.DeleteLicenseAssignment(ctx, productID, skuID, user.PrimaryEmail)
+	if err != nil {
+		gerr := &googleapi.Error{}
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			l.Info("google-workspace: license assignment is being deleted but doesn't exist",
+				zap.String("license", grant.Entitlement.Resource.Id.Resource),
+				zap.String(argUserID, userID))
+			return nil, nil
+		}
+		return nil, fmt.Errorf("google-workspace: failed to remove license assignment: %w", err)
+	}
+	return nil, nil
+}
+
+func (o *licenseResourceType) Get(_ context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	productID, skuID, err := parseLicenseResourceID(resourceId.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, product := range licenseCatalog {
+		if product.ID != productID {
+			continue
+		}
+		for _, sku := range product.Skus {
+			if sku.ID == skuID {
+				licenseResource, err := licenseToResource(product, sku)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to create license resource in Get: %w", err)
+				}
+				return licenseResource, nil, nil
+			}
+		}
+	}
+	return nil, nil, uhttp.WrapErrors(codes.NotFound, fmt.Sprintf("google-workspace: unknown license %s", resourceId.Resource))
+}
+
+func licenseBuilder(client *gwclient.GoogleWorkspaceClient, customerId string, domain string) *licenseResourceType {
+	return &licenseResourceType{
+		resourceType: resourceTypeLicense,
+		client:       client,
+		customerId:   customerId,
+		domain:       domain,
+	}
+}
+
+// licenseNotHeldMessages are the error messages, lower-cased, with which
+// the Licensing API rejects a probe for a product or SKU the customer does
+// not subscribe to: a 403 for a product outside the subscription and a 400
+// for a SKU the product doesn't sell to this customer.
+var licenseNotHeldMessages = []string{
+	"not authorized to access the application id",
+	"invalid sku",
+	"invalid product",
+}
+
+// isLicenseNotHeldError reports whether a licenseAssignments probe failed
+// because the customer does not subscribe to the product or SKU. Any other
+// 400 or 403 - missing admin privileges, a bad customer ID, an unconsented
+// scope - is a real failure and must not read as an empty license list.
+func isLicenseNotHeldError(err error) bool {
+	gerr := &googleapi.Error{}
+	if !errors.As(err, &gerr) {
+		return false
+	}
+	if gerr.Code != http.StatusBadRequest && gerr.Code != http.StatusForbidden {
+		return false
+	}
+	msg := strings.ToLower(gerr.Message)
+	for _, m := range licenseNotHeldMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// licenseResourceID joins a product and SKU ID into a license resource ID.
+func licenseResourceID(productID, skuID string) string {
+	return productID + ":" + skuID
+}
+
+// parseLicenseResourceID splits a license resource ID into its product and
+// SKU IDs. Neither ever contains a ':'.
+func parseLicenseResourceID(id string) (string, string, error) {
+	productID, skuID, ok := strings.Cut(id, ":")
+	if !ok || productID == "" || skuID == "" {
+		return "", "", uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("google-workspace: invalid license resource ID %q", id))
+	}
+	return productID, skuID, nil
+}
+
+func licenseProfile(product licenseProduct, sku licenseSku) map[string]interface{} {
+	profile := make(map[string]interface{})
+	profile["product_id"] = product.ID
+	profile["product_name"] = product.Name
+	profile["sku_id"] = sku.ID
+	profile["sku_name"] = sku.Name
+	return profile
+}
+
+func licenseToResource(product licenseProduct, sku licenseSku) (*v2.Resource, error) {
+	return rs.NewResource(sku.Name, resourceTypeLicense, licenseResourceID(product.ID, sku.ID),
+		rs.WithAnnotation(&v2.RawId{Id: licenseResourceID(product.ID, sku.ID)}),
+		rs.WithDescription(product.Name),
+		rs.WithResourceProfile(licenseProfile(product, sku)),
+	)
+}
@@ -0,0 +1,234 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	licensing "google.golang.org/api/licensing/v1"
+	"google.golang.org/api/option"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+type testLicenseServerState struct {
+	mtx sync.Mutex
+	// assignments maps "<productId>:<skuId>" to assigned user emails. A SKU
+	// missing from the map answers 403, as Google does for an unheld product.
+	assignments map[string][]string
+	// forbidden, when set, answers every licensing call with a 403 unrelated
+	// to the subscription, as for an admin without license privileges.
+	forbidden bool
+	users     []*directoryAdmin.User
+	userPages int
+	inserted  []string
+	deleted   []string
+}
+
+func newLicenseTestServer(t *testing.T, state *testLicenseServerState) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apps/licensing/v1/product/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		// product/{productId}/sku/{skuId}/{users|user[/{userId}]}
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/apps/licensing/v1/product/"), "/")
+		key := parts[0] + ":" + parts[2]
+		if state.forbidden {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":403,"message":"Insufficient Permission"}}`))
+			return
+		}
+		emails, held := state.assignments[key]
+		if !held {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":403,"message":"Not Authorized to access the application ID"}}`))
+			return
+		}
+		switch {
+		case parts[3] == "users":
+			resp := &licensing.LicenseAssignmentList{}
+			for _, e := range emails {
+				resp.Items = append(resp.Items, &licensing.LicenseAssignment{ProductId: parts[0], SkuId: parts[2], UserId: e})
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case r.Method == http.MethodPost:
+			var body licensing.LicenseAssignmentInsert
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			for _, e := range emails {
+				if e == body.UserId {
+					w.WriteHeader(http.StatusConflict)
+					_, _ = w.Write([]byte(`{"error":{"code":409,"message":"already assigned"}}`))
+					return
+				}
+			}
+			state.inserted = append(state.inserted, body.UserId)
+			state.assignments[key] = append(emails, body.UserId)
+			_ = json.NewEncoder(w).Encode(&licensing.LicenseAssignment{UserId: body.UserId})
+		case r.Method == http.MethodDelete:
+			// The Licensing API only accepts the assignee's email here.
+			if !strings.Contains(parts[4], "@") {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":{"code":400,"message":"Invalid userId"}}`))
+				return
+			}
+			state.deleted = append(state.deleted, parts[4])
+			_ = json.NewEncoder(w).Encode(struct{}{})
+		}
+	})
+	mux.HandleFunc("/admin/directory/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		state.userPages++
+		// Serve one user per page so the index walk spans several calls.
+		resp := &directoryAdmin.Users{}
+		i, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		resp.Users = []*directoryAdmin.User{state.users[i]}
+		if i+1 < len(state.users) {
+			resp.NextPageToken = strconv.Itoa(i + 1)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/admin/directory/v1/users/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		id := strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/users/")
+		for _, u := range state.users {
+			if u.Id == id {
+				_ = json.NewEncoder(w).Encode(u)
+				return
+			}
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestLicenseResourceType(t *testing.T, server *httptest.Server) *licenseResourceType {
+	t.Helper()
+	lic, err := licensing.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+	return licenseBuilder(&gwclient.GoogleWorkspaceClient{
+		LicensingService: lic,
+		UserService:      newTestDirectoryService(t, server.URL, server.Client()),
+	}, "test-customer", "")
+}
+
+func TestLicenseList_SkipsUnheldSkus(t *testing.T) {
+	state := &testLicenseServerState{assignments: map[string][]string{
+		"Google-Apps:1010020028": nil,
+	}}
+	server := newLicenseTestServer(t, state)
+	defer server.Close()
+	o := newTestLicenseResourceType(t, server)
+
+	resources, results, err := o.List(context.Background(), nil, rs.SyncOpAttrs{PageToken: pagination.Token{}})
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	require.Equal(t, "Google-Apps:1010020028", resources[0].Id.Resource)
+	require.Equal(t, "Google Workspace Business Standard", resources[0].DisplayName)
+	require.Equal(t, "1", results.NextPageToken)
+
+	// The last product ends pagination.
+	last := strconv.Itoa(len(licenseCatalog) - 1)
+	_, results, err = o.List(context.Background(), nil, rs.SyncOpAttrs{PageToken: pagination.Token{Token: last}})
+	require.NoError(t, err)
+	require.Empty(t, results.NextPageToken)
+}
+
+func TestLicenseList_FailsOnPermissionDenied(t *testing.T) {
+	state := &testLicenseServerState{forbidden: true}
+	server := newLicenseTestServer(t, state)
+	defer server.Close()
+	o := newTestLicenseResourceType(t, server)
+
+	_, _, err := o.List(context.Background(), nil, rs.SyncOpAttrs{PageToken: pagination.Token{}})
+	require.Error(t, err, "a 403 unrelated to the subscription must not read as an empty license list")
+}
+
+func TestLicenseGrants_ResolvesEmailsThroughUserIndex(t *testing.T) {
+	state := &testLicenseServerState{
+		assignments: map[string][]string{
+			"Google-Apps:1010020028": {"Alice@example.com", "outsider@other.com"},
+		},
+		users: []*directoryAdmin.User{
+			{Id: "alice-id", PrimaryEmail: "alice@example.com"},
+			{Id: "bob-id", PrimaryEmail: "bob@example.com"},
+		},
+	}
+	server := newLicenseTestServer(t, state)
+	defer server.Close()
+	o := newTestLicenseResourceType(t, server)
+	ss := newFakeSessionStore()
+
+	license, err := licenseToResource(licenseCatalog[0], licenseCatalog[0].Skus[1])
+	require.NoError(t, err)
+
+	var grants []*v2.Grant
+	token := ""
+	for calls := 0; ; calls++ {
+		require.Less(t, calls, 10, "grants pagination did not terminate")
+		page, results, err := o.Grants(context.Background(), license, rs.SyncOpAttrs{Session: ss, PageToken: pagination.Token{Token: token}})
+		require.NoError(t, err)
+		grants = append(grants, page...)
+		token = results.NextPageToken
+		if token == "" {
+			break
+		}
+	}
+	require.Equal(t, 2, state.userPages)
+	require.Len(t, grants, 1, "assignments to users outside the directory must be skipped")
+	require.Equal(t, "alice-id", grants[0].Principal.Id.Resource)
+
+	// A second license reuses the index without walking the directory again.
+	_, results, err := o.Grants(context.Background(), license, rs.SyncOpAttrs{Session: ss})
+	require.NoError(t, err)
+	require.Empty(t, results.NextPageToken)
+	require.Equal(t, 2, state.userPages)
+}
+
+func TestLicenseGrantAndRevoke(t *testing.T) {
+	state := &testLicenseServerState{
+		assignments: map[string][]string{"Google-Apps:1010020028": nil},
+		users:       []*directoryAdmin.User{{Id: "alice-id", PrimaryEmail: "alice@example.com"}},
+	}
+	server := newLicenseTestServer(t, state)
+	defer server.Close()
+	o := newTestLicenseResourceType(t, server)
+
+	license, err := licenseToResource(licenseCatalog[0], licenseCatalog[0].Skus[1])
+	require.NoError(t, err)
+	entitlement := &v2.Entitlement{Resource: license, Slug: licenseAssignedEntitlement}
+	principal := &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeUser.Id, Resource: "alice-id"}}
+
+	grants, _, err := o.Grant(context.Background(), principal, entitlement)
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	require.Equal(t, []string{"alice@example.com"}, state.inserted)
+
+	grants, _, err = o.Grant(context.Background(), principal, entitlement)
+	require.NoError(t, err, "granting an existing assignment must be idempotent")
+	require.Len(t, grants, 1)
+	require.Len(t, state.inserted, 1)
+
+	_, err = o.Revoke(context.Background(), grants[0])
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice@example.com"}, state.deleted)
+
+	// A user deleted since the grant holds no license.
+	gone := &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeUser.Id, Resource: "gone-id"}}
+	_, err = o.Revoke(context.Background(), sdkGrant.NewGrant(license, licenseAssignedEntitlement, gone.Id))
+	require.NoError(t, err)
+	require.Len(t, state.deleted, 1)
+}
@@ -0,0 +1,247 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	reportsAdmin "google.golang.org/api/admin/reports/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+const loginFailureEventName = "login_failure"
+
+// loginRiskEventNames are the Reports API "login" events the login-risk feed
+// reports. login_failure is only reported in bursts; see loginFailureBursts.
+var loginRiskEventNames = []string{
+	"suspicious_login",
+	"suspicious_login_less_secure_app",
+	loginFailureEventName,
+	"gov_attack_warning",
+	"account_disabled_password_leak",
+	"2sv_disable",
+}
+
+const (
+	// loginFailureBurstThreshold is how many failed logins of one user within
+	// loginFailureBurstWindow make a burst.
+	loginFailureBurstThreshold = 5
+	loginFailureBurstWindow    = 15 * time.Minute
+)
+
+// Attributes of a login-risk event, set on the profile of its target.
+const (
+	loginRiskAttrEvent           = "risk_event"
+	loginRiskAttrIPAddress       = "ip_address"
+	loginRiskAttrLoginType       = "login_type"
+	loginRiskAttrChallengeMethod = "login_challenge_method"
+	loginRiskAttrFailureCount    = "failure_count"
+)
+
+// loginRiskEventFeed emits UsageEvents for risky sign-in activity: suspicious
+// logins, bursts of failed logins, government-backed attack warnings, accounts
+// disabled for a leaked password and 2-Step Verification being turned off.
+// Unlike googleLoginEventFeed, which tracks each user's last successful login,
+// it reads every matching event of the "login" audit log once, in order.
+//
+// The actor is the affected user and the target is Google Workspace, whose
+// profile carries the event's attributes (see the loginRiskAttr constants).
+type loginRiskEventFeed struct {
+	client     *gwclient.GoogleWorkspaceClient
+	customerID string
+	domain     string
+}
+
+func newLoginRiskEventFeed(client *gwclient.GoogleWorkspaceClient, customerID, domain string) *loginRiskEventFeed {
+	return &loginRiskEventFeed{client: client, customerID: customerID, domain: domain}
+}
+
+func (f *loginRiskEventFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetadata {
+	return &v2.EventFeedMetadata{
+		Id: "login_risk_event_feed",
+		SupportedEventTypes: []v2.EventType{
+			v2.EventType_EVENT_TYPE_USAGE,
+		},
+	}
+}
+
+func (f *loginRiskEventFeed) ListEvents(ctx context.Context, startAt *timestamppb.Timestamp, pToken *pagination.StreamToken) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	events, streamState, err := listAuditLogPage(ctx, f.client, reportsAppLogin, loginRiskEventNames, startAt, pToken, f.convert)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return events, streamState, nil, nil
+}
+
+func (f *loginRiskEventFeed) convert(ctx context.Context, eventName string, activities []*reportsAdmin.Activity) ([]*v2.Event, error) {
+	l := ctxzap.Extract(ctx)
+
+	if eventName == loginFailureEventName {
+		return loginFailureBursts(ctx, activities), nil
+	}
+
+	events := make([]*v2.Event, 0)
+	for _, activity := range activities {
+		occurredAt := convertIdTimeToTimestamp(activity.Id.Time)
+		if occurredAt == nil || activity.Actor == nil || activity.Actor.ProfileId == "" {
+			continue
+		}
+		for i, e := range activity.Events {
+			if e.Name != eventName {
+				continue
+			}
+			id := strconv.FormatInt(activity.Id.UniqueQualifier, 10) + ":" + strconv.Itoa(i)
+			evt, err := newLoginRiskEvent(id, activity, e, occurredAt, 0)
+			if err != nil {
+				l.Error("failed to create login risk event", zap.Error(err))
+				continue
+			}
+			events = append(events, evt)
+		}
+	}
+	return events, nil
+}
+
+type loginFailure struct {
+	activity   *reportsAdmin.Activity
+	event      *reportsAdmin.ActivityEvents
+	occurredAt *timestamppb.Timestamp
+}
+
+// loginFailureBursts reports each run of at least loginFailureBurstThreshold
+// failed logins of one user within loginFailureBurstWindow as a single event,
+// carrying the attributes of the run's last failure. Failures are only
+// grouped within one page, so a burst split across pages may go unreported.
+func loginFailureBursts(ctx context.Context, activities []*reportsAdmin.Activity) []*v2.Event {
+	l := ctxzap.Extract(ctx)
+
+	byUser := map[string][]loginFailure{}
+	var users []string
+	for _, activity := range activities {
+		occurredAt := convertIdTimeToTimestamp(activity.Id.Time)
+		if occurredAt == nil || activity.Actor == nil || activity.Actor.ProfileId == "" {
+			continue
+		}
+		for _, e := range activity.Events {
+			if e.Name != loginFailureEventName {
+				continue
+			}
+			user := activity.Actor.ProfileId
+			if _, ok := byUser[user]; !ok {
+				users = append(users, user)
+			}
+			byUser[user] = append(byUser[user], loginFailure{activity: activity, event: e, occurredAt: occurredAt})
+		}
+	}
+
+	events := make([]*v2.Event, 0)
+	for _, user := range users {
+		failures := byUser[user]
+		slices.SortStableFunc(failures, func(a, b loginFailure) int {
+			return a.occurredAt.AsTime().Compare(b.occurredAt.AsTime())
+		})
+		start := 0
+		for end := range failures {
+			for failures[end].occurredAt.AsTime().Sub(failures[start].occurredAt.AsTime()) > loginFailureBurstWindow {
+				start++
+			}
+			count := end - start + 1
+			if count < loginFailureBurstThreshold {
+				continue
+			}
+			last := failures[end]
+			id := strconv.FormatInt(last.activity.Id.UniqueQualifier, 10) + ":burst"
+			evt, err := newLoginRiskEvent(id, last.activity, last.event, last.occurredAt, count)
+			if err != nil {
+				l.Error("failed to create login failure burst event", zap.Error(err))
+			} else {
+				events = append(events, evt)
+			}
+			// The next burst needs a full run of new failures.
+			start = end + 1
+		}
+	}
+	return events
+}
+
+// newLoginRiskEvent converts a login audit event. failureCount is the size of
+// a login_failure burst and zero for other events.
+func newLoginRiskEvent(id string, activity *reportsAdmin.Activity, e *reportsAdmin.ActivityEvents, occurredAt *timestamppb.Timestamp, failureCount int) (*v2.Event, error) {
+	actor, err := eventActorResource(activity.Actor.ProfileId, activity.Actor.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := map[string]interface{}{
+		loginRiskAttrEvent: e.Name,
+	}
+	description := []string{e.Name}
+	if failureCount > 0 {
+		attrs[loginRiskAttrFailureCount] = failureCount
+		description = append(description, fmt.Sprintf("x%d", failureCount))
+	}
+	if activity.IpAddress != "" {
+		attrs[loginRiskAttrIPAddress] = activity.IpAddress
+		description = append(description, "from "+activity.IpAddress)
+	}
+	if loginType := getValueFromParameters("login_type", e.Parameters); loginType != "" {
+		attrs[loginRiskAttrLoginType] = loginType
+		description = append(description, "login_type="+loginType)
+	}
+	if challenge := getValuesFromParameters("login_challenge_method", e.Parameters); len(challenge) > 0 {
+		attrs[loginRiskAttrChallengeMethod] = strings.Join(challenge, ",")
+		description = append(description, "challenge="+strings.Join(challenge, ","))
+	}
+
+	appTrait, err := resource.NewAppTrait(resource.WithAppProfile(attrs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app trait: %w", err)
+	}
+
+	return &v2.Event{
+		Id:         id,
+		OccurredAt: occurredAt,
+		Event: &v2.Event_UsageEvent{
+			UsageEvent: &v2.UsageEvent{
+				TargetResource: &v2.Resource{
+					Id: &v2.ResourceId{
+						ResourceType: resourceTypeEnterpriseApplication.Id,
+						Resource:     googleWorkspaceAppID,
+					},
+					DisplayName: googleWorkspaceAppDisplayName,
+					Description: strings.Join(description, " "),
+					Annotations: annotations.New(appTrait),
+				},
+				ActorResource: actor,
+			},
+		},
+	}, nil
+}
+
+// getValuesFromParameters returns a parameter's values, which the Reports API
+// sends as multiValue for list parameters such as login_challenge_method.
+func getValuesFromParameters(name string, parameters []*reportsAdmin.ActivityEventsParameters) []string {
+	for _, p := range parameters {
+		if p.Name != name {
+			continue
+		}
+		if len(p.MultiValue) > 0 {
+			return p.MultiValue
+		}
+		if p.Value != "" {
+			return []string{p.Value}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	reportsAdmin "google.golang.org/api/admin/reports/v1"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+func loginActivity(uniqueQualifier int64, occurredAt time.Time, profileID, name string, params ...*reportsAdmin.ActivityEventsParameters) *reportsAdmin.Activity {
+	return &reportsAdmin.Activity{
+		Id:        &reportsAdmin.ActivityId{Time: occurredAt.UTC().Format(time.RFC3339), UniqueQualifier: uniqueQualifier},
+		Actor:     &reportsAdmin.ActivityActor{Email: profileID + "@example.com", ProfileId: profileID},
+		IpAddress: "203.0.113.7",
+		Events:    []*reportsAdmin.ActivityEvents{{Type: "login", Name: name, Parameters: params}},
+	}
+}
+
+// TestLoginRiskEventFeed_ReportsRiskyLoginsAndFailureBursts checks that risky
+// login events carry their attributes and that failed logins are only
+// reported once they form a burst.
+func TestLoginRiskEventFeed_ReportsRiskyLoginsAndFailureBursts(t *testing.T) {
+	base := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	activities := map[string][]*reportsAdmin.Activity{
+		"suspicious_login": {
+			loginActivity(1, base, "alice", "suspicious_login",
+				&reportsAdmin.ActivityEventsParameters{Name: "login_type", Value: "google_password"},
+				&reportsAdmin.ActivityEventsParameters{Name: "login_challenge_method", MultiValue: []string{"password", "google_prompt"}},
+			),
+		},
+		"2sv_disable": {
+			loginActivity(2, base, "carol", "2sv_disable"),
+		},
+	}
+	// bob fails five times within ten minutes; dave fails five times spread
+	// over an hour and a half.
+	for i := range loginFailureBurstThreshold {
+		activities[loginFailureEventName] = append(activities[loginFailureEventName],
+			loginActivity(int64(10+i), base.Add(time.Duration(i)*2*time.Minute), "bob", loginFailureEventName),
+			loginActivity(int64(20+i), base.Add(time.Duration(i)*20*time.Minute), "dave", loginFailureEventName),
+		)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/reports/v1/activity/users/all/applications/login", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&reportsAdmin.Activities{Items: activities[r.URL.Query().Get("eventName")]})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	feed := newLoginRiskEventFeed(&gwclient.GoogleWorkspaceClient{
+		ReportService: newReportsService(t, server.URL, server.Client()),
+	}, "customer", "example.com")
+
+	profiles := map[string]map[string]interface{}{}
+	token := &pagination.StreamToken{Size: 100}
+	for range 10 {
+		events, state, _, err := feed.ListEvents(context.Background(), nil, token)
+		require.NoError(t, err)
+		for _, e := range events {
+			target := e.GetUsageEvent().GetTargetResource()
+			require.Equal(t, googleWorkspaceAppID, target.GetId().GetResource())
+			appTrait, err := rs.GetAppTrait(target)
+			require.NoError(t, err)
+			profiles[e.GetUsageEvent().GetActorResource().GetId().GetResource()] = appTrait.GetProfile().AsMap()
+		}
+		token = &pagination.StreamToken{Size: 100, Cursor: state.Cursor}
+		if !state.HasMore {
+			break
+		}
+	}
+
+	require.Equal(t, map[string]map[string]interface{}{
+		"alice": {
+			loginRiskAttrEvent:           "suspicious_login",
+			loginRiskAttrIPAddress:       "203.0.113.7",
+			loginRiskAttrLoginType:       "google_password",
+			loginRiskAttrChallengeMethod: "password,google_prompt",
+		},
+		"bob": {
+			loginRiskAttrEvent:        loginFailureEventName,
+			loginRiskAttrIPAddress:    "203.0.113.7",
+			loginRiskAttrFailureCount: float64(loginFailureBurstThreshold),
+		},
+		"carol": {
+			loginRiskAttrEvent:     "2sv_disable",
+			loginRiskAttrIPAddress: "203.0.113.7",
+		},
+	}, profiles)
+}
@@ -0,0 +1,375 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	admin "google.golang.org/api/admin/directory/v1"
+	gmail "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+const (
+	mailboxDelegateEntitlement = "delegate"
+
+	// profileKeyMailboxEmail holds the mailbox owner's primary email, which
+	// the Gmail API needs to impersonate them.
+	profileKeyMailboxEmail = "email"
+
+	// gmailDelegateAccepted is the Delegate.VerificationStatus of a delegate
+	// that can access the mailbox. Pending, rejected and expired invitations
+	// grant nothing.
+	gmailDelegateAccepted = "accepted"
+)
+
+type mailboxResourceType struct {
+	tenantPartitions
+	syncFilters
+	resourceType *v2.ResourceType
+	client       *gwclient.GoogleWorkspaceClient
+	customerId   string
+	domain       string
+}
+
+func (o *mailboxResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return o.resourceType
+}
+
+// List returns a mailbox for each user whose Gmail mailbox is set up. Users
+// that are suspended or archived are skipped: Google refuses to impersonate
+// them, so their mailbox settings can't be read.
+func (o *mailboxResourceType) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.listPartitions(ctx, parentResourceID, attrs)
+	}
+
+	l := ctxzap.Extract(ctx)
+	users, err := o.client.ListMailboxUsers(ctx, o.customerId, o.domain, o.userFilter.apiQuery(), attrs.PageToken.Token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to list users for mailboxes: %w", err)
+	}
+
+	rv := make([]*v2.Resource, 0, len(users.Users))
+	for _, u := range users.Users {
+		if !o.userFilter.matches(u) {
+			continue
+		}
+		if !u.IsMailboxSetup || u.Suspended || u.Archived {
+			l.Debug("google-workspace: skipping mailbox that can't be read",
+				zap.String(argUserID, u.Id),
+				zap.Bool("mailbox_setup", u.IsMailboxSetup),
+				zap.Bool("suspended", u.Suspended),
+				zap.Bool("archived", u.Archived))
+			continue
+		}
+		mailboxResource, err := o.mailboxResource(ctx, u)
+		if err != nil {
+			if isMailServiceNotEnabledError(err) {
+				l.Debug("google-workspace: skipping user without Gmail", zap.String(argUserID, u.Id), zap.Error(err))
+				continue
+			}
+			return nil, nil, err
+		}
+		rv = append(rv, mailboxResource)
+	}
+	return rv, &rs.SyncOpResults{NextPageToken: users.NextPageToken}, nil
+}
+
+func (o *mailboxResourceType) Entitlements(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.entitlementsPartitions(ctx, resource, attrs)
+	}
+
+	delegate := sdkEntitlement.NewAssignmentEntitlement(resource, mailboxDelegateEntitlement, sdkEntitlement.WithGrantableTo(resourceTypeUser))
+	delegate.Description = fmt.Sprintf("Can read, send and delete mail in the %s mailbox", resource.DisplayName)
+	delegate.DisplayName = fmt.Sprintf("%s Mailbox Delegate", resource.DisplayName)
+	return []*v2.Entitlement{delegate}, nil, nil
+}
+
+// Grants returns a "delegate" grant for each user with accepted delegate
+// access to the mailbox. Delegates are named by email, so unless an earlier
+// call of this sync built it, Grants first walks the user directory (one page
+// per call) into the session's user email index.
+func (o *mailboxResourceType) Grants(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.grantsPartitions(ctx, resource, attrs)
+	}
+
+	l := ctxzap.Extract(ctx)
+	bag := &pagination.Bag{}
+	err := bag.Unmarshal(attrs.PageToken.Token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal pagination token in mailbox Grants: %w", err)
+	}
+	if err := o.userEmails().begin(ctx, attrs.Session, bag, resource); err != nil {
+		return nil, nil, err
+	}
+
+	if bag.ResourceTypeID() == resourceTypeUser.Id {
+		nextPage, err := o.userEmails().indexPage(ctx, attrs.Session, bag)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+	}
+
+	ownerEmail, err := o.mailboxEmail(ctx, resource)
+	if err != nil {
+		return nil, nil, err
+	}
+	delegates, err := o.client.ListMailDelegates(ctx, ownerEmail)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to list delegates for mailbox %s: %w", resource.Id.Resource, err)
+	}
+
+	emails := make([]string, 0, len(delegates.Delegates))
+	for _, d := range delegates.Delegates {
+		if d.DelegateEmail == "" {
+			continue
+		}
+		if d.VerificationStatus != gmailDelegateAccepted {
+			l.Debug("google-workspace: skipping mailbox delegate that has not accepted",
+				zap.String("mailbox", resource.Id.Resource),
+				zap.String("email", d.DelegateEmail),
+				zap.String("verification_status", d.VerificationStatus))
+			continue
+		}
+		emails = append(emails, strings.ToLower(d.DelegateEmail))
+	}
+	userIDs, leftOut, err := o.userEmails().resolve(ctx, attrs.Session, emails)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rv []*v2.Grant
+	for _, email := range emails {
+		userID, ok := userIDs[email]
+		if !ok && leftOut[email] {
+			l.Debug("google-workspace: mailbox delegate left out by the sync filters or synced domains, skipping",
+				zap.String("mailbox", resource.Id.Resource),
+				zap.String("email", email))
+			continue
+		}
+		if !ok {
+			l.Debug("google-workspace: mailbox delegate outside the directory, skipping",
+				zap.String("mailbox", resource.Id.Resource),
+				zap.String("email", email))
+			continue
+		}
+		principalID, err := rs.NewResourceID(resourceTypeUser, userID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create user resource ID in mailbox Grants: %w", err)
+		}
+		rv = append(rv, sdkGrant.NewGrant(resource, mailboxDelegateEntitlement, principalID))
+	}
+
+	nextPage, err := bag.NextToken("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate next page token in mailbox Grants: %w", err)
+	}
+	return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+}
+
+// Grant makes the principal a delegate of the mailbox. The Gmail API names
+// both the mailbox and the delegate by email, so both users are looked up.
+func (o *mailboxResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	if p, ok := o.provisioner(entitlement.GetResource()).(*mailboxResourceType); ok {
+		return p.Grant(ctx, principal, entitlement)
+	}
+
+	if o.client.GmailProvisioningService == nil {
+		return nil, nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", gmail.GmailSettingsSharingScope))
+	}
+	if principal.GetId().GetResourceType() != resourceTypeUser.Id {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "user principal is required")
+	}
+
+	owner, err := o.client.GetUser(ctx, entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to get mailbox owner: %w", err)
+	}
+	delegate, err := o.client.GetUser(ctx, principal.GetId().GetResource())
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to get user for mailbox delegation: %w", err)
+	}
+
+	_, err = o.client.CreateMailDelegate(ctx, owner.PrimaryEmail, delegate.PrimaryEmail)
+	if err != nil {
+		gerr := &googleapi.Error{}
+		if !errors.As(err, &gerr) || gerr.Code != http.StatusConflict {
+			return nil, nil, fmt.Errorf("google-workspace: failed to add mailbox delegate: %w", err)
+		}
+		// Already a delegate; fall through and report the grant.
+	}
+
+	grant := sdkGrant.NewGrant(entitlement.Resource, mailboxDelegateEntitlement, principal.GetId())
+	return []*v2.Grant{grant}, nil, nil
+}
+
+// Revoke removes the principal as a delegate of the mailbox. A mailbox owner
+// or delegate that no longer exists leaves no delegation to remove.
+func (o *mailboxResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	if p, ok := o.provisioner(grant.GetEntitlement().GetResource()).(*mailboxResourceType); ok {
+		return p.Revoke(ctx, grant)
+	}
+
+	if o.client.GmailProvisioningService == nil {
+		return nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", gmail.GmailSettingsSharingScope))
+	}
+	if grant.Principal.GetId().GetResourceType() != resourceTypeUser.Id {
+		return nil, uhttp.WrapErrors(codes.InvalidArgument, "user principal is required")
+	}
+	l := ctxzap.Extract(ctx)
+	mailboxID := grant.Entitlement.Resource.Id.Resource
+	userID := grant.Principal.GetId().GetResource()
+
+	var emails [2]string
+	for i, id := range []string{mailboxID, userID} {
+		user, err := o.client.GetUser(ctx, id)
+		if err != nil {
+			gerr := &googleapi.Error{}
+			if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+				l.Info("google-workspace: mailbox delegate is being revoked but the user doesn't exist",
+					zap.String("mailbox", mailboxID),
+					zap.String(argUserID, id))
+				return nil, nil
+			}
+			return nil, fmt.Errorf("google-workspace: failed to get user for mailbox delegate removal: %w", err)
+		}
+		emails[i] = user.PrimaryEmail
+	}
+
+	err := o.client.DeleteMailDelegate(ctx, emails[0], emails[1])
+	if err != nil {
+		gerr := &googleapi.Error{}
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			l.Info("google-workspace: mailbox delegate is being removed but doesn't exist",
+				zap.String("mailbox", mailboxID),
+				zap.String(argUserID, userID))
+			return nil, nil
+		}
+		return nil, fmt.Errorf("google-workspace: failed to remove mailbox delegate: %w", err)
+	}
+	return nil, nil
+}
+
+func (o *mailboxResourceType) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	user, err := o.client.GetUser(ctx, resourceId.Resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to get mailbox owner: %w", err)
+	}
+	mailboxResource, err := o.mailboxResource(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mailboxResource, nil, nil
+}
+
+// mailboxEmail returns the mailbox owner's email from the resource profile,
+// falling back to the directory for resources synced without it.
+func (o *mailboxResourceType) mailboxEmail(ctx context.Context, resource *v2.Resource) (string, error) {
+	if profile := rs.GetProfile(resource); profile != nil {
+		if email, ok := rs.GetProfileStringValue(profile, profileKeyMailboxEmail); ok && email != "" {
+			return email, nil
+		}
+	}
+	user, err := o.client.GetUser(ctx, resource.Id.Resource)
+	if err != nil {
+		return "", fmt.Errorf("google-workspace: failed to get mailbox owner: %w", err)
+	}
+	return user.PrimaryEmail, nil
+}
+
+// mailboxResource reads the user's forwarding and send-as settings and builds
+// the mailbox resource.
+func (o *mailboxResourceType) mailboxResource(ctx context.Context, user *admin.User) (*v2.Resource, error) {
+	forwarding, err := o.client.GetMailAutoForwarding(ctx, user.PrimaryEmail)
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace: failed to get auto-forwarding for mailbox %s: %w", user.Id, err)
+	}
+	addresses, err := o.client.ListMailForwardingAddresses(ctx, user.PrimaryEmail)
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace: failed to list forwarding addresses for mailbox %s: %w", user.Id, err)
+	}
+	sendAs, err := o.client.ListMailSendAs(ctx, user.PrimaryEmail)
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace: failed to list send-as aliases for mailbox %s: %w", user.Id, err)
+	}
+	mailboxResource, err := mailboxToResource(user, forwarding, addresses.ForwardingAddresses, sendAs.SendAs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mailbox resource: %w", err)
+	}
+	return mailboxResource, nil
+}
+
+func (o *mailboxResourceType) userEmails() userEmailIndex {
+	return userEmailIndex{client: o.client, customerId: o.customerId, domain: o.domain, domains: o.tenantDomains, filter: o.userFilter}
+}
+
+func mailboxBuilder(client *gwclient.GoogleWorkspaceClient, customerId string, domain string) *mailboxResourceType {
+	return &mailboxResourceType{
+		resourceType: resourceTypeMailbox,
+		client:       client,
+		customerId:   customerId,
+		domain:       domain,
+	}
+}
+
+// isMailServiceNotEnabledError reports whether a Gmail call failed because
+// the user has no Gmail service, e.g. no license that includes it.
+func isMailServiceNotEnabledError(err error) bool {
+	gerr := &googleapi.Error{}
+	if !errors.As(err, &gerr) || gerr.Code != http.StatusBadRequest {
+		return false
+	}
+	return strings.Contains(strings.ToLower(gerr.Message), "mail service not enabled")
+}
+
+func mailboxProfile(user *admin.User, forwarding *gmail.AutoForwarding, addresses []*gmail.ForwardingAddress, sendAs []*gmail.SendAs) map[string]interface{} {
+	profile := make(map[string]interface{})
+	profile["user_id"] = user.Id
+	profile[profileKeyMailboxEmail] = user.PrimaryEmail
+	if forwarding != nil {
+		profile["auto_forwarding_enabled"] = forwarding.Enabled
+		profile["auto_forwarding_email"] = forwarding.EmailAddress
+		profile["auto_forwarding_disposition"] = forwarding.Disposition
+	}
+	forwardingAddresses := make([]interface{}, 0, len(addresses))
+	for _, a := range addresses {
+		if a.ForwardingEmail != "" {
+			forwardingAddresses = append(forwardingAddresses, a.ForwardingEmail)
+		}
+	}
+	profile["forwarding_addresses"] = forwardingAddresses
+	aliases := make([]interface{}, 0, len(sendAs))
+	for _, a := range sendAs {
+		// The primary send-as entry is the mailbox's own address.
+		if a.SendAsEmail != "" && !a.IsPrimary {
+			aliases = append(aliases, a.SendAsEmail)
+		}
+	}
+	profile["send_as_aliases"] = aliases
+	return profile
+}
+
+func mailboxToResource(user *admin.User, forwarding *gmail.AutoForwarding, addresses []*gmail.ForwardingAddress, sendAs []*gmail.SendAs) (*v2.Resource, error) {
+	return rs.NewResource(user.PrimaryEmail, resourceTypeMailbox, user.Id,
+		rs.WithAnnotation(&v2.RawId{Id: user.Id}),
+		rs.WithResourceProfile(mailboxProfile(user, forwarding, addresses, sendAs)),
+	)
+}
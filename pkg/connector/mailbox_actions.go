@@ -0,0 +1,115 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	gmail "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var _ connectorbuilder.ResourceActionProvider = (*mailboxResourceType)(nil)
+
+const fieldForwardingAddressesRemoved = "forwarding_addresses_removed"
+
+var disableExternalForwardingActionSchema = &v2.BatonActionSchema{
+	Name:        "disable_external_forwarding",
+	DisplayName: "Disable External Mail Forwarding",
+	Description: "Turns off automatic forwarding for a user's mailbox and removes every forwarding address, which also stops filters from forwarding mail. Intended for offboarding.",
+	Arguments: []*config.Field{
+		{
+			Name:        argUserID,
+			DisplayName: displayUserID,
+			Description: "The resource ID of the user whose mail forwarding should be disabled.",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+	},
+	ReturnTypes: []*config.Field{
+		{
+			Name:        fieldSuccess,
+			DisplayName: displaySuccess,
+			Description: "Whether forwarding was disabled successfully.",
+			Field:       &config.Field_BoolField{},
+		},
+		{
+			Name:        fieldForwardingAddressesRemoved,
+			DisplayName: "Forwarding Addresses Removed",
+			Description: "The number of forwarding addresses that were removed.",
+			Field:       &config.Field_IntField{},
+		},
+	},
+	ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_DYNAMIC},
+}
+
+// ResourceActions implements the ResourceActionProvider interface for mailbox actions.
+func (o *mailboxResourceType) ResourceActions(ctx context.Context, registry actions.ActionRegistry) error {
+	return registry.Register(ctx, disableExternalForwardingActionSchema, o.disableExternalForwardingActionHandler)
+}
+
+func (o *mailboxResourceType) disableExternalForwardingActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	if o.client.GmailService == nil || o.client.GmailProvisioningService == nil {
+		return nil, nil, uhttp.WrapErrors(codes.FailedPrecondition,
+			fmt.Sprintf("google-workspace: gmail provisioning service not available - requires %s and %s scopes", gmail.GmailSettingsBasicScope, gmail.GmailSettingsSharingScope))
+	}
+	userId, err := extractUserId(args, l, disableExternalForwardingActionSchema.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := o.client.GetUser(ctx, userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: disable_external_forwarding: failed to get user: %w", err)
+	}
+	email := user.PrimaryEmail
+
+	err = withRateLimitWait(ctx, func() error {
+		return o.client.DisableMailAutoForwarding(ctx, email)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: disable_external_forwarding: %w", err)
+	}
+
+	addresses, err := withRateLimitWaitValue(ctx, func() (*gmail.ListForwardingAddressesResponse, error) {
+		return o.client.ListMailForwardingAddresses(ctx, email)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: disable_external_forwarding: %w", err)
+	}
+
+	removed := 0
+	waitLoop := newRateLimitWaitLoop(ctx)
+	for _, a := range addresses.ForwardingAddresses {
+		err := waitLoop(func() error {
+			return o.client.DeleteMailForwardingAddress(ctx, email, a.ForwardingEmail)
+		})
+		if err != nil {
+			gerr := &googleapi.Error{}
+			if !errors.As(err, &gerr) || gerr.Code != http.StatusNotFound {
+				return nil, nil, fmt.Errorf("google-workspace: disable_external_forwarding: removed %d of %d forwarding addresses: %w",
+					removed, len(addresses.ForwardingAddresses), err)
+			}
+		}
+		removed++
+	}
+
+	l.Debug("google-workspace: mailbox action handler: disabled mail forwarding",
+		zap.String(argUserID, userId),
+		zap.Int(fieldForwardingAddressesRemoved, removed))
+
+	removedRv := actions.NewNumberReturnField(fieldForwardingAddressesRemoved, float64(removed))
+	return actions.NewReturnValues(true, removedRv), nil, nil
+}
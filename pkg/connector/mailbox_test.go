@@ -0,0 +1,264 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	gmail "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+// testImpersonationHeader carries the impersonated mailbox owner to the test
+// server, standing in for the subject of a domain-wide delegation token.
+const testImpersonationHeader = "X-Test-Impersonate"
+
+type impersonatingTransport struct {
+	email string
+	base  http.RoundTripper
+}
+
+func (t *impersonatingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set(testImpersonationHeader, t.email)
+	return t.base.RoundTrip(r)
+}
+
+type testMailbox struct {
+	delegates   []*gmail.Delegate
+	forwarding  *gmail.AutoForwarding
+	addresses   []*gmail.ForwardingAddress
+	sendAs      []*gmail.SendAs
+	mailEnabled bool
+}
+
+type testMailboxServerState struct {
+	mtx       sync.Mutex
+	users     []*directoryAdmin.User
+	mailboxes map[string]*testMailbox
+}
+
+func newMailboxTestServer(t *testing.T, state *testMailboxServerState) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/directory/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.Users{Users: state.users})
+	})
+	mux.HandleFunc("/admin/directory/v1/users/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/users/")
+		for _, u := range state.users {
+			if u.Id == key || u.PrimaryEmail == key {
+				_ = json.NewEncoder(w).Encode(u)
+				return
+			}
+		}
+		http.Error(w, `{"error":{"code":404,"message":"Resource Not Found: userKey"}}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/gmail/v1/users/me/settings/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		mb, ok := state.mailboxes[r.Header.Get(testImpersonationHeader)]
+		if !ok {
+			http.Error(w, `{"error":{"code":401,"message":"not impersonating a mailbox owner"}}`, http.StatusUnauthorized)
+			return
+		}
+		if !mb.mailEnabled {
+			http.Error(w, `{"error":{"code":400,"message":"Mail service not enabled"}}`, http.StatusBadRequest)
+			return
+		}
+		setting, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/gmail/v1/users/me/settings/"), "/")
+		switch {
+		case setting == "delegates" && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(&gmail.ListDelegatesResponse{Delegates: mb.delegates})
+		case setting == "delegates" && r.Method == http.MethodPost:
+			var d gmail.Delegate
+			_ = json.NewDecoder(r.Body).Decode(&d)
+			for _, existing := range mb.delegates {
+				if existing.DelegateEmail == d.DelegateEmail {
+					http.Error(w, `{"error":{"code":409,"message":"Delegate already exists"}}`, http.StatusConflict)
+					return
+				}
+			}
+			d.VerificationStatus = gmailDelegateAccepted
+			mb.delegates = append(mb.delegates, &d)
+			_ = json.NewEncoder(w).Encode(&d)
+		case setting == "delegates" && r.Method == http.MethodDelete:
+			for i, d := range mb.delegates {
+				if d.DelegateEmail == key {
+					mb.delegates = append(mb.delegates[:i], mb.delegates[i+1:]...)
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+			http.Error(w, `{"error":{"code":404,"message":"Delegate not found"}}`, http.StatusNotFound)
+		case setting == "autoForwarding" && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(mb.forwarding)
+		case setting == "autoForwarding" && r.Method == http.MethodPut:
+			var f gmail.AutoForwarding
+			_ = json.NewDecoder(r.Body).Decode(&f)
+			mb.forwarding = &f
+			_ = json.NewEncoder(w).Encode(&f)
+		case setting == "forwardingAddresses" && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(&gmail.ListForwardingAddressesResponse{ForwardingAddresses: mb.addresses})
+		case setting == "forwardingAddresses" && r.Method == http.MethodDelete:
+			for i, a := range mb.addresses {
+				if a.ForwardingEmail == key {
+					mb.addresses = append(mb.addresses[:i], mb.addresses[i+1:]...)
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+			http.Error(w, `{"error":{"code":404,"message":"Not found"}}`, http.StatusNotFound)
+		case setting == "sendAs" && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(&gmail.ListSendAsResponse{SendAs: mb.sendAs})
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestMailboxClient(t *testing.T, server *httptest.Server) *gwclient.GoogleWorkspaceClient {
+	t.Helper()
+	factory := func(ctx context.Context, userEmail string) (*gmail.Service, error) {
+		hc := &http.Client{Transport: &impersonatingTransport{email: userEmail, base: server.Client().Transport}}
+		return gmail.NewService(ctx, option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(hc))
+	}
+	return &gwclient.GoogleWorkspaceClient{
+		UserService:              newTestDirectoryService(t, server.URL, server.Client()),
+		GmailService:             factory,
+		GmailProvisioningService: factory,
+	}
+}
+
+func TestMailboxList_GrantsDelegates(t *testing.T) {
+	state := &testMailboxServerState{
+		users: []*directoryAdmin.User{
+			{Id: "alice-id", PrimaryEmail: "alice@example.com", IsMailboxSetup: true},
+			{Id: "bob-id", PrimaryEmail: "bob@example.com", IsMailboxSetup: true},
+			{Id: "carol-id", PrimaryEmail: "carol@example.com", IsMailboxSetup: true, Suspended: true},
+			{Id: "dave-id", PrimaryEmail: "dave@example.com", IsMailboxSetup: true},
+		},
+		mailboxes: map[string]*testMailbox{
+			"alice@example.com": {
+				mailEnabled: true,
+				delegates: []*gmail.Delegate{
+					{DelegateEmail: "Bob@example.com", VerificationStatus: "accepted"},
+					{DelegateEmail: "dave@example.com", VerificationStatus: "pending"},
+				},
+				forwarding: &gmail.AutoForwarding{Enabled: true, EmailAddress: "alice@personal.com", Disposition: "leaveInInbox"},
+				addresses:  []*gmail.ForwardingAddress{{ForwardingEmail: "alice@personal.com", VerificationStatus: "accepted"}},
+				sendAs: []*gmail.SendAs{
+					{SendAsEmail: "alice@example.com", IsPrimary: true},
+					{SendAsEmail: "support@example.com"},
+				},
+			},
+			"bob@example.com":  {mailEnabled: true, forwarding: &gmail.AutoForwarding{}},
+			"dave@example.com": {mailEnabled: false},
+		},
+	}
+	server := newMailboxTestServer(t, state)
+	defer server.Close()
+	syncer := mailboxBuilder(newTestMailboxClient(t, server), "test-customer", "")
+
+	mailboxes, _, err := syncer.List(context.Background(), nil, rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, mailboxes, 2, "suspended users and users without Gmail must be skipped")
+	require.Equal(t, "alice@example.com", mailboxes[0].DisplayName)
+	profile := mailboxes[0].GetProfile().AsMap()
+	require.Equal(t, true, profile["auto_forwarding_enabled"])
+	require.Equal(t, "alice@personal.com", profile["auto_forwarding_email"])
+	require.Equal(t, []interface{}{"alice@personal.com"}, profile["forwarding_addresses"])
+	require.Equal(t, []interface{}{"support@example.com"}, profile["send_as_aliases"])
+
+	grants := collectDeviceGrants(t, syncer, mailboxes[0], rs.SyncOpAttrs{Session: newFakeSessionStore()})
+	require.Len(t, grants, 1, "pending delegates must be skipped")
+	require.Equal(t, "bob-id", grants[0].Principal.Id.Resource)
+	require.Equal(t, sdkEntitlement.NewEntitlementID(mailboxes[0], mailboxDelegateEntitlement), grants[0].Entitlement.Id)
+}
+
+func TestMailboxGrantRevokeAndDisableForwarding(t *testing.T) {
+	state := &testMailboxServerState{
+		users: []*directoryAdmin.User{
+			{Id: "alice-id", PrimaryEmail: "alice@example.com", IsMailboxSetup: true},
+			{Id: "bob-id", PrimaryEmail: "bob@example.com", IsMailboxSetup: true},
+		},
+		mailboxes: map[string]*testMailbox{
+			"alice@example.com": {
+				mailEnabled: true,
+				forwarding:  &gmail.AutoForwarding{Enabled: true, EmailAddress: "alice@personal.com"},
+				addresses: []*gmail.ForwardingAddress{
+					{ForwardingEmail: "alice@personal.com"},
+					{ForwardingEmail: "alice@backup.com"},
+				},
+			},
+		},
+	}
+	server := newMailboxTestServer(t, state)
+	defer server.Close()
+	syncer := mailboxBuilder(newTestMailboxClient(t, server), "test-customer", "")
+	ctx := context.Background()
+	alice := state.mailboxes["alice@example.com"]
+
+	mailbox, err := rs.NewResource("alice@example.com", resourceTypeMailbox, "alice-id")
+	require.NoError(t, err)
+	bob, err := rs.NewResource("Bob", resourceTypeUser, "bob-id")
+	require.NoError(t, err)
+	delegate := sdkEntitlement.NewAssignmentEntitlement(mailbox, mailboxDelegateEntitlement)
+
+	_, _, err = syncer.Grant(ctx, bob, delegate)
+	require.NoError(t, err)
+	require.Len(t, alice.delegates, 1)
+	require.Equal(t, "bob@example.com", alice.delegates[0].DelegateEmail)
+
+	// Granting an existing delegate is a no-op.
+	_, _, err = syncer.Grant(ctx, bob, delegate)
+	require.NoError(t, err)
+	require.Len(t, alice.delegates, 1)
+
+	grant := sdkGrant.NewGrant(mailbox, mailboxDelegateEntitlement, bob.Id)
+	_, err = syncer.Revoke(ctx, grant)
+	require.NoError(t, err)
+	require.Empty(t, alice.delegates)
+	_, err = syncer.Revoke(ctx, grant)
+	require.NoError(t, err)
+
+	rv, _, err := syncer.disableExternalForwardingActionHandler(ctx, deviceActionArgs(t, map[string]interface{}{argUserID: "alice-id"}))
+	require.NoError(t, err)
+	require.True(t, rv.GetFields()[fieldSuccess].GetBoolValue())
+	require.Equal(t, float64(2), rv.GetFields()[fieldForwardingAddressesRemoved].GetNumberValue())
+	require.False(t, alice.forwarding.Enabled)
+	require.Empty(t, alice.addresses)
+}
+
+func TestGmailServiceFactory_CachesPerMailbox(t *testing.T) {
+	srv := newFakeWorkspace(t)
+	srv.GrantScopes(gmail.GmailSettingsBasicScope)
+	c := newFakeWorkspaceConnector(t, srv)
+	factory, err := c.getGmailServiceFactory(context.Background(), gmail.GmailSettingsBasicScope)
+	require.NoError(t, err)
+
+	alice, err := factory(context.Background(), "alice@example.com")
+	require.NoError(t, err)
+	again, err := factory(context.Background(), "Alice@example.com")
+	require.NoError(t, err)
+	require.Same(t, alice, again, "a mailbox's calls share one service and token")
+	bob, err := factory(context.Background(), "bob@example.com")
+	require.NoError(t, err)
+	require.NotSame(t, alice, bob)
+}
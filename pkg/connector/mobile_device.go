@@ -0,0 +1,130 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	admin "google.golang.org/api/admin/directory/v1"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+type mobileDeviceResourceType struct {
+	tenantPartitions
+	syncFilters
+	resourceType *v2.ResourceType
+	client       *gwclient.GoogleWorkspaceClient
+	customerId   string
+	domain       string
+}
+
+func (o *mobileDeviceResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return o.resourceType
+}
+
+func (o *mobileDeviceResourceType) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.listPartitions(ctx, parentResourceID, attrs)
+	}
+
+	devices, err := o.client.ListMobileDevices(ctx, o.customerId, attrs.PageToken.Token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to list mobile devices: %w", err)
+	}
+
+	rv := make([]*v2.Resource, 0, len(devices.Mobiledevices))
+	for _, d := range devices.Mobiledevices {
+		deviceResource, err := mobileDeviceToResource(d)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create mobile device resource in List: %w", err)
+		}
+		rv = append(rv, deviceResource)
+	}
+	return rv, &rs.SyncOpResults{NextPageToken: devices.NextPageToken}, nil
+}
+
+func (o *mobileDeviceResourceType) Entitlements(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.entitlementsPartitions(ctx, resource, attrs)
+	}
+
+	return deviceOwnerEntitlements(resource), nil, nil
+}
+
+// Grants returns an "owner" grant for each account the device is registered to.
+func (o *mobileDeviceResourceType) Grants(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.grantsPartitions(ctx, resource, attrs)
+	}
+
+	return deviceOwnerGrants(ctx, o.userEmails(), resource, attrs)
+}
+
+func (o *mobileDeviceResourceType) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	device, err := o.client.GetMobileDevice(ctx, o.customerId, resourceId.Resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to get mobile device: %w", err)
+	}
+	deviceResource, err := mobileDeviceToResource(device)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create mobile device resource in Get: %w", err)
+	}
+	return deviceResource, nil, nil
+}
+
+func (o *mobileDeviceResourceType) userEmails() userEmailIndex {
+	return userEmailIndex{client: o.client, customerId: o.customerId, domain: o.domain, domains: o.tenantDomains, filter: o.userFilter}
+}
+
+func mobileDeviceBuilder(client *gwclient.GoogleWorkspaceClient, customerId string, domain string) *mobileDeviceResourceType {
+	return &mobileDeviceResourceType{
+		resourceType: resourceTypeMobileDevice,
+		client:       client,
+		customerId:   customerId,
+		domain:       domain,
+	}
+}
+
+func mobileDeviceProfile(device *admin.MobileDevice) map[string]interface{} {
+	profile := make(map[string]interface{})
+	profile["resource_id"] = device.ResourceId
+	profile["device_id"] = device.DeviceId
+	profile["status"] = device.Status
+	profile["type"] = device.Type
+	profile["model"] = device.Model
+	profile["manufacturer"] = device.Manufacturer
+	profile["os"] = device.Os
+	profile["serial_number"] = device.SerialNumber
+	profile["first_sync"] = device.FirstSync
+	profile["last_sync"] = device.LastSync
+	profile["device_compromised_status"] = device.DeviceCompromisedStatus
+	profile["encryption_status"] = device.EncryptionStatus
+	profile[profileKeyOwnerEmails] = ownerEmailsValue(device.Email)
+	return profile
+}
+
+// mobileDeviceDisplayName prefers the model and first owner, since mobile
+// devices have no user-assigned name.
+func mobileDeviceDisplayName(device *admin.MobileDevice) string {
+	name := device.Model
+	if name == "" {
+		name = device.ResourceId
+	}
+	if len(device.Email) > 0 && device.Email[0] != "" {
+		name = fmt.Sprintf("%s (%s)", name, device.Email[0])
+	}
+	return name
+}
+
+func mobileDeviceToResource(device *admin.MobileDevice) (*v2.Resource, error) {
+	if device.ResourceId == "" {
+		return nil, fmt.Errorf("google-workspace: mobile device %s has no resource id", device.DeviceId)
+	}
+	return rs.NewResource(mobileDeviceDisplayName(device), resourceTypeMobileDevice, device.ResourceId,
+		rs.WithAnnotation(&v2.RawId{Id: device.ResourceId}),
+		rs.WithResourceProfile(mobileDeviceProfile(device)),
+	)
+}
@@ -0,0 +1,103 @@
+package connector
+
+import (
+	"slices"
+	"strings"
+)
+
+// OAuth scope risk tiers, following Google's OAuth app verification
+// classification: restricted scopes give access to Gmail and Drive content,
+// sensitive scopes to other private user data or admin APIs, and basic
+// scopes only to the user's identity or the app's own files.
+//
+// https://support.google.com/cloud/answer/13464321
+const (
+	oauthRiskRestricted = "restricted"
+	oauthRiskSensitive  = "sensitive"
+	oauthRiskBasic      = "basic"
+)
+
+const googleScopePrefix = "https://www.googleapis.com/auth/"
+
+// restrictedOAuthScopes are Google's restricted scopes, by short name.
+var restrictedOAuthScopes = []string{
+	"mail.google.com",
+	"gmail.readonly",
+	"gmail.metadata",
+	"gmail.modify",
+	"gmail.insert",
+	"gmail.compose",
+	"gmail.settings.basic",
+	"gmail.settings.sharing",
+	"drive",
+	"drive.readonly",
+	"drive.metadata",
+	"drive.metadata.readonly",
+	"drive.activity",
+	"drive.activity.readonly",
+	"drive.scripts",
+	"drive.photos.readonly",
+}
+
+// basicOAuthScopes are the non-sensitive scopes apps commonly request, by
+// short name. Scopes in neither list are treated as sensitive, so an
+// unfamiliar scope is never reported as low risk.
+var basicOAuthScopes = []string{
+	"openid",
+	"email",
+	"profile",
+	"userinfo.email",
+	"userinfo.profile",
+	"drive.file",
+	"drive.appdata",
+	"drive.install",
+	"calendar.app.created",
+	"gmail.labels",
+	"gmail.addons.current.action.compose",
+	"gmail.addons.current.message.action",
+	"script.external_request",
+	"script.locale",
+}
+
+// oauthScopeShortName is scope without Google's scope prefix or scheme, as
+// Google's scope lists name them, e.g. "drive.readonly" or "mail.google.com".
+// It is also the scope's entitlement slug, which can't contain ':'.
+func oauthScopeShortName(scope string) string {
+	short := strings.TrimPrefix(scope, googleScopePrefix)
+	short = strings.TrimPrefix(short, "https://")
+	short = strings.TrimSuffix(short, "/")
+	return strings.ReplaceAll(short, ":", "_")
+}
+
+// oauthScopeRisk is the risk tier of an OAuth scope.
+func oauthScopeRisk(scope string) string {
+	short := oauthScopeShortName(scope)
+	switch {
+	case slices.Contains(restrictedOAuthScopes, short):
+		return oauthRiskRestricted
+	case slices.Contains(basicOAuthScopes, short):
+		return oauthRiskBasic
+	default:
+		return oauthRiskSensitive
+	}
+}
+
+// oauthScopesRisk is the highest risk tier of scopes, or basic when empty.
+func oauthScopesRisk(scopes []string) string {
+	risk := oauthRiskBasic
+	for _, scope := range scopes {
+		switch oauthScopeRisk(scope) {
+		case oauthRiskRestricted:
+			return oauthRiskRestricted
+		case oauthRiskSensitive:
+			risk = oauthRiskSensitive
+		}
+	}
+	return risk
+}
+
+// isOAuthAppID reports whether an enterprise application ID is an OAuth
+// client ID, rather than a SAML app or Google Workspace itself.
+func isOAuthAppID(appID string) bool {
+	return appID != googleWorkspaceAppID && !strings.HasPrefix(appID, samlAppIDPrefix)
+}
@@ -0,0 +1,327 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// offboard_user step outcomes, reported per step in fieldOffboardSteps.
+const (
+	offboardStepDone    = "done"
+	offboardStepSkipped = "skipped"
+	offboardStepFailed  = "failed"
+)
+
+const (
+	fieldOffboardSteps       = "steps"
+	fieldOffboardStepErrors  = "step_errors"
+	fieldOffboardStepDetails = "step_details"
+	argArchiveAccount        = "archive_account"
+)
+
+// offboardingStep is one step of offboard_user. Each step runs the handler of
+// the action it is named after, so offboard_user behaves exactly like
+// chaining those actions, and every step is idempotent on its own.
+type offboardingStep struct {
+	// name is the action the step runs; its bool argument of the same name
+	// turns the step off when false.
+	name string
+	// requires is the argument the step cannot run without, if any.
+	requires string
+	run      func(ctx context.Context, c *GoogleWorkspace, users *userResourceType, in offboardingInput) (*structpb.Struct, annotations.Annotations, error)
+}
+
+type offboardingInput struct {
+	userID         string
+	targetUserID   string
+	orgUnitPath    string
+	archiveAccount bool
+	dryRun         bool
+}
+
+// offboardingSteps run in this order. Access is cut first (suspension, then
+// sessions, tokens and app passwords), then data is handed over, and only then
+// is the account hidden, optionally archived, and moved to its final
+// organizational unit, whose policies may restrict the earlier steps.
+var offboardingSteps = []offboardingStep{
+	{
+		name: "disable_user",
+		run: func(ctx context.Context, c *GoogleWorkspace, _ *userResourceType, in offboardingInput) (*structpb.Struct, annotations.Annotations, error) {
+			return c.disableUserActionHandler(ctx, in.args(argUserID, in.userID))
+		},
+	},
+	{
+		name: "sign_out_user",
+		run: func(ctx context.Context, _ *GoogleWorkspace, users *userResourceType, in offboardingInput) (*structpb.Struct, annotations.Annotations, error) {
+			return users.signOutUserActionHandler(ctx, in.args(argUserID, in.userID))
+		},
+	},
+	{
+		name: "delete_all_oauth_tokens",
+		run: func(ctx context.Context, _ *GoogleWorkspace, users *userResourceType, in offboardingInput) (*structpb.Struct, annotations.Annotations, error) {
+			return users.deleteAllOAuthTokensActionHandler(ctx, in.args(argUserID, in.userID))
+		},
+	},
+	{
+		name: "delete_all_application_passwords",
+		run: func(ctx context.Context, _ *GoogleWorkspace, users *userResourceType, in offboardingInput) (*structpb.Struct, annotations.Annotations, error) {
+			return users.deleteAllApplicationPasswordsActionHandler(ctx, in.args(argUserID, in.userID))
+		},
+	},
+	{
+		name:     "transfer_user_drive_files",
+		requires: argTargetResourceID,
+		run: func(ctx context.Context, c *GoogleWorkspace, _ *userResourceType, in offboardingInput) (*structpb.Struct, annotations.Annotations, error) {
+			return c.transferUserDriveFiles(ctx, in.args(argResourceID, in.userID, argTargetResourceID, in.targetUserID))
+		},
+	},
+	{
+		name:     "transfer_user_calendar",
+		requires: argTargetResourceID,
+		run: func(ctx context.Context, c *GoogleWorkspace, _ *userResourceType, in offboardingInput) (*structpb.Struct, annotations.Annotations, error) {
+			return c.transferUserCalendar(ctx, in.args(argResourceID, in.userID, argTargetResourceID, in.targetUserID))
+		},
+	},
+	{
+		name: "offboarding_profile_update",
+		run: func(ctx context.Context, _ *GoogleWorkspace, users *userResourceType, in offboardingInput) (*structpb.Struct, annotations.Annotations, error) {
+			args := in.args(argUserID, in.userID)
+			args.Fields[argArchiveAccount] = structpb.NewBoolValue(in.archiveAccount)
+			return users.offboardingProfileUpdateActionHandler(ctx, args)
+		},
+	},
+	{
+		name:     "change_user_org_unit",
+		requires: argOrgUnitPath,
+		run: func(ctx context.Context, _ *GoogleWorkspace, users *userResourceType, in offboardingInput) (*structpb.Struct, annotations.Annotations, error) {
+			return users.changeUserOrgUnitActionHandler(ctx, in.args(argUserID, in.userID, argOrgUnitPath, in.orgUnitPath))
+		},
+	},
+}
+
+var offboardUserActionSchema = newOffboardUserActionSchema()
+
+func newOffboardUserActionSchema() *v2.BatonActionSchema {
+	arguments := []*config.Field{
+		{
+			Name:        argUserID,
+			DisplayName: displayUserID,
+			Description: "The resource ID of the user to offboard.",
+			Field:       &config.Field_StringField{},
+			IsRequired:  true,
+		},
+		{
+			Name:        argTargetResourceID,
+			DisplayName: "Transfer Target User ID",
+			Description: "The user ID that receives the user's Drive files and calendars. Without it the transfer steps are skipped.",
+			Field:       &config.Field_StringField{},
+		},
+		{
+			Name:        argOrgUnitPath,
+			DisplayName: "Organizational Unit Path",
+			Description: "The organizational unit to move the user to at the end (e.g., '/offboarded'). Without it the user is not moved.",
+			Field:       &config.Field_StringField{},
+		},
+		{
+			Name:        argArchiveAccount,
+			DisplayName: "Archive Account",
+			Description: "Whether offboarding_profile_update archives the account. Archiving requires an archived user license. Defaults to false.",
+			Field:       &config.Field_BoolField{},
+		},
+	}
+	for _, step := range offboardingSteps {
+		arguments = append(arguments, &config.Field{
+			Name:        step.name,
+			DisplayName: "Run " + step.name,
+			Description: fmt.Sprintf("Whether to run the %s step. Defaults to true.", step.name),
+			Field:       &config.Field_BoolField{BoolField: &config.BoolField{DefaultValue: true}},
+		})
+	}
+
+	return &v2.BatonActionSchema{
+		Name:        "offboard_user",
+		DisplayName: "Offboard User",
+		Description: "Offboards a user in one action: suspends them, signs them out, deletes their OAuth tokens and application passwords, " +
+			"transfers their Drive files and calendars, updates their profile for offboarding and moves them to an organizational unit. " +
+			"Every step can be turned off. A failed step does not stop the others, and re-running the action is safe, so it can resume after a failure.",
+		Arguments: arguments,
+		ReturnTypes: []*config.Field{
+			{
+				Name:        fieldSuccess,
+				DisplayName: displaySuccess,
+				Description: "Whether every step that ran succeeded.",
+				Field:       &config.Field_BoolField{},
+			},
+			{
+				Name:        fieldOffboardSteps,
+				DisplayName: "Steps",
+				Description: "The outcome of each step by name: done, skipped or failed.",
+				Field:       &config.Field_StringMapField{},
+			},
+			{
+				Name:        fieldOffboardStepErrors,
+				DisplayName: "Step Errors",
+				Description: "The error of each failed step, or why a step was skipped.",
+				Field:       &config.Field_StringMapField{},
+			},
+			{
+				Name:        fieldOffboardStepDetails,
+				DisplayName: "Step Details",
+				Description: "What each step returned, such as the number of tokens deleted or the data transfer ID.",
+				Field:       &config.Field_StringMapField{},
+			},
+		},
+		ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_DYNAMIC},
+	}
+}
+
+// offboardUserActionHandler runs offboardingSteps for one user and reports
+// each step's outcome. Invalid arguments fail the whole action before any
+// step runs; a failing step is reported and the remaining steps still run.
+func (c *GoogleWorkspace) offboardUserActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	userID, err := extractUserId(args, l, "offboard_user")
+	if err != nil {
+		return nil, nil, err
+	}
+	in := offboardingInput{
+		userID:       userID,
+		targetUserID: getStringField(args, argTargetResourceID),
+		orgUnitPath:  getStringField(args, argOrgUnitPath),
+		dryRun:       isDryRun(args),
+	}
+	in.archiveAccount, _ = getBoolField(args, argArchiveAccount)
+	if strings.EqualFold(in.userID, in.targetUserID) {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "user_id and target_resource_id must be different")
+	}
+	if in.orgUnitPath != "" && !strings.HasPrefix(in.orgUnitPath, "/") {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "org_unit_path must start with '/' (e.g., '/corp/sales')")
+	}
+	provided := map[string]bool{
+		argTargetResourceID: in.targetUserID != "",
+		argOrgUnitPath:      in.orgUnitPath != "",
+	}
+	for _, step := range offboardingSteps {
+		explicit, ok := getBoolField(args, step.name)
+		if ok && explicit && step.requires != "" && !provided[step.requires] {
+			return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("%s requires %s", step.name, step.requires))
+		}
+	}
+
+	client, err := c.getClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	users := userBuilder(client, c.customerID, c.domain)
+
+	outcomes := map[string]string{}
+	stepErrors := map[string]string{}
+	details := map[string]string{}
+	var changes []fieldChange
+	success := true
+	for _, step := range offboardingSteps {
+		if enabled, ok := getBoolField(args, step.name); ok && !enabled {
+			outcomes[step.name] = offboardStepSkipped
+			stepErrors[step.name] = "turned off"
+			continue
+		}
+		if step.requires != "" && !provided[step.requires] {
+			outcomes[step.name] = offboardStepSkipped
+			stepErrors[step.name] = "no " + step.requires
+			continue
+		}
+
+		rv, _, err := step.run(ctx, c, users, in)
+		if err != nil {
+			l.Warn("google-workspace: offboard_user: step failed",
+				zap.String(argUserID, userID),
+				zap.String("step", step.name),
+				zap.Error(err))
+			outcomes[step.name] = offboardStepFailed
+			stepErrors[step.name] = err.Error()
+			success = false
+			continue
+		}
+		outcomes[step.name] = offboardStepDone
+		for _, change := range dryRunChanges(rv) {
+			change.field = step.name + "." + change.field
+			changes = append(changes, change)
+		}
+		if detail := offboardingStepDetail(rv); detail != "" {
+			details[step.name] = detail
+		}
+	}
+
+	l.Debug("google-workspace: offboard_user: finished",
+		zap.String(argUserID, userID),
+		zap.Bool(fieldSuccess, success),
+		zap.Any(fieldOffboardSteps, outcomes))
+
+	fields := []actions.ReturnField{
+		actions.NewReturnField(fieldOffboardSteps, stringMapValue(outcomes)),
+		actions.NewReturnField(fieldOffboardStepErrors, stringMapValue(stepErrors)),
+		actions.NewReturnField(fieldOffboardStepDetails, stringMapValue(details)),
+	}
+	if in.dryRun {
+		// Each step ran as a dry run; their changes are reported together,
+		// each field prefixed with its step's name.
+		rv := dryRunResult(changes, fields...)
+		rv.Fields[fieldSuccess] = structpb.NewBoolValue(success)
+		return rv, nil, nil
+	}
+	return actions.NewReturnValues(success, fields...), nil, nil
+}
+
+// args builds a step's arguments from key/value pairs, passing dry_run on.
+func (in offboardingInput) args(kv ...string) *structpb.Struct {
+	args := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	for i := 0; i+1 < len(kv); i += 2 {
+		args.Fields[kv[i]] = structpb.NewStringValue(kv[i+1])
+	}
+	if in.dryRun {
+		args.Fields[argDryRun] = structpb.NewBoolValue(true)
+	}
+	return args
+}
+
+// offboardingStepDetail summarizes the scalar fields a step returned besides
+// success, e.g. "tokens_deleted=2" or "status=new, transfer_id=abc".
+func offboardingStepDetail(rv *structpb.Struct) string {
+	var parts []string
+	for key, value := range rv.GetFields() {
+		if key == fieldSuccess || key == argDryRun {
+			continue
+		}
+		switch v := value.GetKind().(type) {
+		case *structpb.Value_StringValue:
+			parts = append(parts, key+"="+v.StringValue)
+		case *structpb.Value_NumberValue:
+			parts = append(parts, fmt.Sprintf("%s=%g", key, v.NumberValue))
+		case *structpb.Value_BoolValue:
+			parts = append(parts, fmt.Sprintf("%s=%t", key, v.BoolValue))
+		}
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ", ")
+}
+
+func stringMapValue(m map[string]string) *structpb.Value {
+	fields := make(map[string]*structpb.Value, len(m))
+	for k, v := range m {
+		fields[k] = structpb.NewStringValue(v)
+	}
+	return structpb.NewStructValue(&structpb.Struct{Fields: fields})
+}
@@ -0,0 +1,212 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	"github.com/stretchr/testify/require"
+	datatransferAdmin "google.golang.org/api/admin/datatransfer/v1"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type offboardingTestState struct {
+	mtx         sync.Mutex
+	suspended   bool
+	orgUnitPath string
+	tokens      []string
+	failAsps    bool
+	requests    []string
+}
+
+func newOffboardingTestServer(state *offboardingTestState) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/directory/v1/users/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		path := strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/users/")
+		state.requests = append(state.requests, r.Method+" "+path)
+		switch {
+		case path == "u1" && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(offboardingTestUser(state))
+		case path == "u1" && r.Method == http.MethodPut:
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if v, ok := body["suspended"].(bool); ok {
+				state.suspended = v
+			}
+			if v, ok := body["orgUnitPath"].(string); ok {
+				state.orgUnitPath = v
+			}
+			_ = json.NewEncoder(w).Encode(offboardingTestUser(state))
+		case path == "u1/signOut":
+			w.WriteHeader(http.StatusNoContent)
+		case path == "u1/tokens" && r.Method == http.MethodGet:
+			tokens := &directoryAdmin.Tokens{}
+			for _, id := range state.tokens {
+				tokens.Items = append(tokens.Items, &directoryAdmin.Token{ClientId: id})
+			}
+			_ = json.NewEncoder(w).Encode(tokens)
+		case strings.HasPrefix(path, "u1/tokens/") && r.Method == http.MethodDelete:
+			state.tokens = nil
+			w.WriteHeader(http.StatusNoContent)
+		case path == "u1/asps":
+			if state.failAsps {
+				http.Error(w, `{"error":{"code":400,"message":"asps unavailable"}}`, http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(&directoryAdmin.Asps{})
+		default:
+			http.Error(w, "unexpected", http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("/admin/datatransfer/v1/transfers", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		state.requests = append(state.requests, r.Method+" transfers")
+		if r.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode(&datatransferAdmin.DataTransfersListResponse{})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&datatransferAdmin.DataTransfer{Id: "tr-1", OverallTransferStatusCode: "new"})
+	})
+	return httptest.NewServer(mux)
+}
+
+func countRequests(requests []string, request string) int {
+	n := 0
+	for _, r := range requests {
+		if r == request {
+			n++
+		}
+	}
+	return n
+}
+
+// TestOffboardUser_ReportsEachStepAndResumes checks that a failed step is
+// reported without stopping the others, and that re-running the action
+// completes it without redoing finished work.
+func TestOffboardUser_ReportsEachStepAndResumes(t *testing.T) {
+	state := &offboardingTestState{orgUnitPath: "/", tokens: []string{"app-1"}, failAsps: true}
+	server := newOffboardingTestServer(state)
+	defer server.Close()
+
+	c := newTestConnector()
+	dir := newTestDirectoryService(t, server.URL, server.Client())
+	primeServiceCache(c, dir, newTestDataTransferService(t, server.URL, server.Client()))
+	c.client.UserSecurityService = dir
+
+	args := &structpb.Struct{Fields: map[string]*structpb.Value{
+		argUserID:                strArg("u1"),
+		argTargetResourceID:      strArg("u2"),
+		argOrgUnitPath:           strArg("/offboarded"),
+		"transfer_user_calendar": structpb.NewBoolValue(false),
+	}}
+
+	rv, _, err := c.offboardUserActionHandler(context.Background(), args)
+	require.NoError(t, err)
+	require.False(t, rv.Fields[fieldSuccess].GetBoolValue())
+	require.Equal(t, map[string]interface{}{
+		"disable_user":                     offboardStepDone,
+		"sign_out_user":                    offboardStepDone,
+		"delete_all_oauth_tokens":          offboardStepDone,
+		"delete_all_application_passwords": offboardStepFailed,
+		"transfer_user_drive_files":        offboardStepDone,
+		"transfer_user_calendar":           offboardStepSkipped,
+		"offboarding_profile_update":       offboardStepDone,
+		"change_user_org_unit":             offboardStepDone,
+	}, rv.Fields[fieldOffboardSteps].GetStructValue().AsMap())
+	require.Contains(t, rv.Fields[fieldOffboardStepErrors].GetStructValue().Fields["delete_all_application_passwords"].GetStringValue(), "asps unavailable")
+	require.Equal(t, "tokens_deleted=1", rv.Fields[fieldOffboardStepDetails].GetStructValue().Fields["delete_all_oauth_tokens"].GetStringValue())
+	require.Equal(t, "status=new, transfer_id=tr-1", rv.Fields[fieldOffboardStepDetails].GetStructValue().Fields["transfer_user_drive_files"].GetStringValue())
+	require.True(t, state.suspended)
+	require.Equal(t, "/offboarded", state.orgUnitPath)
+
+	state.failAsps = false
+	state.requests = nil
+	rv, _, err = c.offboardUserActionHandler(context.Background(), args)
+	require.NoError(t, err)
+	require.True(t, rv.Fields[fieldSuccess].GetBoolValue())
+	require.Equal(t, offboardStepDone, rv.Fields[fieldOffboardSteps].GetStructValue().Fields["delete_all_application_passwords"].GetStringValue())
+	// Already suspended and already in the target org unit: only the
+	// offboarding profile update writes the user again.
+	require.Equal(t, 1, countRequests(state.requests, "PUT u1"))
+}
+
+func TestOffboardUser_RejectsInvalidArguments(t *testing.T) {
+	c := newTestConnector()
+	primeServiceCache(c, nil, nil)
+
+	for name, fields := range map[string]map[string]*structpb.Value{
+		"missing user":         {},
+		"target is the user":   {argUserID: strArg("u1"), argTargetResourceID: strArg("U1")},
+		"relative org unit":    {argUserID: strArg("u1"), argOrgUnitPath: strArg("offboarded")},
+		"transfer w/o target":  {argUserID: strArg("u1"), "transfer_user_drive_files": structpb.NewBoolValue(true)},
+		"org unit step w/o ou": {argUserID: strArg("u1"), "change_user_org_unit": structpb.NewBoolValue(true)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := c.offboardUserActionHandler(context.Background(), &structpb.Struct{Fields: fields})
+			require.Error(t, err)
+		})
+	}
+}
+
+func offboardingTestUser(state *offboardingTestState) *directoryAdmin.User {
+	return &directoryAdmin.User{
+		Id:           "u1",
+		PrimaryEmail: "u1@example.com",
+		Name:         &directoryAdmin.UserName{FullName: "User One"},
+		Suspended:    state.suspended,
+		OrgUnitPath:  state.orgUnitPath,
+	}
+}
+
+// TestOffboardUser_DryRunWritesNothing checks that a dry run only reads, and
+// reports the changes of every step prefixed with the step's name.
+func TestOffboardUser_DryRunWritesNothing(t *testing.T) {
+	state := &offboardingTestState{orgUnitPath: "/", tokens: []string{"app-1"}}
+	server := newOffboardingTestServer(state)
+	defer server.Close()
+
+	c := newTestConnector()
+	dir := newTestDirectoryService(t, server.URL, server.Client())
+	primeServiceCache(c, dir, newTestDataTransferService(t, server.URL, server.Client()))
+	c.client.UserSecurityService = dir
+
+	rv, _, err := c.offboardUserActionHandler(context.Background(), &structpb.Struct{Fields: map[string]*structpb.Value{
+		argUserID:           strArg("u1"),
+		argTargetResourceID: strArg("u2"),
+		argOrgUnitPath:      strArg("/offboarded"),
+		argDryRun:           structpb.NewBoolValue(true),
+	}})
+	require.NoError(t, err)
+	require.True(t, rv.Fields[fieldSuccess].GetBoolValue())
+	require.True(t, rv.Fields[argDryRun].GetBoolValue())
+	for _, r := range state.requests {
+		require.Truef(t, strings.HasPrefix(r, http.MethodGet+" "), "dry run sent %s", r)
+	}
+	require.False(t, state.suspended)
+	require.Equal(t, []string{"app-1"}, state.tokens)
+
+	// The changes are the string list the schema declares.
+	i := slices.IndexFunc(dryRunReturnTypes, func(f *config.Field) bool { return f.Name == fieldChanges })
+	require.IsType(t, &config.Field_StringSliceField{}, dryRunReturnTypes[i].Field)
+	for _, v := range rv.Fields[fieldChanges].GetListValue().GetValues() {
+		require.Contains(t, v.GetStringValue(), `"field":`)
+	}
+	changes := map[string][2]string{}
+	for _, change := range dryRunChanges(rv) {
+		changes[change.field] = [2]string{change.oldValue, change.newValue}
+	}
+	require.Equal(t, [2]string{"false", "true"}, changes["disable_user.suspended"])
+	require.Contains(t, changes, "delete_all_oauth_tokens.oauth_token:app-1")
+	require.Equal(t, [2]string{"/", "/offboarded"}, changes["change_user_org_unit.org_unit_path"])
+	require.Contains(t, changes, "transfer_user_drive_files.drive_files_owner")
+}
@@ -0,0 +1,327 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/session"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-sdk/pkg/types/sessions"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/grpc/codes"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+const (
+	orgUnitMemberEntitlement = "member"
+
+	// rootOrgUnitPath is the path of the tenant's root OU. The orgunits API
+	// never returns the root itself, so it is not synced as a resource; OUs
+	// directly under it are top-level resources with no parent.
+	rootOrgUnitPath = "/"
+)
+
+var (
+	// orgUnitUsersNamespace maps an orgUnitPathKey to the set of IDs of the
+	// users directly in that OU. A set, so re-indexing a page is harmless.
+	orgUnitUsersNamespace       = sessions.WithPrefix("org_unit_users")
+	orgUnitUsersLoadedNamespace = sessions.WithPrefix("org_unit_users_loaded")
+)
+
+type orgUnitResourceType struct {
+	tenantPartitions
+	syncFilters
+	resourceType *v2.ResourceType
+	client       *gwclient.GoogleWorkspaceClient
+	customerId   string
+	domain       string
+}
+
+func (o *orgUnitResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return o.resourceType
+}
+
+// List returns the immediate children of parentResourceID, or the top-level
+// OUs when it is nil. Each OU carries a ChildResourceType annotation, so the
+// SDK walks the hierarchy one level at a time.
+func (o *orgUnitResourceType) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.listPartitions(ctx, parentResourceID, attrs)
+	}
+
+	l := ctxzap.Extract(ctx)
+
+	var parentOrgUnit string
+	if parentResourceID != nil {
+		if parentResourceID.ResourceType != resourceTypeOrgUnit.Id {
+			return nil, nil, nil
+		}
+		parentOrgUnit = parentResourceID.Resource
+	}
+
+	orgUnits, err := o.client.ListOrgUnits(ctx, o.customerId, parentOrgUnit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to list org units: %w", err)
+	}
+
+	rv := make([]*v2.Resource, 0, len(orgUnits.OrganizationUnits))
+	for _, ou := range orgUnits.OrganizationUnits {
+		if ou.OrgUnitId == "" {
+			l.Error("org unit had no id", zap.String("path", ou.OrgUnitPath))
+			continue
+		}
+		orgUnitResource, err := orgUnitToResource(ou)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create org unit resource in List: %w", err)
+		}
+		rv = append(rv, orgUnitResource)
+	}
+	return rv, nil, nil
+}
+
+func (o *orgUnitResourceType) Entitlements(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.entitlementsPartitions(ctx, resource, attrs)
+	}
+
+	member := sdkEntitlement.NewAssignmentEntitlement(resource, orgUnitMemberEntitlement, sdkEntitlement.WithGrantableTo(resourceTypeUser))
+	member.Description = fmt.Sprintf("Is in the %s organizational unit in Google Workspace", resource.DisplayName)
+	member.DisplayName = fmt.Sprintf("%s Organizational Unit Member", resource.DisplayName)
+	return []*v2.Entitlement{member}, nil, nil
+}
+
+// Grants returns a grant for every user whose orgUnitPath is exactly this OU.
+// Users in descendant OUs are granted by those OUs instead. Google's
+// orgUnitPath user query matches a whole subtree, so rather than listing each
+// OU's subtree the first org unit Grants call of a sync walks the directory
+// once (one page per call) to index users by OU path in the session store,
+// which every later call reuses.
+func (o *orgUnitResourceType) Grants(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.grantsPartitions(ctx, resource, attrs)
+	}
+
+	bag := &pagination.Bag{}
+	err := bag.Unmarshal(attrs.PageToken.Token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal pagination token in org unit Grants: %w", err)
+	}
+	if bag.Current() == nil {
+		bag.Push(pagination.PageState{
+			ResourceTypeID: resource.Id.ResourceType,
+			ResourceID:     resource.Id.Resource,
+		})
+		_, loaded, err := session.GetJSON[string](ctx, attrs.Session, "done", orgUnitUsersLoadedNamespace)
+		if err != nil {
+			return nil, nil, fmt.Errorf("google-workspace: failed to check org unit user index loaded flag: %w", err)
+		}
+		if !loaded {
+			bag.Push(pagination.PageState{ResourceTypeID: resourceTypeUser.Id})
+		}
+	}
+
+	if bag.ResourceTypeID() == resourceTypeUser.Id {
+		nextPage, err := o.indexUserOrgUnits(ctx, attrs.Session, bag)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+	}
+
+	orgUnitPath, err := o.orgUnitPath(ctx, resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userIDs, _, err := session.GetJSON[map[string]bool](ctx, attrs.Session, orgUnitPathKey(orgUnitPath), orgUnitUsersNamespace)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to read org unit user index from session: %w", err)
+	}
+
+	rv := make([]*v2.Grant, 0, len(userIDs))
+	for _, id := range slices.Sorted(maps.Keys(userIDs)) {
+		userID, err := rs.NewResourceID(resourceTypeUser, id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create user resource ID in org unit Grants: %w", err)
+		}
+		rv = append(rv, sdkGrant.NewGrant(resource, orgUnitMemberEntitlement, userID))
+	}
+
+	nextPage, err := bag.NextToken("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate next page token in org unit Grants: %w", err)
+	}
+	return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+}
+
+// indexUserOrgUnits adds one directory page of users to the session's
+// OU-path-to-user-IDs index, marking the index loaded once the last page is
+// reached. Adding a page again, as a retried call does, changes nothing.
+func (o *orgUnitResourceType) indexUserOrgUnits(ctx context.Context, ss sessions.SessionStore, bag *pagination.Bag) (string, error) {
+	users, err := o.client.ListUserOrgUnitsPage(ctx, o.customerId, o.domain, o.userFilter.apiQuery(), bag.PageToken())
+	if err != nil {
+		return "", fmt.Errorf("google-workspace: failed to list users for org unit grants: %w", err)
+	}
+	pageIDs := make(map[string]map[string]bool)
+	for _, u := range users.Users {
+		if u.Id == "" || u.OrgUnitPath == "" || !o.userFilter.matches(u) {
+			continue
+		}
+		key := orgUnitPathKey(u.OrgUnitPath)
+		if pageIDs[key] == nil {
+			pageIDs[key] = make(map[string]bool)
+		}
+		pageIDs[key][u.Id] = true
+	}
+	if len(pageIDs) > 0 {
+		keys := make([]string, 0, len(pageIDs))
+		for key := range pageIDs {
+			keys = append(keys, key)
+		}
+		// Earlier pages may already hold users of the same OUs.
+		existing, err := session.GetManyJSON[map[string]bool](ctx, ss, keys, orgUnitUsersNamespace)
+		if err != nil {
+			return "", fmt.Errorf("google-workspace: failed to read org unit user index from session: %w", err)
+		}
+		for key, ids := range pageIDs {
+			maps.Copy(ids, existing[key])
+		}
+		if err := session.SetManyJSON(ctx, ss, pageIDs, orgUnitUsersNamespace); err != nil {
+			return "", fmt.Errorf("google-workspace: failed to store org unit user index in session: %w", err)
+		}
+	}
+	if users.NextPageToken == "" {
+		if err := session.SetJSON(ctx, ss, "done", "true", orgUnitUsersLoadedNamespace); err != nil {
+			return "", fmt.Errorf("google-workspace: failed to mark org unit user index as loaded: %w", err)
+		}
+	}
+	nextPage, err := bag.NextToken(users.NextPageToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate next page token in org unit Grants: %w", err)
+	}
+	return nextPage, nil
+}
+
+// Grant moves the user into the OU. Every user belongs to exactly one OU, so
+// this implicitly ends their membership of the previous one.
+func (o *orgUnitResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	if p, ok := o.provisioner(entitlement.GetResource()).(*orgUnitResourceType); ok {
+		return p.Grant(ctx, principal, entitlement)
+	}
+
+	if o.client.UserProvisioningService == nil {
+		return nil, nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", admin.AdminDirectoryUserScope))
+	}
+	if principal.GetId().GetResourceType() != resourceTypeUser.Id {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "user principal is required")
+	}
+
+	orgUnitPath, err := o.orgUnitPath(ctx, entitlement.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := moveUserToOrgUnit(ctx, o.client, principal.GetId().GetResource(), orgUnitPath); err != nil {
+		return nil, nil, err
+	}
+
+	grant := sdkGrant.NewGrant(entitlement.Resource, orgUnitMemberEntitlement, principal.GetId())
+	return []*v2.Grant{grant}, nil, nil
+}
+
+// Revoke is not supported: a user cannot be left without an OU. Grant
+// membership of the destination OU instead.
+func (o *orgUnitResourceType) Revoke(_ context.Context, _ *v2.Grant) (annotations.Annotations, error) {
+	return nil, uhttp.WrapErrors(codes.Unimplemented,
+		"google-workspace: users cannot be removed from an organizational unit; grant membership of another organizational unit to move them")
+}
+
+func (o *orgUnitResourceType) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	ou, err := o.client.GetOrgUnit(ctx, o.customerId, resourceId.Resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to get org unit: %w", err)
+	}
+
+	orgUnitResource, err := orgUnitToResource(ou)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create org unit resource in Get: %w", err)
+	}
+	return orgUnitResource, nil, nil
+}
+
+// orgUnitPath returns the OU path stored on resource's profile, falling back
+// to looking the OU up when the profile is missing (e.g. a resource built
+// from an entitlement reference only).
+func (o *orgUnitResourceType) orgUnitPath(ctx context.Context, resource *v2.Resource) (string, error) {
+	if orgUnitPath, ok := rs.GetProfileStringValue(rs.GetProfile(resource), profileKeyOrgUnitPath); ok && orgUnitPath != "" {
+		return orgUnitPath, nil
+	}
+	ou, err := o.client.GetOrgUnit(ctx, o.customerId, resource.Id.Resource)
+	if err != nil {
+		return "", fmt.Errorf("google-workspace: failed to resolve org unit path: %w", err)
+	}
+	return ou.OrgUnitPath, nil
+}
+
+// orgUnitPathKey normalizes an OU path for comparison. Google matches OU
+// paths case-insensitively, so every membership check goes through this.
+func orgUnitPathKey(orgUnitPath string) string {
+	return strings.ToLower(orgUnitPath)
+}
+
+func orgUnitBuilder(client *gwclient.GoogleWorkspaceClient, customerId string, domain string) *orgUnitResourceType {
+	return &orgUnitResourceType{
+		resourceType: resourceTypeOrgUnit,
+		client:       client,
+		customerId:   customerId,
+		domain:       domain,
+	}
+}
+
+func orgUnitProfile(ou *admin.OrgUnit) map[string]interface{} {
+	profile := make(map[string]interface{})
+	profile["org_unit_id"] = ou.OrgUnitId
+	profile["org_unit_name"] = ou.Name
+	profile[profileKeyOrgUnitPath] = ou.OrgUnitPath
+	profile["parent_org_unit_id"] = ou.ParentOrgUnitId
+	profile["parent_org_unit_path"] = ou.ParentOrgUnitPath
+	profile["description"] = ou.Description
+	return profile
+}
+
+// orgUnitToResource converts an admin.OrgUnit to a v2.Resource nested under
+// its parent OU. Top-level OUs (whose parent is the unsynced root) have no
+// parent resource.
+func orgUnitToResource(ou *admin.OrgUnit) (*v2.Resource, error) {
+	if ou.OrgUnitId == "" {
+		return nil, fmt.Errorf("google-workspace: org unit %s has no id", ou.OrgUnitPath)
+	}
+	resourceOpts := []rs.ResourceOption{
+		rs.WithResourceProfile(orgUnitProfile(ou)),
+		rs.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: resourceTypeOrgUnit.Id}),
+		rs.WithAnnotation(&v2.RawId{Id: ou.OrgUnitId}),
+	}
+	if ou.Description != "" {
+		resourceOpts = append(resourceOpts, rs.WithDescription(ou.Description))
+	}
+	if ou.ParentOrgUnitId != "" && ou.ParentOrgUnitPath != rootOrgUnitPath {
+		parentID, err := rs.NewResourceID(resourceTypeOrgUnit, ou.ParentOrgUnitId)
+		if err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to create parent org unit resource ID: %w", err)
+		}
+		resourceOpts = append(resourceOpts, rs.WithParentResourceID(parentID))
+	}
+	return rs.NewGroupResource(ou.Name, resourceTypeOrgUnit, ou.OrgUnitId, nil, resourceOpts...)
+}
@@ -0,0 +1,278 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+type testOrgUnitServerState struct {
+	mtx        sync.Mutex
+	orgUnits   []*directoryAdmin.OrgUnit
+	users      map[string]*testUserWithOrgUnit
+	userPages  int
+	putCount   int
+	listParent []string
+}
+
+func newOrgUnitTestServer(t *testing.T, state *testOrgUnitServerState) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/directory/v1/customer/test-customer/orgunits", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		parent := r.URL.Query().Get("orgUnitPath")
+		state.listParent = append(state.listParent, parent)
+		resp := &directoryAdmin.OrgUnits{}
+		for _, ou := range state.orgUnits {
+			if (parent == "" && ou.ParentOrgUnitPath == rootOrgUnitPath) || (parent != "" && ou.ParentOrgUnitId == parent) {
+				resp.OrganizationUnits = append(resp.OrganizationUnits, ou)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/admin/directory/v1/customer/test-customer/orgunits/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/customer/test-customer/orgunits/")
+		for _, ou := range state.orgUnits {
+			if ou.OrgUnitId == key {
+				_ = json.NewEncoder(w).Encode(ou)
+				return
+			}
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/admin/directory/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		state.userPages++
+		// Serve one user per page, in ID order, so the index walk spans
+		// several calls.
+		ids := make([]string, 0, len(state.users))
+		for id := range state.users {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		i, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		u := state.users[ids[i]]
+		resp := &directoryAdmin.Users{Users: []*directoryAdmin.User{{Id: u.Id, OrgUnitPath: u.OrgUnitPath}}}
+		if i+1 < len(ids) {
+			resp.NextPageToken = strconv.Itoa(i + 1)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/admin/directory/v1/users/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		u := state.users[strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/users/")]
+		if u == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodPut {
+			state.putCount++
+			var body directoryAdmin.User
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			u.OrgUnitPath = body.OrgUnitPath
+		}
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.User{Id: u.Id, PrimaryEmail: u.PrimaryEmail, OrgUnitPath: u.OrgUnitPath})
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestOrgUnitResourceType(t *testing.T, server *httptest.Server) *orgUnitResourceType {
+	t.Helper()
+	dir := newTestDirectoryService(t, server.URL, server.Client())
+	return orgUnitBuilder(&gwclient.GoogleWorkspaceClient{
+		OrgUnitService:          dir,
+		UserService:             dir,
+		UserProvisioningService: dir,
+	}, "test-customer", "")
+}
+
+func testOrgUnits() []*directoryAdmin.OrgUnit {
+	return []*directoryAdmin.OrgUnit{
+		{OrgUnitId: "id:sales", Name: "sales", OrgUnitPath: "/sales", ParentOrgUnitId: "id:root", ParentOrgUnitPath: "/"},
+		{OrgUnitId: "id:emea", Name: "emea", OrgUnitPath: "/sales/emea", ParentOrgUnitId: "id:sales", ParentOrgUnitPath: "/sales"},
+	}
+}
+
+func TestOrgUnitList_NestsChildrenUnderParent(t *testing.T) {
+	state := &testOrgUnitServerState{orgUnits: testOrgUnits()}
+	server := newOrgUnitTestServer(t, state)
+	defer server.Close()
+	o := newTestOrgUnitResourceType(t, server)
+
+	top, _, err := o.List(context.Background(), nil, rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	require.Equal(t, "id:sales", top[0].Id.Resource)
+	require.Nil(t, top[0].ParentResourceId, "OUs directly under the root must be top-level resources")
+	childType := &v2.ChildResourceType{}
+	annos := annotations.Annotations(top[0].Annotations)
+	ok, err := annos.Pick(childType)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, resourceTypeOrgUnit.Id, childType.ResourceTypeId)
+
+	children, _, err := o.List(context.Background(), top[0].Id, rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, children, 1)
+	require.Equal(t, "id:emea", children[0].Id.Resource)
+	require.Equal(t, "id:sales", children[0].ParentResourceId.Resource)
+	require.Equal(t, []string{"", "id:sales"}, state.listParent)
+}
+
+// collectOrgUnitGrants pages through Grants for resource until the token is
+// exhausted.
+func collectOrgUnitGrants(t *testing.T, o *orgUnitResourceType, resource *v2.Resource, ss *fakeSessionStore) []*v2.Grant {
+	t.Helper()
+	var grants []*v2.Grant
+	token := ""
+	for calls := 0; ; calls++ {
+		require.Less(t, calls, 10, "grants pagination did not terminate")
+		page, results, err := o.Grants(context.Background(), resource, rs.SyncOpAttrs{Session: ss, PageToken: pagination.Token{Token: token}})
+		require.NoError(t, err)
+		grants = append(grants, page...)
+		token = results.NextPageToken
+		if token == "" {
+			return grants
+		}
+	}
+}
+
+func TestOrgUnitGrants_OnlyDirectMembers(t *testing.T) {
+	state := &testOrgUnitServerState{
+		orgUnits: testOrgUnits(),
+		users: map[string]*testUserWithOrgUnit{
+			"alice": {Id: "alice", OrgUnitPath: "/sales"},
+			"bob":   {Id: "bob", OrgUnitPath: "/sales/emea"},
+			"carol": {Id: "carol", OrgUnitPath: "/Sales/EMEA"},
+		},
+	}
+	server := newOrgUnitTestServer(t, state)
+	defer server.Close()
+	o := newTestOrgUnitResourceType(t, server)
+	ss := newFakeSessionStore()
+
+	sales, err := orgUnitToResource(testOrgUnits()[0])
+	require.NoError(t, err)
+	emea, err := orgUnitToResource(testOrgUnits()[1])
+	require.NoError(t, err)
+
+	grants := collectOrgUnitGrants(t, o, sales, ss)
+	require.Len(t, grants, 1, "users in a descendant OU must not be granted the parent OU")
+	require.Equal(t, "alice", grants[0].Principal.Id.Resource)
+	require.Equal(t, 3, state.userPages)
+
+	// The second OU reuses the index without walking the directory again,
+	// and OU paths match case-insensitively.
+	grants = collectOrgUnitGrants(t, o, emea, ss)
+	require.Len(t, grants, 2)
+	require.ElementsMatch(t, []string{"bob", "carol"}, []string{grants[0].Principal.Id.Resource, grants[1].Principal.Id.Resource})
+	require.Equal(t, 3, state.userPages)
+}
+
+// TestOrgUnitGrants_RetriedIndexPage checks that indexing a directory page
+// again, as a retried Grants call does, grants its users once.
+func TestOrgUnitGrants_RetriedIndexPage(t *testing.T) {
+	state := &testOrgUnitServerState{
+		orgUnits: testOrgUnits(),
+		users: map[string]*testUserWithOrgUnit{
+			"alice": {Id: "alice", OrgUnitPath: "/sales"},
+			"bob":   {Id: "bob", OrgUnitPath: "/sales"},
+		},
+	}
+	server := newOrgUnitTestServer(t, state)
+	defer server.Close()
+	o := newTestOrgUnitResourceType(t, server)
+	ss := newFakeSessionStore()
+	sales, err := orgUnitToResource(testOrgUnits()[0])
+	require.NoError(t, err)
+
+	for range 2 {
+		_, results, err := o.Grants(context.Background(), sales, rs.SyncOpAttrs{Session: ss})
+		require.NoError(t, err)
+		require.NotEmpty(t, results.NextPageToken)
+	}
+	grants := collectOrgUnitGrants(t, o, sales, ss)
+	require.Len(t, grants, 2)
+	require.Equal(t, "alice", grants[0].Principal.Id.Resource)
+	require.Equal(t, "bob", grants[1].Principal.Id.Resource)
+}
+
+func TestOrgUnitGrant_MovesUserAndIsIdempotent(t *testing.T) {
+	state := &testOrgUnitServerState{
+		orgUnits: testOrgUnits(),
+		users: map[string]*testUserWithOrgUnit{
+			"alice": {Id: "alice", PrimaryEmail: "alice@example.com", OrgUnitPath: "/"},
+		},
+	}
+	server := newOrgUnitTestServer(t, state)
+	defer server.Close()
+	o := newTestOrgUnitResourceType(t, server)
+
+	emea, err := orgUnitToResource(testOrgUnits()[1])
+	require.NoError(t, err)
+	entitlement := &v2.Entitlement{Resource: emea, Slug: orgUnitMemberEntitlement}
+	principal := &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeUser.Id, Resource: "alice"}}
+
+	grants, _, err := o.Grant(context.Background(), principal, entitlement)
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	require.Equal(t, "/sales/emea", state.users["alice"].OrgUnitPath)
+	require.Equal(t, 1, state.putCount)
+
+	_, _, err = o.Grant(context.Background(), principal, entitlement)
+	require.NoError(t, err)
+	require.Equal(t, 1, state.putCount, "granting the OU a user is already in must not issue an update")
+
+	// Grants matches OU paths case-insensitively, so Grant must too.
+	state.users["alice"].OrgUnitPath = "/Sales/EMEA"
+	_, _, err = o.Grant(context.Background(), principal, entitlement)
+	require.NoError(t, err)
+	require.Equal(t, 1, state.putCount, "an OU path differing only in case is the same OU")
+}
+
+func TestOrgUnitGrant_ResolvesPathWhenProfileMissing(t *testing.T) {
+	state := &testOrgUnitServerState{
+		orgUnits: testOrgUnits(),
+		users: map[string]*testUserWithOrgUnit{
+			"alice": {Id: "alice", OrgUnitPath: "/"},
+		},
+	}
+	server := newOrgUnitTestServer(t, state)
+	defer server.Close()
+	o := newTestOrgUnitResourceType(t, server)
+
+	entitlement := &v2.Entitlement{Resource: &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeOrgUnit.Id, Resource: "id:sales"}}}
+	principal := &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeUser.Id, Resource: "alice"}}
+
+	_, _, err := o.Grant(context.Background(), principal, entitlement)
+	require.NoError(t, err)
+	require.Equal(t, "/sales", state.users["alice"].OrgUnitPath)
+}
+
+func TestOrgUnitRevoke_Unimplemented(t *testing.T) {
+	o := orgUnitBuilder(&gwclient.GoogleWorkspaceClient{}, "test-customer", "")
+	_, err := o.Revoke(context.Background(), &v2.Grant{})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
@@ -0,0 +1,329 @@
+// rate_limiter.go throttles every Google API call the connector makes, per API family.
+//
+// Google enforces quotas per API and per project, and every connector sharing a GCP project
+// spends from the same quota. Each family therefore gets its own token buckets, shared by the
+// syncers, event feeds and actions of a connector and its additional tenants: a per-minute bucket that delays calls until
+// a token refills, and a per-day bucket that fails calls once the day's budget is spent, since
+// waiting hours for it would only stall the sync. Budgets come from the api-rate-limits setting;
+// only the Reports API is limited by default, to its filter-query quota:
+// https://developers.google.com/workspace/admin/reports/v1/limits
+package connector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/metrics"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+// apiFamily is a Google API whose calls share one quota.
+type apiFamily string
+
+const (
+	apiDirectory      apiFamily = "directory"
+	apiReports        apiFamily = "reports"
+	apiDataTransfer   apiFamily = "data_transfer"
+	apiGroupsSettings apiFamily = "groups_settings"
+	apiLicensing      apiFamily = "licensing"
+	apiCloudIdentity  apiFamily = "cloud_identity"
+	apiDrive          apiFamily = "drive"
+	apiGmail          apiFamily = "gmail"
+	apiAlertCenter    apiFamily = "alert_center"
+)
+
+// apiFamilyPathPrefixes classifies a request by its path, which unlike its
+// host is kept when base-url sends every API to one server.
+var apiFamilyPathPrefixes = []struct {
+	prefix string
+	family apiFamily
+}{
+	{"/admin/directory/", apiDirectory},
+	{"/batch/admin/directory_", apiDirectory},
+	{"/admin/reports/", apiReports},
+	{"/admin/datatransfer/", apiDataTransfer},
+	{"/groups/v1/", apiGroupsSettings},
+	{"/apps/licensing/", apiLicensing},
+	{"/drive/", apiDrive},
+	{"/gmail/", apiGmail},
+	{"/v1beta1/alerts", apiAlertCenter},
+	{"/v1/inboundS", apiCloudIdentity},
+}
+
+func apiFamilyForPath(path string) (apiFamily, bool) {
+	for _, p := range apiFamilyPathPrefixes {
+		if strings.HasPrefix(path, p.prefix) {
+			return p.family, true
+		}
+	}
+	return "", false
+}
+
+// reportsFilterQueryQuotaPerMinute mirrors Google's documented 250/min filter-query cap, with a
+// small safety margin below the hard limit to tolerate clock/measurement jitter. Nearly every
+// Reports call the connector makes is scoped by user, event or filters, so it is the Reports
+// family's default per-minute budget.
+const reportsFilterQueryQuotaPerMinute = 220
+
+// apiRateLimit is one family's budget. Zero means unlimited.
+type apiRateLimit struct {
+	PerMinute int
+	PerDay    int
+}
+
+func defaultAPIRateLimits() map[apiFamily]apiRateLimit {
+	return map[apiFamily]apiRateLimit{
+		apiReports: {PerMinute: reportsFilterQueryQuotaPerMinute},
+	}
+}
+
+// parseAPIRateLimits parses api-rate-limits entries of the form family=N/min
+// or family=N/day over the defaults. A family listed without a per-minute
+// budget keeps its default one.
+func parseAPIRateLimits(entries []string) (map[apiFamily]apiRateLimit, error) {
+	limits := defaultAPIRateLimits()
+	for _, entry := range entries {
+		name, budget, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		family := apiFamily(strings.ToLower(strings.TrimSpace(name)))
+		if !ok || !knownAPIFamily(family) {
+			return nil, fmt.Errorf("google-workspace: invalid api rate limit %q: must be <api>=<n>/min or <api>=<n>/day with a known api", entry)
+		}
+		count, per, ok := strings.Cut(strings.TrimSpace(budget), "/")
+		n, err := strconv.Atoi(count)
+		if !ok || err != nil || n <= 0 {
+			return nil, fmt.Errorf("google-workspace: invalid api rate limit %q: budget must be a positive number per min or day", entry)
+		}
+		l := limits[family]
+		switch per {
+		case "min":
+			l.PerMinute = n
+		case "day":
+			l.PerDay = n
+		default:
+			return nil, fmt.Errorf("google-workspace: invalid api rate limit %q: budget must be per min or day", entry)
+		}
+		limits[family] = l
+	}
+	return limits, nil
+}
+
+func knownAPIFamily(family apiFamily) bool {
+	for _, p := range apiFamilyPathPrefixes {
+		if p.family == family {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenBucket holds up to capacity tokens, refilled continuously over period.
+type tokenBucket struct {
+	tokens     float64
+	capacity   float64
+	period     time.Duration
+	lastRefill time.Time
+}
+
+func newTokenBucket(capacity int, period time.Duration, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		tokens:     float64(capacity),
+		capacity:   float64(capacity),
+		period:     period,
+		lastRefill: now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.lastRefill)
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = math.Min(b.capacity, b.tokens+elapsed.Seconds()*b.capacity/b.period.Seconds())
+	b.lastRefill = now
+}
+
+// untilTokens is how long until the bucket holds n tokens, or is full when
+// it can't hold that many.
+func (b *tokenBucket) untilTokens(n float64) time.Duration {
+	n = math.Min(n, b.capacity)
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) * float64(b.period) / b.capacity)
+}
+
+// apiRateLimiter spends one family's budget. A nil bucket is unlimited.
+type apiRateLimiter struct {
+	family apiFamily
+	mu     sync.Mutex
+	minute *tokenBucket
+	day    *tokenBucket
+	now    func() time.Time
+}
+
+func newAPIRateLimiter(family apiFamily, limit apiRateLimit, now func() time.Time) *apiRateLimiter {
+	l := &apiRateLimiter{family: family, now: now}
+	if limit.PerMinute > 0 {
+		l.minute = newTokenBucket(limit.PerMinute, time.Minute, now())
+	}
+	if limit.PerDay > 0 {
+		l.day = newTokenBucket(limit.PerDay, 24*time.Hour, now())
+	}
+	return l
+}
+
+// Wait blocks until the per-minute budget has tokens for n calls or ctx is
+// cancelled, and fails once the per-day budget is spent. A batch of more calls
+// than a budget holds waits for a full bucket and leaves it in debt, which
+// later calls wait out. It returns how long it waited.
+func (l *apiRateLimiter) Wait(ctx context.Context, n int) (time.Duration, error) {
+	var waited time.Duration
+	for {
+		l.mu.Lock()
+		now := l.now()
+		if l.day != nil {
+			l.day.refill(now)
+			if wait := l.day.untilTokens(float64(n)); wait > 0 {
+				l.mu.Unlock()
+				return waited, uhttp.WrapErrors(codes.ResourceExhausted,
+					fmt.Sprintf("google-workspace: daily %s api budget spent; the next call is allowed in %s", l.family, wait.Round(time.Second)))
+			}
+		}
+		var wait time.Duration
+		if l.minute != nil {
+			l.minute.refill(now)
+			wait = l.minute.untilTokens(float64(n))
+		}
+		if wait == 0 {
+			if l.minute != nil {
+				l.minute.tokens -= float64(n)
+			}
+			if l.day != nil {
+				l.day.tokens -= float64(n)
+			}
+			l.mu.Unlock()
+			return waited, nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-time.After(wait):
+			waited += wait
+		case <-ctx.Done():
+			return waited, ctx.Err()
+		}
+	}
+}
+
+// rateLimiterRegistry holds the limiter of each API family and records how
+// long calls wait for them. Each connector has its own, built from its
+// api-rate-limits, which its additional tenants share.
+type rateLimiterRegistry struct {
+	limiters map[apiFamily]*apiRateLimiter
+
+	waitTime  metrics.Int64Histogram
+	throttled metrics.Int64Counter
+}
+
+// rateLimitMetrics receives the metrics of every registry. It reports to the
+// global OpenTelemetry meter provider, which is a no-op unless the process
+// configures one.
+var rateLimitMetrics = metrics.NewOtelHandler(context.Background(), otel.GetMeterProvider(), "baton-google-workspace")
+
+// newRateLimiterRegistry creates a limiter with full buckets for each family
+// with a budget in limits.
+func newRateLimiterRegistry(handler metrics.Handler, limits map[apiFamily]apiRateLimit) *rateLimiterRegistry {
+	r := &rateLimiterRegistry{
+		limiters:  map[apiFamily]*apiRateLimiter{},
+		waitTime:  handler.Int64Histogram("google_workspace_api_rate_limit_wait", "Time Google API calls waited for their API's rate limit", metrics.Milliseconds),
+		throttled: handler.Int64Counter("google_workspace_api_rate_limited_calls", "Google API calls delayed or refused by their API's rate limit", metrics.Dimensionless),
+	}
+	for family, limit := range limits {
+		if limit != (apiRateLimit{}) {
+			r.limiters[family] = newAPIRateLimiter(family, limit, time.Now)
+		}
+	}
+	return r
+}
+
+// Wait waits for n calls to family's API.
+func (r *rateLimiterRegistry) Wait(ctx context.Context, family apiFamily, n int) error {
+	l, ok := r.limiters[family]
+	if !ok {
+		return nil
+	}
+	waited, err := l.Wait(ctx, n)
+	tags := map[string]string{"api": string(family)}
+	r.waitTime.Record(ctx, waited.Milliseconds(), tags)
+	if err != nil || waited > 0 {
+		r.throttled.Add(ctx, int64(n), tags)
+	}
+	return err
+}
+
+// rateLimitTransport waits for each request's API family budget before
+// sending it, spending one call for each call of a batch request. Requests
+// outside the known families, such as token requests, and requests without a
+// registry are not limited. It
+// applies the request timeout itself, once the wait is over, since an
+// http.Client timeout would also count the time spent queued behind other
+// calls. Batch requests get the timeout once per batchCallsPerTimeout calls.
+type rateLimitTransport struct {
+	base     http.RoundTripper
+	registry *rateLimiterRegistry
+	timeout  time.Duration
+}
+
+// batchCallsPerTimeout is how many calls of a batch request share one
+// request timeout.
+const batchCallsPerTimeout = 100
+
+func newRateLimitTransport(base http.RoundTripper, registry *rateLimiterRegistry, timeout time.Duration) *rateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitTransport{base: base, registry: registry, timeout: timeout}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	calls := gwclient.BatchSize(req.Context())
+	if family, ok := apiFamilyForPath(req.URL.Path); ok && t.registry != nil {
+		if err := t.registry.Wait(req.Context(), family, calls); err != nil {
+			return nil, err
+		}
+	}
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	timeout := t.timeout * time.Duration(1+(calls-1)/batchCallsPerTimeout)
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's timeout once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,128 @@
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseAPIRateLimits(t *testing.T) {
+	limits, err := parseAPIRateLimits([]string{"directory=1500/min", " Directory = 100000/day", "reports=50000/day"})
+	require.NoError(t, err)
+	require.Equal(t, map[apiFamily]apiRateLimit{
+		apiDirectory: {PerMinute: 1500, PerDay: 100000},
+		apiReports:   {PerMinute: reportsFilterQueryQuotaPerMinute, PerDay: 50000},
+	}, limits)
+
+	for _, entry := range []string{"directory", "calendar=10/min", "directory=0/min", "directory=10/hour", "directory=ten/min"} {
+		_, err := parseAPIRateLimits([]string{entry})
+		require.Error(t, err, entry)
+	}
+}
+
+func TestAPIFamilyForPath(t *testing.T) {
+	for path, want := range map[string]apiFamily{
+		"/admin/directory/v1/users":                               apiDirectory,
+		"/admin/reports/v1/activity/users/all/applications/login": apiReports,
+		"/admin/datatransfer/v1/transfers":                        apiDataTransfer,
+		"/groups/v1/groups/eng@example.com":                       apiGroupsSettings,
+		"/apps/licensing/v1/product/Google-Apps/users":            apiLicensing,
+		"/v1/inboundSsoAssignments":                               apiCloudIdentity,
+		"/drive/v3/drives":                                        apiDrive,
+		"/gmail/v1/users/me/settings/delegates":                   apiGmail,
+		"/v1beta1/alerts":                                         apiAlertCenter,
+	} {
+		got, ok := apiFamilyForPath(path)
+		require.True(t, ok, path)
+		require.Equal(t, want, got, path)
+	}
+	_, ok := apiFamilyForPath("/token")
+	require.False(t, ok)
+}
+
+type recordingHistogram struct {
+	metrics.Int64Histogram
+	values []int64
+}
+
+func (h *recordingHistogram) Record(_ context.Context, value int64, _ map[string]string) {
+	h.values = append(h.values, value)
+}
+
+func TestRateLimiterRegistry(t *testing.T) {
+	ctx := context.Background()
+	r := newRateLimiterRegistry(metrics.NewNoOpHandler(ctx), map[apiFamily]apiRateLimit{apiDirectory: {PerMinute: 600, PerDay: 602}})
+	waits := &recordingHistogram{}
+	r.waitTime = waits
+
+	// Unlimited families never wait.
+	require.NoError(t, r.Wait(ctx, apiGmail, 1))
+	require.Empty(t, waits.values)
+
+	// The per-minute bucket starts full, then refills a token every 100ms.
+	for range 600 {
+		require.NoError(t, r.Wait(ctx, apiDirectory, 1))
+	}
+	start := time.Now()
+	require.NoError(t, r.Wait(ctx, apiDirectory, 1))
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	require.Positive(t, waits.values[len(waits.values)-1])
+
+	// A cancelled wait returns the context's error.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, r.Wait(cancelled, apiDirectory, 1), context.Canceled)
+
+	// Once the daily budget is spent, calls fail instead of waiting.
+	require.NoError(t, r.Wait(ctx, apiDirectory, 1))
+	err := r.Wait(ctx, apiDirectory, 1)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+// TestFakeServer_APIRateLimits checks that configured budgets apply to the
+// connector's calls, across services built for different scopes, and only to
+// the connector they are configured for.
+func TestFakeServer_APIRateLimits(t *testing.T) {
+	srv := newFakeWorkspace(t)
+	c, err := NewConnector(context.Background(), Config{
+		CustomerID:         srv.CustomerID,
+		AdministratorEmail: "admin@example.com",
+		Credentials:        srv.CredentialsJSON(),
+		BaseURL:            srv.URL(),
+		APIRateLimits:      []string{"directory=3/day"},
+	})
+	require.NoError(t, err)
+	client, err := c.getClient(context.Background())
+	require.NoError(t, err)
+	// Another connector's budgets don't replace this one's.
+	other, err := NewConnector(context.Background(), Config{
+		CustomerID:         srv.CustomerID,
+		AdministratorEmail: "admin@example.com",
+		Credentials:        srv.CredentialsJSON(),
+		BaseURL:            srv.URL(),
+	})
+	require.NoError(t, err)
+	otherClient, err := other.getClient(context.Background())
+	require.NoError(t, err)
+
+	srv.ResetRequests()
+	_, err = client.GetUser(context.Background(), "alice@example.com")
+	require.NoError(t, err)
+	_, err = client.GetGroup(context.Background(), "eng@example.com")
+	require.NoError(t, err)
+	_, err = client.GetUser(context.Background(), "bob@example.com")
+	require.NoError(t, err)
+	_, err = client.GetGroup(context.Background(), "eng@example.com")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Len(t, srv.Requests(), 3, "the refused call never reaches the server")
+	_, err = otherClient.GetGroup(context.Background(), "eng@example.com")
+	require.NoError(t, err, "the other connector has no directory budget")
+
+	_, err = NewConnector(context.Background(), Config{APIRateLimits: []string{"directory=fast"}})
+	require.Error(t, err)
+}
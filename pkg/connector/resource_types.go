@@ -27,6 +27,8 @@ var (
 		Annotations: v1AnnotationsWithPermissions("role", capabilityPermissions(
 			"admin.directory.rolemanagement",
 			"admin.directory.domain.readonly",
+			// Entitlements enumerate OUs for the per-OU scoped assignments.
+			"admin.directory.orgunit.readonly",
 		)),
 	}
 	resourceTypeGroup = &v2.ResourceType{
@@ -37,9 +39,9 @@ var (
 			"admin.directory.group",
 			"admin.directory.group.member",
 			"admin.directory.domain.readonly",
-			// Requested at runtime by getGroupsSettingsService for
-			// modify_group_settings; previously undeclared here, causing the
-			// action to fail silently.
+			// Requested at runtime by getGroupsSettingsService for the group
+			// profile's settings and modify_group_settings; previously
+			// undeclared here, causing the action to fail silently.
 			"apps.groups.settings",
 		)),
 	}
@@ -63,6 +65,76 @@ var (
 			// transfer_user_drive_files/transfer_user_calendar; previously
 			// undeclared here, causing them to fail silently.
 			"admin.datatransfer",
+			// Alert Center alerts are linked to the affected user; also
+			// needed by the update_alert action.
+			"apps.alerts",
+		)),
+	}
+	resourceTypeOrgUnit = &v2.ResourceType{
+		Id:          "org_unit",
+		DisplayName: "Organizational Unit",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_GROUP},
+		Annotations: annotations.New(capabilityPermissions(
+			"admin.directory.orgunit.readonly",
+			// Grants list users by orgUnitPath, and granting "member" moves
+			// the user, so the user write scope is needed for provisioning.
+			"admin.directory.user",
+		)),
+	}
+	resourceTypeLicense = &v2.ResourceType{
+		Id:          "license",
+		DisplayName: "License",
+		Annotations: annotations.New(capabilityPermissions(
+			"apps.licensing",
+			// Grants resolve each assignee's email to a user ID.
+			"admin.directory.user.readonly",
+		)),
+	}
+	resourceTypeMobileDevice = &v2.ResourceType{
+		Id:          "mobile_device",
+		DisplayName: "Mobile Device",
+		Annotations: annotations.New(capabilityPermissions(
+			"admin.directory.device.mobile.readonly",
+			// approve/block/wipe actions.
+			"admin.directory.device.mobile.action",
+			// Grants resolve each owner's email to a user ID.
+			"admin.directory.user.readonly",
+		)),
+	}
+	resourceTypeChromeDevice = &v2.ResourceType{
+		Id:          "chrome_device",
+		DisplayName: "Chrome OS Device",
+		Annotations: annotations.New(capabilityPermissions(
+			// The write scope covers listing as well as the
+			// disable/deprovision actions.
+			"admin.directory.device.chromeos",
+			// Grants resolve the annotated user's email to a user ID.
+			"admin.directory.user.readonly",
+		)),
+	}
+	resourceTypeSharedDrive = &v2.ResourceType{
+		Id:          "shared_drive",
+		DisplayName: "Shared Drive",
+		Annotations: annotations.New(capabilityPermissions(
+			// The write scope covers listing drives and members as well as
+			// adding and removing members.
+			"drive",
+			// Grants resolve member emails to user and group IDs.
+			"admin.directory.user.readonly",
+			"admin.directory.group.readonly",
+		)),
+	}
+	resourceTypeMailbox = &v2.ResourceType{
+		Id:          "mailbox",
+		DisplayName: "Mailbox",
+		Annotations: annotations.New(capabilityPermissions(
+			// Delegates, forwarding and send-as settings. Google offers no
+			// read-only variant.
+			"gmail.settings.basic",
+			// Adding and removing delegates and forwarding addresses.
+			"gmail.settings.sharing",
+			// Grants resolve each delegate's email to a user ID.
+			"admin.directory.user.readonly",
 		)),
 	}
 	resourceTypeEnterpriseApplication = &v2.ResourceType{
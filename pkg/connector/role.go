@@ -11,9 +11,11 @@ import (
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/session"
 	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-sdk/pkg/types/sessions"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
@@ -26,9 +28,29 @@ import (
 
 const (
 	roleMemberEntitlement = "member"
+
+	// roleOrgUnitEntitlementPrefix prefixes the slug of a role's per-OU
+	// entitlement, followed by the OU ID without its "id:" prefix. The plain
+	// "member" entitlement is the tenant-wide (CUSTOMER-scoped) assignment.
+	// The slug has no ':' so entitlementSlug can recover it from an ID.
+	roleOrgUnitEntitlementPrefix = "member_org_unit_"
+
+	roleScopeTypeCustomer = "CUSTOMER"
+	roleScopeTypeOrgUnit  = "ORG_UNIT"
 )
 
+// roleOrgUnitsNamespace holds the org units every role has a scoped
+// entitlement for, listed by the first role Entitlements call of a sync.
+var roleOrgUnitsNamespace = sessions.WithPrefix("role_org_units")
+
+// roleOrgUnit is an org unit as kept in roleOrgUnitsNamespace.
+type roleOrgUnit struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+}
+
 type roleResourceType struct {
+	tenantPartitions
 	resourceType *v2.ResourceType
 	client       *gwclient.GoogleWorkspaceClient
 	customerId   string
@@ -38,7 +60,11 @@ func (o *roleResourceType) ResourceType(_ context.Context) *v2.ResourceType {
 	return o.resourceType
 }
 
-func (o *roleResourceType) List(ctx context.Context, _ *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+func (o *roleResourceType) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.listPartitions(ctx, parentResourceID, attrs)
+	}
+
 	l := ctxzap.Extract(ctx)
 	bag := &pagination.Bag{}
 	err := bag.Unmarshal(attrs.PageToken.Token)
@@ -56,16 +82,20 @@ func (o *roleResourceType) List(ctx context.Context, _ *v2.ResourceId, attrs rs.
 	}
 
 	rv := make([]*v2.Resource, 0, len(roles.Items))
+	if len(roles.Items) == 0 {
+		nextPage, err := bag.NextToken(roles.NextPageToken)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate next page token in role List: %w", err)
+		}
+		return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+	}
+	privileges := o.privilegeIndex(ctx)
 	for _, r := range roles.Items {
-		tempRoleId := strconv.FormatInt(r.RoleId, 10)
-		if tempRoleId == "" {
+		if r.RoleId == 0 {
 			l.Error("role had no id", zap.String("name", r.RoleName))
 			continue
 		}
-		annos := &v2.V1Identifier{
-			Id: tempRoleId,
-		}
-		roleResource, err := rs.NewRoleResource(r.RoleName, resourceTypeRole, tempRoleId, nil, rs.WithAnnotation(annos), rs.WithResourceProfile(roleProfile(r)))
+		roleResource, err := roleToResource(r, privileges)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to create role resource in List: %w", err)
 		}
@@ -78,7 +108,11 @@ func (o *roleResourceType) List(ctx context.Context, _ *v2.ResourceId, attrs rs.
 	return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
 }
 
-func (o *roleResourceType) Entitlements(ctx context.Context, resource *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+func (o *roleResourceType) Entitlements(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.entitlementsPartitions(ctx, resource, attrs)
+	}
+
 	var annos annotations.Annotations
 	annos.Update(&v2.V1Identifier{
 		Id: V1MembershipEntitlementID(resource.Id.Resource),
@@ -87,10 +121,63 @@ func (o *roleResourceType) Entitlements(ctx context.Context, resource *v2.Resour
 	member.Description = fmt.Sprintf("Has the %s role in Google Workspace", resource.DisplayName)
 	member.Annotations = annos
 	member.DisplayName = fmt.Sprintf("%s Role Member", resource.DisplayName)
-	return []*v2.Entitlement{member}, nil, nil
+	rv := []*v2.Entitlement{member}
+
+	// Without the org unit scope, OU-scoped assignments still sync as grants
+	// but their entitlements can't be enumerated.
+	if o.client.OrgUnitService == nil {
+		return rv, nil, nil
+	}
+	orgUnits, err := o.orgUnits(ctx, attrs.Session)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, ou := range orgUnits {
+		scoped := sdkEntitlement.NewAssignmentEntitlement(resource, roleOrgUnitEntitlementSlug(ou.ID),
+			sdkEntitlement.WithGrantableTo(resourceTypeUser, resourceTypeGroup))
+		scoped.Description = fmt.Sprintf("Has the %s role in Google Workspace, scoped to the %s organizational unit", resource.DisplayName, ou.Path)
+		scoped.DisplayName = fmt.Sprintf("%s Role Member (%s)", resource.DisplayName, ou.Path)
+		rv = append(rv, scoped)
+	}
+	return rv, nil, nil
+}
+
+// orgUnits lists the customer's org units once per sync: the first call
+// stores them in the session and later calls, for other roles, reuse them.
+func (o *roleResourceType) orgUnits(ctx context.Context, ss sessions.SessionStore) ([]roleOrgUnit, error) {
+	if ss != nil {
+		cached, ok, err := session.GetJSON[[]roleOrgUnit](ctx, ss, "all", roleOrgUnitsNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to read role org units from session: %w", err)
+		}
+		if ok {
+			return cached, nil
+		}
+	}
+	resp, err := o.client.ListAllOrgUnits(ctx, o.customerId)
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace: failed to list org units for role entitlements: %w", err)
+	}
+	rv := make([]roleOrgUnit, 0, len(resp.OrganizationUnits))
+	for _, ou := range resp.OrganizationUnits {
+		if ou.OrgUnitId == "" {
+			continue
+		}
+		rv = append(rv, roleOrgUnit{ID: ou.OrgUnitId, Path: ou.OrgUnitPath})
+	}
+	if ss != nil {
+		if err := session.SetJSON(ctx, ss, "all", rv, roleOrgUnitsNamespace); err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to store role org units in session: %w", err)
+		}
+	}
+	return rv, nil
 }
 
 func (o *roleResourceType) Grants(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.grantsPartitions(ctx, resource, attrs)
+	}
+
 	bag := &pagination.Bag{}
 	err := bag.Unmarshal(attrs.PageToken.Token)
 	if err != nil {
@@ -140,7 +227,16 @@ func (o *roleResourceType) Grants(ctx context.Context, resource *v2.Resource, at
 			}
 		}
 
-		grant := sdkGrant.NewGrant(resource, roleMemberEntitlement, rmID, opts...)
+		slug := roleMemberEntitlement
+		if strings.EqualFold(roleAssignment.ScopeType, roleScopeTypeOrgUnit) && roleAssignment.OrgUnitId != "" {
+			slug = roleOrgUnitEntitlementSlug(roleAssignment.OrgUnitId)
+		}
+		opts = append(opts, sdkGrant.WithGrantMetadata(map[string]interface{}{
+			"scope_type":  roleAssignment.ScopeType,
+			"org_unit_id": roleAssignment.OrgUnitId,
+		}))
+
+		grant := sdkGrant.NewGrant(resource, slug, rmID, opts...)
 		grant.Id = tempRoleAssignmentId
 		rv = append(rv, grant)
 	}
@@ -153,6 +249,13 @@ func (o *roleResourceType) Grants(ctx context.Context, resource *v2.Resource, at
 	return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
 }
 
+// roleOrgUnitEntitlementSlug returns the slug of a role's entitlement scoped
+// to orgUnitID, which may carry the "id:" prefix OrgUnit.OrgUnitId uses;
+// RoleAssignment.OrgUnitId omits it.
+func roleOrgUnitEntitlementSlug(orgUnitID string) string {
+	return roleOrgUnitEntitlementPrefix + strings.TrimPrefix(orgUnitID, "id:")
+}
+
 func roleBuilder(client *gwclient.GoogleWorkspaceClient, customerId string) *roleResourceType {
 	return &roleResourceType{
 		resourceType: resourceTypeRole,
@@ -161,14 +264,100 @@ func roleBuilder(client *gwclient.GoogleWorkspaceClient, customerId string) *rol
 	}
 }
 
-func roleProfile(role *admin.Role) map[string]interface{} {
+func roleProfile(role *admin.Role, privileges map[string]*admin.Privilege) map[string]interface{} {
 	profile := make(map[string]interface{})
 	profile["role_id"] = role.RoleId
 	profile["role_name"] = role.RoleName
+	profile["role_description"] = role.RoleDescription
+	profile["is_system_role"] = role.IsSystemRole
+	profile["is_super_admin_role"] = role.IsSuperAdminRole
+	if privileges != nil {
+		profile["privileges"] = rolePrivilegeEntries(role, privileges)
+	}
 	return profile
 }
 
+func roleToResource(role *admin.Role, privileges map[string]*admin.Privilege) (*v2.Resource, error) {
+	roleId := strconv.FormatInt(role.RoleId, 10)
+	return rs.NewRoleResource(role.RoleName, resourceTypeRole, roleId, nil,
+		rs.WithAnnotation(&v2.V1Identifier{Id: roleId}),
+		rs.WithResourceProfile(roleProfile(role, privileges)))
+}
+
+// rolePrivilegeKey identifies a privilege; privilege names are only unique
+// within a service.
+func rolePrivilegeKey(serviceId, privilegeName string) string {
+	return serviceId + ":" + privilegeName
+}
+
+// privilegeIndex flattens the customer's privilege tree so a role's
+// privileges can be expanded to the child privileges they confer. An
+// administrator who may read roles may still be denied the privilege tree, so
+// a failure to list it is logged and returns nil, which leaves privileges off
+// role profiles rather than failing the roles themselves.
+func (o *roleResourceType) privilegeIndex(ctx context.Context) map[string]*admin.Privilege {
+	resp, err := o.client.ListPrivileges(ctx, o.customerId)
+	if err != nil {
+		ctxzap.Extract(ctx).Warn("google-workspace: failed to list privileges; role profiles will not include privileges", zap.Error(err))
+		return nil
+	}
+	index := make(map[string]*admin.Privilege)
+	var walk func([]*admin.Privilege)
+	walk = func(ps []*admin.Privilege) {
+		for _, p := range ps {
+			index[rolePrivilegeKey(p.ServiceId, p.PrivilegeName)] = p
+			walk(p.ChildPrivileges)
+		}
+	}
+	walk(resp.Items)
+	return index
+}
+
+// rolePrivilegeEntries lists the role's privileges followed by the child
+// privileges each one confers, which carry the name of their parent.
+func rolePrivilegeEntries(role *admin.Role, privileges map[string]*admin.Privilege) []interface{} {
+	rv := make([]interface{}, 0, len(role.RolePrivileges))
+	seen := make(map[string]bool)
+	var addChildren func(parent *admin.Privilege)
+	addChildren = func(parent *admin.Privilege) {
+		for _, c := range parent.ChildPrivileges {
+			key := rolePrivilegeKey(c.ServiceId, c.PrivilegeName)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			rv = append(rv, map[string]interface{}{
+				"service_id":            c.ServiceId,
+				"privilege_name":        c.PrivilegeName,
+				"parent_privilege_name": parent.PrivilegeName,
+			})
+			addChildren(c)
+		}
+	}
+	for _, rp := range role.RolePrivileges {
+		key := rolePrivilegeKey(rp.ServiceId, rp.PrivilegeName)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rv = append(rv, map[string]interface{}{
+			"service_id":     rp.ServiceId,
+			"privilege_name": rp.PrivilegeName,
+		})
+	}
+	for _, rp := range role.RolePrivileges {
+		if p, ok := privileges[rolePrivilegeKey(rp.ServiceId, rp.PrivilegeName)]; ok {
+			addChildren(p)
+		}
+	}
+	return rv
+}
+
 func (o *roleResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	if p, ok := o.provisioner(entitlement.GetResource()).(*roleResourceType); ok {
+		return p.Grant(ctx, principal, entitlement)
+	}
+
 	if o.client.RoleProvisioningService == nil {
 		return nil, nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", admin.AdminDirectoryRolemanagementScope))
 	}
@@ -180,11 +369,25 @@ func (o *roleResourceType) Grant(ctx context.Context, principal *v2.Resource, en
 	if err != nil {
 		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "failed to convert roleId to integer", err)
 	}
-	assignment, err := o.client.InsertRoleAssignment(ctx, o.customerId, &admin.RoleAssignment{
+	slug := entitlementSlug(entitlement)
+	if slug == "" {
+		slug = roleMemberEntitlement
+	}
+	roleAssignment := &admin.RoleAssignment{
 		AssignedTo: principal.GetId().GetResource(),
 		RoleId:     tempRoleId,
-		ScopeType:  "CUSTOMER",
-	})
+		ScopeType:  roleScopeTypeCustomer,
+	}
+	if orgUnitID, ok := strings.CutPrefix(slug, roleOrgUnitEntitlementPrefix); ok {
+		if orgUnitID == "" {
+			return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("google-workspace: invalid role entitlement %q", slug))
+		}
+		roleAssignment.ScopeType = roleScopeTypeOrgUnit
+		roleAssignment.OrgUnitId = orgUnitID
+	} else if slug != roleMemberEntitlement {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("google-workspace: unknown role entitlement %q", slug))
+	}
+	assignment, err := o.client.InsertRoleAssignment(ctx, o.customerId, roleAssignment)
 	if err != nil {
 		gerr := &googleapi.Error{}
 		if errors.As(err, &gerr) && gerr.Code == http.StatusConflict {
@@ -195,12 +398,16 @@ func (o *roleResourceType) Grant(ctx context.Context, principal *v2.Resource, en
 		return nil, nil, err
 	}
 
-	grant := sdkGrant.NewGrant(entitlement.Resource, roleMemberEntitlement, principal.GetId())
+	grant := sdkGrant.NewGrant(entitlement.Resource, slug, principal.GetId())
 	grant.Id = strconv.FormatInt(assignment.RoleAssignmentId, 10)
 	return []*v2.Grant{grant}, nil, nil
 }
 
 func (o *roleResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	if p, ok := o.provisioner(grant.GetEntitlement().GetResource()).(*roleResourceType); ok {
+		return p.Revoke(ctx, grant)
+	}
+
 	if o.client.RoleProvisioningService == nil {
 		return nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", admin.AdminDirectoryRolemanagementScope))
 	}
@@ -233,15 +440,12 @@ func (o *roleResourceType) Get(ctx context.Context, resourceId *v2.ResourceId, p
 		return nil, nil, err
 	}
 
-	tempRoleId := strconv.FormatInt(role.RoleId, 10)
-	if tempRoleId == "" {
+	if role.RoleId == 0 {
 		l.Error("role had no id", zap.String("name", role.RoleName))
 		return nil, nil, nil
 	}
-	annos := &v2.V1Identifier{
-		Id: tempRoleId,
-	}
-	roleResource, err := rs.NewRoleResource(role.RoleName, resourceTypeRole, tempRoleId, nil, rs.WithAnnotation(annos), rs.WithResourceProfile(roleProfile(role)))
+	privileges := o.privilegeIndex(ctx)
+	roleResource, err := roleToResource(role, privileges)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create role resource in Get: %w", err)
 	}
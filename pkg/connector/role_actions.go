@@ -0,0 +1,347 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var _ connectorbuilder.ResourceActionProvider = (*roleResourceType)(nil)
+
+const (
+	argRoleID          = "role_id"
+	argRoleName        = "role_name"
+	argRoleDescription = "role_description"
+	argRolePrivileges  = "privileges"
+	displayRoleID      = "Role ID"
+	// descriptionRolePrivileges is shared by create_role and update_role.
+	descriptionRolePrivileges = "Privileges to grant, each as serviceId:privilegeName (e.g. 00haapch16h1ysv:USERS_RETRIEVE). " +
+		"List a child privilege explicitly to grant it without its parent."
+)
+
+var (
+	createRoleActionSchema = &v2.BatonActionSchema{
+		Name:        "create_role",
+		DisplayName: "Create Admin Role",
+		Description: "Creates a custom Google Workspace admin role from a set of privileges.",
+		Arguments: []*config.Field{
+			{
+				Name:        argRoleName,
+				DisplayName: "Role Name",
+				Description: "The name of the role. Must be unique within the customer.",
+				Field:       &config.Field_StringField{},
+				IsRequired:  true,
+			},
+			{
+				Name:        argRoleDescription,
+				DisplayName: "Role Description",
+				Description: "A short description of the role.",
+				Field:       &config.Field_StringField{},
+				IsRequired:  false,
+			},
+			{
+				Name:        argRolePrivileges,
+				DisplayName: "Privileges",
+				Description: descriptionRolePrivileges,
+				Field:       &config.Field_StringSliceField{},
+				IsRequired:  true,
+			},
+		},
+		ReturnTypes: []*config.Field{
+			{
+				Name:        fieldSuccess,
+				DisplayName: displaySuccess,
+				Description: "Whether the role was created successfully.",
+				Field:       &config.Field_BoolField{},
+			},
+			{
+				Name:        fieldResource,
+				DisplayName: "Created Role",
+				Description: "The created role resource.",
+				Field:       &config.Field_ResourceField{},
+			},
+		},
+		ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_RESOURCE_CREATE},
+	}
+
+	updateRoleActionSchema = &v2.BatonActionSchema{
+		Name:        "update_role",
+		DisplayName: "Update Admin Role",
+		Description: "Updates the name, description, or privileges of a custom Google Workspace admin role. Privileges, when provided, replace the role's current set.",
+		Arguments: []*config.Field{
+			{
+				Name:        argRoleID,
+				DisplayName: displayRoleID,
+				Description: "ID of the role to update.",
+				Field:       &config.Field_StringField{},
+				IsRequired:  true,
+			},
+			{
+				Name:        argRoleName,
+				DisplayName: "Role Name",
+				Description: "The new name of the role.",
+				Field:       &config.Field_StringField{},
+				IsRequired:  false,
+			},
+			{
+				Name:        argRoleDescription,
+				DisplayName: "Role Description",
+				Description: "The new description of the role.",
+				Field:       &config.Field_StringField{},
+				IsRequired:  false,
+			},
+			{
+				Name:        argRolePrivileges,
+				DisplayName: "Privileges",
+				Description: descriptionRolePrivileges,
+				Field:       &config.Field_StringSliceField{},
+				IsRequired:  false,
+			},
+		},
+		ReturnTypes: []*config.Field{
+			{
+				Name:        fieldSuccess,
+				DisplayName: displaySuccess,
+				Description: "Whether the role was updated successfully.",
+				Field:       &config.Field_BoolField{},
+			},
+			{
+				Name:        fieldResource,
+				DisplayName: "Updated Role",
+				Description: "The updated role resource.",
+				Field:       &config.Field_ResourceField{},
+			},
+		},
+		ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_DYNAMIC},
+	}
+
+	deleteRoleActionSchema = &v2.BatonActionSchema{
+		Name:        "delete_role",
+		DisplayName: "Delete Admin Role",
+		Description: "Deletes a custom Google Workspace admin role. System roles cannot be deleted, and Google rejects deleting a role that still has assignments.",
+		Arguments: []*config.Field{
+			{
+				Name:        argRoleID,
+				DisplayName: displayRoleID,
+				Description: "ID of the role to delete.",
+				Field:       &config.Field_StringField{},
+				IsRequired:  true,
+			},
+		},
+		ReturnTypes: []*config.Field{
+			{
+				Name:        fieldSuccess,
+				DisplayName: displaySuccess,
+				Description: "Whether the role was deleted (or was already gone).",
+				Field:       &config.Field_BoolField{},
+			},
+		},
+		ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_RESOURCE_DELETE},
+	}
+)
+
+// ResourceActions implements the ResourceActionProvider interface for role resource actions.
+func (o *roleResourceType) ResourceActions(ctx context.Context, registry actions.ActionRegistry) error {
+	if err := registry.Register(ctx, createRoleActionSchema, o.createRoleActionHandler); err != nil {
+		return err
+	}
+	if err := registry.Register(ctx, updateRoleActionSchema, o.updateRoleActionHandler); err != nil {
+		return err
+	}
+	if err := registry.Register(ctx, deleteRoleActionSchema, o.deleteRoleActionHandler); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *roleResourceType) requireRoleProvisioning() error {
+	if o.client.RoleProvisioningService == nil {
+		return uhttp.WrapErrors(codes.FailedPrecondition,
+			fmt.Sprintf("google-workspace: role provisioning service not available - requires %s scope", admin.AdminDirectoryRolemanagementScope))
+	}
+	return nil
+}
+
+// parseRolePrivileges converts serviceId:privilegeName pairs to role
+// privileges, dropping duplicates.
+func parseRolePrivileges(values []string) ([]*admin.RoleRolePrivileges, error) {
+	rv := make([]*admin.RoleRolePrivileges, 0, len(values))
+	seen := make(map[string]bool)
+	for _, v := range values {
+		serviceId, privilegeName, ok := strings.Cut(strings.TrimSpace(v), ":")
+		serviceId, privilegeName = strings.TrimSpace(serviceId), strings.TrimSpace(privilegeName)
+		if !ok || serviceId == "" || privilegeName == "" {
+			return nil, uhttp.WrapErrors(codes.InvalidArgument,
+				fmt.Sprintf("google-workspace: invalid privilege %q, expected serviceId:privilegeName", v))
+		}
+		key := rolePrivilegeKey(serviceId, privilegeName)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rv = append(rv, &admin.RoleRolePrivileges{ServiceId: serviceId, PrivilegeName: privilegeName})
+	}
+	return rv, nil
+}
+
+// roleActionResult builds the resource return value for a created or updated
+// role. The role change has already been applied, so a failure to read the
+// privilege tree only leaves privileges off the returned profile.
+func (o *roleResourceType) roleActionResult(ctx context.Context, role *admin.Role) (*structpb.Struct, error) {
+	resource, err := roleToResource(role, o.privilegeIndex(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace: failed to create role resource: %w", err)
+	}
+	resourceRv, err := actions.NewResourceReturnField(fieldResource, resource)
+	if err != nil {
+		return nil, err
+	}
+	return actions.NewReturnValues(true, resourceRv), nil
+}
+
+func (o *roleResourceType) createRoleActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	if err := o.requireRoleProvisioning(); err != nil {
+		return nil, nil, err
+	}
+
+	name := getStringField(args, argRoleName)
+	if name == "" {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "google-workspace: create_role: missing role_name argument")
+	}
+	values, _ := actions.GetStringSliceArg(args, argRolePrivileges)
+	privileges, err := parseRolePrivileges(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(privileges) == 0 {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "google-workspace: create_role: at least one privilege is required")
+	}
+
+	role := &admin.Role{
+		RoleName:        name,
+		RoleDescription: getStringField(args, argRoleDescription),
+		RolePrivileges:  privileges,
+	}
+	created, err := withRateLimitWaitValue(ctx, func() (*admin.Role, error) {
+		return o.client.InsertRole(ctx, o.customerId, role)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to create role: %w", err)
+	}
+	l.Debug("google-workspace: role action handler: created role",
+		zap.Int64(argRoleID, created.RoleId),
+		zap.String(argRoleName, created.RoleName))
+
+	rv, err := o.roleActionResult(ctx, created)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rv, nil, nil
+}
+
+func (o *roleResourceType) updateRoleActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	if err := o.requireRoleProvisioning(); err != nil {
+		return nil, nil, err
+	}
+
+	roleId := getStringField(args, argRoleID)
+	if _, err := strconv.ParseInt(roleId, 10, 64); err != nil {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("google-workspace: update_role: invalid role_id %q", roleId))
+	}
+
+	patch := &admin.Role{}
+	changed := false
+	name, err := optionalStringField(args, argRoleName)
+	if err != nil {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("google-workspace: update_role: %s", err))
+	}
+	if name != nil && *name != "" {
+		patch.RoleName = *name
+		changed = true
+	}
+	description, err := optionalStringField(args, argRoleDescription)
+	if err != nil {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("google-workspace: update_role: %s", err))
+	}
+	if description != nil {
+		// An empty description clears it.
+		patch.RoleDescription = *description
+		patch.ForceSendFields = append(patch.ForceSendFields, "RoleDescription")
+		changed = true
+	}
+	if values, ok := actions.GetStringSliceArg(args, argRolePrivileges); ok {
+		privileges, err := parseRolePrivileges(values)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(privileges) == 0 {
+			return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "google-workspace: update_role: a role must keep at least one privilege")
+		}
+		patch.RolePrivileges = privileges
+		changed = true
+	}
+	if !changed {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "google-workspace: update_role: nothing to update")
+	}
+
+	updated, err := withRateLimitWaitValue(ctx, func() (*admin.Role, error) {
+		return o.client.PatchRole(ctx, o.customerId, roleId, patch)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to update role %s: %w", roleId, err)
+	}
+	l.Debug("google-workspace: role action handler: updated role",
+		zap.String(argRoleID, roleId),
+		zap.Int("privileges", len(updated.RolePrivileges)))
+
+	rv, err := o.roleActionResult(ctx, updated)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rv, nil, nil
+}
+
+func (o *roleResourceType) deleteRoleActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	if err := o.requireRoleProvisioning(); err != nil {
+		return nil, nil, err
+	}
+
+	roleId := getStringField(args, argRoleID)
+	if _, err := strconv.ParseInt(roleId, 10, 64); err != nil {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("google-workspace: delete_role: invalid role_id %q", roleId))
+	}
+
+	err := withRateLimitWait(ctx, func() error {
+		return o.client.DeleteRole(ctx, o.customerId, roleId)
+	})
+	if err != nil {
+		gerr := &googleapi.Error{}
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			l.Info("google-workspace: role is being deleted but doesn't exist", zap.String(argRoleID, roleId))
+			return actions.NewReturnValues(true), nil, nil
+		}
+		return nil, nil, fmt.Errorf("google-workspace: failed to delete role %s: %w", roleId, err)
+	}
+	l.Debug("google-workspace: role action handler: deleted role", zap.String(argRoleID, roleId))
+
+	return actions.NewReturnValues(true), nil, nil
+}
@@ -0,0 +1,308 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+type testRoleServerState struct {
+	mtx          sync.Mutex
+	orgUnits     []*directoryAdmin.OrgUnit
+	orgUnitLists int
+	assignments  []*directoryAdmin.RoleAssignment
+	inserted     []*directoryAdmin.RoleAssignment
+	roles        map[string]*directoryAdmin.Role
+	patches      []*directoryAdmin.Role
+	privileges   []*directoryAdmin.Privilege
+	// privilegesDenied makes listing privileges fail with a 403.
+	privilegesDenied bool
+}
+
+func newRoleTestServer(t *testing.T, state *testRoleServerState) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/directory/v1/customer/test-customer/orgunits", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		if r.URL.Query().Get("type") != "all" {
+			http.Error(w, "expected type=all", http.StatusBadRequest)
+			return
+		}
+		state.orgUnitLists++
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.OrgUnits{OrganizationUnits: state.orgUnits})
+	})
+	mux.HandleFunc("/admin/directory/v1/customer/test-customer/roleassignments", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(&directoryAdmin.RoleAssignments{Items: state.assignments})
+		case http.MethodPost:
+			var ra directoryAdmin.RoleAssignment
+			_ = json.NewDecoder(r.Body).Decode(&ra)
+			ra.RoleAssignmentId = int64(100 + len(state.inserted))
+			state.inserted = append(state.inserted, &ra)
+			_ = json.NewEncoder(w).Encode(&ra)
+		}
+	})
+	mux.HandleFunc("/admin/directory/v1/customer/test-customer/roles/ALL/privileges", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		if state.privilegesDenied {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":403,"message":"Not Authorized to access this resource/api"}}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.Privileges{Items: state.privileges})
+	})
+	mux.HandleFunc("/admin/directory/v1/customer/test-customer/roles", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		var role directoryAdmin.Role
+		_ = json.NewDecoder(r.Body).Decode(&role)
+		role.RoleId = int64(200 + len(state.roles))
+		state.roles[strconv.FormatInt(role.RoleId, 10)] = &role
+		_ = json.NewEncoder(w).Encode(&role)
+	})
+	mux.HandleFunc("/admin/directory/v1/customer/test-customer/roles/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		id := strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/customer/test-customer/roles/")
+		role, ok := state.roles[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"Not Found"}}`))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(role)
+		case http.MethodPatch:
+			var patch directoryAdmin.Role
+			_ = json.NewDecoder(r.Body).Decode(&patch)
+			state.patches = append(state.patches, &patch)
+			if patch.RoleName != "" {
+				role.RoleName = patch.RoleName
+			}
+			if patch.RolePrivileges != nil {
+				role.RolePrivileges = patch.RolePrivileges
+			}
+			_ = json.NewEncoder(w).Encode(role)
+		case http.MethodDelete:
+			delete(state.roles, id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func testPrivileges() []*directoryAdmin.Privilege {
+	return []*directoryAdmin.Privilege{
+		{ServiceId: "users", PrivilegeName: "USERS_ALL", ChildPrivileges: []*directoryAdmin.Privilege{
+			{ServiceId: "users", PrivilegeName: "USERS_RETRIEVE"},
+			{ServiceId: "users", PrivilegeName: "USERS_UPDATE", ChildPrivileges: []*directoryAdmin.Privilege{
+				{ServiceId: "users", PrivilegeName: "USERS_ALIAS"},
+			}},
+		}},
+		{ServiceId: "groups", PrivilegeName: "GROUPS_RETRIEVE"},
+	}
+}
+
+func newTestRoleResourceType(t *testing.T, server *httptest.Server) *roleResourceType {
+	t.Helper()
+	dir := newTestDirectoryService(t, server.URL, server.Client())
+	return roleBuilder(&gwclient.GoogleWorkspaceClient{
+		RoleService:             dir,
+		RoleProvisioningService: dir,
+		OrgUnitService:          dir,
+	}, "test-customer")
+}
+
+func TestRoleEntitlements_OnePerOrgUnit(t *testing.T) {
+	state := &testRoleServerState{orgUnits: testOrgUnits()}
+	server := newRoleTestServer(t, state)
+	defer server.Close()
+	o := newTestRoleResourceType(t, server)
+
+	ents, _, err := o.Entitlements(context.Background(), testRoleResource(), rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	slugs := make([]string, 0, len(ents))
+	for _, e := range ents {
+		slugs = append(slugs, e.Slug)
+	}
+	require.Equal(t, []string{roleMemberEntitlement, "member_org_unit_sales", "member_org_unit_emea"}, slugs)
+}
+
+func TestRoleEntitlements_ListsOrgUnitsOncePerSync(t *testing.T) {
+	state := &testRoleServerState{orgUnits: testOrgUnits()}
+	server := newRoleTestServer(t, state)
+	defer server.Close()
+	o := newTestRoleResourceType(t, server)
+	ss := newFakeSessionStore()
+
+	other := testRoleResource()
+	other.Id.Resource = "987654321"
+	for _, role := range []*v2.Resource{testRoleResource(), other} {
+		ents, _, err := o.Entitlements(context.Background(), role, rs.SyncOpAttrs{Session: ss})
+		require.NoError(t, err)
+		require.Len(t, ents, 3)
+	}
+	require.Equal(t, 1, state.orgUnitLists)
+}
+
+func TestRoleGrants_CarryScope(t *testing.T) {
+	state := &testRoleServerState{assignments: []*directoryAdmin.RoleAssignment{
+		{RoleAssignmentId: 1, RoleId: 1234567890, AssignedTo: "tenant-admin", AssigneeType: "user", ScopeType: roleScopeTypeCustomer},
+		{RoleAssignmentId: 2, RoleId: 1234567890, AssignedTo: "ou-admin", AssigneeType: "user", ScopeType: roleScopeTypeOrgUnit, OrgUnitId: "emea"},
+	}}
+	server := newRoleTestServer(t, state)
+	defer server.Close()
+	o := newTestRoleResourceType(t, server)
+
+	grants, _, err := o.Grants(context.Background(), testRoleResource(), rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, grants, 2)
+
+	got := map[string]string{}
+	for _, g := range grants {
+		got[g.Principal.Id.Resource] = entitlementSlug(g.Entitlement)
+	}
+	require.Equal(t, roleMemberEntitlement, got["tenant-admin"])
+	require.Equal(t, "member_org_unit_emea", got["ou-admin"])
+
+	md := &v2.GrantMetadata{}
+	annos := annotations.Annotations(grants[1].Annotations)
+	ok, err := annos.Pick(md)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, roleScopeTypeOrgUnit, md.Metadata.Fields["scope_type"].GetStringValue())
+	require.Equal(t, "emea", md.Metadata.Fields["org_unit_id"].GetStringValue())
+}
+
+func TestRoleGrant_ScopesToOrgUnit(t *testing.T) {
+	state := &testRoleServerState{}
+	server := newRoleTestServer(t, state)
+	defer server.Close()
+	o := newTestRoleResourceType(t, server)
+	principal := &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeUser.Id, Resource: "alice"}}
+
+	_, _, err := o.Grant(context.Background(), principal, sdkEntitlement.NewAssignmentEntitlement(testRoleResource(), roleMemberEntitlement))
+	require.NoError(t, err)
+	grants, _, err := o.Grant(context.Background(), principal, sdkEntitlement.NewAssignmentEntitlement(testRoleResource(), roleOrgUnitEntitlementSlug("id:emea")))
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	require.Equal(t, "member_org_unit_emea", entitlementSlug(grants[0].Entitlement))
+
+	require.Len(t, state.inserted, 2)
+	require.Equal(t, roleScopeTypeCustomer, state.inserted[0].ScopeType)
+	require.Empty(t, state.inserted[0].OrgUnitId)
+	require.Equal(t, roleScopeTypeOrgUnit, state.inserted[1].ScopeType)
+	require.Equal(t, "emea", state.inserted[1].OrgUnitId)
+}
+
+func roleActionArgs(t *testing.T, args map[string]interface{}) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(args)
+	require.NoError(t, err)
+	return s
+}
+
+func TestRoleGet_ProfileExpandsChildPrivileges(t *testing.T) {
+	state := &testRoleServerState{
+		privileges: testPrivileges(),
+		roles: map[string]*directoryAdmin.Role{
+			"1234567890": {RoleId: 1234567890, RoleName: "Helpdesk", RolePrivileges: []*directoryAdmin.RoleRolePrivileges{
+				{ServiceId: "users", PrivilegeName: "USERS_UPDATE"},
+				{ServiceId: "groups", PrivilegeName: "GROUPS_RETRIEVE"},
+			}},
+		},
+	}
+	server := newRoleTestServer(t, state)
+	defer server.Close()
+	o := newTestRoleResourceType(t, server)
+
+	r, _, err := o.Get(context.Background(), testRoleResource().Id, nil)
+	require.NoError(t, err)
+	var got []string
+	for _, v := range r.GetProfile().GetFields()["privileges"].GetListValue().GetValues() {
+		f := v.GetStructValue().GetFields()
+		got = append(got, f["service_id"].GetStringValue()+":"+f["privilege_name"].GetStringValue()+"<"+f["parent_privilege_name"].GetStringValue())
+	}
+	require.Equal(t, []string{"users:USERS_UPDATE<", "groups:GROUPS_RETRIEVE<", "users:USERS_ALIAS<USERS_UPDATE"}, got)
+}
+
+func TestRoleGet_WithoutPrivilegeAccess(t *testing.T) {
+	state := &testRoleServerState{
+		privilegesDenied: true,
+		roles: map[string]*directoryAdmin.Role{
+			"1234567890": {RoleId: 1234567890, RoleName: "Helpdesk", RolePrivileges: []*directoryAdmin.RoleRolePrivileges{
+				{ServiceId: "users", PrivilegeName: "USERS_UPDATE"},
+			}},
+		},
+	}
+	server := newRoleTestServer(t, state)
+	defer server.Close()
+	o := newTestRoleResourceType(t, server)
+
+	r, _, err := o.Get(context.Background(), testRoleResource().Id, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Helpdesk", r.DisplayName)
+	require.NotContains(t, r.GetProfile().GetFields(), "privileges")
+}
+
+func TestRoleActions_CreateUpdateDelete(t *testing.T) {
+	state := &testRoleServerState{privileges: testPrivileges(), roles: map[string]*directoryAdmin.Role{}}
+	server := newRoleTestServer(t, state)
+	defer server.Close()
+	o := newTestRoleResourceType(t, server)
+	ctx := context.Background()
+
+	_, _, err := o.createRoleActionHandler(ctx, roleActionArgs(t, map[string]interface{}{
+		argRoleName:       "Reader",
+		argRolePrivileges: []interface{}{"users"},
+	}))
+	require.Error(t, err, "a privilege without a service ID must be rejected")
+	require.Empty(t, state.roles)
+
+	rv, _, err := o.createRoleActionHandler(ctx, roleActionArgs(t, map[string]interface{}{
+		argRoleName:       "Reader",
+		argRolePrivileges: []interface{}{"users:USERS_RETRIEVE", "users:USERS_RETRIEVE", "groups:GROUPS_RETRIEVE"},
+	}))
+	require.NoError(t, err)
+	require.True(t, rv.Fields[fieldSuccess].GetBoolValue())
+	require.Len(t, state.roles["200"].RolePrivileges, 2)
+
+	_, _, err = o.updateRoleActionHandler(ctx, roleActionArgs(t, map[string]interface{}{
+		argRoleID:         "200",
+		argRolePrivileges: []interface{}{"users:USERS_ALL"},
+	}))
+	require.NoError(t, err)
+	require.Len(t, state.patches, 1)
+	require.Empty(t, state.patches[0].RoleName, "an update must only send the provided fields")
+	require.Equal(t, []*directoryAdmin.RoleRolePrivileges{{ServiceId: "users", PrivilegeName: "USERS_ALL"}}, state.roles["200"].RolePrivileges)
+
+	_, _, err = o.updateRoleActionHandler(ctx, roleActionArgs(t, map[string]interface{}{argRoleID: "200"}))
+	require.Error(t, err, "an update with nothing to change must be rejected")
+
+	_, _, err = o.deleteRoleActionHandler(ctx, roleActionArgs(t, map[string]interface{}{argRoleID: "200"}))
+	require.NoError(t, err)
+	require.Empty(t, state.roles)
+	_, _, err = o.deleteRoleActionHandler(ctx, roleActionArgs(t, map[string]interface{}{argRoleID: "200"}))
+	require.NoError(t, err, "deleting a missing role must be idempotent")
+}
@@ -0,0 +1,249 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/session"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	"github.com/conductorone/baton-sdk/pkg/types/sessions"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	admin "google.golang.org/api/admin/directory/v1"
+	cloudidentity "google.golang.org/api/cloudidentity/v1"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+const (
+	ssoModeSAML = "SAML_SSO"
+
+	cloudIdentityGroupPrefix   = "groups/"
+	cloudIdentityOrgUnitPrefix = "orgUnits/"
+)
+
+// samlAppAssignmentsNamespace maps a SAML app ID to the groups and org units
+// assigned to it.
+var samlAppAssignmentsNamespace = sessions.WithPrefix("saml_app_assignments")
+
+// samlAppTargets are the resource IDs of the groups and org units whose users
+// sign in through a SAML app. The root org unit isn't synced as a resource, so
+// when it signs in through the app, its users are targets of their own.
+type samlAppTargets struct {
+	Groups   []string `json:"groups,omitempty"`
+	OrgUnits []string `json:"org_units,omitempty"`
+	Users    []string `json:"users,omitempty"`
+	// rootOrgUnit marks the app the root org unit signs in through, whose
+	// users loadSAMLAppAssignments lists into Users.
+	rootOrgUnit bool
+}
+
+// loadSAMLAppAssignments reads the customer's Cloud Identity inbound SSO
+// assignments once per sync and stores each SAML app's targets in the session,
+// for applicationResource.Grants. Assignments are read with the same scope as
+// SAML profiles, so an API that is not enabled is tolerated the same way.
+// Users directly in an assigned root org unit are listed, in domain when one
+// is set, and narrowed by filter like synced users are.
+func loadSAMLAppAssignments(ctx context.Context, ss sessions.SessionStore, client *gwclient.GoogleWorkspaceClient, customerID, domain string, filter userFilter) error {
+	var assignments []*cloudidentity.InboundSsoAssignment
+	err := client.ListInboundSsoAssignments(ctx, customerID, func(resp *cloudidentity.ListInboundSsoAssignmentsResponse) error {
+		assignments = append(assignments, resp.InboundSsoAssignments...)
+		return nil
+	})
+	if err != nil {
+		if isCloudIdentityAPIDisabledError(err) {
+			ctxzap.Extract(ctx).Info("google-workspace: Cloud Identity API is not enabled for this project; SAML app assignments are not synced", zap.Error(err))
+			return nil
+		}
+		return fmt.Errorf("google-workspace-connector: failed to load SSO assignments from Cloud Identity: %w", err)
+	}
+
+	// Sub-org units inherit their parent's assignment, which takes the org
+	// unit tree. Without the org unit scope, only the assigned org units
+	// themselves are targets.
+	var orgUnits []*admin.OrgUnit
+	if client.OrgUnitService != nil {
+		resp, err := client.ListAllOrgUnits(ctx, customerID)
+		if err != nil {
+			return fmt.Errorf("google-workspace-connector: failed to list org units for SSO assignments: %w", err)
+		}
+		orgUnits = resp.OrganizationUnits
+	}
+
+	targets := samlAppTargetsFromAssignments(assignments, orgUnits)
+	if len(targets) == 0 {
+		return nil
+	}
+	for appID, t := range targets {
+		if !t.rootOrgUnit {
+			continue
+		}
+		t.Users, err = rootOrgUnitUsers(ctx, client, customerID, domain, filter)
+		if err != nil {
+			return err
+		}
+		targets[appID] = t
+	}
+	if err := session.SetManyJSON(ctx, ss, targets, samlAppAssignmentsNamespace); err != nil {
+		return fmt.Errorf("google-workspace-connector: failed to store saml app assignments in session: %w", err)
+	}
+	return nil
+}
+
+// samlAppTargetsFromAssignments resolves SSO assignments to each SAML app's
+// target groups and org units. An org unit signs in with the assignment of
+// its nearest assigned ancestor, itself included, so an org unit assigned to
+// another profile or to no SSO at all stops the inheritance.
+func samlAppTargetsFromAssignments(assignments []*cloudidentity.InboundSsoAssignment, orgUnits []*admin.OrgUnit) map[string]samlAppTargets {
+	rv := map[string]samlAppTargets{}
+	// assignedOrgUnits maps an assigned org unit's ID to its SAML app ID, or
+	// to "" when the assignment doesn't use a SAML profile.
+	assignedOrgUnits := map[string]string{}
+	for _, a := range assignments {
+		appID := ""
+		if a.SsoMode == ssoModeSAML && a.SamlSsoInfo != nil && a.SamlSsoInfo.InboundSamlSsoProfile != "" {
+			appID = samlAppIDPrefix + a.SamlSsoInfo.InboundSamlSsoProfile
+		}
+		if groupID, ok := strings.CutPrefix(a.TargetGroup, cloudIdentityGroupPrefix); ok {
+			if appID != "" {
+				t := rv[appID]
+				t.Groups = append(t.Groups, groupID)
+				rv[appID] = t
+			}
+			continue
+		}
+		if orgUnitID, ok := strings.CutPrefix(a.TargetOrgUnit, cloudIdentityOrgUnitPrefix); ok {
+			// Cloud Identity names org units without the Directory API's "id:" prefix.
+			assignedOrgUnits["id:"+orgUnitID] = appID
+		}
+	}
+
+	if len(orgUnits) == 0 {
+		for orgUnitID, appID := range assignedOrgUnits {
+			if appID != "" {
+				t := rv[appID]
+				t.OrgUnits = append(t.OrgUnits, orgUnitID)
+				rv[appID] = t
+			}
+		}
+	} else {
+		// The root isn't listed, but its ID and path are on its children.
+		paths := map[string]string{}
+		for _, ou := range orgUnits {
+			paths[ou.OrgUnitId] = ou.OrgUnitPath
+			if ou.ParentOrgUnitId != "" {
+				paths[ou.ParentOrgUnitId] = ou.ParentOrgUnitPath
+			}
+		}
+		assignedPaths := map[string]string{}
+		for orgUnitID, appID := range assignedOrgUnits {
+			if p, ok := paths[orgUnitID]; ok {
+				assignedPaths[orgUnitPathKey(p)] = appID
+			}
+		}
+		for _, ou := range orgUnits {
+			if appID := nearestOrgUnitAssignment(ou.OrgUnitPath, assignedPaths); appID != "" {
+				t := rv[appID]
+				t.OrgUnits = append(t.OrgUnits, ou.OrgUnitId)
+				rv[appID] = t
+			}
+		}
+		if appID := nearestOrgUnitAssignment(rootOrgUnitPath, assignedPaths); appID != "" {
+			t := rv[appID]
+			t.rootOrgUnit = true
+			rv[appID] = t
+		}
+	}
+
+	for appID, t := range rv {
+		slices.Sort(t.Groups)
+		slices.Sort(t.OrgUnits)
+		rv[appID] = samlAppTargets{Groups: slices.Compact(t.Groups), OrgUnits: slices.Compact(t.OrgUnits), rootOrgUnit: t.rootOrgUnit}
+	}
+	return rv
+}
+
+// rootOrgUnitUsers returns the IDs of the users directly in the root org unit.
+// Users below it are granted through their org units. Without the user
+// service, the root's users are left out.
+func rootOrgUnitUsers(ctx context.Context, client *gwclient.GoogleWorkspaceClient, customerID, domain string, filter userFilter) ([]string, error) {
+	if client.UserService == nil {
+		ctxzap.Extract(ctx).Info("google-workspace: user service is not available; users of the root org unit are not granted its SAML app")
+		return nil, nil
+	}
+	var rv []string
+	pageToken := ""
+	for {
+		users, err := client.ListUserOrgUnitsPage(ctx, customerID, domain, filter.apiQuery(), pageToken)
+		if err != nil {
+			return nil, fmt.Errorf("google-workspace-connector: failed to list users of the root org unit for SSO assignments: %w", err)
+		}
+		for _, u := range users.Users {
+			if u.Id != "" && u.OrgUnitPath == rootOrgUnitPath && filter.matches(u) {
+				rv = append(rv, u.Id)
+			}
+		}
+		if users.NextPageToken == "" {
+			break
+		}
+		pageToken = users.NextPageToken
+	}
+	slices.Sort(rv)
+	return rv, nil
+}
+
+// nearestOrgUnitAssignment walks from orgUnitPath up to the root and returns
+// the SAML app ID of the first assigned org unit, or "" if there is none or it
+// doesn't use a SAML profile.
+func nearestOrgUnitAssignment(orgUnitPath string, assignedPaths map[string]string) string {
+	p := orgUnitPath
+	for {
+		if appID, ok := assignedPaths[orgUnitPathKey(p)]; ok {
+			return appID
+		}
+		if p == rootOrgUnitPath || p == "" {
+			return ""
+		}
+		if i := strings.LastIndex(p, "/"); i > 0 {
+			p = p[:i]
+		} else {
+			p = rootOrgUnitPath
+		}
+	}
+}
+
+// samlAppAssignmentGrants grants a SAML app's access entitlement to its
+// assigned groups and org units, expanding each to its members, and to the
+// users of an assigned root org unit.
+func samlAppAssignmentGrants(ctx context.Context, ss sessions.SessionStore, resource *v2.Resource) ([]*v2.Grant, error) {
+	targets, found, err := session.GetJSON[samlAppTargets](ctx, ss, resource.Id.Resource, samlAppAssignmentsNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace-connector: failed to read saml app assignments from session: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	grants := make([]*v2.Grant, 0, len(targets.Groups)+len(targets.OrgUnits)+len(targets.Users))
+	for _, groupID := range targets.Groups {
+		principal := &v2.ResourceId{ResourceType: resourceTypeGroup.Id, Resource: groupID}
+		grants = append(grants, grant.NewGrant(resource, applicationAccessEntitlement, principal,
+			grant.WithAnnotation(&v2.GrantExpandable{
+				EntitlementIds: []string{fmt.Sprintf("%s:%s:%s", resourceTypeGroup.Id, groupID, groupMemberEntitlement)},
+			})))
+	}
+	for _, orgUnitID := range targets.OrgUnits {
+		principal := &v2.ResourceId{ResourceType: resourceTypeOrgUnit.Id, Resource: orgUnitID}
+		grants = append(grants, grant.NewGrant(resource, applicationAccessEntitlement, principal,
+			grant.WithAnnotation(&v2.GrantExpandable{
+				EntitlementIds: []string{fmt.Sprintf("%s:%s:%s", resourceTypeOrgUnit.Id, orgUnitID, orgUnitMemberEntitlement)},
+			})))
+	}
+	for _, userID := range targets.Users {
+		principal := &v2.ResourceId{ResourceType: resourceTypeUser.Id, Resource: userID}
+		grants = append(grants, grant.NewGrant(resource, applicationAccessEntitlement, principal))
+	}
+	return grants, nil
+}
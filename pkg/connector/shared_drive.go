@@ -0,0 +1,545 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/session"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-sdk/pkg/types/sessions"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+// Drive API Permission.Type values that name a single principal.
+const (
+	drivePermissionTypeUser  = "user"
+	drivePermissionTypeGroup = "group"
+)
+
+// sharedDriveRole is a Drive API permission role that a shared drive member
+// can hold. The entitlement slug is the API role itself.
+type sharedDriveRole struct {
+	Role        string
+	DisplayName string
+	Description string
+}
+
+// sharedDriveRoles lists the shared drive member roles, most privileged first.
+// "owner" is not a shared drive role: the drive itself owns its files.
+var sharedDriveRoles = []sharedDriveRole{
+	{Role: "organizer", DisplayName: "Manager", Description: "Can manage members, content and settings of"},
+	{Role: "fileOrganizer", DisplayName: "Content Manager", Description: "Can add, edit, move and delete files in"},
+	{Role: "writer", DisplayName: "Contributor", Description: "Can add and edit files in"},
+	{Role: "commenter", DisplayName: "Commenter", Description: "Can view and comment on files in"},
+	{Role: "reader", DisplayName: "Viewer", Description: "Can view files in"},
+}
+
+// sharedDriveGroupNamespace maps a lower-cased group email to its Directory
+// group ID, or "" for a group outside the customer, so each group member of
+// a shared drive is looked up once per sync.
+var sharedDriveGroupNamespace = sessions.WithPrefix("shared_drive_group")
+
+type sharedDriveResourceType struct {
+	tenantPartitions
+	syncFilters
+	resourceType *v2.ResourceType
+	client       *gwclient.GoogleWorkspaceClient
+	customerId   string
+	domain       string
+}
+
+func (o *sharedDriveResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return o.resourceType
+}
+
+func (o *sharedDriveResourceType) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.listPartitions(ctx, parentResourceID, attrs)
+	}
+
+	drives, err := o.client.ListSharedDrives(ctx, attrs.PageToken.Token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to list shared drives: %w", err)
+	}
+
+	rv := make([]*v2.Resource, 0, len(drives.Drives))
+	for _, d := range drives.Drives {
+		driveResource, err := sharedDriveToResource(d)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create shared drive resource in List: %w", err)
+		}
+		rv = append(rv, driveResource)
+	}
+	return rv, &rs.SyncOpResults{NextPageToken: drives.NextPageToken}, nil
+}
+
+func (o *sharedDriveResourceType) Entitlements(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.entitlementsPartitions(ctx, resource, attrs)
+	}
+
+	rv := make([]*v2.Entitlement, 0, len(sharedDriveRoles))
+	for _, role := range sharedDriveRoles {
+		e := sdkEntitlement.NewAssignmentEntitlement(resource, role.Role,
+			sdkEntitlement.WithGrantableTo(resourceTypeUser, resourceTypeGroup))
+		e.Description = fmt.Sprintf("%s the %s shared drive", role.Description, resource.DisplayName)
+		e.DisplayName = fmt.Sprintf("%s %s", resource.DisplayName, role.DisplayName)
+		rv = append(rv, e)
+	}
+	return rv, nil, nil
+}
+
+// Grants returns a grant for each user or group member of the shared drive.
+// Permissions name members by email, so unless an earlier call of this sync
+// built it, Grants first walks the user directory (one page per call) into
+// the session's user email index. Members outside the customer's domains that
+// are not its users or groups are granted as external principals matched by
+// email. Members in the customer's domains that were not synced, such as
+// users left out by the sync filters, are skipped rather than reported as
+// external. Domain and "anyone" permissions have no principal and are skipped.
+func (o *sharedDriveResourceType) Grants(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.grantsPartitions(ctx, resource, attrs)
+	}
+
+	l := ctxzap.Extract(ctx)
+	bag := &pagination.Bag{}
+	err := bag.Unmarshal(attrs.PageToken.Token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal pagination token in shared drive Grants: %w", err)
+	}
+	if err := o.userEmails().begin(ctx, attrs.Session, bag, resource); err != nil {
+		return nil, nil, err
+	}
+
+	if bag.ResourceTypeID() == resourceTypeUser.Id {
+		nextPage, err := o.userEmails().indexPage(ctx, attrs.Session, bag)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+	}
+
+	permissions, err := o.client.ListDrivePermissions(ctx, resource.Id.Resource, bag.PageToken())
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to list permissions for shared drive %s: %w", resource.Id.Resource, err)
+	}
+
+	internalDomains, err := customerDomains(ctx, attrs.Session, o.client, o.customerId, o.domain)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Only the customer's domains have users, so other emails are not
+	// looked up.
+	var userEmails, groupEmails []string
+	for _, p := range permissions.Permissions {
+		switch {
+		case p.EmailAddress == "" || p.Deleted:
+		case p.Type == drivePermissionTypeUser && emailInDomains(p.EmailAddress, internalDomains):
+			userEmails = append(userEmails, strings.ToLower(p.EmailAddress))
+		case p.Type == drivePermissionTypeGroup:
+			groupEmails = append(groupEmails, strings.ToLower(p.EmailAddress))
+		}
+	}
+	userIDs, leftOut, err := o.userEmails().resolve(ctx, attrs.Session, userEmails)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := o.prefetchGroupIDs(ctx, attrs.Session, groupEmails); err != nil {
+		return nil, nil, err
+	}
+
+	var rv []*v2.Grant
+	for _, p := range permissions.Permissions {
+		if !isSharedDriveRole(p.Role) {
+			l.Debug("google-workspace: skipping shared drive permission with unknown role",
+				zap.String("shared_drive", resource.Id.Resource),
+				zap.String("permission_id", p.Id),
+				zap.String("role", p.Role))
+			continue
+		}
+		email := strings.ToLower(p.EmailAddress)
+		if p.Deleted || email == "" || (p.Type != drivePermissionTypeUser && p.Type != drivePermissionTypeGroup) {
+			l.Debug("google-workspace: skipping shared drive permission without a principal",
+				zap.String("shared_drive", resource.Id.Resource),
+				zap.String("permission_id", p.Id),
+				zap.String("type", p.Type),
+				zap.Bool("deleted", p.Deleted))
+			continue
+		}
+
+		var grant *v2.Grant
+		switch p.Type {
+		case drivePermissionTypeUser:
+			if userID, ok := userIDs[email]; ok {
+				principalID, err := rs.NewResourceID(resourceTypeUser, userID)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to create user resource ID in shared drive Grants: %w", err)
+				}
+				grant = sdkGrant.NewGrant(resource, p.Role, principalID)
+			}
+		case drivePermissionTypeGroup:
+			groupID, err := o.groupIDForEmail(ctx, attrs.Session, email)
+			if err != nil {
+				return nil, nil, err
+			}
+			if groupID != "" {
+				principalID, err := rs.NewResourceID(resourceTypeGroup, groupID)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to create group resource ID in shared drive Grants: %w", err)
+				}
+				grant = sdkGrant.NewGrant(resource, p.Role, principalID,
+					sdkGrant.WithAnnotation(&v2.GrantExpandable{
+						EntitlementIds: []string{
+							fmt.Sprintf("group:%s:member", groupID),
+						},
+					}))
+			}
+		}
+		if grant == nil && leftOut[email] {
+			l.Info("google-workspace: skipping shared drive member left out by the sync filters or synced domains",
+				zap.String("shared_drive", resource.Id.Resource),
+				zap.String("permission_id", p.Id),
+				zap.String("email", email))
+			continue
+		}
+		if grant == nil && emailInDomains(email, internalDomains) {
+			l.Info("google-workspace: skipping shared drive member of the customer's domains that was not synced",
+				zap.String("shared_drive", resource.Id.Resource),
+				zap.String("permission_id", p.Id),
+				zap.String("type", p.Type),
+				zap.String("email", email))
+			continue
+		}
+		if grant == nil {
+			grant, err = externalSharedDriveGrant(resource, p.Role, p.Type, email)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		rv = append(rv, grant)
+	}
+
+	nextPage, err := bag.NextToken(permissions.NextPageToken)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate next page token in shared drive Grants: %w", err)
+	}
+	return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
+}
+
+// externalSharedDriveGrant grants role to a member outside the synced
+// directory, e.g. a consumer account or another organization's group. The
+// principal ID is the email, and the grant asks the SDK to match it to an
+// external user or group with that email.
+func externalSharedDriveGrant(resource *v2.Resource, role, permissionType, email string) (*v2.Grant, error) {
+	principalType, trait := resourceTypeUser, v2.ResourceType_TRAIT_USER
+	if permissionType == drivePermissionTypeGroup {
+		principalType, trait = resourceTypeGroup, v2.ResourceType_TRAIT_GROUP
+	}
+	principalID, err := rs.NewResourceID(principalType, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create external principal ID in shared drive Grants: %w", err)
+	}
+	return sdkGrant.NewGrant(resource, role, principalID,
+		sdkGrant.WithAnnotation(&v2.ExternalResourceMatch{
+			ResourceType: trait,
+			Key:          "email",
+			Value:        email,
+		})), nil
+}
+
+// prefetchGroupIDs resolves the group emails missing from the session in
+// batches and caches them, so that groupIDForEmail finds them cached. A
+// lookup that fails for another reason than the group not being the
+// customer's is left to groupIDForEmail, which reports it.
+func (o *sharedDriveResourceType) prefetchGroupIDs(ctx context.Context, ss sessions.SessionStore, emails []string) error {
+	if o.client.GroupService == nil || len(emails) == 0 {
+		return nil
+	}
+	cached, err := session.GetManyJSON[string](ctx, ss, emails, sharedDriveGroupNamespace)
+	if err != nil {
+		return fmt.Errorf("google-workspace: failed to read shared drive group cache from session: %w", err)
+	}
+	var missing []string
+	for _, email := range emails {
+		if _, ok := cached[email]; !ok && !slices.Contains(missing, email) {
+			missing = append(missing, email)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	results, err := o.client.BatchGetGroups(ctx, missing)
+	if err != nil {
+		return fmt.Errorf("google-workspace: failed to get groups for shared drive members: %w", err)
+	}
+	groupIDs := make(map[string]string, len(results))
+	for i, r := range results {
+		gerr := &googleapi.Error{}
+		switch {
+		case r.Err == nil:
+			groupIDs[missing[i]] = r.Value.Id
+		case errors.As(r.Err, &gerr) && (gerr.Code == http.StatusNotFound || gerr.Code == http.StatusForbidden):
+			groupIDs[missing[i]] = ""
+		}
+	}
+	if err := session.SetManyJSON(ctx, ss, groupIDs, sharedDriveGroupNamespace); err != nil {
+		return fmt.Errorf("google-workspace: failed to store shared drive group cache in session: %w", err)
+	}
+	return nil
+}
+
+// groupIDForEmail resolves a group member's email to its Directory group ID,
+// caching the result (including "not a customer group") in the session.
+func (o *sharedDriveResourceType) groupIDForEmail(ctx context.Context, ss sessions.SessionStore, email string) (string, error) {
+	if o.client.GroupService == nil {
+		return "", nil
+	}
+	groupID, ok, err := session.GetJSON[string](ctx, ss, email, sharedDriveGroupNamespace)
+	if err != nil {
+		return "", fmt.Errorf("google-workspace: failed to read shared drive group cache from session: %w", err)
+	}
+	if ok {
+		return groupID, nil
+	}
+
+	group, err := o.client.GetGroup(ctx, email)
+	if err != nil {
+		gerr := &googleapi.Error{}
+		if !errors.As(err, &gerr) || (gerr.Code != http.StatusNotFound && gerr.Code != http.StatusForbidden) {
+			return "", fmt.Errorf("google-workspace: failed to get group for shared drive member %s: %w", email, err)
+		}
+		// 403 is what the Directory API returns for groups of other customers.
+	} else {
+		groupID = group.Id
+	}
+	if err := session.SetJSON(ctx, ss, email, groupID, sharedDriveGroupNamespace); err != nil {
+		return "", fmt.Errorf("google-workspace: failed to store shared drive group cache in session: %w", err)
+	}
+	return groupID, nil
+}
+
+func (o *sharedDriveResourceType) userEmails() userEmailIndex {
+	return userEmailIndex{client: o.client, customerId: o.customerId, domain: o.domain, domains: o.tenantDomains, filter: o.userFilter}
+}
+
+// principalEmail returns the email that Drive permissions know the principal
+// by. External principals are already keyed by email.
+func (o *sharedDriveResourceType) principalEmail(ctx context.Context, principalID *v2.ResourceId) (string, string, error) {
+	switch principalID.GetResourceType() {
+	case resourceTypeUser.Id:
+		if strings.Contains(principalID.GetResource(), "@") {
+			return drivePermissionTypeUser, principalID.GetResource(), nil
+		}
+		user, err := o.client.GetUser(ctx, principalID.GetResource())
+		if err != nil {
+			return "", "", err
+		}
+		return drivePermissionTypeUser, user.PrimaryEmail, nil
+	case resourceTypeGroup.Id:
+		if strings.Contains(principalID.GetResource(), "@") {
+			return drivePermissionTypeGroup, principalID.GetResource(), nil
+		}
+		group, err := o.client.GetGroup(ctx, principalID.GetResource())
+		if err != nil {
+			return "", "", err
+		}
+		return drivePermissionTypeGroup, group.Email, nil
+	default:
+		return "", "", uhttp.WrapErrors(codes.InvalidArgument, "user or group principal is required")
+	}
+}
+
+// findPermission pages through a shared drive's permissions for the one
+// held by email, returning nil if it has none.
+func (o *sharedDriveResourceType) findPermission(ctx context.Context, driveID, email string) (*drive.Permission, error) {
+	pageToken := ""
+	for {
+		permissions, err := o.client.ListDrivePermissions(ctx, driveID, pageToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range permissions.Permissions {
+			if emailsEqual(p.EmailAddress, email) {
+				return p, nil
+			}
+		}
+		if permissions.NextPageToken == "" {
+			return nil, nil
+		}
+		pageToken = permissions.NextPageToken
+	}
+}
+
+// Grant adds the principal to the shared drive with the entitlement's role.
+// A drive member holds a single role, so an existing member with another
+// role is moved to this one.
+func (o *sharedDriveResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	if p, ok := o.provisioner(entitlement.GetResource()).(*sharedDriveResourceType); ok {
+		return p.Grant(ctx, principal, entitlement)
+	}
+
+	if o.client.DriveProvisioningService == nil {
+		return nil, nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", drive.DriveScope))
+	}
+	role := entitlementSlug(entitlement)
+	if !isSharedDriveRole(role) {
+		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, fmt.Sprintf("google-workspace: unknown shared drive entitlement %q", role))
+	}
+	driveID := entitlement.Resource.Id.Resource
+
+	permissionType, email, err := o.principalEmail(ctx, principal.GetId())
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to get principal email for shared drive grant: %w", err)
+	}
+
+	existing, err := o.findPermission(ctx, driveID, email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to look up shared drive member: %w", err)
+	}
+	switch {
+	case existing == nil:
+		_, err = o.client.CreateDrivePermission(ctx, driveID, &drive.Permission{
+			Type:         permissionType,
+			Role:         role,
+			EmailAddress: email,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("google-workspace: failed to add shared drive member: %w", err)
+		}
+	case existing.Role != role:
+		_, err = o.client.UpdateDrivePermissionRole(ctx, driveID, existing.Id, role)
+		if err != nil {
+			return nil, nil, fmt.Errorf("google-workspace: failed to update shared drive member role: %w", err)
+		}
+	}
+
+	grant := sdkGrant.NewGrant(entitlement.Resource, role, principal.GetId())
+	return []*v2.Grant{grant}, nil, nil
+}
+
+// Revoke removes the principal from the shared drive if it holds the grant's
+// role. A member that already left, or now holds another role, is left alone.
+func (o *sharedDriveResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	if p, ok := o.provisioner(grant.GetEntitlement().GetResource()).(*sharedDriveResourceType); ok {
+		return p.Revoke(ctx, grant)
+	}
+
+	if o.client.DriveProvisioningService == nil {
+		return nil, uhttp.WrapErrors(codes.FailedPrecondition, fmt.Sprintf("unable to get service for scope %s", drive.DriveScope))
+	}
+	l := ctxzap.Extract(ctx)
+	role := entitlementSlug(grant.Entitlement)
+	driveID := grant.Entitlement.Resource.Id.Resource
+	principalID := grant.Principal.GetId()
+
+	_, email, err := o.principalEmail(ctx, principalID)
+	if err != nil {
+		gerr := &googleapi.Error{}
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			l.Info("google-workspace: shared drive member is being revoked but the principal doesn't exist",
+				zap.String("shared_drive", driveID),
+				zap.String("principal", principalID.GetResource()))
+			return nil, nil
+		}
+		return nil, fmt.Errorf("google-workspace: failed to get principal email for shared drive revoke: %w", err)
+	}
+
+	existing, err := o.findPermission(ctx, driveID, email)
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace: failed to look up shared drive member: %w", err)
+	}
+	if existing == nil || existing.Role != role {
+		l.Info("google-workspace: shared drive member is being revoked but doesn't hold the role",
+			zap.String("shared_drive", driveID),
+			zap.String("email", email),
+			zap.String("role", role))
+		return nil, nil
+	}
+
+	err = o.client.DeleteDrivePermission(ctx, driveID, existing.Id)
+	if err != nil {
+		gerr := &googleapi.Error{}
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("google-workspace: failed to remove shared drive member: %w", err)
+	}
+	return nil, nil
+}
+
+func (o *sharedDriveResourceType) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	d, err := o.client.GetSharedDrive(ctx, resourceId.Resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to get shared drive: %w", err)
+	}
+	driveResource, err := sharedDriveToResource(d)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create shared drive resource in Get: %w", err)
+	}
+	return driveResource, nil, nil
+}
+
+func sharedDriveBuilder(client *gwclient.GoogleWorkspaceClient, customerId string, domain string) *sharedDriveResourceType {
+	return &sharedDriveResourceType{
+		resourceType: resourceTypeSharedDrive,
+		client:       client,
+		customerId:   customerId,
+		domain:       domain,
+	}
+}
+
+func isSharedDriveRole(role string) bool {
+	for _, r := range sharedDriveRoles {
+		if r.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+func sharedDriveProfile(d *drive.Drive) map[string]interface{} {
+	profile := make(map[string]interface{})
+	profile["drive_id"] = d.Id
+	profile["name"] = d.Name
+	profile["created_time"] = d.CreatedTime
+	profile["hidden"] = d.Hidden
+	profile["org_unit_id"] = d.OrgUnitId
+	if r := d.Restrictions; r != nil {
+		profile["admin_managed_restrictions"] = r.AdminManagedRestrictions
+		profile["copy_requires_writer_permission"] = r.CopyRequiresWriterPermission
+		profile["domain_users_only"] = r.DomainUsersOnly
+		profile["drive_members_only"] = r.DriveMembersOnly
+	}
+	return profile
+}
+
+func sharedDriveToResource(d *drive.Drive) (*v2.Resource, error) {
+	if d.Id == "" {
+		return nil, fmt.Errorf("google-workspace: shared drive %s has no id", d.Name)
+	}
+	name := d.Name
+	if name == "" {
+		name = d.Id
+	}
+	return rs.NewResource(name, resourceTypeSharedDrive, d.Id,
+		rs.WithAnnotation(&v2.RawId{Id: d.Id}),
+		rs.WithResourceProfile(sharedDriveProfile(d)),
+	)
+}
@@ -0,0 +1,311 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+type testSharedDriveServerState struct {
+	mtx         sync.Mutex
+	users       []*directoryAdmin.User
+	groups      []*directoryAdmin.Group
+	groupGets   int
+	drives      []*drive.Drive
+	permissions map[string][]*drive.Permission
+	nextID      int
+}
+
+func newSharedDriveTestServer(t *testing.T, state *testSharedDriveServerState) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/directory/v1/customer/test-customer/domains", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.Domains2{Domains: []*directoryAdmin.Domains{
+			{DomainName: "example.com", Verified: true, DomainAliases: []*directoryAdmin.DomainAlias{{DomainAliasName: "example.org", Verified: true}}},
+		}})
+	})
+	mux.HandleFunc("/admin/directory/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.Users{Users: state.users})
+	})
+	mux.HandleFunc("/admin/directory/v1/users/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/users/")
+		for _, u := range state.users {
+			if u.Id == key {
+				_ = json.NewEncoder(w).Encode(u)
+				return
+			}
+		}
+		http.Error(w, `{"error":{"code":404,"message":"Resource Not Found: userKey"}}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/admin/directory/v1/groups/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		state.groupGets++
+		key := strings.TrimPrefix(r.URL.Path, "/admin/directory/v1/groups/")
+		for _, g := range state.groups {
+			if g.Id == key || strings.EqualFold(g.Email, key) {
+				_ = json.NewEncoder(w).Encode(g)
+				return
+			}
+		}
+		http.Error(w, `{"error":{"code":404,"message":"Resource Not Found: groupKey"}}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/drives", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		if r.URL.Query().Get("useDomainAdminAccess") != "true" {
+			http.Error(w, "useDomainAdminAccess not set", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&drive.DriveList{Drives: state.drives})
+	})
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		state.mtx.Lock()
+		defer state.mtx.Unlock()
+		q := r.URL.Query()
+		if q.Get("useDomainAdminAccess") != "true" || q.Get("supportsAllDrives") != "true" {
+			http.Error(w, "useDomainAdminAccess and supportsAllDrives must be set", http.StatusBadRequest)
+			return
+		}
+		driveID, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/files/"), "/permissions")
+		permissionID := strings.TrimPrefix(rest, "/")
+		switch {
+		case r.Method == http.MethodGet && permissionID == "":
+			// Serve one permission per page so Grants spans several calls.
+			i, _ := strconv.Atoi(q.Get("pageToken"))
+			resp := &drive.PermissionList{}
+			if i < len(state.permissions[driveID]) {
+				resp.Permissions = state.permissions[driveID][i : i+1]
+			}
+			if i+1 < len(state.permissions[driveID]) {
+				resp.NextPageToken = strconv.Itoa(i + 1)
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case r.Method == http.MethodPost:
+			if q.Get("sendNotificationEmail") != "false" {
+				http.Error(w, "sendNotificationEmail must be false", http.StatusBadRequest)
+				return
+			}
+			var p drive.Permission
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			state.nextID++
+			p.Id = "perm-" + strconv.Itoa(state.nextID)
+			state.permissions[driveID] = append(state.permissions[driveID], &p)
+			_ = json.NewEncoder(w).Encode(&p)
+		case r.Method == http.MethodPatch:
+			var body drive.Permission
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			for _, p := range state.permissions[driveID] {
+				if p.Id == permissionID {
+					p.Role = body.Role
+					_ = json.NewEncoder(w).Encode(p)
+					return
+				}
+			}
+			http.Error(w, "not found", http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			perms := state.permissions[driveID]
+			for i, p := range perms {
+				if p.Id == permissionID {
+					state.permissions[driveID] = append(perms[:i], perms[i+1:]...)
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+			http.Error(w, "not found", http.StatusNotFound)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestSharedDriveClient(t *testing.T, server *httptest.Server) *gwclient.GoogleWorkspaceClient {
+	t.Helper()
+	directory := newTestDirectoryService(t, server.URL, server.Client())
+	driveService, err := drive.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+	return &gwclient.GoogleWorkspaceClient{
+		DomainService:            directory,
+		UserService:              directory,
+		GroupService:             directory,
+		DriveService:             driveService,
+		DriveProvisioningService: driveService,
+	}
+}
+
+func TestSharedDriveList_GrantsMembersByRole(t *testing.T) {
+	state := &testSharedDriveServerState{
+		users: []*directoryAdmin.User{
+			{Id: "alice-id", PrimaryEmail: "alice@example.com"},
+		},
+		groups: []*directoryAdmin.Group{
+			{Id: "eng-id", Email: "eng@example.com"},
+		},
+		drives: []*drive.Drive{
+			{Id: "drive-1", Name: "Engineering", Hidden: true, Restrictions: &drive.DriveRestrictions{DomainUsersOnly: true}},
+		},
+		permissions: map[string][]*drive.Permission{
+			"drive-1": {
+				{Id: "p1", Type: "user", Role: "organizer", EmailAddress: "Alice@example.com"},
+				{Id: "p2", Type: "group", Role: "writer", EmailAddress: "eng@example.com"},
+				{Id: "p3", Type: "user", Role: "reader", EmailAddress: "guest@other.com"},
+				{Id: "p4", Type: "group", Role: "commenter", EmailAddress: "partners@other.com"},
+				{Id: "p5", Type: "domain", Role: "reader", Domain: "example.com"},
+				{Id: "p6", Type: "user", Role: "reader", EmailAddress: "gone@example.com", Deleted: true},
+				// Members of the customer's domains that were not synced.
+				{Id: "p7", Type: "user", Role: "reader", EmailAddress: "filtered@example.com"},
+				{Id: "p8", Type: "group", Role: "reader", EmailAddress: "old-team@Example.org"},
+			},
+		},
+	}
+	server := newSharedDriveTestServer(t, state)
+	defer server.Close()
+	syncer := sharedDriveBuilder(newTestSharedDriveClient(t, server), "test-customer", "")
+	ss := newFakeSessionStore()
+
+	drives, _, err := syncer.List(context.Background(), nil, rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, drives, 1)
+	require.Equal(t, "Engineering", drives[0].DisplayName)
+	profile := drives[0].GetProfile().AsMap()
+	require.Equal(t, true, profile["hidden"])
+	require.Equal(t, true, profile["domain_users_only"])
+
+	entitlements, _, err := syncer.Entitlements(context.Background(), drives[0], rs.SyncOpAttrs{})
+	require.NoError(t, err)
+	require.Len(t, entitlements, len(sharedDriveRoles))
+
+	grants := collectDeviceGrants(t, syncer, drives[0], rs.SyncOpAttrs{Session: ss})
+	require.Len(t, grants, 4, "domain, deleted and unsynced internal permissions must be skipped")
+
+	byPrincipal := map[string]*v2.Grant{}
+	for _, g := range grants {
+		byPrincipal[g.Principal.Id.ResourceType+":"+g.Principal.Id.Resource] = g
+	}
+
+	alice := byPrincipal["user:alice-id"]
+	require.NotNil(t, alice)
+	require.Equal(t, sdkEntitlement.NewEntitlementID(drives[0], "organizer"), alice.Entitlement.Id)
+
+	eng := byPrincipal["group:eng-id"]
+	require.NotNil(t, eng)
+	require.Equal(t, sdkEntitlement.NewEntitlementID(drives[0], "writer"), eng.Entitlement.Id)
+	expandable := &v2.GrantExpandable{}
+	engAnnos := annotations.Annotations(eng.Annotations)
+	ok, err := engAnnos.Pick(expandable)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"group:eng-id:member"}, expandable.EntitlementIds)
+
+	for key, trait := range map[string]v2.ResourceType_Trait{
+		"user:guest@other.com":     v2.ResourceType_TRAIT_USER,
+		"group:partners@other.com": v2.ResourceType_TRAIT_GROUP,
+	} {
+		g := byPrincipal[key]
+		require.NotNil(t, g, key)
+		match := &v2.ExternalResourceMatch{}
+		annos := annotations.Annotations(g.Annotations)
+		ok, err := annos.Pick(match)
+		require.NoError(t, err)
+		require.True(t, ok, key)
+		require.Equal(t, trait, match.ResourceType)
+		require.Equal(t, "email", match.Key)
+	}
+
+	// Group lookups, including misses, are cached for the rest of the sync.
+	gets := state.groupGets
+	collectDeviceGrants(t, syncer, drives[0], rs.SyncOpAttrs{Session: ss})
+	require.Equal(t, gets, state.groupGets)
+}
+
+func TestSharedDriveGrantRevoke(t *testing.T) {
+	state := &testSharedDriveServerState{
+		users: []*directoryAdmin.User{
+			{Id: "alice-id", PrimaryEmail: "alice@example.com"},
+		},
+		groups: []*directoryAdmin.Group{
+			{Id: "eng-id", Email: "eng@example.com"},
+		},
+		permissions: map[string][]*drive.Permission{
+			"drive-1": {
+				{Id: "p1", Type: "group", Role: "reader", EmailAddress: "eng@example.com"},
+			},
+		},
+	}
+	server := newSharedDriveTestServer(t, state)
+	defer server.Close()
+	syncer := sharedDriveBuilder(newTestSharedDriveClient(t, server), "test-customer", "")
+	ctx := context.Background()
+
+	driveResource, err := rs.NewResource("Engineering", resourceTypeSharedDrive, "drive-1")
+	require.NoError(t, err)
+	alice, err := rs.NewResource("Alice", resourceTypeUser, "alice-id")
+	require.NoError(t, err)
+	eng, err := rs.NewResource("Eng", resourceTypeGroup, "eng-id")
+	require.NoError(t, err)
+	writer := sdkEntitlement.NewAssignmentEntitlement(driveResource, "writer")
+	reader := sdkEntitlement.NewAssignmentEntitlement(driveResource, "reader")
+
+	// A new member is added with the entitlement's role.
+	_, _, err = syncer.Grant(ctx, alice, writer)
+	require.NoError(t, err)
+	require.Len(t, state.permissions["drive-1"], 2)
+	added := state.permissions["drive-1"][1]
+	require.Equal(t, "user", added.Type)
+	require.Equal(t, "writer", added.Role)
+	require.Equal(t, "alice@example.com", added.EmailAddress)
+
+	// Granting the role a member already holds is a no-op.
+	_, _, err = syncer.Grant(ctx, alice, writer)
+	require.NoError(t, err)
+	require.Len(t, state.permissions["drive-1"], 2)
+
+	// An existing member is moved to the new role.
+	_, _, err = syncer.Grant(ctx, eng, writer)
+	require.NoError(t, err)
+	require.Len(t, state.permissions["drive-1"], 2)
+	require.Equal(t, "writer", state.permissions["drive-1"][0].Role)
+
+	// Revoking a role the member no longer holds leaves it in place.
+	_, err = syncer.Revoke(ctx, sdkGrant.NewGrant(driveResource, "reader", eng.Id))
+	require.NoError(t, err)
+	require.Len(t, state.permissions["drive-1"], 2)
+
+	_, err = syncer.Revoke(ctx, sdkGrant.NewGrant(driveResource, "writer", eng.Id))
+	require.NoError(t, err)
+	require.Len(t, state.permissions["drive-1"], 1)
+
+	// Revoking again, or from a user that no longer exists, succeeds.
+	_, err = syncer.Revoke(ctx, sdkGrant.NewGrant(driveResource, "writer", eng.Id))
+	require.NoError(t, err)
+	ghost, err := rs.NewResourceID(resourceTypeUser, "ghost-id")
+	require.NoError(t, err)
+	_, err = syncer.Revoke(ctx, sdkGrant.NewGrant(driveResource, "writer", ghost))
+	require.NoError(t, err)
+
+	_, _, err = syncer.Grant(ctx, alice, reader)
+	require.NoError(t, err)
+	require.Equal(t, "reader", state.permissions["drive-1"][0].Role)
+}
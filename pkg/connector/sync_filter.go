@@ -0,0 +1,187 @@
+package connector
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+// syncFilters narrows the users and groups a sync covers. It is embedded in
+// every syncer that lists users or groups or resolves grants to them, so
+// resources outside the filters are neither synced nor granted to.
+type syncFilters struct {
+	userFilter  userFilter
+	groupFilter groupFilter
+}
+
+func (f *syncFilters) setSyncFilters(filters syncFilters) {
+	*f = filters
+}
+
+// filterable is implemented by the syncers that embed syncFilters.
+type filterable interface {
+	setSyncFilters(filters syncFilters)
+}
+
+// applySyncFilters hands filters to syncer when it embeds syncFilters.
+func applySyncFilters(syncer any, filters syncFilters) {
+	if f, ok := syncer.(filterable); ok {
+		f.setSyncFilters(filters)
+	}
+}
+
+// newSyncFilters validates and normalizes the filter settings of config.
+func newSyncFilters(config Config) (syncFilters, error) {
+	for _, p := range slices.Concat(config.GroupEmailPatterns, config.ExcludeGroupEmailPatterns) {
+		if _, err := path.Match(strings.ToLower(p), ""); err != nil {
+			return syncFilters{}, fmt.Errorf("google-workspace: invalid group email pattern %q: %w", p, err)
+		}
+	}
+	return syncFilters{
+		userFilter: userFilter{
+			includeOrgUnits:  normalizeOrgUnitPaths(config.IncludeOrgUnits),
+			excludeOrgUnits:  normalizeOrgUnitPaths(config.ExcludeOrgUnits),
+			query:            strings.TrimSpace(config.UserQuery),
+			excludeSuspended: config.ExcludeSuspendedUsers,
+			excludeArchived:  config.ExcludeArchivedUsers,
+		},
+		groupFilter: groupFilter{
+			include: lowerAll(config.GroupEmailPatterns),
+			exclude: lowerAll(config.ExcludeGroupEmailPatterns),
+		},
+	}, nil
+}
+
+// userFilter selects the synced users. Whatever the Directory API can match
+// is sent as its search query; the rest is matched client-side on each page.
+type userFilter struct {
+	includeOrgUnits  []string
+	excludeOrgUnits  []string
+	query            string
+	excludeSuspended bool
+	excludeArchived  bool
+}
+
+// active reports whether any user is filtered out.
+func (f userFilter) active() bool {
+	return len(f.includeOrgUnits) > 0 || len(f.excludeOrgUnits) > 0 || f.query != "" || f.excludeSuspended || f.excludeArchived
+}
+
+// apiQuery is the Directory API search query for the filter. Query terms are
+// ANDed, so a single included org unit (whose orgUnitPath term also matches
+// its sub-org units) is pushed down, while several are matched client-side.
+// Archived users cannot be searched for and are matched client-side too.
+func (f userFilter) apiQuery() string {
+	var terms []string
+	if f.query != "" {
+		terms = append(terms, f.query)
+	}
+	if f.excludeSuspended {
+		terms = append(terms, "isSuspended=false")
+	}
+	if len(f.includeOrgUnits) == 1 {
+		terms = append(terms, "orgUnitPath='"+strings.ReplaceAll(f.includeOrgUnits[0], "'", `\'`)+"'")
+	}
+	return strings.Join(terms, " ")
+}
+
+// matches applies the client-side part of the filter to a listed user. The
+// raw query is left to the API, which has already applied it.
+func (f userFilter) matches(u *admin.User) bool {
+	if f.excludeSuspended && u.Suspended {
+		return false
+	}
+	if f.excludeArchived && u.Archived {
+		return false
+	}
+	if len(f.includeOrgUnits) > 0 && !orgUnitWithin(u.OrgUnitPath, f.includeOrgUnits) {
+		return false
+	}
+	return !orgUnitWithin(u.OrgUnitPath, f.excludeOrgUnits)
+}
+
+// orgUnitWithin reports whether orgUnitPath is one of roots or below one.
+// Org unit paths are matched case-insensitively, like the Admin console does.
+func orgUnitWithin(orgUnitPath string, roots []string) bool {
+	p := strings.ToLower(orgUnitPath)
+	for _, root := range roots {
+		root = strings.ToLower(root)
+		if root == "/" || p == root || strings.HasPrefix(p, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeOrgUnitPaths gives each path a leading slash and no trailing one,
+// so "Sales/" and "/Sales" name the same org unit.
+func normalizeOrgUnitPaths(paths []string) []string {
+	var rv []string
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		p = "/" + strings.Trim(p, "/")
+		rv = append(rv, p)
+	}
+	return rv
+}
+
+// groupFilter selects the synced groups by email. Patterns are lower-cased
+// path.Match patterns, where * matches any run of characters.
+type groupFilter struct {
+	include []string
+	exclude []string
+}
+
+// active reports whether any group is filtered out.
+func (f groupFilter) active() bool {
+	return len(f.include) > 0 || len(f.exclude) > 0
+}
+
+// apiQuery pushes a single include pattern down as a Directory API email
+// prefix search when it is a literal prefix followed by one trailing *.
+// Every listed group is still matched client-side.
+func (f groupFilter) apiQuery() string {
+	if len(f.include) != 1 {
+		return ""
+	}
+	prefix, ok := strings.CutSuffix(f.include[0], "*")
+	if !ok || prefix == "" || strings.ContainsAny(prefix, `*?[\`) {
+		return ""
+	}
+	return "email:" + prefix + "*"
+}
+
+// matches reports whether a group with email is synced.
+func (f groupFilter) matches(email string) bool {
+	email = strings.ToLower(email)
+	if len(f.include) > 0 && !matchesAnyPattern(email, f.include) {
+		return false
+	}
+	return !matchesAnyPattern(email, f.exclude)
+}
+
+func matchesAnyPattern(email string, patterns []string) bool {
+	for _, p := range patterns {
+		// Patterns were validated by newSyncFilters.
+		if ok, _ := path.Match(p, email); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func lowerAll(values []string) []string {
+	var rv []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			rv = append(rv, strings.ToLower(v))
+		}
+	}
+	return rv
+}
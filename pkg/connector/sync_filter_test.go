@@ -0,0 +1,148 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+func TestUserFilter_PushesDownWhatTheAPICanMatch(t *testing.T) {
+	filters, err := newSyncFilters(Config{
+		IncludeOrgUnits:       []string{"Engineering/"},
+		ExcludeOrgUnits:       []string{"/Engineering/Contractors"},
+		UserQuery:             "orgTitle:Engineer",
+		ExcludeSuspendedUsers: true,
+		ExcludeArchivedUsers:  true,
+	})
+	require.NoError(t, err)
+	f := filters.userFilter
+	require.True(t, f.active())
+	require.Equal(t, "orgTitle:Engineer isSuspended=false orgUnitPath='/Engineering'", f.apiQuery())
+
+	require.True(t, f.matches(&directoryAdmin.User{OrgUnitPath: "/Engineering"}))
+	require.True(t, f.matches(&directoryAdmin.User{OrgUnitPath: "/Engineering/Platform"}))
+	require.False(t, f.matches(&directoryAdmin.User{OrgUnitPath: "/EngineeringOps"}))
+	require.False(t, f.matches(&directoryAdmin.User{OrgUnitPath: "/Engineering/Contractors/EU"}))
+	require.False(t, f.matches(&directoryAdmin.User{OrgUnitPath: "/Engineering", Archived: true}))
+	require.False(t, f.matches(&directoryAdmin.User{OrgUnitPath: "/Engineering", Suspended: true}))
+
+	several := userFilter{includeOrgUnits: normalizeOrgUnitPaths([]string{"/Sales", "/Support"})}
+	require.Empty(t, several.apiQuery(), "several org units cannot be ANDed into one query")
+	require.True(t, several.matches(&directoryAdmin.User{OrgUnitPath: "/Support/Tier1"}))
+	require.False(t, userFilter{}.active())
+}
+
+func TestGroupFilter_MatchesEmailPatterns(t *testing.T) {
+	filters, err := newSyncFilters(Config{
+		GroupEmailPatterns:        []string{"Eng-*"},
+		ExcludeGroupEmailPatterns: []string{"*-archive@example.com"},
+	})
+	require.NoError(t, err)
+	f := filters.groupFilter
+	require.Equal(t, "email:eng-*", f.apiQuery())
+	require.True(t, f.matches("eng-platform@example.com"))
+	require.False(t, f.matches("eng-old-archive@example.com"))
+	require.False(t, f.matches("sales@example.com"))
+
+	require.Empty(t, groupFilter{include: []string{"*@example.com"}}.apiQuery())
+	require.Empty(t, groupFilter{include: []string{"a-*", "b-*"}}.apiQuery())
+
+	_, err = newSyncFilters(Config{GroupEmailPatterns: []string{"eng-["}})
+	require.Error(t, err)
+}
+
+// TestGroupGrants_SkipMembersOutsideSyncedSet checks that a group's users
+// left out by the user filter and nested groups outside the group filter get
+// no grant, while members that are no users of the customer keep theirs, and
+// that the user filter is pushed down when the index is built.
+func TestGroupGrants_SkipMembersOutsideSyncedSet(t *testing.T) {
+	var mu sync.Mutex
+	var userQueries, userGets []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/directory/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		userQueries = append(userQueries, r.URL.Query().Get("query"))
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.Users{Users: []*directoryAdmin.User{
+			{Id: "alice-id", PrimaryEmail: "alice@example.com", OrgUnitPath: "/Engineering"},
+			{Id: "bob-id", PrimaryEmail: "bob@example.com", OrgUnitPath: "/Engineering", Archived: true},
+		}})
+	})
+	mux.HandleFunc("/admin/directory/v1/users/{userKey}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		userGets = append(userGets, r.PathValue("userKey"))
+		mu.Unlock()
+		switch r.PathValue("userKey") {
+		case "bob@example.com":
+			_ = json.NewEncoder(w).Encode(&directoryAdmin.User{Id: "bob-id", PrimaryEmail: "bob@example.com", OrgUnitPath: "/Engineering", Archived: true})
+		case "dave@example.com":
+			_ = json.NewEncoder(w).Encode(&directoryAdmin.User{Id: "dave-id", PrimaryEmail: "dave@example.com", OrgUnitPath: "/Sales"})
+		default:
+			http.Error(w, `{"error":{"code":404,"message":"Resource Not Found: userKey"}}`, http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("/admin/directory/v1/groups/eng/members", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.Members{Members: []*directoryAdmin.Member{
+			{Id: "alice-id", Email: "Alice@example.com", Type: "USER", Role: "OWNER"},
+			{Id: "bob-id", Email: "bob@example.com", Type: "USER", Role: "MEMBER"},
+			{Id: "carol-id", Email: "carol@example.com", Type: "USER", Role: "MEMBER"},
+			{Id: "dave-id", Email: "dave@example.com", Type: "USER", Role: "MEMBER"},
+			{Id: "eng-sub-id", Email: "eng-sub@example.com", Type: "GROUP", Role: "MEMBER"},
+			{Id: "sales-id", Email: "sales@example.com", Type: "GROUP", Role: "MEMBER"},
+		}})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	dir := newTestDirectoryService(t, server.URL, server.Client())
+	filters, err := newSyncFilters(Config{
+		IncludeOrgUnits:      []string{"/Engineering"},
+		ExcludeArchivedUsers: true,
+		GroupEmailPatterns:   []string{"eng*"},
+	})
+	require.NoError(t, err)
+	o := groupBuilder(&gwclient.GoogleWorkspaceClient{UserService: dir, GroupMemberService: dir}, "customer", "")
+	applySyncFilters(o, filters)
+
+	group, err := rs.NewGroupResource("Engineering", resourceTypeGroup, "eng", nil)
+	require.NoError(t, err)
+
+	ss := newFakeSessionStore()
+	principals := map[string][]string{}
+	token := ""
+	for range 10 {
+		grants, results, err := o.Grants(context.Background(), group, rs.SyncOpAttrs{Session: ss, PageToken: pagination.Token{Token: token}})
+		require.NoError(t, err)
+		for _, g := range grants {
+			principals[g.Principal.Id.Resource] = append(principals[g.Principal.Id.Resource], g.Entitlement.Id)
+		}
+		token = results.NextPageToken
+		if token == "" {
+			break
+		}
+	}
+	require.Empty(t, token, "Grants did not finish")
+	require.Equal(t, map[string][]string{
+		"alice-id":   {"group:eng:member", "group:eng:owner"},
+		"carol-id":   {"group:eng:member"},
+		"eng-sub-id": {"group:eng:member"},
+	}, principals)
+	require.Equal(t, []string{"orgUnitPath='/Engineering'"}, userQueries)
+	require.ElementsMatch(t, []string{"bob@example.com", "carol@example.com", "dave@example.com"}, userGets)
+
+	// A later group of the sync reuses what the first one looked up.
+	grants, _, err := o.Grants(context.Background(), group, rs.SyncOpAttrs{Session: ss})
+	require.NoError(t, err)
+	require.Len(t, grants, 4)
+	require.Len(t, userGets, 3)
+}
@@ -0,0 +1,41 @@
+package connector
+
+// syncOptions configures the optional parts of a sync. Like syncFilters it
+// is embedded in the syncers that use it and handed to every
+// tenant's syncers.
+type syncOptions struct {
+	// groupSettings adds each group's Groups Settings to its profile, at the
+	// cost of one settings call per listed group.
+	groupSettings bool
+	// oauthAppDiscovery is the source enterprise applications discover
+	// OAuth apps from.
+	oauthAppDiscovery oauthAppDiscovery
+}
+
+func (o *syncOptions) setSyncOptions(options syncOptions) {
+	*o = options
+}
+
+// configurable is implemented by the syncers that embed syncOptions.
+type configurable interface {
+	setSyncOptions(options syncOptions)
+}
+
+// applySyncOptions hands options to syncer when it embeds syncOptions.
+func applySyncOptions(syncer any, options syncOptions) {
+	if o, ok := syncer.(configurable); ok {
+		o.setSyncOptions(options)
+	}
+}
+
+// newSyncOptions validates and reads the optional sync settings of config.
+func newSyncOptions(config Config) (syncOptions, error) {
+	discovery, err := parseOAuthAppDiscovery(config.OAuthAppDiscovery)
+	if err != nil {
+		return syncOptions{}, err
+	}
+	return syncOptions{
+		groupSettings:     config.SyncGroupSettings,
+		oauthAppDiscovery: discovery,
+	}, nil
+}
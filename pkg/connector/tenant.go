@@ -0,0 +1,524 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/session"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-sdk/pkg/types/sessions"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+// Profile keys tagging every resource of a multi-domain or multi-tenant sync
+// with the customer and domain it was listed from.
+const (
+	profileKeyTenantCustomerID = "tenant_customer_id"
+	profileKeySourceDomain     = "source_domain"
+)
+
+// partitionPageType marks the pagination bag states of a partitioned List,
+// one per partition, whose ResourceID is the partition's index.
+const partitionPageType = "partition"
+
+// partitionParentNamespace maps a listed resource that has child resources
+// (an org unit) to its partition's index, so listing its children only asks
+// the tenant it belongs to.
+var partitionParentNamespace = sessions.WithPrefix("partition_parent")
+
+// tenantConfig is one entry of the additional-tenants file.
+type tenantConfig struct {
+	CustomerID          string          `json:"customer_id"`
+	AdministratorEmail  string          `json:"administrator_email"`
+	Credentials         json.RawMessage `json:"credentials"`
+	ServiceAccountEmail string          `json:"service_account_email"`
+	Domains             []string        `json:"domains"`
+	AllDomains          bool            `json:"all_domains"`
+}
+
+// parseAdditionalTenants parses the additional-tenants file: a JSON array of
+// customers, each authenticated with its own service account key or, keyless,
+// by impersonating service_account_email with the primary tenant's external
+// account credentials.
+func parseAdditionalTenants(data []byte, externalAccountCredentials []byte) ([]Config, error) {
+	var tenants []tenantConfig
+	if err := json.Unmarshal(data, &tenants); err != nil {
+		return nil, fmt.Errorf("additional-tenants-file-path must be a JSON array of tenants: %w", err)
+	}
+	rv := make([]Config, 0, len(tenants))
+	for i, t := range tenants {
+		switch {
+		case t.CustomerID == "" || t.AdministratorEmail == "":
+			return nil, fmt.Errorf("additional tenant %d: customer_id and administrator_email are required", i)
+		case (len(t.Credentials) == 0) == (t.ServiceAccountEmail == ""):
+			return nil, fmt.Errorf("additional tenant %s: exactly one of credentials or service_account_email is required", t.CustomerID)
+		case len(t.Domains) > 0 && t.AllDomains:
+			return nil, fmt.Errorf("additional tenant %s: domains and all_domains are mutually exclusive", t.CustomerID)
+		}
+		config := Config{
+			CustomerID:          t.CustomerID,
+			AdministratorEmail:  t.AdministratorEmail,
+			Credentials:         []byte(t.Credentials),
+			ServiceAccountEmail: t.ServiceAccountEmail,
+			Domains:             t.Domains,
+			AllDomains:          t.AllDomains,
+		}
+		if t.ServiceAccountEmail != "" {
+			config.ExternalAccountCredentials = externalAccountCredentials
+		}
+		rv = append(rv, config)
+	}
+	return rv, nil
+}
+
+// partitioned reports whether resources are listed per domain or per tenant
+// rather than in a single pass over the customer.
+func (c *GoogleWorkspace) partitioned() bool {
+	return len(c.domains) > 0 || c.allDomains || len(c.additionalTenants) > 0
+}
+
+// syncDomains returns the domains to list users, groups and mailboxes from,
+// one at a time. A single "" entry lists the configured domain, or the whole
+// customer, in one pass.
+func (c *GoogleWorkspace) syncDomains(ctx context.Context, client *gwclient.GoogleWorkspaceClient) ([]string, error) {
+	switch {
+	case c.allDomains:
+		resp, err := client.ListDomains(ctx, c.customerID)
+		if err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to enumerate domains for customer %s: %w", c.customerID, err)
+		}
+		domains := make([]string, 0, len(resp.Domains))
+		for _, d := range resp.Domains {
+			if d.Verified && d.DomainName != "" {
+				domains = append(domains, d.DomainName)
+			}
+		}
+		if len(domains) == 0 {
+			return nil, fmt.Errorf("google-workspace: customer %s has no verified domains", c.customerID)
+		}
+		return domains, nil
+	case len(c.domains) > 0:
+		return c.domains, nil
+	default:
+		return []string{c.domain}, nil
+	}
+}
+
+// syncTenant is one customer's client and the domains synced from it.
+type syncTenant struct {
+	client     *gwclient.GoogleWorkspaceClient
+	customerID string
+	domains    []string
+	filters    syncFilters
+	options    syncOptions
+}
+
+// domain is the domain that customer-wide resource types are scoped to: the
+// only synced domain, or "" for the whole customer.
+func (t syncTenant) domain() string {
+	if len(t.domains) == 1 {
+		return t.domains[0]
+	}
+	return ""
+}
+
+// syncTenants returns the primary tenant followed by every additional one.
+// When the connector is not partitioned, that is the primary tenant alone with
+// its single configured domain.
+func (c *GoogleWorkspace) syncTenants(ctx context.Context, client *gwclient.GoogleWorkspaceClient) ([]syncTenant, error) {
+	if !c.partitioned() {
+		return []syncTenant{{client: client, customerID: c.customerID, domains: []string{c.domain}, filters: c.filters, options: c.options}}, nil
+	}
+	domains, err := c.syncDomains(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	rv := []syncTenant{{client: client, customerID: c.customerID, domains: domains, filters: c.filters, options: c.options}}
+	for _, t := range c.additionalTenants {
+		tenantClient, err := t.getClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to initialize tenant %s: %w", t.customerID, err)
+		}
+		domains, err := t.syncDomains(ctx, tenantClient)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, syncTenant{client: tenantClient, customerID: t.customerID, domains: domains, filters: t.filters, options: t.options})
+	}
+	return rv, nil
+}
+
+// partitioning decides which slices of the synced tenants a resource type is
+// listed from.
+type partitioning int
+
+const (
+	// partitionByDomain lists the type once per domain of every tenant.
+	partitionByDomain partitioning = iota
+	// partitionByTenant lists customer-wide types once per tenant.
+	partitionByTenant
+	// partitionPrimaryTenant lists the type from the primary tenant only,
+	// for types whose resource IDs are not unique across customers.
+	partitionPrimaryTenant
+)
+
+// partitionable is a resource syncer that can fan its sync out over
+// partitions; see tenantPartitions.
+type partitionable interface {
+	connectorbuilder.ResourceSyncerV2
+	setPartitions(partitions []syncPartition)
+	setTenantDomains(domains []string)
+}
+
+// resourceSyncerSpec describes how ResourceSyncers builds one resource type's
+// syncer: the services it needs and how it is partitioned.
+type resourceSyncerSpec struct {
+	partitioning partitioning
+	available    func(client *gwclient.GoogleWorkspaceClient) bool
+	build        func(client *gwclient.GoogleWorkspaceClient, customerID, domain string) partitionable
+}
+
+func newResourceSyncerSpec[S partitionable](
+	p partitioning,
+	available func(client *gwclient.GoogleWorkspaceClient) bool,
+	build func(client *gwclient.GoogleWorkspaceClient, customerID, domain string) S,
+) resourceSyncerSpec {
+	return resourceSyncerSpec{
+		partitioning: p,
+		available:    available,
+		build: func(client *gwclient.GoogleWorkspaceClient, customerID, domain string) partitionable {
+			return build(client, customerID, domain)
+		},
+	}
+}
+
+// syncer builds the primary tenant's syncer. With more than the one default
+// slice to sync, it also gets a partition per tenant or domain whose client
+// provides the type's services.
+func (s resourceSyncerSpec) syncer(tenants []syncTenant, partitioned bool) connectorbuilder.ResourceSyncerV2 {
+	primary := tenants[0]
+	rv := s.build(primary.client, primary.customerID, primary.domain())
+	applySyncFilters(rv, primary.filters)
+	applySyncOptions(rv, primary.options)
+	if !partitioned {
+		return rv
+	}
+	var partitions []syncPartition
+	for i, t := range tenants {
+		if i > 0 && (s.partitioning == partitionPrimaryTenant || !s.available(t.client)) {
+			continue
+		}
+		domains := []string{t.domain()}
+		if s.partitioning == partitionByDomain {
+			domains = t.domains
+		}
+		for _, d := range domains {
+			syncer := s.build(t.client, t.customerID, d)
+			applySyncFilters(syncer, t.filters)
+			applySyncOptions(syncer, t.options)
+			syncer.setTenantDomains(t.domains)
+			partitions = append(partitions, syncPartition{
+				customerID: t.customerID,
+				domain:     d,
+				syncer:     syncer,
+			})
+		}
+	}
+	rv.setPartitions(partitions)
+	return rv
+}
+
+// syncPartition is one customer/domain slice of a resource type, synced by a
+// syncer built for just that slice.
+type syncPartition struct {
+	customerID string
+	domain     string
+	syncer     connectorbuilder.ResourceSyncerV2
+}
+
+// session scopes the sync's session store to the partition, so that caches
+// keyed by email or OU path stay separate across tenants and domains.
+func (p syncPartition) session(ss sessions.SessionStore) sessions.SessionStore {
+	if ss == nil {
+		return nil
+	}
+	return &partitionSessionStore{SessionStore: ss, customerID: p.customerID, prefix: p.customerID + "/" + p.domain + ":"}
+}
+
+// tenantSession widens a partition's session store to its whole tenant, for
+// caches shared by the tenant's domains, such as the user email index. Any
+// other store is returned as is.
+func tenantSession(ss sessions.SessionStore) sessions.SessionStore {
+	if ps, ok := ss.(*partitionSessionStore); ok {
+		return &partitionSessionStore{SessionStore: ps.SessionStore, customerID: ps.customerID, prefix: ps.customerID + ":"}
+	}
+	return ss
+}
+
+// tenantPartitions is embedded in every resource syncer. Once partitions are
+// set, the syncer's List walks them one at a time, tagging each resource with
+// its customer and domain, and Entitlements, Grants and provisioning for a
+// resource are handed to the partition that listed it.
+type tenantPartitions struct {
+	partitions []syncPartition
+	// tenantDomains, set on a partition's syncer, are all the domains synced
+	// from its tenant, of which the partition may list just one.
+	tenantDomains []string
+}
+
+func (p *tenantPartitions) setPartitions(partitions []syncPartition) {
+	p.partitions = partitions
+}
+
+func (p *tenantPartitions) setTenantDomains(domains []string) {
+	p.tenantDomains = domains
+}
+
+func (p *tenantPartitions) partitioned() bool {
+	return len(p.partitions) > 0
+}
+
+// listPartitions lists one page of the current partition. The bag holds a
+// state per partition, so pages iterate partition by partition with each
+// state carrying that partition's own page token.
+func (p *tenantPartitions) listPartitions(ctx context.Context, parentResourceID *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	bag := &pagination.Bag{}
+	if err := bag.Unmarshal(attrs.PageToken.Token); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal pagination token in partitioned List: %w", err)
+	}
+	if bag.Current() == nil {
+		indexes, err := p.listIndexes(ctx, attrs.Session, parentResourceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, i := range slices.Backward(indexes) {
+			bag.Push(pagination.PageState{ResourceTypeID: partitionPageType, ResourceID: strconv.Itoa(i)})
+		}
+	}
+	i, err := strconv.Atoi(bag.ResourceID())
+	if err != nil || i < 0 || i >= len(p.partitions) {
+		return nil, nil, fmt.Errorf("google-workspace: invalid partition %q in List page token", bag.ResourceID())
+	}
+	partition := p.partitions[i]
+
+	ss := attrs.Session
+	attrs.PageToken = pagination.Token{Size: attrs.PageToken.Size, Token: bag.PageToken()}
+	attrs.Session = partition.session(ss)
+	resources, results, err := partition.syncer.List(ctx, parentResourceID, attrs)
+	if err != nil {
+		return nil, nil, err
+	}
+	parents := make(map[string]int)
+	for _, r := range resources {
+		if err := tagResourceTenant(r, partition.customerID, partition.domain); err != nil {
+			return nil, nil, err
+		}
+		annos := annotations.Annotations(r.GetAnnotations())
+		if annos.Contains(&v2.ChildResourceType{}) {
+			parents[partitionParentKey(r.GetId())] = i
+		}
+	}
+	if len(parents) > 0 && ss != nil {
+		if err := session.SetManyJSON(ctx, ss, parents, partitionParentNamespace); err != nil {
+			return nil, nil, fmt.Errorf("google-workspace: failed to store resource partitions in session: %w", err)
+		}
+	}
+
+	var innerNext string
+	var annos annotations.Annotations
+	if results != nil {
+		innerNext = results.NextPageToken
+		annos = results.Annotations
+	}
+	nextPage, err := bag.NextToken(innerNext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate next page token in partitioned List: %w", err)
+	}
+	return resources, &rs.SyncOpResults{NextPageToken: nextPage, Annotations: annos}, nil
+}
+
+// listIndexes returns the partitions to list: the one that listed the
+// parent resource when known, otherwise all of them.
+func (p *tenantPartitions) listIndexes(ctx context.Context, ss sessions.SessionStore, parentResourceID *v2.ResourceId) ([]int, error) {
+	if parentResourceID != nil && ss != nil {
+		i, ok, err := session.GetJSON[int](ctx, ss, partitionParentKey(parentResourceID), partitionParentNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("google-workspace: failed to read resource partition from session: %w", err)
+		}
+		if ok && i >= 0 && i < len(p.partitions) {
+			return []int{i}, nil
+		}
+	}
+	indexes := make([]int, len(p.partitions))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes, nil
+}
+
+func partitionParentKey(id *v2.ResourceId) string {
+	return id.GetResourceType() + "/" + id.GetResource()
+}
+
+// ownerPartition returns the partition that listed r, matched by its tenant tags, or
+// the first partition for an untagged resource.
+func (p *tenantPartitions) ownerPartition(r *v2.Resource) syncPartition {
+	customerID, domain := resourceTenant(r)
+	for _, partition := range p.partitions {
+		if partition.customerID == customerID && partition.domain == domain {
+			return partition
+		}
+	}
+	return p.partitions[0]
+}
+
+func (p *tenantPartitions) entitlementsPartitions(ctx context.Context, r *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	partition := p.ownerPartition(r)
+	attrs.Session = partition.session(attrs.Session)
+	return partition.syncer.Entitlements(ctx, r, attrs)
+}
+
+func (p *tenantPartitions) grantsPartitions(ctx context.Context, r *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	partition := p.ownerPartition(r)
+	attrs.Session = partition.session(attrs.Session)
+	return partition.syncer.Grants(ctx, r, attrs)
+}
+
+// provisioner returns the syncer to grant or revoke r's entitlements with:
+// that of the partition that listed r, or nil when not partitioned.
+func (p *tenantPartitions) provisioner(r *v2.Resource) connectorbuilder.ResourceSyncerV2 {
+	if !p.partitioned() || r == nil {
+		return nil
+	}
+	return p.ownerPartition(r).syncer
+}
+
+// tenantTraits are the traits whose profile carries a resource's tenant tags
+// alongside the resource profile.
+func tenantTraits() []profiledTrait {
+	return []profiledTrait{&v2.UserTrait{}, &v2.GroupTrait{}, &v2.RoleTrait{}, &v2.AppTrait{}}
+}
+
+type profiledTrait interface {
+	proto.Message
+	GetProfile() *structpb.Struct
+	SetProfile(*structpb.Struct)
+}
+
+// tagResourceTenant records the customer and domain r was listed from on its
+// resource profile and on its trait's profile, if it has one.
+func tagResourceTenant(r *v2.Resource, customerID, domain string) error {
+	tag := func(profile *structpb.Struct) *structpb.Struct {
+		if profile == nil {
+			profile = &structpb.Struct{}
+		}
+		if profile.Fields == nil {
+			profile.Fields = map[string]*structpb.Value{}
+		}
+		profile.Fields[profileKeyTenantCustomerID] = structpb.NewStringValue(customerID)
+		if domain != "" {
+			profile.Fields[profileKeySourceDomain] = structpb.NewStringValue(domain)
+		}
+		return profile
+	}
+
+	r.SetProfile(tag(r.GetProfile()))
+	annos := annotations.Annotations(r.GetAnnotations())
+	for _, trait := range tenantTraits() {
+		ok, err := annos.Pick(trait)
+		if err != nil {
+			return fmt.Errorf("google-workspace: failed to read trait of %s: %w", r.GetId().GetResource(), err)
+		}
+		if ok {
+			trait.SetProfile(tag(trait.GetProfile()))
+			annos.Update(trait)
+			r.SetAnnotations(annos)
+			return nil
+		}
+	}
+	return nil
+}
+
+// resourceTenant reads the tenant tags written by tagResourceTenant,
+// preferring the resource profile over a trait's.
+func resourceTenant(r *v2.Resource) (string, string) {
+	fields := r.GetProfile().GetFields()
+	if _, ok := fields[profileKeyTenantCustomerID]; !ok {
+		annos := annotations.Annotations(r.GetAnnotations())
+		for _, trait := range tenantTraits() {
+			if ok, err := annos.Pick(trait); err == nil && ok {
+				fields = trait.GetProfile().GetFields()
+				break
+			}
+		}
+	}
+	return fields[profileKeyTenantCustomerID].GetStringValue(), fields[profileKeySourceDomain].GetStringValue()
+}
+
+// partitionSessionStore prefixes every session key namespace with the
+// partition's, on top of any namespace the caller selects.
+type partitionSessionStore struct {
+	sessions.SessionStore
+	customerID string
+	prefix     string
+}
+
+func (s *partitionSessionStore) opts(opt []sessions.SessionStoreOption) []sessions.SessionStoreOption {
+	return append(slices.Clip(opt), func(_ context.Context, bag *sessions.SessionStoreBag) error {
+		bag.Prefix = s.prefix + bag.Prefix
+		return nil
+	})
+}
+
+func (s *partitionSessionStore) Get(ctx context.Context, key string, opt ...sessions.SessionStoreOption) ([]byte, bool, error) {
+	return s.SessionStore.Get(ctx, key, s.opts(opt)...)
+}
+
+func (s *partitionSessionStore) GetMany(ctx context.Context, keys []string, opt ...sessions.SessionStoreOption) (map[string][]byte, []string, error) {
+	return s.SessionStore.GetMany(ctx, keys, s.opts(opt)...)
+}
+
+func (s *partitionSessionStore) Set(ctx context.Context, key string, value []byte, opt ...sessions.SessionStoreOption) error {
+	return s.SessionStore.Set(ctx, key, value, s.opts(opt)...)
+}
+
+func (s *partitionSessionStore) SetMany(ctx context.Context, values map[string][]byte, opt ...sessions.SessionStoreOption) error {
+	return s.SessionStore.SetMany(ctx, values, s.opts(opt)...)
+}
+
+func (s *partitionSessionStore) Delete(ctx context.Context, key string, opt ...sessions.SessionStoreOption) error {
+	return s.SessionStore.Delete(ctx, key, s.opts(opt)...)
+}
+
+func (s *partitionSessionStore) Clear(ctx context.Context, opt ...sessions.SessionStoreOption) error {
+	return s.SessionStore.Clear(ctx, s.opts(opt)...)
+}
+
+func (s *partitionSessionStore) GetAll(ctx context.Context, pageToken string, opt ...sessions.SessionStoreOption) (map[string][]byte, string, error) {
+	return s.SessionStore.GetAll(ctx, pageToken, s.opts(opt)...)
+}
+
+// validateDomains checks that every configured domain belongs to the customer.
+func (c *GoogleWorkspace) validateDomains(available []string) error {
+	configured := c.domains
+	if c.domain != "" {
+		configured = []string{c.domain}
+	}
+	for _, want := range configured {
+		if !slices.ContainsFunc(available, func(d string) bool { return strings.EqualFold(want, d) }) {
+			return fmt.Errorf("domain '%s' is not a valid domain for customer '%s'", want, c.customerID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+	directoryAdmin "google.golang.org/api/admin/directory/v1"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+// newTenantTestServer serves one customer's users, paged one user at a time
+// and filtered by the domain query parameter, its role assignments, and every
+// user as a member of any group.
+func newTenantTestServer(t *testing.T, customerID string, users []*directoryAdmin.User) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/directory/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("domain") == "" && q.Get("customer") != customerID {
+			http.Error(w, `{"error":{"code":403,"message":"wrong customer"}}`, http.StatusForbidden)
+			return
+		}
+		var matched []*directoryAdmin.User
+		for _, u := range users {
+			if d := q.Get("domain"); d == "" || strings.HasSuffix(u.PrimaryEmail, "@"+d) {
+				matched = append(matched, u)
+			}
+		}
+		start := 0
+		if tok := q.Get("pageToken"); tok != "" {
+			for i, u := range matched {
+				if u.Id == tok {
+					start = i
+				}
+			}
+		}
+		resp := &directoryAdmin.Users{}
+		if start < len(matched) {
+			resp.Users = matched[start : start+1]
+			if start+1 < len(matched) {
+				resp.NextPageToken = matched[start+1].Id
+			}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/admin/directory/v1/customer/"+customerID+"/roleassignments", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&directoryAdmin.RoleAssignments{Items: []*directoryAdmin.RoleAssignment{
+			{RoleAssignmentId: 1, RoleId: 7, AssignedTo: users[0].Id, AssigneeType: "user", ScopeType: "CUSTOMER"},
+		}})
+	})
+	mux.HandleFunc("/admin/directory/v1/groups/{groupKey}/members", func(w http.ResponseWriter, r *http.Request) {
+		resp := &directoryAdmin.Members{}
+		for _, u := range users {
+			resp.Members = append(resp.Members, &directoryAdmin.Member{Id: u.Id, Email: u.PrimaryEmail, Type: "USER", Role: "MEMBER"})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTenantTestClient(t *testing.T, server *httptest.Server) *gwclient.GoogleWorkspaceClient {
+	t.Helper()
+	directory := newTestDirectoryService(t, server.URL, server.Client())
+	return &gwclient.GoogleWorkspaceClient{UserService: directory, RoleService: directory, GroupMemberService: directory}
+}
+
+func newTestTenants(t *testing.T) []syncTenant {
+	t.Helper()
+	primary := newTenantTestServer(t, "C-primary", []*directoryAdmin.User{
+		{Id: "alice-id", PrimaryEmail: "alice@example.com", Name: &directoryAdmin.UserName{FullName: "Alice"}},
+		{Id: "bob-id", PrimaryEmail: "bob@example.com", Name: &directoryAdmin.UserName{FullName: "Bob"}},
+		{Id: "carol-id", PrimaryEmail: "carol@example.org", Name: &directoryAdmin.UserName{FullName: "Carol"}},
+	})
+	acquired := newTenantTestServer(t, "C-acquired", []*directoryAdmin.User{
+		{Id: "dave-id", PrimaryEmail: "dave@acquired.com", Name: &directoryAdmin.UserName{FullName: "Dave"}},
+	})
+	return []syncTenant{
+		{client: newTenantTestClient(t, primary), customerID: "C-primary", domains: []string{"example.com", "example.org"}},
+		{client: newTenantTestClient(t, acquired), customerID: "C-acquired", domains: []string{""}},
+	}
+}
+
+func TestPartitionedList_IteratesDomainByDomainAndTagsResources(t *testing.T) {
+	spec := newResourceSyncerSpec(partitionByDomain, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.UserService != nil
+	}, userBuilder)
+	syncer := spec.syncer(newTestTenants(t), true)
+
+	var ids []string
+	tenants := map[string][2]string{}
+	token := ""
+	for range 10 {
+		users, results, err := syncer.List(context.Background(), nil, rs.SyncOpAttrs{
+			Session:   newFakeSessionStore(),
+			PageToken: pagination.Token{Token: token},
+		})
+		require.NoError(t, err)
+		for _, u := range users {
+			ids = append(ids, u.Id.Resource)
+			customerID, domain := resourceTenant(u)
+			tenants[u.Id.Resource] = [2]string{customerID, domain}
+		}
+		token = results.NextPageToken
+		if token == "" {
+			break
+		}
+	}
+	require.Empty(t, token, "List did not finish")
+	require.Equal(t, []string{"alice-id", "bob-id", "carol-id", "dave-id"}, ids)
+	require.Equal(t, [2]string{"C-primary", "example.com"}, tenants["bob-id"])
+	require.Equal(t, [2]string{"C-primary", "example.org"}, tenants["carol-id"])
+	require.Equal(t, [2]string{"C-acquired", ""}, tenants["dave-id"])
+}
+
+func TestPartitionedGrants_RouteToListingTenant(t *testing.T) {
+	spec := newResourceSyncerSpec(partitionByTenant, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.RoleService != nil
+	}, func(client *gwclient.GoogleWorkspaceClient, customerID, _ string) *roleResourceType {
+		return roleBuilder(client, customerID)
+	})
+	syncer := spec.syncer(newTestTenants(t), true)
+
+	for customerID, wantPrincipal := range map[string]string{"C-primary": "alice-id", "C-acquired": "dave-id"} {
+		role, err := rs.NewRoleResource("Admin", resourceTypeRole, "7", nil)
+		require.NoError(t, err)
+		require.NoError(t, tagResourceTenant(role, customerID, ""))
+
+		grants, _, err := syncer.Grants(context.Background(), role, rs.SyncOpAttrs{Session: newFakeSessionStore()})
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		require.Equal(t, wantPrincipal, grants[0].Principal.Id.Resource)
+	}
+}
+
+// TestPartitionedGrants_ResolveUsersOfSiblingDomains checks that the user
+// email index of a partition covers every domain synced from its tenant, so a
+// group keeps its members from another domain when user filters are active.
+func TestPartitionedGrants_ResolveUsersOfSiblingDomains(t *testing.T) {
+	filters, err := newSyncFilters(Config{ExcludeSuspendedUsers: true})
+	require.NoError(t, err)
+	tenants := newTestTenants(t)[:1]
+	tenants[0].filters = filters
+	spec := newResourceSyncerSpec(partitionByDomain, func(client *gwclient.GoogleWorkspaceClient) bool {
+		return client.GroupMemberService != nil
+	}, groupBuilder)
+	syncer := spec.syncer(tenants, true)
+	ss := newFakeSessionStore()
+
+	for i, domain := range []string{"example.com", "example.org"} {
+		group, err := rs.NewGroupResource("Engineering", resourceTypeGroup, "eng-"+domain, nil)
+		require.NoError(t, err)
+		require.NoError(t, tagResourceTenant(group, "C-primary", domain))
+
+		var members []string
+		token := ""
+		calls := 0
+		for range 10 {
+			calls++
+			grants, results, err := syncer.Grants(context.Background(), group, rs.SyncOpAttrs{Session: ss, PageToken: pagination.Token{Token: token}})
+			require.NoError(t, err)
+			for _, g := range grants {
+				members = append(members, g.Principal.Id.Resource)
+			}
+			token = results.NextPageToken
+			if token == "" {
+				break
+			}
+		}
+		require.Equal(t, []string{"alice-id", "bob-id", "carol-id"}, members, domain)
+		if i > 0 {
+			require.Equal(t, 1, calls, "the second domain reuses the tenant's index")
+		}
+	}
+}
+
+func TestSyncTenants_UnpartitionedUsesSingleDomain(t *testing.T) {
+	c := &GoogleWorkspace{customerID: "C-primary", domain: "example.com"}
+	tenants, err := c.syncTenants(context.Background(), &gwclient.GoogleWorkspaceClient{})
+	require.NoError(t, err)
+	require.Len(t, tenants, 1)
+	require.Equal(t, "example.com", tenants[0].domain())
+
+	syncer := resourceSyncerSpecs[1].syncer(tenants, false)
+	require.False(t, syncer.(*userResourceType).partitioned())
+}
+
+func TestTagResourceTenant_TagsTraitAndResourceProfiles(t *testing.T) {
+	user, err := rs.NewUserResource("Alice", resourceTypeUser, "alice-id", nil)
+	require.NoError(t, err)
+	require.NoError(t, tagResourceTenant(user, "C-primary", "example.com"))
+	trait, err := rs.GetUserTrait(user)
+	require.NoError(t, err)
+	require.Equal(t, "example.com", trait.GetProfile().GetFields()[profileKeySourceDomain].GetStringValue())
+	customerID, domain := resourceTenant(user)
+	require.Equal(t, "C-primary", customerID)
+	require.Equal(t, "example.com", domain)
+
+	drive := &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeSharedDrive.Id, Resource: "drive-id"}}
+	require.NoError(t, tagResourceTenant(drive, "C-acquired", ""))
+	require.Equal(t, "C-acquired", drive.GetProfile().GetFields()[profileKeyTenantCustomerID].GetStringValue())
+}
+
+func TestParseAdditionalTenants(t *testing.T) {
+	tenants, err := parseAdditionalTenants([]byte(`[
+		{"customer_id": "C1", "administrator_email": "admin@a.com", "credentials": {"type": "service_account"}, "domains": ["a.com"]},
+		{"customer_id": "C2", "administrator_email": "admin@b.com", "service_account_email": "dwd@p.iam.gserviceaccount.com", "all_domains": true}
+	]`), []byte(`{"type":"external_account"}`))
+	require.NoError(t, err)
+	require.Len(t, tenants, 2)
+	require.JSONEq(t, `{"type": "service_account"}`, string(tenants[0].Credentials))
+	require.Nil(t, tenants[0].ExternalAccountCredentials)
+	require.Equal(t, []string{"a.com"}, tenants[0].Domains)
+	require.True(t, tenants[1].AllDomains)
+	require.Equal(t, `{"type":"external_account"}`, string(tenants[1].ExternalAccountCredentials))
+
+	for _, bad := range []string{
+		`{"customer_id": "C1"}`,
+		`[{"customer_id": "C1", "credentials": {}}]`,
+		`[{"customer_id": "C1", "administrator_email": "admin@a.com"}]`,
+		`[{"customer_id": "C1", "administrator_email": "admin@a.com", "credentials": {}, "service_account_email": "x"}]`,
+		`[{"customer_id": "C1", "administrator_email": "admin@a.com", "credentials": {}, "domains": ["a.com"], "all_domains": true}]`,
+	} {
+		_, err := parseAdditionalTenants([]byte(bad), nil)
+		require.Error(t, err, bad)
+	}
+}
@@ -24,6 +24,8 @@ import (
 )
 
 type userResourceType struct {
+	tenantPartitions
+	syncFilters
 	resourceType *v2.ResourceType
 	client       *gwclient.GoogleWorkspaceClient
 	customerId   string
@@ -61,7 +63,11 @@ func (o *userResourceType) userStatus(user *admin.User) (v2.UserTrait_Status_Sta
 	return v2.UserTrait_Status_STATUS_ENABLED, ""
 }
 
-func (o *userResourceType) List(ctx context.Context, _ *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+func (o *userResourceType) List(ctx context.Context, parentResourceID *v2.ResourceId, attrs rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.listPartitions(ctx, parentResourceID, attrs)
+	}
+
 	l := ctxzap.Extract(ctx)
 	bag := &pagination.Bag{}
 	err := bag.Unmarshal(attrs.PageToken.Token)
@@ -77,7 +83,7 @@ func (o *userResourceType) List(ctx context.Context, _ *v2.ResourceId, attrs rs.
 
 	// https://developers.google.com/admin-sdk/directory/v1/limits
 	// Users – A default of 100 entries and a maximum of 500 entries per page.
-	users, err := o.client.ListUsers(ctx, o.customerId, o.domain, bag.PageToken())
+	users, err := o.client.ListUsers(ctx, o.customerId, o.domain, o.userFilter.apiQuery(), bag.PageToken())
 	if err != nil {
 		return nil, nil, fmt.Errorf("google-workspace: failed to list users: %w", err)
 	}
@@ -88,6 +94,9 @@ func (o *userResourceType) List(ctx context.Context, _ *v2.ResourceId, attrs rs.
 			l.Error("user had no id", zap.String("email", user.PrimaryEmail))
 			continue
 		}
+		if !o.userFilter.matches(user) {
+			continue
+		}
 
 		userResource, err := o.userResource(ctx, user)
 		if err != nil {
@@ -104,11 +113,19 @@ func (o *userResourceType) List(ctx context.Context, _ *v2.ResourceId, attrs rs.
 	return rv, &rs.SyncOpResults{NextPageToken: nextPage}, nil
 }
 
-func (o *userResourceType) Entitlements(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+func (o *userResourceType) Entitlements(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.entitlementsPartitions(ctx, resource, attrs)
+	}
+
 	return nil, nil, nil
 }
 
-func (o *userResourceType) Grants(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+func (o *userResourceType) Grants(ctx context.Context, resource *v2.Resource, attrs rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	if o.partitioned() {
+		return o.grantsPartitions(ctx, resource, attrs)
+	}
+
 	return nil, nil, nil
 }
 
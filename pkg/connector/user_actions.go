@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/mail"
+	"strconv"
 	"strings"
 
 	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
@@ -81,7 +82,7 @@ var (
 				IsRequired:  true,
 			},
 			{
-				Name:        "archive_account",
+				Name:        argArchiveAccount,
 				DisplayName: "Archive Account",
 				Description: "Whether to archive the user account. Archiving requires an archived user license. Defaults to false.",
 				Field:       &config.Field_BoolField{},
@@ -416,7 +417,7 @@ func (o *userResourceType) ResourceActions(ctx context.Context, registry actions
 }
 
 func (o *userResourceType) registerChangeUserOrgUnitAction(ctx context.Context, registry actions.ActionRegistry) error {
-	return registry.Register(ctx, changeUserOrgUnitActionSchema, o.changeUserOrgUnitActionHandler)
+	return registry.Register(ctx, withDryRun(changeUserOrgUnitActionSchema), o.changeUserOrgUnitActionHandler)
 }
 
 func (o *userResourceType) changeUserOrgUnitActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
@@ -448,33 +449,62 @@ func (o *userResourceType) changeUserOrgUnitActionHandler(ctx context.Context, a
 		return nil, nil, fmt.Errorf("org_unit_path must start with '/' (e.g., '/corp/sales')")
 	}
 
+	if isDryRun(args) {
+		currentUser, err := withRateLimitWaitValue(ctx, func() (*admin.User, error) {
+			return o.client.GetUserForProvisioning(ctx, userId)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		var changes []fieldChange
+		if orgUnitPathKey(currentUser.OrgUnitPath) != orgUnitPathKey(orgUnitPath) {
+			changes = diffField(argOrgUnitPath, currentUser.OrgUnitPath, orgUnitPath)
+		}
+		return dryRunResult(changes), nil, nil
+	}
+
+	updatedUser, err := moveUserToOrgUnit(ctx, o.client, userId, orgUnitPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Create the user resource
+	userResource, err := o.userResource(ctx, updatedUser)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to create user resource: %w", err)
+	}
+
+	resourceRv, err := actions.NewResourceReturnField(fieldResource, userResource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to build resource return field: %w", err)
+	}
+
+	return actions.NewReturnValues(true, resourceRv), nil, nil
+}
+
+// moveUserToOrgUnit moves userId into orgUnitPath, returning the user as it
+// stands afterwards. A user already in orgUnitPath is returned unchanged
+// without an update. Shared by change_user_org_unit and the org_unit "member"
+// entitlement's Grant.
+func moveUserToOrgUnit(ctx context.Context, client *gwclient.GoogleWorkspaceClient, userId, orgUnitPath string) (*admin.User, error) {
+	l := ctxzap.Extract(ctx)
+
 	// Get current user to check current org unit
 	currentUser, err := withRateLimitWaitValue(ctx, func() (*admin.User, error) {
-		return o.client.GetUserForProvisioning(ctx, userId)
+		return client.GetUserForProvisioning(ctx, userId)
 	})
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Check if already in the target org unit
-	if currentUser.OrgUnitPath == orgUnitPath {
-		// Already in the target org unit, return success
-		userResource, err := o.userResource(ctx, currentUser)
-		if err != nil {
-			return nil, nil, fmt.Errorf("google-workspace: failed to create user resource: %w", err)
-		}
-
-		resourceRv, err := actions.NewResourceReturnField(fieldResource, userResource)
-		if err != nil {
-			return nil, nil, fmt.Errorf("google-workspace: failed to build resource return field: %w", err)
-		}
-
-		return actions.NewReturnValues(true, resourceRv), nil, nil
+	if orgUnitPathKey(currentUser.OrgUnitPath) == orgUnitPathKey(orgUnitPath) {
+		return currentUser, nil
 	}
 
 	// Update the user's organizational unit
 	updatedUser, err := withRateLimitWaitValue(ctx, func() (*admin.User, error) {
-		return o.client.UpdateUser(ctx, userId, &admin.User{
+		return client.UpdateUser(ctx, userId, &admin.User{
 			OrgUnitPath:     orgUnitPath,
 			ForceSendFields: []string{"OrgUnitPath"},
 		})
@@ -484,7 +514,7 @@ func (o *userResourceType) changeUserOrgUnitActionHandler(ctx context.Context, a
 		if errors.As(err, &gerr) {
 			// Check if it's a 400 Bad Request error (INVALID_OU_ID)
 			if gerr.Code == http.StatusBadRequest {
-				return nil, nil, fmt.Errorf(
+				return nil, fmt.Errorf(
 					"google-workspace: failed to change user org unit (400 Bad Request). "+
 						"Invalid org_unit_path '%s'. "+
 						"Note: Org unit paths should NOT include the domain name. "+
@@ -493,30 +523,19 @@ func (o *userResourceType) changeUserOrgUnitActionHandler(ctx context.Context, a
 					orgUnitPath, err)
 			}
 		}
-		return nil, nil, err
+		return nil, err
 	}
 
-	l.Debug("google-workspace: user action handler: changed org unit",
+	l.Debug("google-workspace: changed user org unit",
 		zap.String(argUserID, userId),
 		zap.String("old_org_unit", currentUser.OrgUnitPath),
 		zap.String("new_org_unit", orgUnitPath))
 
-	// Create the user resource
-	userResource, err := o.userResource(ctx, updatedUser)
-	if err != nil {
-		return nil, nil, fmt.Errorf("google-workspace: failed to create user resource: %w", err)
-	}
-
-	resourceRv, err := actions.NewResourceReturnField(fieldResource, userResource)
-	if err != nil {
-		return nil, nil, fmt.Errorf("google-workspace: failed to build resource return field: %w", err)
-	}
-
-	return actions.NewReturnValues(true, resourceRv), nil, nil
+	return updatedUser, nil
 }
 
 func (o *userResourceType) registerOffboardingProfileUpdateAction(ctx context.Context, registry actions.ActionRegistry) error {
-	return registry.Register(ctx, offboardingProfileUpdateActionSchema, o.offboardingProfileUpdateActionHandler)
+	return registry.Register(ctx, withDryRun(offboardingProfileUpdateActionSchema), o.offboardingProfileUpdateActionHandler)
 }
 
 func (o *userResourceType) offboardingProfileUpdateActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
@@ -533,12 +552,31 @@ func (o *userResourceType) offboardingProfileUpdateActionHandler(ctx context.Con
 
 	// Extract archive_account argument (defaults to false if not provided)
 	archiveAccount := false
-	if archiveAccountValue, ok := args.Fields["archive_account"]; ok && archiveAccountValue != nil {
+	if archiveAccountValue, ok := args.Fields[argArchiveAccount]; ok && archiveAccountValue != nil {
 		if archiveAccountField, ok := archiveAccountValue.GetKind().(*structpb.Value_BoolValue); ok {
 			archiveAccount = archiveAccountField.BoolValue
 		}
 	}
 
+	if isDryRun(args) {
+		current, err := withRateLimitWaitValue(ctx, func() (*admin.User, error) {
+			return o.client.GetUserFullForProvisioning(ctx, userId)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		changes := diffBoolField("include_in_global_address_list", current.IncludeInGlobalAddressList, false)
+		changes = append(changes, diffField(argRecoveryEmail, current.RecoveryEmail, "")...)
+		changes = append(changes, diffField(argRecoveryPhone, current.RecoveryPhone, "")...)
+		changes = append(changes, diffField("addresses", jsonValue(current.Addresses), "")...)
+		changes = append(changes, diffField("phones", jsonValue(current.Phones), "")...)
+		changes = append(changes, diffField("emails", jsonValue(current.Emails), "")...)
+		if archiveAccount {
+			changes = append(changes, diffBoolField("archived", current.Archived, true)...)
+		}
+		return dryRunResult(changes), nil, nil
+	}
+
 	// Build the update request
 	updateUser := &admin.User{
 		IncludeInGlobalAddressList: false,
@@ -589,15 +627,15 @@ func (o *userResourceType) offboardingProfileUpdateActionHandler(ctx context.Con
 }
 
 func (o *userResourceType) registerSignOutUserAction(ctx context.Context, registry actions.ActionRegistry) error {
-	return registry.Register(ctx, signOutUserActionSchema, o.signOutUserActionHandler)
+	return registry.Register(ctx, withDryRun(signOutUserActionSchema), o.signOutUserActionHandler)
 }
 
 func (o *userResourceType) registerDeleteAllOAuthTokensAction(ctx context.Context, registry actions.ActionRegistry) error {
-	return registry.Register(ctx, deleteAllOAuthTokensActionSchema, o.deleteAllOAuthTokensActionHandler)
+	return registry.Register(ctx, withDryRun(deleteAllOAuthTokensActionSchema), o.deleteAllOAuthTokensActionHandler)
 }
 
 func (o *userResourceType) registerDeleteAllApplicationPasswordsAction(ctx context.Context, registry actions.ActionRegistry) error {
-	return registry.Register(ctx, deleteAllApplicationPasswordsActionSchema, o.deleteAllApplicationPasswordsActionHandler)
+	return registry.Register(ctx, withDryRun(deleteAllApplicationPasswordsActionSchema), o.deleteAllApplicationPasswordsActionHandler)
 }
 
 func (o *userResourceType) signOutUserActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
@@ -612,6 +650,12 @@ func (o *userResourceType) signOutUserActionHandler(ctx context.Context, args *s
 		return nil, nil, err
 	}
 
+	// Sign-in sessions cannot be listed, so a dry run reports the sign-out
+	// without reading anything.
+	if isDryRun(args) {
+		return dryRunResult([]fieldChange{{field: "sessions", newValue: "signed out"}}), nil, nil
+	}
+
 	// Sign out the user
 	err = withRateLimitWait(ctx, func() error {
 		return o.client.SignOutUser(ctx, userId)
@@ -646,6 +690,16 @@ func (o *userResourceType) deleteAllOAuthTokensActionHandler(ctx context.Context
 		return nil, nil, err
 	}
 
+	if isDryRun(args) {
+		changes := make([]fieldChange, 0, len(tokens.Items))
+		for _, token := range tokens.Items {
+			if token.ClientId != "" {
+				changes = append(changes, fieldChange{field: "oauth_token:" + token.ClientId, oldValue: token.DisplayText})
+			}
+		}
+		return dryRunResult(changes), nil, nil
+	}
+
 	// If no tokens, return success with 0 deleted
 	if len(tokens.Items) == 0 {
 		tokensDeletedRv := actions.NewNumberReturnField("tokens_deleted", 0)
@@ -724,6 +778,14 @@ func (o *userResourceType) deleteAllApplicationPasswordsActionHandler(ctx contex
 		return nil, nil, err
 	}
 
+	if isDryRun(args) {
+		changes := make([]fieldChange, 0, len(asps.Items))
+		for _, asp := range asps.Items {
+			changes = append(changes, fieldChange{field: "application_password:" + strconv.FormatInt(asp.CodeId, 10), oldValue: asp.Name})
+		}
+		return dryRunResult(changes), nil, nil
+	}
+
 	// If no application passwords, return success with 0 deleted
 	if len(asps.Items) == 0 {
 		passwordsDeletedRv := actions.NewNumberReturnField("passwords_deleted", 0)
@@ -777,7 +839,7 @@ func (o *userResourceType) deleteAllApplicationPasswordsActionHandler(ctx contex
 }
 
 func (o *userResourceType) registerUpdateUserManagerAction(ctx context.Context, registry actions.ActionRegistry) error {
-	return registry.Register(ctx, updateUserManagerActionSchema, o.updateUserManagerActionHandler)
+	return registry.Register(ctx, withDryRun(updateUserManagerActionSchema), o.updateUserManagerActionHandler)
 }
 
 func (o *userResourceType) updateUserManagerActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
@@ -819,6 +881,13 @@ func (o *userResourceType) updateUserManagerActionHandler(ctx context.Context, a
 
 	// Check if already set to the target manager (idempotency)
 	currentManagerEmail := extractManagerEmail(currentUser)
+	if isDryRun(args) {
+		var changes []fieldChange
+		if !emailsEqual(currentManagerEmail, managerEmail) {
+			changes = diffField(argManagerEmail, currentManagerEmail, managerEmail)
+		}
+		return dryRunResult(changes), nil, nil
+	}
 	if emailsEqual(currentManagerEmail, managerEmail) {
 		userResource, err := o.userResource(ctx, currentUser)
 		if err != nil {
@@ -868,7 +937,7 @@ func (o *userResourceType) updateUserManagerActionHandler(ctx context.Context, a
 }
 
 func (o *userResourceType) registerUpdateUserProfileAction(ctx context.Context, registry actions.ActionRegistry) error {
-	return registry.Register(ctx, updateUserProfileActionSchema, o.updateUserProfileActionHandler)
+	return registry.Register(ctx, withDryRun(updateUserProfileActionSchema), o.updateUserProfileActionHandler)
 }
 
 func (o *userResourceType) updateUserProfileActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
@@ -918,10 +987,14 @@ func (o *userResourceType) updateUserProfileActionHandler(ctx context.Context, a
 		patch.customSchemas = schemas
 	}
 
-	updatedUser, updatedFields, skippedFields, err := applyUserProfilePatch(ctx, o.client, userId, patch)
+	dryRun := isDryRun(args)
+	updatedUser, updatedFields, skippedFields, changes, err := applyUserProfilePatch(ctx, o.client, userId, patch, dryRun)
 	if err != nil {
 		return nil, nil, err
 	}
+	if dryRun {
+		return dryRunResult(changes, actions.NewStringReturnField(fieldSkippedFields, strings.Join(skippedFields, ", "))), nil, nil
+	}
 
 	l.Debug("google-workspace: user action handler: updated user profile",
 		zap.String(argUserID, userId),
@@ -943,7 +1016,7 @@ func (o *userResourceType) updateUserProfileActionHandler(ctx context.Context, a
 }
 
 func (o *userResourceType) registerMakeAdminAction(ctx context.Context, registry actions.ActionRegistry) error {
-	return registry.Register(ctx, makeUserAdminActionSchema, o.makeAdminActionHandler)
+	return registry.Register(ctx, withDryRun(makeUserAdminActionSchema), o.makeAdminActionHandler)
 }
 
 // makeAdminActionHandler promotes (status=true) or demotes (status=false) a user
@@ -951,7 +1024,8 @@ func (o *userResourceType) registerMakeAdminAction(ctx context.Context, registry
 // users.makeAdmin is a state-set (not a toggle) and returns 2xx when the user is
 // already in the target admin state (verified against a live tenant). Skipping
 // the GET that enable_user/disable_user perform avoids an extra API call and a
-// TOCTOU window on every invocation.
+// TOCTOU window on every invocation. Only a dry run reads the user, to report
+// its current admin state.
 func (o *userResourceType) makeAdminActionHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
 	if o.client.UserProvisioningService == nil {
@@ -970,6 +1044,16 @@ func (o *userResourceType) makeAdminActionHandler(ctx context.Context, args *str
 		return nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "google-workspace: make_admin: missing status argument")
 	}
 
+	if isDryRun(args) {
+		u, err := withRateLimitWaitValue(ctx, func() (*admin.User, error) {
+			return o.client.GetUserForProvisioning(ctx, userId)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return dryRunResult(diffBoolField("is_admin", u.IsAdmin, status)), nil, nil
+	}
+
 	err = withRateLimitWait(ctx, func() error {
 		return o.client.MakeAdmin(ctx, userId, status)
 	})
@@ -1010,13 +1094,15 @@ type userProfilePatch struct {
 // aborting the whole call. Shared by update_user_profile and the global
 // update_user action. Uses Users.Update (PUT) instead of Patch only when an
 // employee_id change shrinks ExternalIds (see usePut below); everything else
-// uses Patch.
+// uses Patch. A dry run writes nothing and returns the current user and the
+// changes the update would make instead.
 func applyUserProfilePatch(
 	ctx context.Context,
 	client *gwclient.GoogleWorkspaceClient,
 	userId string,
 	patch userProfilePatch,
-) (*admin.User, []string, []string, error) {
+	dryRun bool,
+) (*admin.User, []string, []string, []fieldChange, error) {
 	forceSend := make([]string, 0)
 	var skippedFields []string
 
@@ -1057,7 +1143,7 @@ func applyUserProfilePatch(
 	// external-ID types, other relation types) the caller did not set. Fetch
 	// once and reuse across all three blocks below, plus the Name block,
 	// instead of issuing a GET per field.
-	needCurrent := (setGiven != setFamily) || setOrg || patch.employeeID != nil || setManagerEmail
+	needCurrent := (setGiven != setFamily) || setOrg || patch.employeeID != nil || setManagerEmail || dryRun
 	var current *admin.User
 	if needCurrent {
 		var err error
@@ -1065,7 +1151,7 @@ func applyUserProfilePatch(
 			return client.GetUserFullForProvisioning(ctx, userId)
 		})
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 	}
 
@@ -1085,7 +1171,7 @@ func applyUserProfilePatch(
 	if patch.employeeID != nil {
 		currentExtIDs, err := extractFromInterface[*admin.UserExternalId](current.ExternalIds)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("google-workspace: failed to parse external ids: %w", err)
+			return nil, nil, nil, nil, fmt.Errorf("google-workspace: failed to parse external ids: %w", err)
 		}
 		updatedExternalIDs, externalIDsChanged = buildUpdatedExternalIDs(currentExtIDs, *patch.employeeID)
 		externalIDsWillShrink = len(updatedExternalIDs) < len(currentExtIDs)
@@ -1151,7 +1237,7 @@ func applyUserProfilePatch(
 		// Empty string is a legitimate "clear" request; only validate non-empty values.
 		if *patch.recoveryEmail != "" {
 			if _, err := mail.ParseAddress(*patch.recoveryEmail); err != nil {
-				return nil, nil, nil, uhttp.WrapErrors(codes.InvalidArgument,
+				return nil, nil, nil, nil, uhttp.WrapErrors(codes.InvalidArgument,
 					fmt.Sprintf("google-workspace: invalid recovery_email: %s", *patch.recoveryEmail), err)
 			}
 		}
@@ -1174,7 +1260,7 @@ func applyUserProfilePatch(
 	if setOrg {
 		orgs, err := extractFromInterface[*admin.UserOrganization](current.Organizations)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("google-workspace: failed to parse organizations: %w", err)
+			return nil, nil, nil, nil, fmt.Errorf("google-workspace: failed to parse organizations: %w", err)
 		}
 		updatedOrgs, changed := buildUpdatedOrganizations(orgs, patch)
 		// Only assign update.Organizations when something actually changed:
@@ -1206,7 +1292,7 @@ func applyUserProfilePatch(
 	if setManagerEmail {
 		currentRelations, err := extractFromInterface[*admin.UserRelation](current.Relations)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("google-workspace: failed to parse relations: %w", err)
+			return nil, nil, nil, nil, fmt.Errorf("google-workspace: failed to parse relations: %w", err)
 		}
 		update.Relations = buildManagerRelations(currentRelations, *patch.managerEmail)
 		forceSend = append(forceSend, "Relations")
@@ -1243,14 +1329,22 @@ func applyUserProfilePatch(
 			// WAS provided here, it just couldn't be applied - leading with
 			// "requires at least one updatable field" while also naming a
 			// provided-but-rejected field reads as self-contradictory.
-			return nil, nil, nil, uhttp.WrapErrors(codes.InvalidArgument,
+			return nil, nil, nil, nil, uhttp.WrapErrors(codes.InvalidArgument,
 				fmt.Sprintf("google-workspace: no updatable field was applied - the only field(s) provided were skipped: %s", strings.Join(skippedFields, "; ")))
 		}
-		return nil, nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "google-workspace: profile update requires at least one updatable field")
+		return nil, nil, nil, nil, uhttp.WrapErrors(codes.InvalidArgument, "google-workspace: profile update requires at least one updatable field")
 	}
 
 	update.ForceSendFields = forceSend
 
+	updatedFields := append([]string{}, forceSend...)
+	if customSchemasSet {
+		updatedFields = append(updatedFields, "CustomSchemas")
+	}
+	if dryRun {
+		return current, updatedFields, skippedFields, userProfileChanges(current, update, updatedFields, patch), nil
+	}
+
 	var updatedUser *admin.User
 	var err error
 	switch {
@@ -1271,14 +1365,63 @@ func applyUserProfilePatch(
 		})
 	}
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
+	return updatedUser, updatedFields, skippedFields, nil, nil
+}
 
-	updatedFields := append([]string{}, forceSend...)
-	if customSchemasSet {
-		updatedFields = append(updatedFields, "CustomSchemas")
+// userProfileChanges describes, for a dry run, how applyUserProfilePatch's
+// update would change each of the updated fields of current.
+func userProfileChanges(current, update *admin.User, updatedFields []string, patch userProfilePatch) []fieldChange {
+	var changes []fieldChange
+	for _, field := range updatedFields {
+		switch field {
+		case "Name":
+			var oldGiven, oldFamily string
+			if current.Name != nil {
+				oldGiven, oldFamily = current.Name.GivenName, current.Name.FamilyName
+			}
+			changes = append(changes, diffField(argGivenName, oldGiven, update.Name.GivenName)...)
+			changes = append(changes, diffField(argFamilyName, oldFamily, update.Name.FamilyName)...)
+		case "RecoveryEmail":
+			changes = append(changes, diffField(argRecoveryEmail, current.RecoveryEmail, update.RecoveryEmail)...)
+		case "RecoveryPhone":
+			changes = append(changes, diffField(argRecoveryPhone, current.RecoveryPhone, update.RecoveryPhone)...)
+		case "Organizations":
+			oldOrg := extractPrimaryOrganizations(current)
+			newOrg := extractPrimaryOrganizations(&admin.User{Organizations: update.Organizations})
+			if oldOrg == nil {
+				oldOrg = &admin.UserOrganization{}
+			}
+			if newOrg == nil {
+				newOrg = &admin.UserOrganization{}
+			}
+			changes = append(changes, diffField(argDepartment, oldOrg.Department, newOrg.Department)...)
+			changes = append(changes, diffField(argJobTitle, oldOrg.Title, newOrg.Title)...)
+			changes = append(changes, diffField(argCostCenter, oldOrg.CostCenter, newOrg.CostCenter)...)
+			changes = append(changes, diffField(argEmployeeType, oldOrg.Description, newOrg.Description)...)
+		case "ExternalIds":
+			var oldIDs []string
+			ids, _ := extractFromInterface[*admin.UserExternalId](current.ExternalIds)
+			for _, id := range ids {
+				if id.Type == externalIDTypeOrganization {
+					oldIDs = append(oldIDs, id.Value)
+				}
+			}
+			changes = append(changes, diffField(argEmployeeID, strings.Join(oldIDs, ", "), *patch.employeeID)...)
+		case "Relations":
+			changes = append(changes, diffField(argManagerEmail, extractManagerEmail(current), *patch.managerEmail)...)
+		case "CustomSchemas":
+			oldSchemas := make(map[string]googleapi.RawMessage, len(patch.customSchemas))
+			for name := range patch.customSchemas {
+				if v, ok := current.CustomSchemas[name]; ok {
+					oldSchemas[name] = v
+				}
+			}
+			changes = append(changes, diffField(argCustomSchemas, jsonValue(oldSchemas), jsonValue(patch.customSchemas))...)
+		}
 	}
-	return updatedUser, updatedFields, skippedFields, nil
+	return changes
 }
 
 // buildUpdatedOrganizations merges the requested department/job title/cost
@@ -1562,10 +1705,16 @@ func (c *GoogleWorkspace) updateUserActionHandler(ctx context.Context, args *str
 			fmt.Sprintf("google-workspace: update_user: user provisioning service not available - requires %s scope", admin.AdminDirectoryUserScope))
 	}
 
-	_, updatedFields, skippedFields, err := applyUserProfilePatch(ctx, client, userId, patch)
+	dryRun := isDryRun(args)
+	_, updatedFields, skippedFields, changes, err := applyUserProfilePatch(ctx, client, userId, patch, dryRun)
 	if err != nil {
 		return nil, nil, err
 	}
+	if dryRun {
+		return dryRunResult(changes,
+			actions.NewStringReturnField("updated_fields", strings.Join(updatedFields, ", ")),
+			actions.NewStringReturnField(fieldSkippedFields, strings.Join(skippedFields, ", "))), nil, nil
+	}
 
 	l.Debug("google-workspace: update_user: updated user profile",
 		zap.String(argUserID, userId),
@@ -328,7 +328,7 @@ func TestUpdateUserProfile_DepartmentOnly_UsesPatchAndOmitsCustomSchemas(t *test
 	userRT := newTestUserResourceType(t, server)
 
 	patch := userProfilePatch{department: strPtr("New Dept")}
-	_, updatedFields, _, err := applyUserProfilePatch(context.Background(), userRT.client, "user123", patch)
+	_, updatedFields, _, _, err := applyUserProfilePatch(context.Background(), userRT.client, "user123", patch, false)
 	if err != nil {
 		t.Fatalf("applyUserProfilePatch: %v", err)
 	}
@@ -391,7 +391,7 @@ func TestUpdateUserProfile_CustomSchemas_SendsVerbatimNotMerged(t *testing.T) {
 		department:    strPtr("New Dept"),
 		customSchemas: map[string]googleapi.RawMessage{"QATestSchema": googleapi.RawMessage(`{"region":"apac"}`)},
 	}
-	_, _, _, err := applyUserProfilePatch(context.Background(), userRT.client, "user123", patch)
+	_, _, _, _, err := applyUserProfilePatch(context.Background(), userRT.client, "user123", patch, false)
 	if err != nil {
 		t.Fatalf("applyUserProfilePatch: %v", err)
 	}
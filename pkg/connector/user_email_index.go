@@ -0,0 +1,260 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/session"
+	"github.com/conductorone/baton-sdk/pkg/types/sessions"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/googleapi"
+
+	gwclient "github.com/conductorone/baton-google-workspace/pkg/client"
+)
+
+var (
+	// userEmailNamespace maps a lower-cased primary email to a user ID, for
+	// syncers whose upstream records name users by email (license assignments,
+	// device owners) while user resources are keyed by the immutable
+	// Directory ID. One index per sync is shared by all of them.
+	userEmailNamespace       = sessions.WithPrefix("user_email")
+	userEmailLoadedNamespace = sessions.WithPrefix("user_email_loaded")
+	// userEmailUnresolvedNamespace records, per lower-cased email missing
+	// from the index, what the Directory API knows of it, so each address
+	// is looked up once per sync.
+	userEmailUnresolvedNamespace = sessions.WithPrefix("user_email_unresolved")
+)
+
+// unresolvedUser is what an email missing from the index turned out to name.
+type unresolvedUser struct {
+	// ID is set for another address, such as an alias, of a synced user.
+	ID string `json:"id,omitempty"`
+	// LeftOut is set for a user of the customer that the sync filters or
+	// the synced domains leave out of the sync.
+	LeftOut bool `json:"left_out,omitempty"`
+}
+
+// userEmailIndex builds and reads the session-scoped email-to-ID index. A
+// syncer's Grants calls begin on its first page, which stacks a
+// resourceTypeUser page state while the index is not loaded, and calls
+// indexPage for that state, one directory page per call.
+type userEmailIndex struct {
+	client     *gwclient.GoogleWorkspaceClient
+	customerId string
+	domain     string
+	// domains, set when syncing partitions, are all the domains synced from
+	// the customer. The index then covers every one of them, whichever
+	// domain the syncer lists, so that a group or mailbox of one domain
+	// resolves users of another. It is kept in the tenant's session, built
+	// once for all of its partitions.
+	domains []string
+	// filter leaves users outside the sync filters out of the index, so
+	// grants are never resolved to a user that was not synced.
+	filter userFilter
+}
+
+// session returns the session store the index is kept in.
+func (x userEmailIndex) session(ss sessions.SessionStore) sessions.SessionStore {
+	if len(x.domains) > 0 {
+		return tenantSession(ss)
+	}
+	return ss
+}
+
+// listDomain returns the domain to list users from: the syncer's own, the
+// customer's only synced domain, or "" to list the whole customer.
+func (x userEmailIndex) listDomain() string {
+	switch len(x.domains) {
+	case 0:
+		return x.domain
+	case 1:
+		return x.domains[0]
+	default:
+		return ""
+	}
+}
+
+// covers reports whether u belongs to a synced domain of the customer. Only
+// a whole-customer listing of several synced domains returns others.
+func (x userEmailIndex) covers(u *admin.User) bool {
+	return len(x.domains) <= 1 || emailInDomains(u.PrimaryEmail, x.domains)
+}
+
+// lists reports whether u is one of the users the index lists.
+func (x userEmailIndex) lists(u *admin.User) bool {
+	if d := x.listDomain(); d != "" {
+		return emailInDomains(u.PrimaryEmail, []string{d})
+	}
+	return x.covers(u)
+}
+
+// loaded reports whether a previous call in this sync finished the index.
+func (x userEmailIndex) loaded(ctx context.Context, ss sessions.SessionStore) (bool, error) {
+	_, ok, err := session.GetJSON[string](ctx, x.session(ss), "done", userEmailLoadedNamespace)
+	if err != nil {
+		return false, fmt.Errorf("google-workspace: failed to check user email index loaded flag: %w", err)
+	}
+	return ok, nil
+}
+
+// begin initializes an empty Grants bag: it pushes resource's own page state
+// and, unless the index is already loaded, a resourceTypeUser state on top of
+// it so the directory is indexed first. A bag already in progress is left as is.
+func (x userEmailIndex) begin(ctx context.Context, ss sessions.SessionStore, bag *pagination.Bag, resource *v2.Resource) error {
+	if bag.Current() != nil {
+		return nil
+	}
+	bag.Push(pagination.PageState{
+		ResourceTypeID: resource.Id.ResourceType,
+		ResourceID:     resource.Id.Resource,
+	})
+	loaded, err := x.loaded(ctx, ss)
+	if err != nil {
+		return err
+	}
+	if !loaded {
+		bag.Push(pagination.PageState{ResourceTypeID: resourceTypeUser.Id})
+	}
+	return nil
+}
+
+// indexPage stores one directory page of email-to-ID entries in the session,
+// marking the index loaded once the last page is reached.
+func (x userEmailIndex) indexPage(ctx context.Context, ss sessions.SessionStore, bag *pagination.Bag) (string, error) {
+	ss = x.session(ss)
+	users, err := x.client.ListUserIDsPage(ctx, x.customerId, x.listDomain(), x.filter.apiQuery(), bag.PageToken())
+	if err != nil {
+		return "", fmt.Errorf("google-workspace: failed to list users for user email index: %w", err)
+	}
+	batch := make(map[string]string, len(users.Users))
+	for _, u := range users.Users {
+		if u.Id == "" || u.PrimaryEmail == "" || !x.covers(u) || !x.filter.matches(u) {
+			continue
+		}
+		batch[strings.ToLower(u.PrimaryEmail)] = u.Id
+	}
+	if len(batch) > 0 {
+		if err := session.SetManyJSON(ctx, ss, batch, userEmailNamespace); err != nil {
+			return "", fmt.Errorf("google-workspace: failed to store user email index in session: %w", err)
+		}
+	}
+	if users.NextPageToken == "" {
+		if err := session.SetJSON(ctx, ss, "done", "true", userEmailLoadedNamespace); err != nil {
+			return "", fmt.Errorf("google-workspace: failed to mark user email index as loaded: %w", err)
+		}
+	}
+	nextPage, err := bag.NextToken(users.NextPageToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate next page token for user email index: %w", err)
+	}
+	return nextPage, nil
+}
+
+// lookup resolves emails to user IDs. Emails are matched case-insensitively;
+// the result is keyed by the lower-cased email and omits users outside the
+// synced directory.
+func (x userEmailIndex) lookup(ctx context.Context, ss sessions.SessionStore, emails []string) (map[string]string, error) {
+	keys := make([]string, 0, len(emails))
+	for _, e := range emails {
+		if e != "" {
+			keys = append(keys, strings.ToLower(e))
+		}
+	}
+	userIDs, err := session.GetManyJSON[string](ctx, x.session(ss), keys, userEmailNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("google-workspace: failed to read user email index from session: %w", err)
+	}
+	return userIDs, nil
+}
+
+// resolve is lookup that also looks up each email the index does not hold,
+// telling users of the customer that the sync leaves out, whose emails are
+// returned in leftOut, apart from addresses that name no user of the
+// customer. An alias of a synced user resolves to the user's ID. Grants to
+// left-out users must be skipped, never granted to an external principal.
+func (x userEmailIndex) resolve(ctx context.Context, ss sessions.SessionStore, emails []string) (map[string]string, map[string]bool, error) {
+	userIDs, err := x.lookup(ctx, ss, emails)
+	if err != nil {
+		return nil, nil, err
+	}
+	var missing []string
+	for _, e := range emails {
+		e = strings.ToLower(e)
+		if _, ok := userIDs[e]; e != "" && !ok && !slices.Contains(missing, e) {
+			missing = append(missing, e)
+		}
+	}
+	leftOut := make(map[string]bool)
+	if len(missing) == 0 {
+		return userIDs, leftOut, nil
+	}
+
+	unresolved, err := session.GetManyJSON[unresolvedUser](ctx, x.session(ss), missing, userEmailUnresolvedNamespace)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google-workspace: failed to read unresolved user emails from session: %w", err)
+	}
+	var uncached []string
+	for _, e := range missing {
+		if _, ok := unresolved[e]; !ok {
+			uncached = append(uncached, e)
+		}
+	}
+	if len(uncached) > 0 {
+		looked := x.lookupUnresolved(ctx, uncached)
+		if err := session.SetManyJSON(ctx, x.session(ss), looked, userEmailUnresolvedNamespace); err != nil {
+			return nil, nil, fmt.Errorf("google-workspace: failed to store unresolved user emails in session: %w", err)
+		}
+		maps.Copy(unresolved, looked)
+	}
+
+	for e, u := range unresolved {
+		switch {
+		case u.ID != "":
+			userIDs[e] = u.ID
+		case u.LeftOut:
+			leftOut[e] = true
+		}
+	}
+	return userIDs, leftOut, nil
+}
+
+// lookupUnresolved gets the users emails name. An email the API fails to
+// look up for any reason but the user not being found is left out of the
+// result, so a later call looks it up again.
+func (x userEmailIndex) lookupUnresolved(ctx context.Context, emails []string) map[string]unresolvedUser {
+	l := ctxzap.Extract(ctx)
+	rv := make(map[string]unresolvedUser, len(emails))
+	results, err := x.client.BatchGetUsers(ctx, emails)
+	if err != nil {
+		l.Debug("google-workspace: failed to look up users missing from the user email index", zap.Error(err))
+		return rv
+	}
+	for i, r := range results {
+		var gerr *googleapi.Error
+		switch {
+		case r.Err == nil:
+			u := r.Value
+			// The raw query can't be matched client-side, so a user it may
+			// have left out is never taken for a synced one.
+			if u.Id != "" && x.filter.query == "" && x.filter.matches(u) && x.lists(u) {
+				rv[emails[i]] = unresolvedUser{ID: u.Id}
+			} else {
+				rv[emails[i]] = unresolvedUser{LeftOut: true}
+			}
+		case errors.As(r.Err, &gerr) && (gerr.Code == http.StatusNotFound || gerr.Code == http.StatusForbidden):
+			rv[emails[i]] = unresolvedUser{}
+		default:
+			l.Debug("google-workspace: failed to look up user missing from the user email index", zap.String("email", emails[i]), zap.Error(r.Err))
+		}
+	}
+	return rv
+}
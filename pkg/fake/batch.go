@@ -0,0 +1,105 @@
+package fake
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+)
+
+// maxBatchCalls is the most calls Google accepts in one batch request.
+const maxBatchCalls = 1000
+
+func (s *Server) registerBatch(mux *http.ServeMux) {
+	mux.HandleFunc("POST /batch/admin/directory_v1", func(w http.ResponseWriter, r *http.Request) {
+		s.handleBatch(w, r, mux)
+	})
+}
+
+type batchCall struct {
+	contentID string
+	req       *http.Request
+}
+
+// handleBatch serves a multipart/mixed batch request by serving each of its
+// calls with api, as Google does: each call is authorized, faulted and rate
+// limited on its own, with the batch request's token unless it has its own,
+// and its response is the part answering its Content-ID.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, api http.Handler) {
+	s.mtx.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	_, authorized := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	status, faulted := s.takeFault(r)
+	s.mtx.Unlock()
+	if !authorized {
+		writeError(w, http.StatusUnauthorized, "authError", "Request had invalid authentication credentials. Expected OAuth 2 access token.")
+		return
+	}
+	if faulted {
+		writeStatusError(w, status)
+		return
+	}
+
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		writeError(w, http.StatusBadRequest, "badRequest", "Batch requests must be multipart/mixed")
+		return
+	}
+	var calls []batchCall
+	parts := multipart.NewReader(r.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "badRequest", "Malformed batch request")
+			return
+		}
+		req, err := http.ReadRequest(bufio.NewReader(part))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "badRequest", "Malformed call in batch request")
+			return
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "badRequest", "Malformed call in batch request")
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if req.Header.Get("Authorization") == "" {
+			req.Header.Set("Authorization", r.Header.Get("Authorization"))
+		}
+		contentID := strings.TrimSuffix(strings.TrimPrefix(part.Header.Get("Content-Id"), "<"), ">")
+		calls = append(calls, batchCall{contentID: contentID, req: req.WithContext(r.Context())})
+	}
+	if len(calls) > maxBatchCalls {
+		writeError(w, http.StatusBadRequest, "badRequest", "A batch request cannot contain more than 1000 requests")
+		return
+	}
+
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	for _, c := range calls {
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, c.req)
+		resp := rec.Result()
+		resp.ContentLength = int64(rec.Body.Len())
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"application/http"},
+			"Content-Id":   {"<response-" + c.contentID + ">"},
+		})
+		if err != nil {
+			return
+		}
+		if err := resp.Write(part); err != nil {
+			return
+		}
+	}
+	_ = mw.Close()
+}
@@ -0,0 +1,95 @@
+package fake
+
+import (
+	"net/http"
+	"strings"
+
+	cloudidentity "google.golang.org/api/cloudidentity/v1"
+)
+
+var inboundSsoReadScopes = []string{cloudidentity.CloudIdentityInboundssoReadonlyScope, cloudidentity.CloudIdentityInboundssoScope}
+
+func (s *Server) registerCloudIdentity(mux *http.ServeMux) {
+	s.handle(mux, "GET /v1/inboundSamlSsoProfiles", inboundSsoReadScopes, s.listSamlProfiles)
+	s.handle(mux, "GET /v1/inboundSamlSsoProfiles/{id}", inboundSsoReadScopes, s.getSamlProfile)
+	s.handle(mux, "GET /v1/inboundSsoAssignments", inboundSsoReadScopes, s.listSsoAssignments)
+}
+
+// AddSAMLProfile adds an inbound SAML SSO profile of the customer, naming it
+// when its Name is unset.
+func (s *Server) AddSAMLProfile(p *cloudidentity.InboundSamlSsoProfile) *cloudidentity.InboundSamlSsoProfile {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	p = clone(p)
+	if p.Name == "" {
+		p.Name = "inboundSamlSsoProfiles/" + s.newID()
+	}
+	p.Customer = "customers/" + s.CustomerID
+	s.samlProfiles = append(s.samlProfiles, p)
+	return clone(p)
+}
+
+// AddSSOAssignment adds an inbound SSO assignment of the customer, naming it
+// when its Name is unset.
+func (s *Server) AddSSOAssignment(a *cloudidentity.InboundSsoAssignment) *cloudidentity.InboundSsoAssignment {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	a = clone(a)
+	if a.Name == "" {
+		a.Name = "inboundSsoAssignments/" + s.newID()
+	}
+	a.Customer = "customers/" + s.CustomerID
+	s.ssoAssignments = append(s.ssoAssignments, a)
+	return clone(a)
+}
+
+// customerFilter checks the customer=="customers/..." filter Cloud Identity
+// lists require.
+func (s *Server) customerFilter(w http.ResponseWriter, r *http.Request) bool {
+	filter := strings.ReplaceAll(r.URL.Query().Get("filter"), " ", "")
+	customer, ok := strings.CutPrefix(filter, `customer=="customers/`)
+	if !ok || !strings.HasSuffix(customer, `"`) {
+		writeError(w, http.StatusBadRequest, "invalid", `Request contains an invalid argument: filter must be customer=="customers/{customer}"`)
+		return false
+	}
+	customer = strings.TrimSuffix(customer, `"`)
+	if customer != s.CustomerID && customer != "my_customer" {
+		writeError(w, http.StatusForbidden, "forbidden", "The caller does not have permission")
+		return false
+	}
+	return true
+}
+
+// listSamlProfiles requires the customer=="customers/..." filter the API
+// documents, and pages with pageSize like other Cloud Identity lists.
+func (s *Server) listSamlProfiles(w http.ResponseWriter, r *http.Request) {
+	if !s.customerFilter(w, r) {
+		return
+	}
+	items, next, ok := page(w, r, s.samlProfiles, "pageSize", 10, 100)
+	if !ok {
+		return
+	}
+	writeJSON(w, &cloudidentity.ListInboundSamlSsoProfilesResponse{InboundSamlSsoProfiles: items, NextPageToken: next})
+}
+
+func (s *Server) getSamlProfile(w http.ResponseWriter, r *http.Request) {
+	for _, p := range s.samlProfiles {
+		if p.Name == "inboundSamlSsoProfiles/"+r.PathValue("id") {
+			writeJSON(w, p)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "notFound", "Requested entity was not found.")
+}
+
+func (s *Server) listSsoAssignments(w http.ResponseWriter, r *http.Request) {
+	if !s.customerFilter(w, r) {
+		return
+	}
+	items, next, ok := page(w, r, s.ssoAssignments, "pageSize", 50, 100)
+	if !ok {
+		return
+	}
+	writeJSON(w, &cloudidentity.ListInboundSsoAssignmentsResponse{InboundSsoAssignments: items, NextPageToken: next})
+}
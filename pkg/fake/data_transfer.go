@@ -0,0 +1,143 @@
+package fake
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	datatransfer "google.golang.org/api/admin/datatransfer/v1"
+)
+
+const (
+	// Application IDs of the Data Transfer API, as Google assigns them.
+	DriveApplicationID    = int64(55656082996)
+	CalendarApplicationID = int64(435070579839)
+
+	dataTransferPrefix = "/admin/datatransfer/v1"
+)
+
+var (
+	dataTransferReadScopes  = []string{datatransfer.AdminDatatransferReadonlyScope, datatransfer.AdminDatatransferScope}
+	dataTransferWriteScopes = []string{datatransfer.AdminDatatransferScope}
+)
+
+var dataTransferApplications = []*datatransfer.Application{
+	{Id: DriveApplicationID, Name: "Drive and Docs", Kind: "admin#datatransfer#ApplicationResource", TransferParams: []*datatransfer.ApplicationTransferParam{
+		{Key: "PRIVACY_LEVEL", Value: []string{"PRIVATE", "SHARED"}},
+	}},
+	{Id: CalendarApplicationID, Name: "Calendar", Kind: "admin#datatransfer#ApplicationResource", TransferParams: []*datatransfer.ApplicationTransferParam{
+		{Key: "RELEASE_RESOURCES", Value: []string{"TRUE"}},
+	}},
+}
+
+func (s *Server) registerDataTransfer(mux *http.ServeMux) {
+	s.handle(mux, "GET "+dataTransferPrefix+"/applications", dataTransferReadScopes, s.listTransferApplications)
+	s.handle(mux, "GET "+dataTransferPrefix+"/transfers", dataTransferReadScopes, s.listTransfers)
+	s.handle(mux, "POST "+dataTransferPrefix+"/transfers", dataTransferWriteScopes, s.insertTransfer)
+	s.handle(mux, "GET "+dataTransferPrefix+"/transfers/{dataTransferId}", dataTransferReadScopes, s.getTransfer)
+}
+
+// SetTransferStatus sets the overall and per-application status of a data
+// transfer. New transfers are "new"; Google moves them to "inProgress" and
+// then "completed" or "failed".
+func (s *Server) SetTransferStatus(id, status string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, t := range s.transfers {
+		if t.Id == id {
+			t.OverallTransferStatusCode = status
+			for _, adt := range t.ApplicationDataTransfers {
+				adt.ApplicationTransferStatus = status
+			}
+		}
+	}
+}
+
+// Transfers returns copies of the data transfers requested so far.
+func (s *Server) Transfers() []*datatransfer.DataTransfer {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	rv := make([]*datatransfer.DataTransfer, 0, len(s.transfers))
+	for _, t := range s.transfers {
+		rv = append(rv, clone(t))
+	}
+	return rv
+}
+
+func (s *Server) listTransferApplications(w http.ResponseWriter, r *http.Request) {
+	items, next, ok := page(w, r, dataTransferApplications, "maxResults", 100, 500)
+	if !ok {
+		return
+	}
+	writeJSON(w, &datatransfer.ApplicationsListResponse{Kind: "admin#datatransfer#applicationsList", Applications: items, NextPageToken: next})
+}
+
+func (s *Server) listTransfers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var transfers []*datatransfer.DataTransfer
+	for _, t := range s.transfers {
+		if v := q.Get("oldOwnerUserId"); v != "" && t.OldOwnerUserId != v {
+			continue
+		}
+		if v := q.Get("newOwnerUserId"); v != "" && t.NewOwnerUserId != v {
+			continue
+		}
+		if v := q.Get("status"); v != "" && t.OverallTransferStatusCode != v {
+			continue
+		}
+		transfers = append(transfers, t)
+	}
+	items, next, ok := page(w, r, transfers, "maxResults", 100, 500)
+	if !ok {
+		return
+	}
+	writeJSON(w, &datatransfer.DataTransfersListResponse{Kind: "admin#datatransfer#dataTransfersList", DataTransfers: items, NextPageToken: next})
+}
+
+func (s *Server) getTransfer(w http.ResponseWriter, r *http.Request) {
+	for _, t := range s.transfers {
+		if t.Id == r.PathValue("dataTransferId") {
+			writeJSON(w, t)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: dataTransferId")
+}
+
+// insertTransfer accepts a transfer between two users of the customer for
+// known applications. Transfers are not carried out; see SetTransferStatus.
+func (s *Server) insertTransfer(w http.ResponseWriter, r *http.Request) {
+	var t datatransfer.DataTransfer
+	if !decode(w, r, &t) {
+		return
+	}
+	switch {
+	case t.OldOwnerUserId == "" || t.NewOwnerUserId == "":
+		writeError(w, http.StatusBadRequest, "required", "Missing required field: oldOwnerUserId or newOwnerUserId")
+		return
+	case t.OldOwnerUserId == t.NewOwnerUserId:
+		writeError(w, http.StatusBadRequest, "invalid", "Old owner and new owner must be different.")
+		return
+	case s.findUser(t.OldOwnerUserId) == nil || s.findUser(t.NewOwnerUserId) == nil:
+		writeError(w, http.StatusBadRequest, "invalid", "Invalid Input: user not found")
+		return
+	case len(t.ApplicationDataTransfers) == 0:
+		writeError(w, http.StatusBadRequest, "required", "Missing required field: applicationDataTransfers")
+		return
+	}
+	for _, adt := range t.ApplicationDataTransfers {
+		if !slices.ContainsFunc(dataTransferApplications, func(a *datatransfer.Application) bool { return a.Id == adt.ApplicationId }) {
+			writeError(w, http.StatusBadRequest, "invalid", "Invalid application ID: "+strconv.FormatInt(adt.ApplicationId, 10))
+			return
+		}
+		adt.ApplicationTransferStatus = "new"
+	}
+	t.Id = s.newID()
+	t.Kind = "admin#datatransfer#DataTransfer"
+	t.OverallTransferStatusCode = "new"
+	t.RequestTime = time.Now().UTC().Format(time.RFC3339)
+	stored := clone(&t)
+	s.transfers = append(s.transfers, stored)
+	writeJSON(w, stored)
+}
@@ -0,0 +1,1055 @@
+package fake
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+const directoryPrefix = "/admin/directory/v1"
+
+var (
+	userReadScopes     = []string{admin.AdminDirectoryUserReadonlyScope, admin.AdminDirectoryUserScope}
+	userWriteScopes    = []string{admin.AdminDirectoryUserScope}
+	userSecurityScopes = []string{admin.AdminDirectoryUserSecurityScope}
+	groupReadScopes    = []string{admin.AdminDirectoryGroupReadonlyScope, admin.AdminDirectoryGroupScope}
+	groupWriteScopes   = []string{admin.AdminDirectoryGroupScope}
+	memberReadScopes   = []string{admin.AdminDirectoryGroupMemberReadonlyScope, admin.AdminDirectoryGroupMemberScope, admin.AdminDirectoryGroupReadonlyScope, admin.AdminDirectoryGroupScope}
+	memberWriteScopes  = []string{admin.AdminDirectoryGroupMemberScope, admin.AdminDirectoryGroupScope}
+	roleReadScopes     = []string{admin.AdminDirectoryRolemanagementReadonlyScope, admin.AdminDirectoryRolemanagementScope}
+	roleWriteScopes    = []string{admin.AdminDirectoryRolemanagementScope}
+	domainReadScopes   = []string{admin.AdminDirectoryDomainReadonlyScope, admin.AdminDirectoryDomainScope}
+)
+
+func (s *Server) registerDirectory(mux *http.ServeMux) {
+	s.handle(mux, "GET "+directoryPrefix+"/users", userReadScopes, s.listUsers)
+	s.handle(mux, "POST "+directoryPrefix+"/users", userWriteScopes, s.insertUser)
+	s.handle(mux, "GET "+directoryPrefix+"/users/{userKey}", userReadScopes, s.getUser)
+	s.handle(mux, "PUT "+directoryPrefix+"/users/{userKey}", userWriteScopes, s.updateUser)
+	s.handle(mux, "PATCH "+directoryPrefix+"/users/{userKey}", userWriteScopes, s.updateUser)
+	s.handle(mux, "DELETE "+directoryPrefix+"/users/{userKey}", userWriteScopes, s.deleteUser)
+	s.handle(mux, "POST "+directoryPrefix+"/users/{userKey}/makeAdmin", userWriteScopes, s.makeAdmin)
+	s.handle(mux, "POST "+directoryPrefix+"/users/{userKey}/signOut", userSecurityScopes, s.signOut)
+	s.handle(mux, "GET "+directoryPrefix+"/users/{userKey}/tokens", userSecurityScopes, s.listTokens)
+	s.handle(mux, "DELETE "+directoryPrefix+"/users/{userKey}/tokens/{clientId}", userSecurityScopes, s.deleteToken)
+	s.handle(mux, "GET "+directoryPrefix+"/users/{userKey}/asps", userSecurityScopes, s.listAsps)
+	s.handle(mux, "DELETE "+directoryPrefix+"/users/{userKey}/asps/{codeId}", userSecurityScopes, s.deleteAsp)
+
+	s.handle(mux, "GET "+directoryPrefix+"/groups", groupReadScopes, s.listGroups)
+	s.handle(mux, "POST "+directoryPrefix+"/groups", groupWriteScopes, s.insertGroup)
+	s.handle(mux, "GET "+directoryPrefix+"/groups/{groupKey}", groupReadScopes, s.getGroup)
+	s.handle(mux, "PUT "+directoryPrefix+"/groups/{groupKey}", groupWriteScopes, s.updateGroup)
+	s.handle(mux, "PATCH "+directoryPrefix+"/groups/{groupKey}", groupWriteScopes, s.updateGroup)
+	s.handle(mux, "DELETE "+directoryPrefix+"/groups/{groupKey}", groupWriteScopes, s.deleteGroup)
+
+	s.handle(mux, "GET "+directoryPrefix+"/groups/{groupKey}/members", memberReadScopes, s.listMembers)
+	s.handle(mux, "POST "+directoryPrefix+"/groups/{groupKey}/members", memberWriteScopes, s.insertMember)
+	s.handle(mux, "GET "+directoryPrefix+"/groups/{groupKey}/members/{memberKey}", memberReadScopes, s.getMember)
+	s.handle(mux, "PUT "+directoryPrefix+"/groups/{groupKey}/members/{memberKey}", memberWriteScopes, s.updateMember)
+	s.handle(mux, "PATCH "+directoryPrefix+"/groups/{groupKey}/members/{memberKey}", memberWriteScopes, s.updateMember)
+	s.handle(mux, "DELETE "+directoryPrefix+"/groups/{groupKey}/members/{memberKey}", memberWriteScopes, s.deleteMember)
+
+	s.handle(mux, "GET "+directoryPrefix+"/customer/{customer}/roles", roleReadScopes, s.listRoles)
+	s.handle(mux, "POST "+directoryPrefix+"/customer/{customer}/roles", roleWriteScopes, s.insertRole)
+	s.handle(mux, "GET "+directoryPrefix+"/customer/{customer}/roles/ALL/privileges", roleReadScopes, s.listPrivileges)
+	s.handle(mux, "GET "+directoryPrefix+"/customer/{customer}/roles/{roleId}", roleReadScopes, s.getRole)
+	s.handle(mux, "PUT "+directoryPrefix+"/customer/{customer}/roles/{roleId}", roleWriteScopes, s.updateRole)
+	s.handle(mux, "PATCH "+directoryPrefix+"/customer/{customer}/roles/{roleId}", roleWriteScopes, s.updateRole)
+	s.handle(mux, "DELETE "+directoryPrefix+"/customer/{customer}/roles/{roleId}", roleWriteScopes, s.deleteRole)
+
+	s.handle(mux, "GET "+directoryPrefix+"/customer/{customer}/roleassignments", roleReadScopes, s.listRoleAssignments)
+	s.handle(mux, "POST "+directoryPrefix+"/customer/{customer}/roleassignments", roleWriteScopes, s.insertRoleAssignment)
+	s.handle(mux, "GET "+directoryPrefix+"/customer/{customer}/roleassignments/{roleAssignmentId}", roleReadScopes, s.getRoleAssignment)
+	s.handle(mux, "DELETE "+directoryPrefix+"/customer/{customer}/roleassignments/{roleAssignmentId}", roleWriteScopes, s.deleteRoleAssignment)
+
+	s.handle(mux, "GET "+directoryPrefix+"/customer/{customer}/domains", domainReadScopes, s.listDomains)
+	s.handle(mux, "GET "+directoryPrefix+"/customer/{customer}/domains/{domainName}", domainReadScopes, s.getDomain)
+}
+
+// ---------------------------------------------------------------------------
+// Seeding
+// ---------------------------------------------------------------------------
+
+// AddDomain adds a verified domain of the customer. Once a domain exists,
+// users and groups can only be created in the customer's domains.
+func (s *Server) AddDomain(name string, primary bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.domains = append(s.domains, &admin.Domains{DomainName: name, IsPrimary: primary, Verified: true})
+}
+
+// AddUser adds a user, filling in its ID, customer and org unit when unset,
+// and returns a copy of what was stored.
+func (s *Server) AddUser(u *admin.User) *admin.User {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	u = s.newUser(u)
+	s.users = append(s.users, u)
+	return clone(u)
+}
+
+// AddUserToken adds an OAuth token a user granted to a third-party app.
+func (s *Server) AddUserToken(userKey string, token *admin.Token) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	u := s.mustFindUser(userKey)
+	token.UserKey = u.Id
+	s.userTokens[u.Id] = append(s.userTokens[u.Id], clone(token))
+}
+
+// AddUserAsp adds an application-specific password of a user.
+func (s *Server) AddUserAsp(userKey string, asp *admin.Asp) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	u := s.mustFindUser(userKey)
+	asp.UserKey = u.Id
+	s.asps[u.Id] = append(s.asps[u.Id], clone(asp))
+}
+
+// AddGroup adds a group with default settings and returns a copy of what was
+// stored.
+func (s *Server) AddGroup(g *admin.Group) *admin.Group {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	g = s.newGroup(g)
+	s.groups = append(s.groups, g)
+	return clone(g)
+}
+
+// AddMember adds a user or group, named by email in m.Email, to a group.
+func (s *Server) AddMember(groupKey string, m *admin.Member) *admin.Member {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	g := s.findGroup(groupKey)
+	if g == nil {
+		panic("fake: no group " + groupKey)
+	}
+	m = s.newMember(m)
+	s.members[g.Id] = append(s.members[g.Id], m)
+	g.DirectMembersCount = int64(len(s.members[g.Id]))
+	return clone(m)
+}
+
+// AddRole adds an admin role, assigning its ID when unset.
+func (s *Server) AddRole(role *admin.Role) *admin.Role {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	role = clone(role)
+	if role.RoleId == 0 {
+		role.RoleId, _ = strconv.ParseInt(s.newID()[1:], 10, 64)
+	}
+	role.Kind = "admin#directory#role"
+	s.roles = append(s.roles, role)
+	return clone(role)
+}
+
+// AddPrivilege adds a privilege assignable to custom roles.
+func (s *Server) AddPrivilege(p *admin.Privilege) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.privileges = append(s.privileges, clone(p))
+}
+
+// AddRoleAssignment assigns a role, assigning the assignment's ID when unset.
+func (s *Server) AddRoleAssignment(ra *admin.RoleAssignment) *admin.RoleAssignment {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	ra = s.newRoleAssignment(ra)
+	s.roleAssignments = append(s.roleAssignments, ra)
+	return clone(ra)
+}
+
+// User returns a copy of the user with the given ID or email, or nil.
+func (s *Server) User(userKey string) *admin.User {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if u := s.findUser(userKey); u != nil {
+		return clone(u)
+	}
+	return nil
+}
+
+// Group returns a copy of the group with the given ID or email, or nil.
+func (s *Server) Group(groupKey string) *admin.Group {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if g := s.findGroup(groupKey); g != nil {
+		return clone(g)
+	}
+	return nil
+}
+
+// Members returns copies of a group's members.
+func (s *Server) Members(groupKey string) []*admin.Member {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	g := s.findGroup(groupKey)
+	if g == nil {
+		return nil
+	}
+	rv := make([]*admin.Member, 0, len(s.members[g.Id]))
+	for _, m := range s.members[g.Id] {
+		rv = append(rv, clone(m))
+	}
+	return rv
+}
+
+// ---------------------------------------------------------------------------
+// Users
+// ---------------------------------------------------------------------------
+
+func (s *Server) newUser(u *admin.User) *admin.User {
+	u = clone(u)
+	if u.Id == "" {
+		u.Id = s.newID()
+	}
+	if u.Name == nil {
+		u.Name = &admin.UserName{}
+	}
+	if u.Name.FullName == "" {
+		u.Name.FullName = strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+	}
+	if u.OrgUnitPath == "" {
+		u.OrgUnitPath = "/"
+	}
+	if u.CreationTime == "" {
+		u.CreationTime = time.Now().UTC().Format(time.RFC3339)
+	}
+	u.CustomerId = s.CustomerID
+	u.Kind = "admin#directory#user"
+	u.Password = ""
+	return u
+}
+
+func (s *Server) findUser(key string) *admin.User {
+	for _, u := range s.users {
+		if u.Id == key || strings.EqualFold(u.PrimaryEmail, key) || slices.ContainsFunc(u.Aliases, func(a string) bool { return strings.EqualFold(a, key) }) {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Server) mustFindUser(key string) *admin.User {
+	u := s.findUser(key)
+	if u == nil {
+		panic("fake: no user " + key)
+	}
+	return u
+}
+
+// userOr404 finds the request's {userKey}, writing Google's 404 when there is
+// no such user.
+func (s *Server) userOr404(w http.ResponseWriter, r *http.Request) *admin.User {
+	u := s.findUser(r.PathValue("userKey"))
+	if u == nil {
+		writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: userKey")
+	}
+	return u
+}
+
+// emailTaken reports whether email is the primary email or an alias of a user
+// or group other than exceptID.
+func (s *Server) emailTaken(email, exceptID string) bool {
+	if u := s.findUser(email); u != nil && u.Id != exceptID {
+		return true
+	}
+	if g := s.findGroup(email); g != nil && g.Id != exceptID {
+		return true
+	}
+	return false
+}
+
+// inDomains reports whether email is in one of the customer's domains, or
+// whether no domains were added.
+func (s *Server) inDomains(email string) bool {
+	if len(s.domains) == 0 {
+		return true
+	}
+	_, domain, _ := strings.Cut(email, "@")
+	return slices.ContainsFunc(s.domains, func(d *admin.Domains) bool { return strings.EqualFold(d.DomainName, domain) })
+}
+
+// userView is what a read returns: customSchemas only with the full or custom
+// projection, as in the Directory API.
+func userView(r *http.Request, u *admin.User) *admin.User {
+	u = clone(u)
+	if p := r.URL.Query().Get("projection"); p != "full" && p != "custom" {
+		u.CustomSchemas = nil
+	}
+	return u
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	domain := q.Get("domain")
+	if domain == "" && !s.customer(w, r, q.Get("customer")) {
+		return
+	}
+	var users []*admin.User
+	for _, u := range s.users {
+		if domain != "" && !strings.HasSuffix(strings.ToLower(u.PrimaryEmail), "@"+strings.ToLower(domain)) {
+			continue
+		}
+		ok, err := userMatches(u, q.Get("query"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid", "Invalid Input: "+err.Error())
+			return
+		}
+		if ok {
+			users = append(users, userView(r, u))
+		}
+	}
+	slices.SortStableFunc(users, func(a, b *admin.User) int {
+		return strings.Compare(strings.ToLower(a.PrimaryEmail), strings.ToLower(b.PrimaryEmail))
+	})
+	if q.Get("sortOrder") == "DESCENDING" {
+		slices.Reverse(users)
+	}
+	items, next, ok := page(w, r, users, "maxResults", 100, 500)
+	if !ok {
+		return
+	}
+	writeJSON(w, &admin.Users{Kind: "admin#directory#users", Users: items, NextPageToken: next})
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	if u := s.userOr404(w, r); u != nil {
+		writeJSON(w, userView(r, u))
+	}
+}
+
+func (s *Server) insertUser(w http.ResponseWriter, r *http.Request) {
+	var u admin.User
+	if !decode(w, r, &u) {
+		return
+	}
+	switch {
+	case u.PrimaryEmail == "":
+		writeError(w, http.StatusBadRequest, "required", "Missing required field: primaryEmail")
+	case u.Name == nil || u.Name.GivenName == "" || u.Name.FamilyName == "":
+		writeError(w, http.StatusBadRequest, "required", "Missing required field: name")
+	case !s.inDomains(u.PrimaryEmail):
+		writeError(w, http.StatusBadRequest, "invalid", "Domain not found.")
+	case s.emailTaken(u.PrimaryEmail, ""):
+		writeError(w, http.StatusConflict, "duplicate", "Entity already exists.")
+	default:
+		u.Id = ""
+		stored := s.newUser(&u)
+		s.users = append(s.users, stored)
+		writeJSON(w, stored)
+	}
+}
+
+// updateUser implements both update and patch, which the Directory API gives
+// the same semantics for users. A changed primary email keeps the old one as
+// an alias.
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) {
+	u := s.userOr404(w, r)
+	if u == nil {
+		return
+	}
+	updated, ok := merge(w, r, u)
+	if !ok {
+		return
+	}
+	if !strings.EqualFold(updated.PrimaryEmail, u.PrimaryEmail) {
+		if s.emailTaken(updated.PrimaryEmail, u.Id) {
+			writeError(w, http.StatusConflict, "duplicate", "Entity already exists.")
+			return
+		}
+		if !s.inDomains(updated.PrimaryEmail) {
+			writeError(w, http.StatusBadRequest, "invalid", "Domain not found.")
+			return
+		}
+		updated.Aliases = append(slices.DeleteFunc(updated.Aliases, func(a string) bool {
+			return strings.EqualFold(a, updated.PrimaryEmail)
+		}), u.PrimaryEmail)
+	}
+	updated.Id, updated.CustomerId, updated.Password = u.Id, u.CustomerId, ""
+	if updated.Name != nil && (updated.Name.FullName == "" || updated.Name.FullName == u.Name.FullName) {
+		updated.Name.FullName = strings.TrimSpace(updated.Name.GivenName + " " + updated.Name.FamilyName)
+	}
+	*u = *updated
+	writeJSON(w, u)
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	u := s.userOr404(w, r)
+	if u == nil {
+		return
+	}
+	s.users = slices.DeleteFunc(s.users, func(v *admin.User) bool { return v == u })
+	for id, members := range s.members {
+		s.members[id] = slices.DeleteFunc(members, func(m *admin.Member) bool { return m.Id == u.Id })
+	}
+	s.roleAssignments = slices.DeleteFunc(s.roleAssignments, func(ra *admin.RoleAssignment) bool { return ra.AssignedTo == u.Id })
+	delete(s.userTokens, u.Id)
+	delete(s.asps, u.Id)
+	writeNoContent(w)
+}
+
+func (s *Server) makeAdmin(w http.ResponseWriter, r *http.Request) {
+	u := s.userOr404(w, r)
+	if u == nil {
+		return
+	}
+	var body admin.UserMakeAdmin
+	if !decode(w, r, &body) {
+		return
+	}
+	u.IsAdmin = body.Status
+	writeNoContent(w)
+}
+
+func (s *Server) signOut(w http.ResponseWriter, r *http.Request) {
+	if s.userOr404(w, r) != nil {
+		writeNoContent(w)
+	}
+}
+
+func (s *Server) listTokens(w http.ResponseWriter, r *http.Request) {
+	if u := s.userOr404(w, r); u != nil {
+		writeJSON(w, &admin.Tokens{Kind: "admin#directory#tokenList", Items: s.userTokens[u.Id]})
+	}
+}
+
+func (s *Server) deleteToken(w http.ResponseWriter, r *http.Request) {
+	u := s.userOr404(w, r)
+	if u == nil {
+		return
+	}
+	tokens := s.userTokens[u.Id]
+	i := slices.IndexFunc(tokens, func(t *admin.Token) bool { return t.ClientId == r.PathValue("clientId") })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: clientId")
+		return
+	}
+	s.userTokens[u.Id] = slices.Delete(tokens, i, i+1)
+	writeNoContent(w)
+}
+
+func (s *Server) listAsps(w http.ResponseWriter, r *http.Request) {
+	if u := s.userOr404(w, r); u != nil {
+		writeJSON(w, &admin.Asps{Kind: "admin#directory#aspList", Items: s.asps[u.Id]})
+	}
+}
+
+func (s *Server) deleteAsp(w http.ResponseWriter, r *http.Request) {
+	u := s.userOr404(w, r)
+	if u == nil {
+		return
+	}
+	asps := s.asps[u.Id]
+	i := slices.IndexFunc(asps, func(a *admin.Asp) bool { return strconv.FormatInt(a.CodeId, 10) == r.PathValue("codeId") })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: codeId")
+		return
+	}
+	s.asps[u.Id] = slices.Delete(asps, i, i+1)
+	writeNoContent(w)
+}
+
+// userMatches reports whether u matches a Directory API user search query.
+// Only the isSuspended, isArchived, isAdmin, orgUnitPath and email fields are
+// supported; any other term is an error, so a test never passes on a query
+// the fake silently ignored.
+func userMatches(u *admin.User, query string) (bool, error) {
+	terms, err := queryTerms(query)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range terms {
+		var ok bool
+		switch t.field {
+		case "isSuspended":
+			ok = t.value == strconv.FormatBool(u.Suspended)
+		case "isArchived":
+			ok = t.value == strconv.FormatBool(u.Archived)
+		case "isAdmin":
+			ok = t.value == strconv.FormatBool(u.IsAdmin)
+		case "orgUnitPath":
+			// Matches the org unit and the org units below it.
+			path := strings.TrimSuffix(strings.ToLower(t.value), "/")
+			ou := strings.ToLower(u.OrgUnitPath)
+			ok = path == "" || ou == path || strings.HasPrefix(ou, path+"/")
+		case "email":
+			ok = matchPrefix(u.PrimaryEmail, t.value)
+		default:
+			return false, fmt.Errorf("unsupported query field %q", t.field)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+type queryTerm struct {
+	field string
+	value string
+}
+
+// queryTerms splits a search query into field=value or field:value terms.
+// Values may be single-quoted to include spaces.
+func queryTerms(query string) ([]queryTerm, error) {
+	var terms []queryTerm
+	for query = strings.TrimSpace(query); query != ""; query = strings.TrimSpace(query) {
+		i := strings.IndexAny(query, "=:")
+		if i <= 0 {
+			return nil, fmt.Errorf("malformed query term %q", query)
+		}
+		term := queryTerm{field: query[:i]}
+		query = query[i+1:]
+		if strings.HasPrefix(query, "'") {
+			end := strings.Index(query[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in query")
+			}
+			term.value, query = query[1:end+1], query[end+2:]
+		} else {
+			end := strings.IndexByte(query, ' ')
+			if end < 0 {
+				end = len(query)
+			}
+			term.value, query = query[:end], query[end:]
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+// matchPrefix matches a query value that may end in *, case-insensitively.
+func matchPrefix(s, pattern string) bool {
+	s, pattern = strings.ToLower(s), strings.ToLower(pattern)
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(s, prefix)
+	}
+	return s == pattern
+}
+
+// ---------------------------------------------------------------------------
+// Groups
+// ---------------------------------------------------------------------------
+
+func (s *Server) newGroup(g *admin.Group) *admin.Group {
+	g = clone(g)
+	if g.Id == "" {
+		g.Id = "0" + s.newID()
+	}
+	if g.Name == "" {
+		g.Name, _, _ = strings.Cut(g.Email, "@")
+	}
+	g.AdminCreated = true
+	g.Kind = "admin#directory#group"
+	g.DirectMembersCount = 0
+	s.settings[g.Id] = defaultGroupSettings()
+	return g
+}
+
+func (s *Server) findGroup(key string) *admin.Group {
+	for _, g := range s.groups {
+		if g.Id == key || strings.EqualFold(g.Email, key) || slices.ContainsFunc(g.Aliases, func(a string) bool { return strings.EqualFold(a, key) }) {
+			return g
+		}
+	}
+	return nil
+}
+
+func (s *Server) groupOr404(w http.ResponseWriter, r *http.Request) *admin.Group {
+	g := s.findGroup(r.PathValue("groupKey"))
+	if g == nil {
+		writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: groupKey")
+	}
+	return g
+}
+
+func (s *Server) listGroups(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	domain, userKey := q.Get("domain"), q.Get("userKey")
+	if domain == "" && userKey == "" && !s.customer(w, r, q.Get("customer")) {
+		return
+	}
+	var memberID string
+	if userKey != "" {
+		u := s.findUser(userKey)
+		if u == nil {
+			writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: userKey")
+			return
+		}
+		memberID = u.Id
+	}
+	terms, err := queryTerms(q.Get("query"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid", "Invalid Input: "+err.Error())
+		return
+	}
+	var groups []*admin.Group
+	for _, g := range s.groups {
+		if domain != "" && !strings.HasSuffix(strings.ToLower(g.Email), "@"+strings.ToLower(domain)) {
+			continue
+		}
+		if memberID != "" && !slices.ContainsFunc(s.members[g.Id], func(m *admin.Member) bool { return m.Id == memberID }) {
+			continue
+		}
+		matched := true
+		for _, t := range terms {
+			switch t.field {
+			case "email":
+				matched = matched && matchPrefix(g.Email, t.value)
+			case "name":
+				matched = matched && matchPrefix(g.Name, t.value)
+			default:
+				writeError(w, http.StatusBadRequest, "invalid", fmt.Sprintf("Invalid Input: unsupported query field %q", t.field))
+				return
+			}
+		}
+		if matched {
+			groups = append(groups, clone(g))
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b *admin.Group) int {
+		return strings.Compare(strings.ToLower(a.Email), strings.ToLower(b.Email))
+	})
+	items, next, ok := page(w, r, groups, "maxResults", 200, 200)
+	if !ok {
+		return
+	}
+	writeJSON(w, &admin.Groups{Kind: "admin#directory#groups", Groups: items, NextPageToken: next})
+}
+
+func (s *Server) getGroup(w http.ResponseWriter, r *http.Request) {
+	if g := s.groupOr404(w, r); g != nil {
+		writeJSON(w, g)
+	}
+}
+
+func (s *Server) insertGroup(w http.ResponseWriter, r *http.Request) {
+	var g admin.Group
+	if !decode(w, r, &g) {
+		return
+	}
+	switch {
+	case g.Email == "":
+		writeError(w, http.StatusBadRequest, "required", "Missing required field: email")
+	case !s.inDomains(g.Email):
+		writeError(w, http.StatusBadRequest, "invalid", "Domain not found.")
+	case s.emailTaken(g.Email, ""):
+		writeError(w, http.StatusConflict, "duplicate", "Entity already exists.")
+	default:
+		g.Id = ""
+		stored := s.newGroup(&g)
+		s.groups = append(s.groups, stored)
+		writeJSON(w, stored)
+	}
+}
+
+func (s *Server) updateGroup(w http.ResponseWriter, r *http.Request) {
+	g := s.groupOr404(w, r)
+	if g == nil {
+		return
+	}
+	updated, ok := merge(w, r, g)
+	if !ok {
+		return
+	}
+	if !strings.EqualFold(updated.Email, g.Email) && s.emailTaken(updated.Email, g.Id) {
+		writeError(w, http.StatusConflict, "duplicate", "Entity already exists.")
+		return
+	}
+	updated.Id, updated.DirectMembersCount = g.Id, g.DirectMembersCount
+	*g = *updated
+	writeJSON(w, g)
+}
+
+func (s *Server) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	g := s.groupOr404(w, r)
+	if g == nil {
+		return
+	}
+	s.groups = slices.DeleteFunc(s.groups, func(v *admin.Group) bool { return v == g })
+	delete(s.members, g.Id)
+	delete(s.settings, g.Id)
+	for id, members := range s.members {
+		s.members[id] = slices.DeleteFunc(members, func(m *admin.Member) bool { return m.Id == g.Id })
+	}
+	writeNoContent(w)
+}
+
+// ---------------------------------------------------------------------------
+// Members
+// ---------------------------------------------------------------------------
+
+// newMember resolves a member's email to a user or group of the customer.
+// Other emails become external USER members.
+func (s *Server) newMember(m *admin.Member) *admin.Member {
+	m = clone(m)
+	if u := s.findUser(m.Email); u != nil {
+		m.Id, m.Email, m.Type = u.Id, u.PrimaryEmail, "USER"
+	} else if g := s.findGroup(m.Email); g != nil {
+		m.Id, m.Email, m.Type = g.Id, g.Email, "GROUP"
+	} else if m.Id == "" {
+		m.Id, m.Type = s.newID(), "USER"
+	}
+	if m.Role == "" {
+		m.Role = "MEMBER"
+	}
+	if m.Status == "" {
+		m.Status = "ACTIVE"
+	}
+	if m.DeliverySettings == "" {
+		m.DeliverySettings = "ALL_MAIL"
+	}
+	m.Kind = "admin#directory#member"
+	return m
+}
+
+func findMember(members []*admin.Member, key string) int {
+	return slices.IndexFunc(members, func(m *admin.Member) bool {
+		return m.Id == key || strings.EqualFold(m.Email, key)
+	})
+}
+
+func (s *Server) listMembers(w http.ResponseWriter, r *http.Request) {
+	g := s.groupOr404(w, r)
+	if g == nil {
+		return
+	}
+	var roles []string
+	if v := r.URL.Query().Get("roles"); v != "" {
+		roles = strings.Split(strings.ToUpper(v), ",")
+	}
+	var members []*admin.Member
+	for _, m := range s.members[g.Id] {
+		if roles == nil || slices.Contains(roles, m.Role) {
+			members = append(members, m)
+		}
+	}
+	items, next, ok := page(w, r, members, "maxResults", 200, 200)
+	if !ok {
+		return
+	}
+	writeJSON(w, &admin.Members{Kind: "admin#directory#members", Members: items, NextPageToken: next})
+}
+
+func (s *Server) getMember(w http.ResponseWriter, r *http.Request) {
+	g := s.groupOr404(w, r)
+	if g == nil {
+		return
+	}
+	i := findMember(s.members[g.Id], r.PathValue("memberKey"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: memberKey")
+		return
+	}
+	writeJSON(w, s.members[g.Id][i])
+}
+
+func (s *Server) insertMember(w http.ResponseWriter, r *http.Request) {
+	g := s.groupOr404(w, r)
+	if g == nil {
+		return
+	}
+	var m admin.Member
+	if !decode(w, r, &m) {
+		return
+	}
+	if m.Email == "" && m.Id == "" {
+		writeError(w, http.StatusBadRequest, "required", "Missing required field: memberKey")
+		return
+	}
+	key := m.Email
+	if key == "" {
+		key = m.Id
+		if u := s.findUser(m.Id); u != nil {
+			m.Email = u.PrimaryEmail
+		} else if sub := s.findGroup(m.Id); sub != nil {
+			m.Email = sub.Email
+		} else {
+			writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: memberKey")
+			return
+		}
+	}
+	if findMember(s.members[g.Id], key) >= 0 || findMember(s.members[g.Id], m.Email) >= 0 {
+		writeError(w, http.StatusConflict, "duplicate", "Member already exists.")
+		return
+	}
+	stored := s.newMember(&m)
+	s.members[g.Id] = append(s.members[g.Id], stored)
+	g.DirectMembersCount = int64(len(s.members[g.Id]))
+	writeJSON(w, stored)
+}
+
+func (s *Server) updateMember(w http.ResponseWriter, r *http.Request) {
+	g := s.groupOr404(w, r)
+	if g == nil {
+		return
+	}
+	i := findMember(s.members[g.Id], r.PathValue("memberKey"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: memberKey")
+		return
+	}
+	current := s.members[g.Id][i]
+	updated, ok := merge(w, r, current)
+	if !ok {
+		return
+	}
+	updated.Id, updated.Email, updated.Type = current.Id, current.Email, current.Type
+	s.members[g.Id][i] = updated
+	writeJSON(w, updated)
+}
+
+func (s *Server) deleteMember(w http.ResponseWriter, r *http.Request) {
+	g := s.groupOr404(w, r)
+	if g == nil {
+		return
+	}
+	i := findMember(s.members[g.Id], r.PathValue("memberKey"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: memberKey")
+		return
+	}
+	s.members[g.Id] = slices.Delete(s.members[g.Id], i, i+1)
+	g.DirectMembersCount = int64(len(s.members[g.Id]))
+	writeNoContent(w)
+}
+
+// ---------------------------------------------------------------------------
+// Roles and role assignments
+// ---------------------------------------------------------------------------
+
+func (s *Server) roleOr404(w http.ResponseWriter, r *http.Request) *admin.Role {
+	if !s.customer(w, r, r.PathValue("customer")) {
+		return nil
+	}
+	for _, role := range s.roles {
+		if strconv.FormatInt(role.RoleId, 10) == r.PathValue("roleId") {
+			return role
+		}
+	}
+	writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: roleId")
+	return nil
+}
+
+func (s *Server) listRoles(w http.ResponseWriter, r *http.Request) {
+	if !s.customer(w, r, r.PathValue("customer")) {
+		return
+	}
+	items, next, ok := page(w, r, s.roles, "maxResults", 100, 100)
+	if !ok {
+		return
+	}
+	writeJSON(w, &admin.Roles{Kind: "admin#directory#roles", Items: items, NextPageToken: next})
+}
+
+func (s *Server) getRole(w http.ResponseWriter, r *http.Request) {
+	if role := s.roleOr404(w, r); role != nil {
+		writeJSON(w, role)
+	}
+}
+
+func (s *Server) insertRole(w http.ResponseWriter, r *http.Request) {
+	if !s.customer(w, r, r.PathValue("customer")) {
+		return
+	}
+	var role admin.Role
+	if !decode(w, r, &role) {
+		return
+	}
+	switch {
+	case role.RoleName == "":
+		writeError(w, http.StatusBadRequest, "required", "Missing required field: roleName")
+	case len(role.RolePrivileges) == 0:
+		writeError(w, http.StatusBadRequest, "required", "Missing required field: rolePrivileges")
+	case slices.ContainsFunc(s.roles, func(v *admin.Role) bool { return strings.EqualFold(v.RoleName, role.RoleName) }):
+		writeError(w, http.StatusConflict, "duplicate", "Entity already exists.")
+	default:
+		role.RoleId, _ = strconv.ParseInt(s.newID()[1:], 10, 64)
+		role.IsSystemRole, role.IsSuperAdminRole = false, false
+		role.Kind = "admin#directory#role"
+		stored := clone(&role)
+		s.roles = append(s.roles, stored)
+		writeJSON(w, stored)
+	}
+}
+
+func (s *Server) updateRole(w http.ResponseWriter, r *http.Request) {
+	role := s.roleOr404(w, r)
+	if role == nil {
+		return
+	}
+	if role.IsSystemRole {
+		writeError(w, http.StatusForbidden, "forbidden", "Not Authorized to access this resource/api")
+		return
+	}
+	updated, ok := merge(w, r, role)
+	if !ok {
+		return
+	}
+	updated.RoleId = role.RoleId
+	*role = *updated
+	writeJSON(w, role)
+}
+
+func (s *Server) deleteRole(w http.ResponseWriter, r *http.Request) {
+	role := s.roleOr404(w, r)
+	if role == nil {
+		return
+	}
+	if role.IsSystemRole {
+		writeError(w, http.StatusForbidden, "forbidden", "Not Authorized to access this resource/api")
+		return
+	}
+	if slices.ContainsFunc(s.roleAssignments, func(ra *admin.RoleAssignment) bool { return ra.RoleId == role.RoleId }) {
+		writeError(w, http.StatusBadRequest, "failedPrecondition", "Role has existing assignments.")
+		return
+	}
+	s.roles = slices.DeleteFunc(s.roles, func(v *admin.Role) bool { return v == role })
+	writeNoContent(w)
+}
+
+func (s *Server) listPrivileges(w http.ResponseWriter, r *http.Request) {
+	if s.customer(w, r, r.PathValue("customer")) {
+		writeJSON(w, &admin.Privileges{Kind: "admin#directory#privileges", Items: s.privileges})
+	}
+}
+
+func (s *Server) newRoleAssignment(ra *admin.RoleAssignment) *admin.RoleAssignment {
+	ra = clone(ra)
+	if ra.RoleAssignmentId == 0 {
+		ra.RoleAssignmentId, _ = strconv.ParseInt(s.newID()[1:], 10, 64)
+	}
+	if ra.ScopeType == "" {
+		ra.ScopeType = "CUSTOMER"
+	}
+	if ra.AssigneeType == "" {
+		ra.AssigneeType = "user"
+		if s.findGroup(ra.AssignedTo) != nil {
+			ra.AssigneeType = "group"
+		}
+	}
+	ra.Kind = "admin#directory#roleAssignment"
+	return ra
+}
+
+func (s *Server) roleAssignmentOr404(w http.ResponseWriter, r *http.Request) *admin.RoleAssignment {
+	if !s.customer(w, r, r.PathValue("customer")) {
+		return nil
+	}
+	for _, ra := range s.roleAssignments {
+		if strconv.FormatInt(ra.RoleAssignmentId, 10) == r.PathValue("roleAssignmentId") {
+			return ra
+		}
+	}
+	writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: roleAssignmentId")
+	return nil
+}
+
+func (s *Server) listRoleAssignments(w http.ResponseWriter, r *http.Request) {
+	if !s.customer(w, r, r.PathValue("customer")) {
+		return
+	}
+	q := r.URL.Query()
+	var assignedTo string
+	if userKey := q.Get("userKey"); userKey != "" {
+		u := s.findUser(userKey)
+		if u == nil {
+			writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: userKey")
+			return
+		}
+		assignedTo = u.Id
+	}
+	var assignments []*admin.RoleAssignment
+	for _, ra := range s.roleAssignments {
+		if roleID := q.Get("roleId"); roleID != "" && strconv.FormatInt(ra.RoleId, 10) != roleID {
+			continue
+		}
+		if assignedTo != "" && ra.AssignedTo != assignedTo {
+			continue
+		}
+		assignments = append(assignments, ra)
+	}
+	items, next, ok := page(w, r, assignments, "maxResults", 100, 200)
+	if !ok {
+		return
+	}
+	writeJSON(w, &admin.RoleAssignments{Kind: "admin#directory#roleAssignments", Items: items, NextPageToken: next})
+}
+
+func (s *Server) getRoleAssignment(w http.ResponseWriter, r *http.Request) {
+	if ra := s.roleAssignmentOr404(w, r); ra != nil {
+		writeJSON(w, ra)
+	}
+}
+
+func (s *Server) insertRoleAssignment(w http.ResponseWriter, r *http.Request) {
+	if !s.customer(w, r, r.PathValue("customer")) {
+		return
+	}
+	var ra admin.RoleAssignment
+	if !decode(w, r, &ra) {
+		return
+	}
+	switch {
+	case ra.RoleId == 0 || ra.AssignedTo == "":
+		writeError(w, http.StatusBadRequest, "required", "Missing required field: roleId or assignedTo")
+		return
+	case !slices.ContainsFunc(s.roles, func(role *admin.Role) bool { return role.RoleId == ra.RoleId }):
+		writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: roleId")
+		return
+	case s.findUser(ra.AssignedTo) == nil && s.findGroup(ra.AssignedTo) == nil:
+		writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: assignedTo")
+		return
+	case ra.ScopeType == "ORG_UNIT" && ra.OrgUnitId == "":
+		writeError(w, http.StatusBadRequest, "required", "Missing required field: orgUnitId")
+		return
+	}
+	ra.RoleAssignmentId = 0
+	stored := s.newRoleAssignment(&ra)
+	if slices.ContainsFunc(s.roleAssignments, func(v *admin.RoleAssignment) bool {
+		return v.RoleId == stored.RoleId && v.AssignedTo == stored.AssignedTo && v.ScopeType == stored.ScopeType && v.OrgUnitId == stored.OrgUnitId
+	}) {
+		writeError(w, http.StatusConflict, "duplicate", "Entity already exists.")
+		return
+	}
+	s.roleAssignments = append(s.roleAssignments, stored)
+	writeJSON(w, stored)
+}
+
+func (s *Server) deleteRoleAssignment(w http.ResponseWriter, r *http.Request) {
+	ra := s.roleAssignmentOr404(w, r)
+	if ra == nil {
+		return
+	}
+	s.roleAssignments = slices.DeleteFunc(s.roleAssignments, func(v *admin.RoleAssignment) bool { return v == ra })
+	writeNoContent(w)
+}
+
+// ---------------------------------------------------------------------------
+// Domains
+// ---------------------------------------------------------------------------
+
+func (s *Server) listDomains(w http.ResponseWriter, r *http.Request) {
+	if s.customer(w, r, r.PathValue("customer")) {
+		writeJSON(w, &admin.Domains2{Kind: "admin#directory#domains", Domains: s.domains})
+	}
+}
+
+func (s *Server) getDomain(w http.ResponseWriter, r *http.Request) {
+	if !s.customer(w, r, r.PathValue("customer")) {
+		return
+	}
+	for _, d := range s.domains {
+		if strings.EqualFold(d.DomainName, r.PathValue("domainName")) {
+			writeJSON(w, d)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: domainName")
+}
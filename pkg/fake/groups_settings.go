@@ -0,0 +1,184 @@
+package fake
+
+import (
+	"net/http"
+	"slices"
+
+	groupssettings "google.golang.org/api/groupssettings/v1"
+)
+
+const groupsSettingsScope = "https://www.googleapis.com/auth/apps.groups.settings"
+
+func (s *Server) registerGroupsSettings(mux *http.ServeMux) {
+	scopes := []string{groupsSettingsScope}
+	s.handle(mux, "GET /groups/v1/groups/{groupUniqueId}", scopes, s.getGroupSettings)
+	s.handle(mux, "PUT /groups/v1/groups/{groupUniqueId}", scopes, s.updateGroupSettings)
+	s.handle(mux, "PATCH /groups/v1/groups/{groupUniqueId}", scopes, s.updateGroupSettings)
+}
+
+var (
+	moderationValues = []string{"ALL_MEMBERS", "OWNERS_AND_MANAGERS", "OWNERS_ONLY", "NONE"}
+	topicValues      = []string{"ALL_MEMBERS", "OWNERS_AND_MANAGERS", "MANAGERS_ONLY", "OWNERS_ONLY", "NONE"}
+	boolValues       = []string{"true", "false"}
+)
+
+// groupSettingValues are the values the Groups Settings API accepts for its
+// enum and boolean settings. Writing any other value fails with 400.
+var groupSettingValues = map[string][]string{
+	"whoCanJoin":                  {"ANYONE_CAN_JOIN", "ALL_IN_DOMAIN_CAN_JOIN", "INVITED_CAN_JOIN", "CAN_REQUEST_TO_JOIN"},
+	"whoCanViewMembership":        {"ALL_IN_DOMAIN_CAN_VIEW", "ALL_MEMBERS_CAN_VIEW", "ALL_MANAGERS_CAN_VIEW", "ALL_OWNERS_CAN_VIEW"},
+	"whoCanViewGroup":             {"ANYONE_CAN_VIEW", "ALL_IN_DOMAIN_CAN_VIEW", "ALL_MEMBERS_CAN_VIEW", "ALL_MANAGERS_CAN_VIEW", "ALL_OWNERS_CAN_VIEW"},
+	"whoCanPostMessage":           {"NONE_CAN_POST", "ALL_MANAGERS_CAN_POST", "ALL_MEMBERS_CAN_POST", "ALL_OWNERS_CAN_POST", "ALL_IN_DOMAIN_CAN_POST", "ANYONE_CAN_POST"},
+	"whoCanDiscoverGroup":         {"ANYONE_CAN_DISCOVER", "ALL_IN_DOMAIN_CAN_DISCOVER", "ALL_MEMBERS_CAN_DISCOVER"},
+	"whoCanContactOwner":          {"ALL_IN_DOMAIN_CAN_CONTACT", "ALL_MANAGERS_CAN_CONTACT", "ALL_MEMBERS_CAN_CONTACT", "ANYONE_CAN_CONTACT"},
+	"whoCanLeaveGroup":            {"ALL_MANAGERS_CAN_LEAVE", "ALL_MEMBERS_CAN_LEAVE", "NONE_CAN_LEAVE"},
+	"whoCanModerateMembers":       moderationValues,
+	"whoCanModerateContent":       moderationValues,
+	"whoCanAssistContent":         topicValues,
+	"messageModerationLevel":      {"MODERATE_ALL_MESSAGES", "MODERATE_NON_MEMBERS", "MODERATE_NEW_MEMBERS", "MODERATE_NONE"},
+	"spamModerationLevel":         {"ALLOW", "MODERATE", "SILENTLY_MODERATE", "REJECT"},
+	"replyTo":                     {"REPLY_TO_CUSTOM", "REPLY_TO_SENDER", "REPLY_TO_LIST", "REPLY_TO_OWNER", "REPLY_TO_IGNORE", "REPLY_TO_MANAGERS"},
+	"default_sender":              {"DEFAULT_SELF", "GROUP"},
+	"allowExternalMembers":        boolValues,
+	"allowWebPosting":             boolValues,
+	"archiveOnly":                 boolValues,
+	"isArchived":                  boolValues,
+	"membersCanPostAsTheGroup":    boolValues,
+	"includeInGlobalAddressList":  boolValues,
+	"enableCollaborativeInbox":    boolValues,
+	"includeCustomFooter":         boolValues,
+	"sendMessageDenyNotification": boolValues,
+	"allowGoogleCommunication":    boolValues,
+	"showInGroupDirectory":        boolValues,
+	"favoriteRepliesOnTop":        boolValues,
+	"messageDisplayFont":          {"DEFAULT_FONT", "FIXED_WIDTH_FONT"},
+
+	// Deprecated settings, which the API merges into whoCanModerateMembers,
+	// whoCanModerateContent and whoCanAssistContent.
+	"whoCanAdd":                           {"ALL_MEMBERS_CAN_ADD", "ALL_MANAGERS_CAN_ADD", "ALL_OWNERS_CAN_ADD", "NONE_CAN_ADD"},
+	"whoCanInvite":                        {"ALL_MEMBERS_CAN_INVITE", "ALL_MANAGERS_CAN_INVITE", "ALL_OWNERS_CAN_INVITE", "NONE_CAN_INVITE"},
+	"whoCanApproveMembers":                {"ALL_OWNERS_CAN_APPROVE", "ALL_MANAGERS_CAN_APPROVE", "ALL_MEMBERS_CAN_APPROVE", "NONE_CAN_APPROVE"},
+	"whoCanBanUsers":                      {"OWNERS_ONLY", "OWNERS_AND_MANAGERS", "NONE"},
+	"whoCanModifyMembers":                 moderationValues,
+	"whoCanApproveMessages":               moderationValues,
+	"whoCanDeleteAnyPost":                 moderationValues,
+	"whoCanDeleteTopics":                  moderationValues,
+	"whoCanLockTopics":                    moderationValues,
+	"whoCanMoveTopicsIn":                  moderationValues,
+	"whoCanMoveTopicsOut":                 moderationValues,
+	"whoCanPostAnnouncements":             moderationValues,
+	"whoCanMakeTopicsSticky":              moderationValues,
+	"whoCanHideAbuse":                     moderationValues,
+	"whoCanAddReferences":                 topicValues,
+	"whoCanAssignTopics":                  topicValues,
+	"whoCanUnassignTopic":                 topicValues,
+	"whoCanTakeTopics":                    topicValues,
+	"whoCanMarkDuplicate":                 topicValues,
+	"whoCanMarkNoResponseNeeded":          topicValues,
+	"whoCanMarkFavoriteReplyOnAnyTopic":   topicValues,
+	"whoCanMarkFavoriteReplyOnOwnTopic":   topicValues,
+	"whoCanUnmarkFavoriteReplyOnAnyTopic": topicValues,
+	"whoCanEnterFreeFormTags":             topicValues,
+	"whoCanModifyTagsAndCategories":       topicValues,
+}
+
+// defaultGroupSettings are the settings of a new group.
+func defaultGroupSettings() *groupssettings.Groups {
+	return &groupssettings.Groups{
+		Kind:                                    "groupsSettings#groups",
+		WhoCanJoin:                              "CAN_REQUEST_TO_JOIN",
+		WhoCanViewMembership:                    "ALL_MEMBERS_CAN_VIEW",
+		WhoCanViewGroup:                         "ALL_MEMBERS_CAN_VIEW",
+		WhoCanPostMessage:                       "ALL_MEMBERS_CAN_POST",
+		WhoCanDiscoverGroup:                     "ALL_IN_DOMAIN_CAN_DISCOVER",
+		WhoCanContactOwner:                      "ANYONE_CAN_CONTACT",
+		WhoCanLeaveGroup:                        "ALL_MEMBERS_CAN_LEAVE",
+		WhoCanModerateMembers:                   "OWNERS_AND_MANAGERS",
+		WhoCanModerateContent:                   "OWNERS_AND_MANAGERS",
+		WhoCanAssistContent:                     "NONE",
+		MessageModerationLevel:                  "MODERATE_NONE",
+		SpamModerationLevel:                     "MODERATE",
+		ReplyTo:                                 "REPLY_TO_IGNORE",
+		DefaultSender:                           "DEFAULT_SELF",
+		AllowExternalMembers:                    "false",
+		AllowWebPosting:                         "true",
+		ArchiveOnly:                             "false",
+		IsArchived:                              "false",
+		MembersCanPostAsTheGroup:                "false",
+		IncludeInGlobalAddressList:              "true",
+		EnableCollaborativeInbox:                "false",
+		IncludeCustomFooter:                     "false",
+		SendMessageDenyNotification:             "false",
+		PrimaryLanguage:                         "en",
+		CustomRolesEnabledForSettingsToBeMerged: "false",
+		MaxMessageBytes:                         26214400,
+	}
+}
+
+// SetGroupSettings replaces the settings of a group.
+func (s *Server) SetGroupSettings(groupKey string, settings *groupssettings.Groups) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	g := s.findGroup(groupKey)
+	if g == nil {
+		panic("fake: no group " + groupKey)
+	}
+	s.settings[g.Id] = clone(settings)
+}
+
+// GroupSettings returns a copy of the settings of a group, or nil.
+func (s *Server) GroupSettings(groupKey string) *groupssettings.Groups {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if g := s.findGroup(groupKey); g != nil {
+		return s.groupSettingsView(g.Id)
+	}
+	return nil
+}
+
+// groupSettingsView is a group's settings as the API returns them, with the
+// group's email, name and description.
+func (s *Server) groupSettingsView(groupID string) *groupssettings.Groups {
+	for _, g := range s.groups {
+		if g.Id == groupID {
+			rv := clone(s.settings[g.Id])
+			rv.Email, rv.Name, rv.Description = g.Email, g.Name, g.Description
+			return rv
+		}
+	}
+	return nil
+}
+
+func (s *Server) getGroupSettings(w http.ResponseWriter, r *http.Request) {
+	g := s.findGroup(r.PathValue("groupUniqueId"))
+	if g == nil {
+		writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: groupUniqueId")
+		return
+	}
+	writeJSON(w, s.groupSettingsView(g.Id))
+}
+
+// updateGroupSettings implements update and patch: only the settings in the
+// body change. Name and description write through to the group.
+func (s *Server) updateGroupSettings(w http.ResponseWriter, r *http.Request) {
+	g := s.findGroup(r.PathValue("groupUniqueId"))
+	if g == nil {
+		writeError(w, http.StatusNotFound, "notFound", "Resource Not Found: groupUniqueId")
+		return
+	}
+	updated, ok := merge(w, r, s.groupSettingsView(g.Id))
+	if !ok {
+		return
+	}
+	fields, _ := jsonFields(updated)
+	for field, values := range groupSettingValues {
+		if v, ok := fields[field].(string); ok && !slices.Contains(values, v) {
+			writeError(w, http.StatusBadRequest, "invalid", "Invalid Value")
+			return
+		}
+	}
+	g.Name, g.Description = updated.Name, updated.Description
+	updated.Email = g.Email
+	s.settings[g.Id] = updated
+	writeJSON(w, updated)
+}
@@ -0,0 +1,152 @@
+package fake
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	reports "google.golang.org/api/admin/reports/v1"
+)
+
+var reportsScopes = []string{reports.AdminReportsAuditReadonlyScope}
+
+// reportsApplications are the applicationName values the Reports API accepts.
+var reportsApplications = []string{
+	"access_transparency", "admin", "calendar", "chat", "chrome", "context_aware_access", "data_studio",
+	"drive", "gcp", "groups", "groups_enterprise", "jamboard", "keep", "login", "meet", "mobile",
+	"rules", "saml", "token", "user_accounts",
+}
+
+func (s *Server) registerReports(mux *http.ServeMux) {
+	s.handle(mux, "GET /admin/reports/v1/activity/users/{userKey}/applications/{applicationName}", reportsScopes, s.listActivities)
+}
+
+// AddActivity adds an audit log activity of application (e.g. "login"). An
+// unset ID gets the current time and a new unique qualifier.
+func (s *Server) AddActivity(application string, a *reports.Activity) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	a = clone(a)
+	if a.Id == nil {
+		a.Id = &reports.ActivityId{}
+	}
+	if a.Id.Time == "" {
+		a.Id.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	if a.Id.UniqueQualifier == 0 {
+		a.Id.UniqueQualifier, _ = strconv.ParseInt(s.newID()[1:], 10, 64)
+	}
+	a.Id.ApplicationName = application
+	a.Id.CustomerId = s.CustomerID
+	a.Kind = "admin#reports#activity"
+	s.activities[application] = append(s.activities[application], a)
+}
+
+// listActivities returns matching activities newest first. startTime is
+// inclusive and endTime exclusive. eventName keeps only the named events of
+// each activity, and filters supports comma-separated name==value and
+// name<>value conditions on event parameters.
+func (s *Server) listActivities(w http.ResponseWriter, r *http.Request) {
+	app := r.PathValue("applicationName")
+	if !slices.Contains(reportsApplications, app) {
+		writeError(w, http.StatusBadRequest, "invalid", "Invalid value for applicationName: "+app)
+		return
+	}
+	q := r.URL.Query()
+	var start, end time.Time
+	for param, t := range map[string]*time.Time{"startTime": &start, "endTime": &end} {
+		if v := q.Get(param); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid", "Invalid value for "+param)
+				return
+			}
+			*t = parsed
+		}
+	}
+	filters, err := activityFilters(q.Get("filters"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid", "Invalid value for filters")
+		return
+	}
+	userKey := r.PathValue("userKey")
+
+	var activities []*reports.Activity
+	for _, a := range s.activities[app] {
+		if userKey != "all" && (a.Actor == nil || (!strings.EqualFold(a.Actor.Email, userKey) && a.Actor.ProfileId != userKey)) {
+			continue
+		}
+		at, _ := time.Parse(time.RFC3339Nano, a.Id.Time)
+		if (!start.IsZero() && at.Before(start)) || (!end.IsZero() && !at.Before(end)) {
+			continue
+		}
+		a = clone(a)
+		a.Events = slices.DeleteFunc(a.Events, func(e *reports.ActivityEvents) bool {
+			if name := q.Get("eventName"); name != "" && e.Name != name {
+				return true
+			}
+			return !filters.match(e)
+		})
+		if len(a.Events) > 0 {
+			activities = append(activities, a)
+		}
+	}
+	slices.SortStableFunc(activities, func(a, b *reports.Activity) int {
+		return strings.Compare(b.Id.Time, a.Id.Time)
+	})
+	items, next, ok := page(w, r, activities, "maxResults", 1000, 1000)
+	if !ok {
+		return
+	}
+	writeJSON(w, &reports.Activities{Kind: "admin#reports#activities", Items: items, NextPageToken: next})
+}
+
+type activityFilter struct {
+	name   string
+	negate bool
+	value  string
+}
+
+type activityFilterList []activityFilter
+
+func activityFilters(filters string) (activityFilterList, error) {
+	var rv activityFilterList
+	for _, cond := range strings.Split(filters, ",") {
+		if cond = strings.TrimSpace(cond); cond == "" {
+			continue
+		}
+		if name, value, ok := strings.Cut(cond, "=="); ok {
+			rv = append(rv, activityFilter{name: name, value: value})
+		} else if name, value, ok := strings.Cut(cond, "<>"); ok {
+			rv = append(rv, activityFilter{name: name, negate: true, value: value})
+		} else {
+			return nil, strconv.ErrSyntax
+		}
+	}
+	return rv, nil
+}
+
+// match reports whether an event's parameters satisfy every filter.
+func (l activityFilterList) match(e *reports.ActivityEvents) bool {
+	for _, f := range l {
+		var values []string
+		for _, p := range e.Parameters {
+			if p.Name != f.name {
+				continue
+			}
+			values = append(values, p.MultiValue...)
+			if p.Value != "" {
+				values = append(values, p.Value)
+			}
+			if p.BoolValue {
+				values = append(values, "true")
+			}
+		}
+		if slices.Contains(values, f.value) == f.negate {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,504 @@
+// Package fake is an in-memory Google Workspace API server for end-to-end
+// tests. It serves the Directory (users, groups, members, roles, role
+// assignments, domains), Groups Settings, Data Transfer, Reports activities and
+// Cloud Identity inboundSamlSsoProfiles endpoints the connector calls, with
+// Google's pagination, error bodies and status codes, and the Directory API's
+// batch endpoint.
+//
+// The server issues OAuth tokens to the service account key returned by
+// CredentialsJSON and rejects requests whose token lacks the endpoint's scope,
+// so a connector configured with that key and the server's URL as its base URL
+// runs unchanged against it:
+//
+//	srv := fake.New("C0fake")
+//	defer srv.Close()
+//	srv.AddDomain("example.com", true)
+//	srv.AddUser(&admin.User{PrimaryEmail: "admin@example.com", IsAdmin: true})
+//	// configure the connector with srv.CredentialsJSON() and srv.URL()
+package fake
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	datatransfer "google.golang.org/api/admin/datatransfer/v1"
+	admin "google.golang.org/api/admin/directory/v1"
+	reports "google.golang.org/api/admin/reports/v1"
+	cloudidentity "google.golang.org/api/cloudidentity/v1"
+	groupssettings "google.golang.org/api/groupssettings/v1"
+)
+
+const (
+	// ServiceAccountEmail is the client_email of CredentialsJSON.
+	ServiceAccountEmail = "connector@fake-project.iam.gserviceaccount.com"
+
+	tokenPath     = "/token"
+	tokenLifetime = time.Hour
+)
+
+// Server is a fake Google Workspace API server. Its zero value is not usable;
+// create one with New. All methods are safe for concurrent use.
+type Server struct {
+	// CustomerID is the customer the server hosts. Requests may name it or
+	// use the "my_customer" alias.
+	CustomerID string
+
+	srv *httptest.Server
+	key *rsa.PrivateKey
+
+	mtx       sync.Mutex
+	scopes    map[string]bool // every scope an endpoint accepts
+	granted   map[string]bool // scopes the service account may request
+	tokens    map[string]map[string]bool
+	faults    []*fault
+	rateLimit *rateLimit
+	requests  []string
+	nextID    int64
+
+	domains         []*admin.Domains
+	users           []*admin.User
+	userTokens      map[string][]*admin.Token
+	asps            map[string][]*admin.Asp
+	groups          []*admin.Group
+	members         map[string][]*admin.Member
+	settings        map[string]*groupssettings.Groups
+	roles           []*admin.Role
+	privileges      []*admin.Privilege
+	roleAssignments []*admin.RoleAssignment
+	transfers       []*datatransfer.DataTransfer
+	activities      map[string][]*reports.Activity
+	samlProfiles    []*cloudidentity.InboundSamlSsoProfile
+	ssoAssignments  []*cloudidentity.InboundSsoAssignment
+}
+
+// New starts a fake server hosting customerID. Close it when done.
+func New(customerID string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("fake: failed to generate service account key: %v", err))
+	}
+	s := &Server{
+		CustomerID: customerID,
+		key:        key,
+		scopes:     map[string]bool{},
+		tokens:     map[string]map[string]bool{},
+		userTokens: map[string][]*admin.Token{},
+		asps:       map[string][]*admin.Asp{},
+		members:    map[string][]*admin.Member{},
+		settings:   map[string]*groupssettings.Groups{},
+		activities: map[string][]*reports.Activity{},
+		nextID:     1,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+tokenPath, s.handleToken)
+	s.registerDirectory(mux)
+	s.registerGroupsSettings(mux)
+	s.registerDataTransfer(mux)
+	s.registerReports(mux)
+	s.registerCloudIdentity(mux)
+	s.registerBatch(mux)
+	s.srv = httptest.NewServer(mux)
+	return s
+}
+
+// URL is the base URL to point the connector at.
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// CredentialsJSON is a service account key whose token_uri is this server.
+func (s *Server) CredentialsJSON() []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(s.key)
+	if err != nil {
+		panic(fmt.Sprintf("fake: failed to marshal service account key: %v", err))
+	}
+	rv, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "fake-project",
+		"private_key_id": "fake-key",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   ServiceAccountEmail,
+		"client_id":      "100000000000000000001",
+		"token_uri":      s.srv.URL + tokenPath,
+	})
+	return rv
+}
+
+// GrantScopes limits the scopes the service account may request tokens for,
+// as the domain-wide delegation settings of the Admin console do. A token
+// request for any other scope fails with unauthorized_client. By default
+// every scope the server's endpoints accept is granted.
+func (s *Server) GrantScopes(scopes ...string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.granted = map[string]bool{}
+	for _, scope := range scopes {
+		s.granted[scope] = true
+	}
+}
+
+// InjectFault makes the next count requests whose method is method (any
+// method when empty) and whose path starts with pathPrefix fail with status
+// and Google's error body for it.
+func (s *Server) InjectFault(method, pathPrefix string, status, count int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.faults = append(s.faults, &fault{method: method, pathPrefix: pathPrefix, status: status, remaining: count})
+}
+
+// SetRateLimit makes API requests beyond limit within each window fail with
+// 429 rateLimitExceeded, like Google's per-user query quotas. A limit of zero
+// removes the rate limit.
+func (s *Server) SetRateLimit(limit int, window time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if limit <= 0 {
+		s.rateLimit = nil
+		return
+	}
+	s.rateLimit = &rateLimit{limit: limit, window: window}
+}
+
+// Requests returns the API requests served so far, as "METHOD /path".
+// Token requests are not included.
+func (s *Server) Requests() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// ResetRequests clears the log returned by Requests.
+func (s *Server) ResetRequests() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.requests = nil
+}
+
+type fault struct {
+	method     string
+	pathPrefix string
+	status     int
+	remaining  int
+}
+
+type rateLimit struct {
+	limit  int
+	window time.Duration
+	start  time.Time
+	used   int
+}
+
+// allow counts a request against the rate limit.
+func (l *rateLimit) allow(now time.Time) bool {
+	if now.Sub(l.start) >= l.window {
+		l.start, l.used = now, 0
+	}
+	l.used++
+	return l.used <= l.limit
+}
+
+// handle registers an API endpoint that accepts tokens carrying any of
+// scopes. Requests are served one at a time, holding s.mtx.
+func (s *Server) handle(mux *http.ServeMux, pattern string, scopes []string, h http.HandlerFunc) {
+	for _, scope := range scopes {
+		s.scopes[scope] = true
+	}
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+		granted, ok := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "authError", "Request had invalid authentication credentials. Expected OAuth 2 access token.")
+			return
+		}
+		if !slices.ContainsFunc(scopes, func(scope string) bool { return granted[scope] }) {
+			writeError(w, http.StatusForbidden, "insufficientPermissions", "Request had insufficient authentication scopes.")
+			return
+		}
+		if status, ok := s.takeFault(r); ok {
+			writeStatusError(w, status)
+			return
+		}
+		if s.rateLimit != nil && !s.rateLimit.allow(time.Now()) {
+			writeStatusError(w, http.StatusTooManyRequests)
+			return
+		}
+		h(w, r)
+	})
+}
+
+// takeFault returns the status of the first injected fault matching r, and
+// counts r against it. The caller holds s.mtx.
+func (s *Server) takeFault(r *http.Request) (int, bool) {
+	for i, f := range s.faults {
+		if (f.method == "" || f.method == r.Method) && strings.HasPrefix(r.URL.Path, f.pathPrefix) {
+			f.remaining--
+			if f.remaining <= 0 {
+				s.faults = slices.Delete(s.faults, i, i+1)
+			}
+			return f.status, true
+		}
+	}
+	return 0, false
+}
+
+// handleToken implements the JWT bearer grant of a service account key: it
+// checks the assertion's signature, expiry and scopes, and that its subject
+// is a user of the customer.
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if r.PostFormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "Invalid grant_type: "+r.PostFormValue("grant_type"))
+		return
+	}
+	parts := strings.Split(r.PostFormValue("assertion"), ".")
+	if len(parts) != 3 {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "Invalid JWT: Token must be a short-lived token and in a reasonable timeframe")
+		return
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err != nil || rsa.VerifyPKCS1v15(&s.key.PublicKey, crypto.SHA256, digest[:], sig) != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "Invalid JWT Signature.")
+		return
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Iss   string `json:"iss"`
+		Scope string `json:"scope"`
+		Sub   string `json:"sub"`
+		Exp   int64  `json:"exp"`
+	}
+	if err != nil || json.Unmarshal(payload, &claims) != nil || claims.Iss != ServiceAccountEmail {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "Invalid JWT: malformed claims")
+		return
+	}
+	if time.Unix(claims.Exp, 0).Before(time.Now()) {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "Invalid JWT: Token must be a short-lived token and in a reasonable timeframe")
+		return
+	}
+	if claims.Sub != "" && s.findUser(claims.Sub) == nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "Invalid email or User ID")
+		return
+	}
+
+	scopes := map[string]bool{}
+	for _, scope := range strings.Fields(claims.Scope) {
+		granted := s.scopes[scope]
+		if s.granted != nil {
+			granted = s.granted[scope]
+		}
+		if !granted {
+			writeOAuthError(w, http.StatusUnauthorized, "unauthorized_client",
+				"Client is unauthorized to retrieve access tokens using this method, or client not authorized for any of the scopes requested.")
+			return
+		}
+		scopes[scope] = true
+	}
+
+	token := "fake-token-" + s.newID()
+	s.tokens[token] = scopes
+	writeJSON(w, map[string]any{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(tokenLifetime.Seconds()),
+	})
+}
+
+// newID returns a new numeric ID, shaped like Google's.
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("1%020d", s.nextID)
+}
+
+// customer reports whether the request's {customer} names this server's
+// customer, writing Google's error when it does not.
+func (s *Server) customer(w http.ResponseWriter, r *http.Request, customer string) bool {
+	if customer == "my_customer" || customer == s.CustomerID {
+		return true
+	}
+	writeError(w, http.StatusBadRequest, "badRequest", "Bad Request")
+	return false
+}
+
+var statusReasons = map[int]struct{ reason, message string }{
+	http.StatusBadRequest:          {"invalid", "Invalid Input"},
+	http.StatusUnauthorized:        {"authError", "Invalid Credentials"},
+	http.StatusForbidden:           {"forbidden", "Not Authorized to access this resource/api"},
+	http.StatusNotFound:            {"notFound", "Resource Not Found"},
+	http.StatusConflict:            {"duplicate", "Entity already exists."},
+	http.StatusTooManyRequests:     {"rateLimitExceeded", "Quota exceeded for quota metric 'Queries' and limit 'Queries per minute per user'."},
+	http.StatusInternalServerError: {"backendError", "Internal error encountered."},
+	http.StatusServiceUnavailable:  {"backendError", "The service is currently unavailable."},
+}
+
+var statusNames = map[int]string{
+	http.StatusBadRequest:          "INVALID_ARGUMENT",
+	http.StatusUnauthorized:        "UNAUTHENTICATED",
+	http.StatusForbidden:           "PERMISSION_DENIED",
+	http.StatusNotFound:            "NOT_FOUND",
+	http.StatusConflict:            "ALREADY_EXISTS",
+	http.StatusTooManyRequests:     "RESOURCE_EXHAUSTED",
+	http.StatusInternalServerError: "INTERNAL",
+	http.StatusServiceUnavailable:  "UNAVAILABLE",
+}
+
+func writeStatusError(w http.ResponseWriter, status int) {
+	e, ok := statusReasons[status]
+	if !ok {
+		e.reason, e.message = "unknown", http.StatusText(status)
+	}
+	writeError(w, status, e.reason, e.message)
+}
+
+// writeError writes a Google API error body.
+func writeError(w http.ResponseWriter, status int, reason, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":    status,
+			"message": message,
+			"status":  statusNames[status],
+			"errors":  []map[string]string{{"domain": "global", "reason": reason, "message": message}},
+		},
+	})
+}
+
+// writeOAuthError writes an RFC 6749 token error body.
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeNoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decode reads the request body into v, writing a 400 when it is not JSON.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "parseError", "Parse Error")
+		return false
+	}
+	return true
+}
+
+// merge applies the request body to current with the Directory API's update
+// semantics: fields in the body replace the current ones, nested objects are
+// merged field by field, null clears a field, and absent fields are left
+// unchanged.
+func merge[T any](w http.ResponseWriter, r *http.Request, current *T) (*T, bool) {
+	var patch map[string]any
+	if !decode(w, r, &patch) {
+		return nil, false
+	}
+	b, _ := json.Marshal(current)
+	fields := map[string]any{}
+	_ = json.Unmarshal(b, &fields)
+	mergeFields(fields, patch)
+	b, _ = json.Marshal(fields)
+	var rv T
+	if err := json.Unmarshal(b, &rv); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid", "Invalid Input: "+err.Error())
+		return nil, false
+	}
+	return &rv, true
+}
+
+func mergeFields(dst, patch map[string]any) {
+	for k, v := range patch {
+		sub, isObject := v.(map[string]any)
+		cur, hasObject := dst[k].(map[string]any)
+		switch {
+		case v == nil:
+			delete(dst, k)
+		case isObject && hasObject:
+			mergeFields(cur, sub)
+		default:
+			dst[k] = v
+		}
+	}
+}
+
+// jsonFields is v's JSON object, keyed by field name.
+func jsonFields(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]any{}
+	return fields, json.Unmarshal(b, &fields)
+}
+
+// clone deep-copies an API object, so callers never share the server's state.
+func clone[T any](v *T) *T {
+	b, _ := json.Marshal(v)
+	var rv T
+	_ = json.Unmarshal(b, &rv)
+	return &rv
+}
+
+// page returns the page of items the request's page token and size select,
+// and the token of the next page. Page tokens are opaque offsets, and sizes
+// above maxSize are capped as Google does.
+func page[T any](w http.ResponseWriter, r *http.Request, items []T, sizeParam string, defaultSize, maxSize int) ([]T, string, bool) {
+	size := defaultSize
+	if v := r.URL.Query().Get(sizeParam); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid", "Invalid value for "+sizeParam)
+			return nil, "", false
+		}
+		if n > 0 {
+			size = min(n, maxSize)
+		}
+	}
+	offset := 0
+	if token := r.URL.Query().Get("pageToken"); token != "" {
+		b, err := base64.RawURLEncoding.DecodeString(token)
+		if err == nil {
+			offset, err = strconv.Atoi(strings.TrimPrefix(string(b), "offset:"))
+		}
+		if err != nil || offset < 0 || offset > len(items) {
+			writeError(w, http.StatusBadRequest, "invalid", "Invalid page token")
+			return nil, "", false
+		}
+	}
+	end := min(offset+size, len(items))
+	next := ""
+	if end < len(items) {
+		next = base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(end)))
+	}
+	return items[offset:end], next, true
+}
@@ -0,0 +1,124 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2/google"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/conductorone/baton-google-workspace/pkg/fake"
+)
+
+func newServer(t *testing.T) *fake.Server {
+	t.Helper()
+	srv := fake.New("C0fake")
+	t.Cleanup(srv.Close)
+	srv.AddDomain("example.com", true)
+	srv.AddUser(&admin.User{PrimaryEmail: "admin@example.com", IsAdmin: true})
+	return srv
+}
+
+func newDirectory(t *testing.T, srv *fake.Server, scopes ...string) *admin.Service {
+	t.Helper()
+	ctx := context.Background()
+	cfg, err := google.JWTConfigFromJSON(srv.CredentialsJSON(), scopes...)
+	require.NoError(t, err)
+	cfg.Subject = "admin@example.com"
+	svc, err := admin.NewService(ctx,
+		option.WithHTTPClient(cfg.Client(ctx)),
+		option.WithEndpoint(srv.URL()+"/"),
+	)
+	require.NoError(t, err)
+	return svc
+}
+
+func requireStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var gerr *googleapi.Error
+	require.True(t, errors.As(err, &gerr), "expected a googleapi error, got %v", err)
+	require.Equal(t, status, gerr.Code)
+}
+
+func TestUsersPagination(t *testing.T) {
+	srv := newServer(t)
+	for i := range 249 {
+		srv.AddUser(&admin.User{PrimaryEmail: fmt.Sprintf("user%03d@example.com", i)})
+	}
+	svc := newDirectory(t, srv, admin.AdminDirectoryUserReadonlyScope)
+
+	seen := map[string]bool{}
+	pages := 0
+	err := svc.Users.List().Customer("my_customer").MaxResults(100).Pages(context.Background(), func(resp *admin.Users) error {
+		pages++
+		for _, u := range resp.Users {
+			require.False(t, seen[u.Id], "user %s listed twice", u.PrimaryEmail)
+			seen[u.Id] = true
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, pages)
+	require.Len(t, seen, 250)
+
+	_, err = svc.Users.List().Customer("my_customer").PageToken("bogus").Do()
+	requireStatus(t, err, http.StatusBadRequest)
+}
+
+func TestErrors(t *testing.T) {
+	srv := newServer(t)
+	srv.AddGroup(&admin.Group{Email: "eng@example.com"})
+	svc := newDirectory(t, srv, admin.AdminDirectoryUserScope, admin.AdminDirectoryGroupScope)
+
+	_, err := svc.Users.Get("nobody@example.com").Do()
+	requireStatus(t, err, http.StatusNotFound)
+
+	_, err = svc.Users.Insert(&admin.User{PrimaryEmail: "admin@example.com", Password: "hunter22hunter22", Name: &admin.UserName{GivenName: "A", FamilyName: "B"}}).Do()
+	requireStatus(t, err, http.StatusConflict)
+
+	_, err = svc.Members.Insert("eng@example.com", &admin.Member{Email: "admin@example.com"}).Do()
+	require.NoError(t, err)
+	_, err = svc.Members.Insert("eng@example.com", &admin.Member{Email: "admin@example.com"}).Do()
+	requireStatus(t, err, http.StatusConflict)
+}
+
+func TestScopes(t *testing.T) {
+	srv := newServer(t)
+
+	// A read-only token cannot write.
+	svc := newDirectory(t, srv, admin.AdminDirectoryUserReadonlyScope)
+	_, err := svc.Users.Update("admin@example.com", &admin.User{Suspended: true}).Do()
+	requireStatus(t, err, http.StatusForbidden)
+
+	// Scopes outside the domain-wide delegation grant get no token at all.
+	srv.GrantScopes(admin.AdminDirectoryUserReadonlyScope)
+	svc = newDirectory(t, srv, admin.AdminDirectoryGroupReadonlyScope)
+	_, err = svc.Groups.List().Customer("my_customer").Do()
+	require.ErrorContains(t, err, "unauthorized_client")
+}
+
+func TestFaultsAndRateLimit(t *testing.T) {
+	srv := newServer(t)
+	svc := newDirectory(t, srv, admin.AdminDirectoryUserReadonlyScope)
+
+	srv.InjectFault(http.MethodGet, "/admin/directory/v1/users", http.StatusServiceUnavailable, 1)
+	_, err := svc.Users.Get("admin@example.com").Do()
+	requireStatus(t, err, http.StatusServiceUnavailable)
+	_, err = svc.Users.Get("admin@example.com").Do()
+	require.NoError(t, err)
+
+	srv.SetRateLimit(2, time.Hour)
+	for range 2 {
+		_, err = svc.Users.Get("admin@example.com").Do()
+		require.NoError(t, err)
+	}
+	_, err = svc.Users.Get("admin@example.com").Do()
+	requireStatus(t, err, http.StatusTooManyRequests)
+}